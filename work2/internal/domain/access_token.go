@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// AccessTokenPrefix personal access token 的前缀，用来和 JWT 区分开
+const AccessTokenPrefix = "wbpat_"
+
+// Scope personal access token 能访问的范围，用 JWT 登录的不受限制
+type Scope string
+
+const (
+	ScopeArticleRead  Scope = "article:read"
+	ScopeArticleWrite Scope = "article:write"
+)
+
+// ValidScope 只接受已经定义的 scope
+func ValidScope(s Scope) bool {
+	switch s {
+	case ScopeArticleRead, ScopeArticleWrite:
+		return true
+	default:
+		return false
+	}
+}
+
+// AccessToken 用户自己创建的 personal access token，只保存哈希
+type AccessToken struct {
+	Id     int64
+	Uid    int64
+	Name   string
+	Scopes []Scope
+	// 明文的前几位，列表里面用来区分不同的 token
+	Hint      string
+	ExpiresAt time.Time
+	Ctime     time.Time
+}
+
+func (t AccessToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+func (t AccessToken) HasScope(s Scope) bool {
+	for _, scope := range t.Scopes {
+		if scope == s {
+			return true
+		}
+	}
+	return false
+}
@@ -32,6 +32,8 @@ const (
 	ArticleStatusPublished
 	// 仅自己可见
 	ArticleStatusPrivate
+	// 被管理员下架，作者不能再修改或者发表
+	ArticleStatusTakenDown
 )
 
 type Author struct {
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type Sms struct {
 	ID        int
 	Phone     string
@@ -16,4 +18,8 @@ type AsyncSms struct {
 	Numbers []string
 	// 重试的配置
 	RetryMax int
+	// 这一次之前已经发送过几次
+	RetryCnt int
+	// 下一次可以发送的时间
+	NextRetryAt time.Time
 }
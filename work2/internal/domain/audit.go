@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditLogin             AuditAction = "login"
+	AuditLogout            AuditAction = "logout"
+	AuditRefresh           AuditAction = "refresh"
+	AuditTwoFactorEnable   AuditAction = "two_factor_enable"
+	AuditSessionsRevoke    AuditAction = "sessions_revoke"
+	AuditAccessTokenCreate AuditAction = "access_token_create"
+	AuditAccessTokenRevoke AuditAction = "access_token_revoke"
+	AuditAccountDelete     AuditAction = "account_delete"
+	AuditUserBan           AuditAction = "user_ban"
+	AuditUserUnban         AuditAction = "user_unban"
+	AuditArticleTakeDown   AuditAction = "article_take_down"
+)
+
+// AuditEvent 安全相关的事件，只追加不修改
+type AuditEvent struct {
+	Id int64
+	// 操作人，登录失败的时候是被登录的账号，账号不存在的时候为 0
+	Uid    int64
+	Action AuditAction
+	// 操作的对象，例如登录用的邮箱、手机号，被封禁的用户 ID
+	Target    string
+	IP        string
+	UserAgent string
+	Success   bool
+	// 补充信息，例如登录方式、失败原因
+	Detail string
+	Ctime  time.Time
+}
+
+// AuditQuery 管理员查询审计日志，零值的条件不生效
+type AuditQuery struct {
+	Uid    int64
+	Action AuditAction
+	Start  time.Time
+	End    time.Time
+	Offset int
+	Limit  int
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// CodeChannel 验证码的发送渠道
+type CodeChannel string
+
+const (
+	CodeChannelSMS   CodeChannel = "sms"
+	CodeChannelEmail CodeChannel = "email"
+	// CodeChannelVoice 打电话把验证码念出来，收不到短信的时候用
+	CodeChannelVoice CodeChannel = "voice"
+)
+
+func (c CodeChannel) Valid() bool {
+	switch c {
+	case CodeChannelSMS, CodeChannelEmail, CodeChannelVoice:
+		return true
+	default:
+		return false
+	}
+}
+
+// ToPhone 短信和语音都是发到手机号，邮件发到邮箱
+func (c CodeChannel) ToPhone() bool {
+	return c == CodeChannelSMS || c == CodeChannelVoice
+}
+
+// CodePolicy 某一个业务的验证码策略，比如登录、重置密码、绑定手机号
+type CodePolicy struct {
+	Biz string
+	// 每个渠道各自的模板
+	Templates map[CodeChannel]string
+	// 验证码的长度和可以使用的字符，字符只支持 ASCII
+	Length   int
+	Alphabet string
+	// 验证码的有效期
+	TTL time.Duration
+	// 多久之后才能重新发送
+	ResendInterval time.Duration
+	// 一个验证码最多可以验证几次
+	MaxAttempts int
+}
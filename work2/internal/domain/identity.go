@@ -0,0 +1,12 @@
+package domain
+
+// Identity 用户在第三方平台上的身份
+type Identity struct {
+	Uid      int64
+	Provider string
+	// 第三方平台上的用户唯一标识，例如 GitHub 的用户 ID，OIDC 的 sub
+	Subject string
+	Email   string
+	Name    string
+	Avatar  string
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// LoginGuard 某个账号当前的登录防护状态
+type LoginGuard struct {
+	// 当前统计窗口内连续失败的次数
+	Failures int
+	// 大于 0 代表被锁定，需要等这么久才能再试
+	RetryAfter time.Duration
+	// 失败次数过多，需要先通过人机验证
+	NeedCaptcha bool
+}
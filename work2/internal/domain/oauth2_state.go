@@ -0,0 +1,12 @@
+package domain
+
+// OAuth2State 一次第三方授权流程在服务端保存的数据，回调时只能使用一次
+type OAuth2State struct {
+	State    string
+	Provider string
+	// OIDC 的 nonce 和 PKCE 的 code_verifier，微信不需要
+	Nonce        string
+	CodeVerifier string
+	// 登录成功之后前端要跳转的地址
+	Redirect string
+}
@@ -0,0 +1,35 @@
+package domain
+
+// Permission 权限，格式是 资源:操作
+type Permission string
+
+const (
+	// PermissionArticleTakeDown 下架任何人发表的帖子
+	PermissionArticleTakeDown Permission = "article:take_down"
+	// PermissionUserBan 封禁和解封用户
+	PermissionUserBan Permission = "user:ban"
+	// PermissionAuditRead 查询所有用户的审计日志
+	PermissionAuditRead Permission = "audit:read"
+)
+
+const RoleAdmin = "admin"
+
+// rolePermissions 每个角色拥有的权限，普通用户没有角色
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionArticleTakeDown,
+		PermissionUserBan,
+		PermissionAuditRead,
+	},
+}
+
+// PermissionsOf 多个角色的权限合在一起，不认识的角色没有任何权限
+func PermissionsOf(roles []string) map[Permission]struct{} {
+	res := make(map[Permission]struct{})
+	for _, r := range roles {
+		for _, p := range rolePermissions[r] {
+			res[p] = struct{}{}
+		}
+	}
+	return res
+}
@@ -0,0 +1,18 @@
+package domain
+
+// TwoFactor 用户的两步验证配置
+type TwoFactor struct {
+	Uid     int64
+	Secret  string
+	Enabled bool
+	// 恢复码的哈希，明文只在绑定的时候展示一次
+	RecoveryCodes []string
+	Utime         int64
+}
+
+// TOTPEnrollment 绑定两步验证时返回给用户的信息
+type TOTPEnrollment struct {
+	Secret        string
+	URI           string
+	RecoveryCodes []string
+}
@@ -8,10 +8,65 @@ type User struct {
 	Phone     string
 	Password  string `json:"-"`
 	NickName  string
+	Avatar    string
 	Birthday  time.Time
 	Biography string
 	CreatedAt int64 `json:"-"`
 	UpdatedAT int64 `json:"-"`
+	// 申请注销之后被匿名化的时间，零值表示没有申请注销
+	DeleteAt time.Time
+	// 被管理员封禁的时间，零值表示没有被封禁
+	BannedAt time.Time
+	// 偏好的验证码渠道，零值表示没有设置
+	CodeChannel CodeChannel
 
 	WechatInfo WechatInfo
 }
+
+// PendingDeletion 已经申请注销，处于冷静期或者已经被匿名化
+func (u User) PendingDeletion() bool {
+	return !u.DeleteAt.IsZero()
+}
+
+// Banned 被管理员封禁了
+func (u User) Banned() bool {
+	return !u.BannedAt.IsZero()
+}
+
+// CodeTarget 选择验证码的渠道和接收的地址。
+// 优先用 prefer，其次是用户自己设置的偏好，都用不了的时候有手机号发短信，没有的话发邮件；
+// 什么都没有绑定的时候返回空字符串
+func (u User) CodeTarget(prefer CodeChannel) (CodeChannel, string) {
+	for _, c := range []CodeChannel{prefer, u.CodeChannel, CodeChannelSMS, CodeChannelEmail} {
+		if target := u.codeAddress(c); target != "" {
+			return c, target
+		}
+	}
+	return "", ""
+}
+
+func (u User) codeAddress(c CodeChannel) string {
+	switch {
+	case c.ToPhone():
+		return u.Phone
+	case c == CodeChannelEmail:
+		return u.Email
+	default:
+		return ""
+	}
+}
+
+// AccountExport 用户导出的个人数据
+type AccountExport struct {
+	Profile  User
+	Articles []Article
+}
+
+// Reverification 敏感操作之前重新验证身份，按照账号的情况填写其中一项或者几项
+type Reverification struct {
+	Password string
+	// Code 发到手机或者邮箱的验证码，CodeChannel 是发送的渠道，为空的时候按照 User.CodeTarget 选择
+	Code          string
+	CodeChannel   CodeChannel
+	TwoFactorCode string
+}
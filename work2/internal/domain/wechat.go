@@ -1,6 +1,26 @@
 package domain
 
+import "time"
+
 type WechatInfo struct {
 	Openid  string
 	Unionid string
 }
+
+// WechatToken 用户授权之后微信下发的凭证
+type WechatToken struct {
+	Uid          int64
+	Openid       string
+	AccessToken  string
+	RefreshToken string
+	Scope        string
+	// access_token 两个小时过期，refresh_token 三十天过期
+	ExpiresAt        time.Time
+	RefreshExpiresAt time.Time
+}
+
+// WechatProfile 微信 userinfo 接口返回的个人信息
+type WechatProfile struct {
+	NickName string
+	Avatar   string
+}
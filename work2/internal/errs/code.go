@@ -0,0 +1,67 @@
+package errs
+
+// 错误码一共 6 位：
+// 第 1 位是大类，4 是用户的问题，5 是系统的问题；
+// 第 2、3 位是模块，00 是通用的；
+// 后 3 位是模块内的编号。
+// 错误码发布之后就不能修改含义，只能新增
+const (
+	CommonInvalidParam = 400001
+	CommonUnauthorized = 400002
+	CommonForbidden    = 400003
+	CommonInternal     = 500001
+)
+
+// 用户和账号
+const (
+	UserDuplicateEmail       = 401001
+	UserInvalidOrPassword    = 401002
+	UserBanned               = 401003
+	UserAccountDeleted       = 401004
+	UserNotFound             = 401005
+	UserLoginLocked          = 401006
+	UserCaptchaRequired      = 401007
+	UserReverificationFailed = 401008
+	UserNoPhone              = 401009
+	UserCannotBanAdmin       = 401010
+	UserNoContact            = 401011
+)
+
+// 帖子
+const (
+	ArticleNotFound  = 402001
+	ArticleTakenDown = 402002
+)
+
+// 微信和第三方登录
+const (
+	OAuth2InvalidCode     = 403001
+	OAuth2InvalidState    = 403002
+	OAuth2InvalidRedirect = 403003
+	OAuth2TokenExpired    = 403004
+	OAuth2Denied          = 403005
+)
+
+// 验证码
+const (
+	CodeSendTooMany        = 404001
+	CodeVerifyTooMany      = 404002
+	CodeInvalid            = 404003
+	CodeChannelUnsupported = 404004
+	CodeChannelLimited     = 404005
+)
+
+// 两步验证
+const (
+	TwoFactorEnabled     = 405001
+	TwoFactorNotEnrolled = 405002
+	TwoFactorInvalidCode = 405003
+)
+
+// personal access token
+const (
+	AccessTokenInvalid      = 406001
+	AccessTokenInvalidParam = 406002
+	AccessTokenTooMany      = 406003
+	AccessTokenNotFound     = 406004
+)
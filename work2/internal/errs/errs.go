@@ -0,0 +1,71 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error 带错误码的业务错误
+// Code 会原样返回给前端，Msg 是中文的默认消息，返回之前会按照错误码在 i18n 里面翻译，
+// Status 是对应的 HTTP 状态码，
+// cause 是底层的原因，只会出现在日志里面
+type Error struct {
+	Code   int
+	Status int
+	Msg    string
+	cause  error
+}
+
+func New(status int, code int, msg string) *Error {
+	return &Error{
+		Code:   code,
+		Status: status,
+		Msg:    msg,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("%d: %s", e.Code, e.Msg)
+	}
+	return fmt.Sprintf("%d: %s: %v", e.Code, e.Msg, e.cause)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 错误码相同就认为是同一个错误，这样 Wrap 之后还可以用 errors.Is 判断
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap 返回一个带上原因的副本，e 本身一般是包级别的变量，不能修改
+func (e *Error) Wrap(cause error) *Error {
+	cp := *e
+	cp.cause = cause
+	return &cp
+}
+
+var (
+	ErrInvalidParam = New(http.StatusBadRequest, CommonInvalidParam, "参数错误")
+	ErrUnauthorized = New(http.StatusUnauthorized, CommonUnauthorized, "请先登录")
+	ErrForbidden    = New(http.StatusForbidden, CommonForbidden, "没有权限")
+	ErrInternal     = New(http.StatusInternalServerError, CommonInternal, "系统错误")
+)
+
+// Internal 系统错误，保留原因方便排查，前端只会看到“系统错误”
+func Internal(cause error) *Error {
+	return ErrInternal.Wrap(cause)
+}
+
+// From 找出错误链上的 *Error，找不到的都当作系统错误
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
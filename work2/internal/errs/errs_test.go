@@ -0,0 +1,66 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError_Wrap(t *testing.T) {
+	cause := errors.New("db 错误")
+	sentinel := New(http.StatusNotFound, UserNotFound, "用户不存在")
+	err := sentinel.Wrap(cause)
+
+	assert.True(t, errors.Is(err, sentinel))
+	assert.True(t, errors.Is(err, cause))
+	assert.False(t, errors.Is(err, ErrInternal))
+	// 包级别的变量不能被修改
+	assert.Nil(t, sentinel.Unwrap())
+	assert.Equal(t, "401005: 用户不存在: db 错误", err.Error())
+}
+
+func TestFrom(t *testing.T) {
+	sentinel := New(http.StatusBadRequest, UserInvalidOrPassword, "用户名或者密码不对")
+	testCases := []struct {
+		name string
+		err  error
+
+		wantCode   int
+		wantStatus int
+		wantMsg    string
+	}{
+		{
+			name:       "业务错误",
+			err:        sentinel,
+			wantCode:   UserInvalidOrPassword,
+			wantStatus: http.StatusBadRequest,
+			wantMsg:    "用户名或者密码不对",
+		},
+		{
+			name:       "被包装过的业务错误",
+			err:        fmt.Errorf("登录失败: %w", sentinel.Wrap(errors.New("原因"))),
+			wantCode:   UserInvalidOrPassword,
+			wantStatus: http.StatusBadRequest,
+			wantMsg:    "用户名或者密码不对",
+		},
+		{
+			name:       "普通错误当作系统错误",
+			err:        errors.New("redis 超时"),
+			wantCode:   CommonInternal,
+			wantStatus: http.StatusInternalServerError,
+			wantMsg:    "系统错误",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := From(tc.err)
+			assert.Equal(t, tc.wantCode, e.Code)
+			assert.Equal(t, tc.wantStatus, e.Status)
+			assert.Equal(t, tc.wantMsg, e.Msg)
+			assert.True(t, errors.Is(e, tc.err) || errors.Is(tc.err, e))
+		})
+	}
+}
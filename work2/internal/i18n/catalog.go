@@ -0,0 +1,131 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bundle 一种语言的全部消息，key 见 keys.go
+type Bundle map[string]string
+
+// Catalog 按照语言管理 Bundle，找不到的消息回退到 fallback 语言。
+// Register 只应该在启动的时候调用，之后只读，所以没有加锁
+type Catalog struct {
+	fallback string
+	bundles  map[string]Bundle
+}
+
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		fallback: fallback,
+		bundles:  make(map[string]Bundle),
+	}
+}
+
+// Register 注册或者覆盖一种语言，locale 用 BCP 47 的写法，例如 zh-CN
+func (c *Catalog) Register(locale string, b Bundle) {
+	c.bundles[locale] = b
+}
+
+func (c *Catalog) Locales() []string {
+	res := make([]string, 0, len(c.bundles))
+	for locale := range c.bundles {
+		res = append(res, locale)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (c *Catalog) Bundle(locale string) Bundle {
+	return c.bundles[locale]
+}
+
+// Match 先找完全一样的，再找语言相同的，例如 en 和 en-GB 都会匹配到 en-US
+func (c *Catalog) Match(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", false
+	}
+	for locale := range c.bundles {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+	lang := primary(tag)
+	// 同一种语言可能注册了多个地区，按照名字排序保证每次结果一样
+	for _, locale := range c.Locales() {
+		if primary(locale) == lang {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate 按照 Accept-Language 里面的权重找第一个支持的语言，都不支持就用 fallback
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if locale, ok := c.Match(tag); ok {
+			return locale
+		}
+	}
+	return c.fallback
+}
+
+// T 查找 key 对应的消息，有 args 的时候当作格式化字符串。
+// 当前语言没有就用 fallback 语言的，都没有就返回 key 本身，方便发现漏掉的翻译
+func (c *Catalog) T(locale, key string, args ...any) string {
+	msg, ok := c.bundles[locale][key]
+	if !ok {
+		msg, ok = c.bundles[c.fallback][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+func primary(tag string) string {
+	lang, _, _ := strings.Cut(tag, "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	return strings.ToLower(lang)
+}
+
+// parseAcceptLanguage 例如 "en-US,en;q=0.9,zh;q=0.8"，按照 q 从大到小返回
+func parseAcceptLanguage(header string) []string {
+	type item struct {
+		tag string
+		q   float64
+	}
+	var items []item
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if val, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		items = append(items, item{tag: tag, q: q})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+	res := make([]string, 0, len(items))
+	for _, it := range items {
+		res = append(res, it.tag)
+	}
+	return res
+}
@@ -0,0 +1,58 @@
+package i18n
+
+import "example/wb/internal/errs"
+
+var enUS = Bundle{
+	MsgOK:                 "OK",
+	MsgSignUpSuccess:      "Signed up successfully",
+	MsgLoginSuccess:       "Logged in successfully",
+	MsgLogoutSuccess:      "Logged out successfully",
+	MsgEditSuccess:        "Profile updated",
+	MsgCodeSent:           "Code sent",
+	MsgLoginLockedRetry:   "Too many attempts, please try again in %d seconds",
+	MsgTwoFactorRequired:  "Please enter your two-factor authentication code",
+	MsgTwoFactorEnabled:   "Two-factor authentication is now enabled",
+	MsgDeletionRequested:  "Account deletion requested",
+	MsgAccessTokenCreated: "Save this token now, it will not be shown again",
+
+	ErrorKey(errs.CommonInvalidParam): "Invalid parameters",
+	ErrorKey(errs.CommonUnauthorized): "Please log in first",
+	ErrorKey(errs.CommonForbidden):    "Permission denied",
+	ErrorKey(errs.CommonInternal):     "Internal server error",
+
+	ErrorKey(errs.UserDuplicateEmail):       "This email is already registered",
+	ErrorKey(errs.UserInvalidOrPassword):    "Incorrect username or password",
+	ErrorKey(errs.UserBanned):               "This account has been banned",
+	ErrorKey(errs.UserAccountDeleted):       "This account is pending deletion",
+	ErrorKey(errs.UserNotFound):             "User not found",
+	ErrorKey(errs.UserLoginLocked):          "Too many attempts, please try again later",
+	ErrorKey(errs.UserCaptchaRequired):      "Please complete the captcha first",
+	ErrorKey(errs.UserReverificationFailed): "Identity verification failed",
+	ErrorKey(errs.UserNoPhone):              "No phone number is bound to this account",
+	ErrorKey(errs.UserCannotBanAdmin):       "Administrators cannot be banned",
+	ErrorKey(errs.UserNoContact):            "No phone number or email is bound to this account",
+
+	ErrorKey(errs.ArticleNotFound):  "Article not found",
+	ErrorKey(errs.ArticleTakenDown): "This article has been taken down",
+
+	ErrorKey(errs.OAuth2InvalidCode):     "Invalid authorization code",
+	ErrorKey(errs.OAuth2InvalidState):    "Invalid request",
+	ErrorKey(errs.OAuth2InvalidRedirect): "Invalid redirect address",
+	ErrorKey(errs.OAuth2TokenExpired):    "Authorization has expired, please authorize again",
+	ErrorKey(errs.OAuth2Denied):          "Authorization was denied",
+
+	ErrorKey(errs.CodeSendTooMany):        "Codes are being sent too often, please try again later",
+	ErrorKey(errs.CodeVerifyTooMany):      "Too many attempts, please request a new code",
+	ErrorKey(errs.CodeInvalid):            "Incorrect code, please try again",
+	ErrorKey(errs.CodeChannelUnsupported): "This delivery method is not supported",
+	ErrorKey(errs.CodeChannelLimited):     "Code delivery is busy, please try again later or use another method",
+
+	ErrorKey(errs.TwoFactorEnabled):     "Two-factor authentication is already enabled",
+	ErrorKey(errs.TwoFactorNotEnrolled): "Please set up two-factor authentication first",
+	ErrorKey(errs.TwoFactorInvalidCode): "Incorrect code, please try again",
+
+	ErrorKey(errs.AccessTokenInvalid):      "Invalid access token",
+	ErrorKey(errs.AccessTokenInvalidParam): "Invalid name, scopes or expiration",
+	ErrorKey(errs.AccessTokenTooMany):      "Too many tokens, please delete unused ones first",
+	ErrorKey(errs.AccessTokenNotFound):     "Token not found",
+}
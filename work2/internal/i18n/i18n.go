@@ -0,0 +1,68 @@
+package i18n
+
+import (
+	"example/wb/internal/errs"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ZhCN = "zh-CN"
+	EnUS = "en-US"
+
+	// 用户在前端主动切换语言之后放在 query 或者 cookie 里面
+	langKey   = "lang"
+	localeKey = "i18n_locale"
+)
+
+// Default 所有 handler 共用的消息目录，新增语言调用 Default.Register 即可
+var Default = NewCatalog(ZhCN)
+
+func init() {
+	Default.Register(ZhCN, zhCN)
+	Default.Register(EnUS, enUS)
+}
+
+// Locale 协商这次请求使用的语言，用户主动选择的优先，其次才是 Accept-Language。
+// 结果会缓存在 ctx 里面
+func Locale(ctx *gin.Context) string {
+	if locale := ctx.GetString(localeKey); locale != "" {
+		return locale
+	}
+	locale := negotiate(ctx)
+	ctx.Set(localeKey, locale)
+	return locale
+}
+
+func negotiate(ctx *gin.Context) string {
+	if locale, ok := Default.Match(ctx.Query(langKey)); ok {
+		return locale
+	}
+	if lang, err := ctx.Cookie(langKey); err == nil {
+		if locale, ok := Default.Match(lang); ok {
+			return locale
+		}
+	}
+	return Default.Negotiate(ctx.GetHeader("Accept-Language"))
+}
+
+// T 按照请求的语言翻译 key
+func T(ctx *gin.Context, key string, args ...any) string {
+	return Default.T(Locale(ctx), key, args...)
+}
+
+// ErrorKey 错误码对应的消息 key
+func ErrorKey(code int) string {
+	return "error." + strconv.Itoa(code)
+}
+
+// Error 按照错误码翻译，目录里面没有的时候用 e.Msg
+func Error(ctx *gin.Context, e *errs.Error) string {
+	key := ErrorKey(e.Code)
+	msg := T(ctx, key)
+	if msg == key {
+		return e.Msg
+	}
+	return msg
+}
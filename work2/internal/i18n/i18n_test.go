@@ -0,0 +1,150 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBundles_Complete 每个语言都要有全部的 key，包括 errs/code.go 里面的每一个错误码
+func TestBundles_Complete(t *testing.T) {
+	keys := append([]string{}, Keys...)
+	for _, code := range errorCodes(t) {
+		keys = append(keys, ErrorKey(code))
+	}
+	want := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		want[key] = struct{}{}
+	}
+	fallback := Default.Bundle(ZhCN)
+	for _, locale := range Default.Locales() {
+		b := Default.Bundle(locale)
+		for key := range want {
+			msg, ok := b[key]
+			if assert.Truef(t, ok, "%s 缺少 %s", locale, key) {
+				assert.NotEmptyf(t, msg, "%s 的 %s 是空的", locale, key)
+				// 格式化参数的个数要一样，否则翻译出来会有 %!d(MISSING)
+				assert.Equalf(t, strings.Count(fallback[key], "%"), strings.Count(msg, "%"),
+					"%s 的 %s 参数个数不对", locale, key)
+			}
+		}
+		for key := range b {
+			_, ok := want[key]
+			assert.Truef(t, ok, "%s 里面的 %s 没有定义", locale, key)
+		}
+	}
+}
+
+// errorCodes 直接解析 errs/code.go，新增错误码不需要再改这里
+func errorCodes(t *testing.T) []int {
+	f, err := parser.ParseFile(token.NewFileSet(), "../errs/code.go", nil, 0)
+	require.NoError(t, err)
+	var codes []int
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			for _, val := range spec.(*ast.ValueSpec).Values {
+				lit, ok := val.(*ast.BasicLit)
+				require.True(t, ok, "错误码只能是字面量")
+				code, ok := constant.Int64Val(constant.MakeFromLiteral(lit.Value, lit.Kind, 0))
+				require.True(t, ok)
+				codes = append(codes, int(code))
+			}
+		}
+	}
+	require.NotEmpty(t, codes)
+	return codes
+}
+
+func TestLocale(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		url            string
+		cookie         string
+		acceptLanguage string
+
+		want string
+	}{
+		{
+			name: "默认中文",
+			url:  "/test",
+			want: ZhCN,
+		},
+		{
+			name:           "按照权重选择",
+			url:            "/test",
+			acceptLanguage: "fr-FR,zh;q=0.5,en;q=0.8",
+			want:           EnUS,
+		},
+		{
+			name:           "地区不同语言相同",
+			url:            "/test",
+			acceptLanguage: "en-GB",
+			want:           EnUS,
+		},
+		{
+			name:           "都不支持",
+			url:            "/test",
+			acceptLanguage: "fr-FR,de;q=0.9",
+			want:           ZhCN,
+		},
+		{
+			name:           "cookie优先",
+			url:            "/test",
+			cookie:         "en-us",
+			acceptLanguage: "zh-CN",
+			want:           EnUS,
+		},
+		{
+			name:           "query最优先",
+			url:            "/test?lang=zh",
+			cookie:         "en-US",
+			acceptLanguage: "en-US",
+			want:           ZhCN,
+		},
+		{
+			name:           "不支持的query忽略",
+			url:            "/test?lang=ja",
+			acceptLanguage: "en-US",
+			want:           EnUS,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tc.cookie})
+			}
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = req
+			assert.Equal(t, tc.want, Locale(ctx))
+		})
+	}
+}
+
+func TestCatalog_T(t *testing.T) {
+	c := NewCatalog(ZhCN)
+	c.Register(ZhCN, Bundle{"a": "中文", "b": "等%d秒"})
+	c.Register(EnUS, Bundle{"b": "wait %d seconds"})
+
+	assert.Equal(t, "wait 3 seconds", c.T(EnUS, "b", 3))
+	// 没有翻译的回退到中文
+	assert.Equal(t, "中文", c.T(EnUS, "a"))
+	assert.Equal(t, "c", c.T(EnUS, "c"))
+}
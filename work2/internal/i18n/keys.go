@@ -0,0 +1,32 @@
+package i18n
+
+// 返回给前端的消息的 key，错误码的消息用 ErrorKey 生成。
+// 新增 key 之后要在所有的 Bundle 里面都加上，否则测试会失败
+const (
+	MsgOK                 = "common.ok"
+	MsgSignUpSuccess      = "user.signup_success"
+	MsgLoginSuccess       = "user.login_success"
+	MsgLogoutSuccess      = "user.logout_success"
+	MsgEditSuccess        = "user.edit_success"
+	MsgCodeSent           = "user.code_sent"
+	MsgLoginLockedRetry   = "user.login_locked_retry"
+	MsgTwoFactorRequired  = "user.2fa_required"
+	MsgTwoFactorEnabled   = "user.2fa_enabled"
+	MsgDeletionRequested  = "account.deletion_requested"
+	MsgAccessTokenCreated = "access_token.created"
+)
+
+// Keys 全部的消息 key，不包括错误码
+var Keys = []string{
+	MsgOK,
+	MsgSignUpSuccess,
+	MsgLoginSuccess,
+	MsgLogoutSuccess,
+	MsgEditSuccess,
+	MsgCodeSent,
+	MsgLoginLockedRetry,
+	MsgTwoFactorRequired,
+	MsgTwoFactorEnabled,
+	MsgDeletionRequested,
+	MsgAccessTokenCreated,
+}
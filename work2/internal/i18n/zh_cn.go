@@ -0,0 +1,58 @@
+package i18n
+
+import "example/wb/internal/errs"
+
+var zhCN = Bundle{
+	MsgOK:                 "OK",
+	MsgSignUpSuccess:      "注册成功",
+	MsgLoginSuccess:       "登录成功",
+	MsgLogoutSuccess:      "退出成功",
+	MsgEditSuccess:        "编辑成功",
+	MsgCodeSent:           "发送成功",
+	MsgLoginLockedRetry:   "尝试次数过多，请%d秒后再试",
+	MsgTwoFactorRequired:  "请输入两步验证码",
+	MsgTwoFactorEnabled:   "两步验证已开启",
+	MsgDeletionRequested:  "已申请注销",
+	MsgAccessTokenCreated: "请保存好 token，之后不会再展示",
+
+	ErrorKey(errs.CommonInvalidParam): "参数错误",
+	ErrorKey(errs.CommonUnauthorized): "请先登录",
+	ErrorKey(errs.CommonForbidden):    "没有权限",
+	ErrorKey(errs.CommonInternal):     "系统错误",
+
+	ErrorKey(errs.UserDuplicateEmail):       "邮箱已经被注册",
+	ErrorKey(errs.UserInvalidOrPassword):    "用户名或者密码不对",
+	ErrorKey(errs.UserBanned):               "账号已被封禁",
+	ErrorKey(errs.UserAccountDeleted):       "账号正在注销中",
+	ErrorKey(errs.UserNotFound):             "用户不存在",
+	ErrorKey(errs.UserLoginLocked):          "尝试次数过多，请稍后再试",
+	ErrorKey(errs.UserCaptchaRequired):      "请先完成人机验证",
+	ErrorKey(errs.UserReverificationFailed): "身份验证失败",
+	ErrorKey(errs.UserNoPhone):              "没有绑定手机号",
+	ErrorKey(errs.UserCannotBanAdmin):       "不能封禁管理员",
+	ErrorKey(errs.UserNoContact):            "没有绑定手机号或者邮箱",
+
+	ErrorKey(errs.ArticleNotFound):  "帖子不存在",
+	ErrorKey(errs.ArticleTakenDown): "帖子已经被下架",
+
+	ErrorKey(errs.OAuth2InvalidCode):     "授权码有误",
+	ErrorKey(errs.OAuth2InvalidState):    "非法请求",
+	ErrorKey(errs.OAuth2InvalidRedirect): "跳转地址不合法",
+	ErrorKey(errs.OAuth2TokenExpired):    "授权已经过期，需要重新授权",
+	ErrorKey(errs.OAuth2Denied):          "授权失败",
+
+	ErrorKey(errs.CodeSendTooMany):        "发送太频繁，请稍后再试",
+	ErrorKey(errs.CodeVerifyTooMany):      "验证次数太多，请重新获取验证码",
+	ErrorKey(errs.CodeInvalid):            "验证码不对，请重新输入",
+	ErrorKey(errs.CodeChannelUnsupported): "不支持这种验证码发送方式",
+	ErrorKey(errs.CodeChannelLimited):     "验证码发送繁忙，请稍后再试或者换一种方式",
+
+	ErrorKey(errs.TwoFactorEnabled):     "两步验证已经开启",
+	ErrorKey(errs.TwoFactorNotEnrolled): "请先绑定两步验证",
+	ErrorKey(errs.TwoFactorInvalidCode): "验证码不对，请重新输入",
+
+	ErrorKey(errs.AccessTokenInvalid):      "access token 无效",
+	ErrorKey(errs.AccessTokenInvalidParam): "名字、scope 或者有效期不对",
+	ErrorKey(errs.AccessTokenTooMany):      "token 太多了，请先删除不用的",
+	ErrorKey(errs.AccessTokenNotFound):     "token 不存在",
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"example/wb/internal/domain"
+	"example/wb/internal/errs"
 	"example/wb/internal/integration/startup"
 	"example/wb/internal/repository/dao"
 	ijwt "example/wb/internal/web/jwt"
@@ -192,9 +193,9 @@ func (s *ArticleMongoDBHandlerSuite) TestEdit() {
 				Title:   "新的标题1",
 				Content: "新的内容",
 			},
-			wantCode: 200,
+			wantCode: http.StatusInternalServerError,
 			wantResult: Result[int64]{
-				Code: 5,
+				Code: errs.CommonInternal,
 				Msg:  "系统错误",
 			},
 		},
@@ -482,9 +483,9 @@ func (s *ArticleMongoDBHandlerSuite) TestPublished() {
 				Title:   "更新一个用于发表的帖子",
 				Content: "这是我新的内容",
 			},
-			wantCode: 200,
+			wantCode: http.StatusInternalServerError,
 			wantResult: Result[int64]{
-				Code: 5,
+				Code: errs.CommonInternal,
 				Msg:  "系统错误",
 			},
 		},
@@ -625,9 +626,9 @@ func (s *ArticleMongoDBHandlerSuite) TestWithdraw() {
 			req: Article{
 				Id: 11,
 			},
-			wantCode: 200,
+			wantCode: http.StatusInternalServerError,
 			wantResult: Result[int64]{
-				Code: 5,
+				Code: errs.CommonInternal,
 				Msg:  "系统错误",
 			},
 		},
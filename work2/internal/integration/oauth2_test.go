@@ -0,0 +1,263 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/integration/startup"
+	"example/wb/internal/repository/dao"
+	"example/wb/internal/service/oauth2"
+	"example/wb/ioc"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type OAuth2HandlerSuite struct {
+	suite.Suite
+	rdb    redis.Cmdable
+	db     *gorm.DB
+	server *gin.Engine
+}
+
+func (s *OAuth2HandlerSuite) SetupSuite() {
+	s.rdb = startup.InitRedis()
+	s.db = ioc.InitDB(ioc.InitLogger())
+	hdl := startup.InitOAuth2Handler([]oauth2.Provider{
+		&fakeProvider{name: "fake"},
+		&fakeProvider{name: "other"},
+	})
+	server := gin.Default()
+	hdl.RegisterRoutes(server)
+	s.server = server
+}
+
+func (s *OAuth2HandlerSuite) TearDownTest() {
+	err := s.db.Exec("TRUNCATE TABLE user_identities").Error
+	assert.NoError(s.T(), err)
+}
+
+func (s *OAuth2HandlerSuite) TestAuthURL() {
+	t := s.T()
+	testCases := []struct {
+		name     string
+		redirect string
+
+		wantCode int64
+		after    func(t *testing.T, state string)
+	}{
+		{
+			name:     "保存 state 和跳转地址",
+			redirect: "/articles/1",
+			after: func(t *testing.T, state string) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				key := "oauth2:state:" + state
+				data, err := s.rdb.Get(ctx, key).Result()
+				require.NoError(t, err)
+				var st domain.OAuth2State
+				require.NoError(t, json.Unmarshal([]byte(data), &st))
+				assert.Equal(t, "fake", st.Provider)
+				assert.Equal(t, "/articles/1", st.Redirect)
+				assert.NotEmpty(t, st.Nonce)
+				assert.NotEmpty(t, st.CodeVerifier)
+				ttl, err := s.rdb.TTL(ctx, key).Result()
+				require.NoError(t, err)
+				assert.True(t, ttl > time.Minute*9)
+				assert.NoError(t, s.rdb.Del(ctx, key).Err())
+			},
+		},
+		{
+			name:     "跳转到站外",
+			redirect: "https://evil.com",
+			wantCode: errs.OAuth2InvalidRedirect,
+		},
+		{
+			name:     "协议相对地址",
+			redirect: "//evil.com",
+			wantCode: errs.OAuth2InvalidRedirect,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := s.get(t, "/oauth2/fake/authurl?redirect="+url.QueryEscape(tc.redirect))
+			assert.Equal(t, tc.wantCode, res.Code)
+			if tc.after != nil {
+				tc.after(t, stateOf(t, res.Data))
+			}
+		})
+	}
+}
+
+func (s *OAuth2HandlerSuite) TestCallBack() {
+	t := s.T()
+	state, cookies := s.authURL(t, "/oauth2/fake/authurl?redirect=%2Fprofile")
+
+	// 第一次回调成功
+	recorder := httptest.NewRecorder()
+	s.server.ServeHTTP(recorder, s.newReq(t, "/oauth2/fake/callback?code=sub-1&state="+state, cookies...))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var res Result[string]
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&res))
+	assert.Equal(t, Result[string]{Msg: "OK", Data: "/profile"}, res)
+	assert.NotEmpty(t, recorder.Header().Get("x-jwt-token"))
+
+	var identity dao.UserIdentity
+	err := s.db.Where("provider = ? AND subject = ?", "fake", "sub-1").First(&identity).Error
+	require.NoError(t, err)
+	assert.True(t, identity.Uid > 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	cnt, err := s.rdb.Exists(ctx, "oauth2:state:"+state).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), cnt)
+
+	// 同一个 state 重放
+	res = s.get(t, "/oauth2/fake/callback?code=sub-1&state="+state, cookies...)
+	assert.Equal(t, Result[string]{Code: errs.OAuth2InvalidState, Msg: "非法请求"}, res)
+}
+
+func (s *OAuth2HandlerSuite) TestCallBack_InvalidState() {
+	t := s.T()
+	testCases := []struct {
+		name  string
+		path  func(t *testing.T) (string, []*http.Cookie)
+		after func(t *testing.T)
+	}{
+		{
+			name: "不存在的 state",
+			path: func(t *testing.T) (string, []*http.Cookie) {
+				return "/oauth2/fake/callback?code=sub-1&state=unknown", nil
+			},
+		},
+		{
+			name: "没有 state",
+			path: func(t *testing.T) (string, []*http.Cookie) {
+				_, cookies := s.authURL(t, "/oauth2/fake/authurl")
+				return "/oauth2/fake/callback?code=sub-1", cookies
+			},
+		},
+		{
+			name: "其他平台的 state",
+			path: func(t *testing.T) (string, []*http.Cookie) {
+				state, cookies := s.authURL(t, "/oauth2/other/authurl")
+				return "/oauth2/fake/callback?code=sub-1&state=" + state, cookies
+			},
+		},
+		{
+			name: "过期的 state",
+			path: func(t *testing.T) (string, []*http.Cookie) {
+				state, cookies := s.authURL(t, "/oauth2/fake/authurl")
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				err := s.rdb.Expire(ctx, "oauth2:state:"+state, time.Millisecond).Err()
+				require.NoError(t, err)
+				time.Sleep(time.Millisecond * 10)
+				return "/oauth2/fake/callback?code=sub-1&state=" + state, cookies
+			},
+		},
+		{
+			name: "别的浏览器发起的登录",
+			path: func(t *testing.T) (string, []*http.Cookie) {
+				// 攻击者自己拿到的 state，受害者的浏览器里面没有对应的 cookie
+				state, _ := s.authURL(t, "/oauth2/fake/authurl")
+				return "/oauth2/fake/callback?code=sub-1&state=" + state, nil
+			},
+		},
+		{
+			name: "cookie 和 state 对不上",
+			path: func(t *testing.T) (string, []*http.Cookie) {
+				state, _ := s.authURL(t, "/oauth2/fake/authurl")
+				_, cookies := s.authURL(t, "/oauth2/fake/authurl")
+				return "/oauth2/fake/callback?code=sub-1&state=" + state, cookies
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cookies := tc.path(t)
+			res := s.get(t, path, cookies...)
+			assert.Equal(t, Result[string]{Code: errs.OAuth2InvalidState, Msg: "非法请求"}, res)
+			var cnt int64
+			err := s.db.Model(&dao.UserIdentity{}).Count(&cnt).Error
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), cnt)
+		})
+	}
+}
+
+// authURL 发起登录，返回 state 和浏览器会保存的 cookie
+func (s *OAuth2HandlerSuite) authURL(t *testing.T, path string) (string, []*http.Cookie) {
+	recorder := httptest.NewRecorder()
+	s.server.ServeHTTP(recorder, s.newReq(t, path))
+	var res Result[string]
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&res))
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	return stateOf(t, res.Data), cookies
+}
+
+func (s *OAuth2HandlerSuite) get(t *testing.T, path string, cookies ...*http.Cookie) Result[string] {
+	recorder := httptest.NewRecorder()
+	s.server.ServeHTTP(recorder, s.newReq(t, path, cookies...))
+	// 出错的时候 HTTP 状态码不是 200，由调用者检查错误码
+	var res Result[string]
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&res))
+	return res
+}
+
+func (s *OAuth2HandlerSuite) newReq(t *testing.T, path string, cookies ...*http.Cookie) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	require.NoError(t, err)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func TestOAuth2Handler(t *testing.T) {
+	suite.Run(t, new(OAuth2HandlerSuite))
+}
+
+// stateOf 从跳转地址里面取出 state
+func stateOf(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	state := u.Query().Get("state")
+	require.NotEmpty(t, state)
+	return state
+}
+
+// fakeProvider 授权码就是第三方平台上的用户 ID
+type fakeProvider struct {
+	name string
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) AuthURL(ctx context.Context, state oauth2.AuthState) (string, error) {
+	return "https://" + p.name + ".com/authorize?state=" + url.QueryEscape(state.State), nil
+}
+
+func (p *fakeProvider) VerifyCode(ctx context.Context, code string, state oauth2.AuthState) (domain.Identity, error) {
+	return domain.Identity{
+		Provider: p.name,
+		Subject:  code,
+		Name:     code,
+	}, nil
+}
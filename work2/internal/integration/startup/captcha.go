@@ -0,0 +1,11 @@
+package startup
+
+import (
+	"example/wb/internal/service/captcha"
+	"example/wb/internal/service/captcha/localcaptcha"
+)
+
+// InitCaptchaService 测试不读配置，带了 ticket 就放行
+func InitCaptchaService() captcha.Service {
+	return localcaptcha.NewLocalService()
+}
@@ -7,8 +7,8 @@ import (
 	"example/wb/internal/repository/cache"
 	"example/wb/internal/repository/dao"
 	"example/wb/internal/service"
+	"example/wb/internal/service/oauth2"
 	"example/wb/internal/web"
-	ijwt "example/wb/internal/web/jwt"
 	"example/wb/ioc"
 
 	"github.com/gin-gonic/gin"
@@ -20,19 +20,35 @@ func InitWebServer() *gin.Engine {
 		// 初始化第三方依赖
 		ioc.InitFreeCache,
 		ioc.InitRedis, ioc.InitDB,
-		ioc.InitWechatService,
+		ioc.InitWechatService, ioc.InitOAuth2Providers,
 		ioc.InitLogger,
 
-		dao.NewUserDao, dao.NewSmsDao,
+		dao.NewUserDao, dao.NewSmsDao, dao.NewTwoFactorDao, dao.NewUserIdentityDao,
+		dao.NewWechatTokenDao, dao.NewArticleGORMDAO, dao.NewUserRoleDao,
+		dao.NewAccessTokenDao, dao.NewAuditDao,
 		// cache部分
-		cache.NewUserCache, cache.NewCodeLocalCache,
+		cache.NewUserCache, ioc.InitCodeCache, cache.NewLoginGuardCache,
+		cache.NewTwoFactorCache,
+		cache.NewOAuth2StateCache, cache.NewArticleRedisCache, cache.NewRoleCache,
 		// repository部分
 		repository.NewCachedCodeRepository, repository.NewCachedUserRepository,
-		repository.NewAsyncSMSRepository,
+		repository.NewAsyncSMSRepository, repository.NewTwoFactorRepository,
+		repository.NewLoginGuardRepository, repository.NewIdentityRepository,
+		repository.NewOAuth2StateRepository, repository.NewWechatTokenRepository,
+		repository.NewArticleRepository, repository.NewRoleRepository,
+		repository.NewAccessTokenRepository, repository.NewAuditRepository,
 		// service部分
-		ioc.InitSMSService, service.NewCodeService, service.NewUserService,
+		ioc.InitSMSService, ioc.InitCodeSenders, ioc.InitCodePolicies, service.NewCodeService, service.NewUserService, ioc.InitPasswordHasher,
+		service.NewTwoFactorService,
+		ioc.InitLoginGuardService, InitCaptchaService,
+		service.NewOAuth2StateService, service.NewWechatLoginService,
+		ioc.InitAccountService, ioc.InitAuthzService, service.NewAdminService,
+		service.NewArticleService, service.NewAccessTokenService,
+		service.NewAuditService,
 		// web部分
-		web.NewUserHandler, web.NewOAuth2WechatHandler, ijwt.NewJwtHandler,
+		web.NewUserHandler, web.NewOAuth2WechatHandler, web.NewOAuth2Handler, ioc.InitJWTHandler,
+		web.NewAccountHandler, web.NewAdminHandler,
+		web.NewArticleHandler, web.NewAccessTokenHandler, web.NewAuditHandler,
 
 		ioc.InitGinMiddlewares,
 		ioc.InitWebServer,
@@ -41,6 +57,21 @@ func InitWebServer() *gin.Engine {
 
 }
 
+// InitOAuth2Handler 第三方平台由测试传入，一般是指向 httptest 的假平台
+func InitOAuth2Handler(providers []oauth2.Provider) *web.OAuth2Handler {
+	wire.Build(
+		ioc.InitLogger, InitRedis, ioc.InitDB,
+		dao.NewUserDao, dao.NewUserIdentityDao, dao.NewAuditDao,
+		cache.NewUserCache, cache.NewOAuth2StateCache,
+		repository.NewCachedUserRepository, repository.NewIdentityRepository,
+		repository.NewOAuth2StateRepository, repository.NewAuditRepository,
+		service.NewUserService, ioc.InitPasswordHasher, service.NewOAuth2StateService, service.NewAuditService,
+		ioc.InitJWTHandler,
+		web.NewOAuth2Handler,
+	)
+	return &web.OAuth2Handler{}
+}
+
 //	func InitUserHandler(dao dao.ArticleDao) *web.UserHandler {
 //		wire.Build()
 //		return &web.UserHandler{}
@@ -48,6 +79,12 @@ func InitWebServer() *gin.Engine {
 func InitArticleHandler(dao dao.ArticleDAO) *web.ArticleHandler {
 	wire.Build(
 		ioc.InitLogger,
+		InitRedis,
+		ioc.InitDB,
+		dao.NewUserDao,
+		cache.NewUserCache,
+		repository.NewCachedUserRepository,
+		cache.NewArticleRedisCache,
 		repository.NewArticleRepository,
 		service.NewArticleService,
 		web.NewArticleHandler,
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository/dao"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ekit/sqlx"
+)
+
+var ErrAccessTokenNotFound = dao.ErrAccessTokenNotFound
+
+type AccessTokenRepository interface {
+	Create(ctx context.Context, t domain.AccessToken, hash string) (int64, error)
+	FindByHash(ctx context.Context, hash string) (domain.AccessToken, error)
+	FindByUid(ctx context.Context, uid int64) ([]domain.AccessToken, error)
+	Delete(ctx context.Context, uid int64, id int64) error
+}
+
+type accessTokenRepository struct {
+	dao dao.AccessTokenDao
+}
+
+func NewAccessTokenRepository(dao dao.AccessTokenDao) AccessTokenRepository {
+	return &accessTokenRepository{
+		dao: dao,
+	}
+}
+
+func (repo *accessTokenRepository) Create(ctx context.Context, t domain.AccessToken, hash string) (int64, error) {
+	return repo.dao.Insert(ctx, dao.AccessToken{
+		Uid:       t.Uid,
+		Name:      t.Name,
+		TokenHash: hash,
+		Hint:      t.Hint,
+		Scopes: sqlx.JsonColumn[[]string]{
+			Val: slice.Map[domain.Scope, string](t.Scopes, func(idx int, src domain.Scope) string {
+				return string(src)
+			}),
+			Valid: true,
+		},
+		ExpiresAt: t.ExpiresAt.UnixMilli(),
+	})
+}
+
+func (repo *accessTokenRepository) FindByHash(ctx context.Context, hash string) (domain.AccessToken, error) {
+	t, err := repo.dao.FindByHash(ctx, hash)
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	return repo.toDomain(t), nil
+}
+
+func (repo *accessTokenRepository) FindByUid(ctx context.Context, uid int64) ([]domain.AccessToken, error) {
+	ts, err := repo.dao.FindByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.AccessToken, domain.AccessToken](ts, func(idx int, src dao.AccessToken) domain.AccessToken {
+		return repo.toDomain(src)
+	}), nil
+}
+
+func (repo *accessTokenRepository) Delete(ctx context.Context, uid int64, id int64) error {
+	return repo.dao.Delete(ctx, uid, id)
+}
+
+func (repo *accessTokenRepository) toDomain(t dao.AccessToken) domain.AccessToken {
+	return domain.AccessToken{
+		Id:   t.Id,
+		Uid:  t.Uid,
+		Name: t.Name,
+		Scopes: slice.Map[string, domain.Scope](t.Scopes.Val, func(idx int, src string) domain.Scope {
+			return domain.Scope(src)
+		}),
+		Hint:      t.Hint,
+		ExpiresAt: time.UnixMilli(t.ExpiresAt),
+		Ctime:     time.UnixMilli(t.Ctime),
+	}
+}
@@ -15,8 +15,14 @@ type ArticleRepository interface {
 	Sync(ctx context.Context, art domain.Article) (int64, error)
 	SyncStatus(ctx context.Context, uid int64, id int64, status domain.ArticleStatus) error
 	GetByAuthor(ctx context.Context, uid int64, limit int, offset int) ([]domain.Article, error)
+	// GetByAuthorFromDB 不走缓存，第一页的缓存里面只有摘要，需要完整内容的时候用这个
+	GetByAuthorFromDB(ctx context.Context, uid int64, limit int, offset int) ([]domain.Article, error)
+	SyncStatusByAuthor(ctx context.Context, uid int64, from domain.ArticleStatus, to domain.ArticleStatus) error
+	GetById(ctx context.Context, id int64) (domain.Article, error)
 }
 
+var ErrArticleNotFound = dao.ErrArticleNotFound
+
 type CachedArticleRepository struct {
 	dao   dao.ArticleDAO
 	cache cache.ArticleCache
@@ -56,6 +62,16 @@ func (c *CachedArticleRepository) GetByAuthor(ctx context.Context, uid int64, li
 }
 
 // Create implements ArticleRepository.
+func (c *CachedArticleRepository) GetByAuthorFromDB(ctx context.Context, uid int64, limit int, offset int) ([]domain.Article, error) {
+	arts, err := c.dao.GetByAuthor(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.Article, domain.Article](arts, func(idx int, src dao.Article) domain.Article {
+		return toDomain(src)
+	}), nil
+}
+
 func (c *CachedArticleRepository) Create(ctx context.Context, art domain.Article) (int64, error) {
 	id, err := c.dao.Insert(ctx, toEntity(art))
 
@@ -96,6 +112,26 @@ func (c *CachedArticleRepository) SyncStatus(ctx context.Context, uid int64, id
 	return err
 }
 
+// SyncStatusByAuthor implements ArticleRepository.
+func (c *CachedArticleRepository) SyncStatusByAuthor(ctx context.Context, uid int64, from domain.ArticleStatus, to domain.ArticleStatus) error {
+	err := c.dao.SyncStatusByAuthor(ctx, uid, uint8(from), uint8(to))
+	if err != nil {
+		return err
+	}
+	// 缓存删除失败不影响结果，最多一个缓存周期内看到旧的数据
+	_ = c.cache.DelFirstPage(ctx, uid)
+	return nil
+}
+
+// GetById implements ArticleRepository.
+func (c *CachedArticleRepository) GetById(ctx context.Context, id int64) (domain.Article, error) {
+	art, err := c.dao.GetById(ctx, id)
+	if err != nil {
+		return domain.Article{}, err
+	}
+	return toDomain(art), nil
+}
+
 // Update implements ArticleRepository.
 func (c *CachedArticleRepository) Update(ctx context.Context, art domain.Article) error {
 	err := c.dao.UpdateById(ctx, toEntity(art))
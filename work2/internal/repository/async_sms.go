@@ -4,6 +4,7 @@ import (
 	"context"
 	"example/wb/internal/domain"
 	"example/wb/internal/repository/dao"
+	"time"
 
 	"github.com/ecodeclub/ekit/sqlx"
 )
@@ -12,8 +13,12 @@ var ErrWaitingSMSNotFound = dao.ErrWaitingSMSNotFound
 
 type AsyncSmsRepository interface {
 	Create(ctx context.Context, s domain.AsyncSms) error
-	PreemptWaitingSMS(ctx context.Context) (domain.AsyncSms, error)
-	ReportScheduleResult(ctx context.Context, id int64, success bool) error
+	// PreemptWaitingSMS 一次抢占最多 limit 条，抢到的在 lease 之内不会被其他人抢到
+	PreemptWaitingSMS(ctx context.Context, limit int, lease time.Duration) ([]domain.AsyncSms, error)
+	// ReportScheduleResult 失败了并且没有超过重试次数的，在 nextRetryAt 重试
+	ReportScheduleResult(ctx context.Context, id int64, success bool, nextRetryAt time.Time) error
+	// Abandon 服务商明确拒绝了，重试也没用，不再发送
+	Abandon(ctx context.Context, id int64) error
 }
 type asyncSmsRepository struct {
 	dao dao.AsyncSmsDao
@@ -36,29 +41,40 @@ func (a *asyncSmsRepository) Create(ctx context.Context, s domain.AsyncSms) erro
 			},
 			Valid: true,
 		},
-		RetryMax: s.RetryMax,
+		RetryMax:    s.RetryMax,
+		NextRetryAt: s.NextRetryAt.UnixMilli(),
 	})
 }
 
-func (a *asyncSmsRepository) PreemptWaitingSMS(ctx context.Context) (domain.AsyncSms, error) {
-	as, err := a.dao.GetWaitingSMS(ctx)
+func (a *asyncSmsRepository) PreemptWaitingSMS(ctx context.Context, limit int, lease time.Duration) ([]domain.AsyncSms, error) {
+	ass, err := a.dao.PreemptWaitingSMS(ctx, time.Now().UnixMilli(), limit, lease.Milliseconds())
 	if err != nil {
-		return domain.AsyncSms{}, err
+		return nil, err
 	}
-	return domain.AsyncSms{
-		Id:       as.Id,
-		TplId:    as.Config.Val.TplId,
-		Numbers:  as.Config.Val.Numbers,
-		Args:     as.Config.Val.Args,
-		RetryMax: as.RetryMax,
-	}, nil
+	res := make([]domain.AsyncSms, 0, len(ass))
+	for _, as := range ass {
+		res = append(res, domain.AsyncSms{
+			Id:          as.Id,
+			TplId:       as.Config.Val.TplId,
+			Numbers:     as.Config.Val.Numbers,
+			Args:        as.Config.Val.Args,
+			RetryMax:    as.RetryMax,
+			RetryCnt:    as.RetryCnt,
+			NextRetryAt: time.UnixMilli(as.NextRetryAt),
+		})
+	}
+	return res, nil
 }
 
-func (a *asyncSmsRepository) ReportScheduleResult(ctx context.Context, id int64, success bool) error {
+func (a *asyncSmsRepository) ReportScheduleResult(ctx context.Context, id int64, success bool, nextRetryAt time.Time) error {
 	if success {
 		return a.dao.MarkSuccess(ctx, id)
 	}
-	return a.dao.MarkFailed(ctx, id)
+	return a.dao.MarkFailed(ctx, id, nextRetryAt.UnixMilli())
+}
+
+func (a *asyncSmsRepository) Abandon(ctx context.Context, id int64) error {
+	return a.dao.MarkAbandoned(ctx, id)
 }
 
 // func NewAsyncSMSRepository(dao dao.AsyncSmsDao) AsyncSmsRepository {
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository/dao"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+)
+
+type AuditRepository interface {
+	CreateBatch(ctx context.Context, events []domain.AuditEvent) error
+	Find(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error)
+}
+
+type auditRepository struct {
+	dao dao.AuditDao
+}
+
+func NewAuditRepository(dao dao.AuditDao) AuditRepository {
+	return &auditRepository{
+		dao: dao,
+	}
+}
+
+func (repo *auditRepository) CreateBatch(ctx context.Context, events []domain.AuditEvent) error {
+	return repo.dao.InsertBatch(ctx, slice.Map[domain.AuditEvent, dao.AuditEvent](events,
+		func(idx int, src domain.AuditEvent) dao.AuditEvent {
+			return dao.AuditEvent{
+				Uid:       src.Uid,
+				Action:    string(src.Action),
+				Target:    src.Target,
+				IP:        src.IP,
+				UserAgent: src.UserAgent,
+				Success:   src.Success,
+				Detail:    src.Detail,
+				Ctime:     src.Ctime.UnixMilli(),
+			}
+		}))
+}
+
+func (repo *auditRepository) Find(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error) {
+	dq := dao.AuditQuery{
+		Uid:    q.Uid,
+		Action: string(q.Action),
+		Offset: q.Offset,
+		Limit:  q.Limit,
+	}
+	if !q.Start.IsZero() {
+		dq.Start = q.Start.UnixMilli()
+	}
+	if !q.End.IsZero() {
+		dq.End = q.End.UnixMilli()
+	}
+	events, err := repo.dao.Find(ctx, dq)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.AuditEvent, domain.AuditEvent](events, func(idx int, src dao.AuditEvent) domain.AuditEvent {
+		return domain.AuditEvent{
+			Id:        src.Id,
+			Uid:       src.Uid,
+			Action:    domain.AuditAction(src.Action),
+			Target:    src.Target,
+			IP:        src.IP,
+			UserAgent: src.UserAgent,
+			Success:   src.Success,
+			Detail:    src.Detail,
+			Ctime:     time.UnixMilli(src.Ctime),
+		}
+	}), nil
+}
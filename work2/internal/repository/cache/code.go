@@ -4,7 +4,9 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"example/wb/internal/domain"
 	"fmt"
+	"strconv"
 	"sync"
 
 	"github.com/coocood/freecache"
@@ -19,11 +21,15 @@ var (
 
 	ErrSendTooMany        = errors.New("验证码发送太频繁")
 	ErrCodeVertifyTooMany = errors.New("验证码验证太频繁")
+	// ErrCodeNoExpiration 验证码存在但是没有过期时间，一般是被人手动改过
+	ErrCodeNoExpiration = errors.New("验证码存在，但没有过期时间")
 )
 
 type CodeCache interface {
-	Set(ctx context.Context, biz, phone, code string) error
-	Vertify(ctx context.Context, biz, phone, code string) (bool, error)
+	// Set 有效期、重新发送的间隔和验证次数都由 p 决定。
+	// 不同渠道的验证码互相独立，target 是手机号或者邮箱
+	Set(ctx context.Context, biz string, channel domain.CodeChannel, target, code string, p domain.CodePolicy) error
+	Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, code string) (bool, error)
 }
 
 type RedisCodeCache struct {
@@ -34,14 +40,15 @@ func NewCodeCache(cmd redis.Cmdable) CodeCache {
 	return &RedisCodeCache{cmd: cmd}
 }
 
-func (cache *RedisCodeCache) Set(ctx context.Context, biz, phone, code string) error {
-	res, err := cache.cmd.Eval(ctx, luaSetCode, []string{cache.key(biz, phone)}, code).Int()
+func (cache *RedisCodeCache) Set(ctx context.Context, biz string, channel domain.CodeChannel, target, code string, p domain.CodePolicy) error {
+	res, err := cache.cmd.Eval(ctx, luaSetCode, []string{codeKey(biz, channel, target)}, code,
+		int64(p.TTL.Seconds()), int64(p.ResendInterval.Seconds()), p.MaxAttempts).Int()
 	if err != nil {
 		return err
 	}
 	switch res {
 	case -2:
-		return errors.New("验证码存在，但没有过期时间")
+		return ErrCodeNoExpiration
 	case -1:
 		return ErrSendTooMany
 	default:
@@ -50,8 +57,8 @@ func (cache *RedisCodeCache) Set(ctx context.Context, biz, phone, code string) e
 	}
 }
 
-func (cache *RedisCodeCache) Vertify(ctx context.Context, biz, phone, code string) (bool, error) {
-	res, err := cache.cmd.Eval(ctx, luaVertifyCode, []string{cache.key(biz, phone)}, code).Int()
+func (cache *RedisCodeCache) Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, code string) (bool, error) {
+	res, err := cache.cmd.Eval(ctx, luaVertifyCode, []string{codeKey(biz, channel, target)}, code).Int()
 	if err != nil {
 		return false, err
 	}
@@ -66,8 +73,9 @@ func (cache *RedisCodeCache) Vertify(ctx context.Context, biz, phone, code strin
 	}
 }
 
-func (cache *RedisCodeCache) key(biz, phone string) string {
-	return fmt.Sprintf("phone_code:%s:%s", biz, phone)
+// codeKey redis 和本地缓存用同一套 key
+func codeKey(biz string, channel domain.CodeChannel, target string) string {
+	return fmt.Sprintf("code:%s:%s:%s", channel, biz, target)
 }
 
 type CodeLocalCache struct {
@@ -81,36 +89,44 @@ func NewCodeLocalCache(cache *freecache.Cache) CodeCache {
 	}
 }
 
-func (c *CodeLocalCache) Set(ctx context.Context, biz, phone, code string) error {
-	curKey := c.key(biz, phone)
+func (c *CodeLocalCache) Set(ctx context.Context, biz string, channel domain.CodeChannel, target, code string, p domain.CodePolicy) error {
+	curKey := codeKey(biz, channel, target)
 	cntKey := curKey + ":cnt"
+	expiration := int(p.TTL.Seconds())
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	lfTime, err := c.cache.TTL([]byte(curKey))
-	// key存在且验证码的存在时间在60秒以内
-	if err == nil && lfTime > 540 {
+	// key存在且还没有过重新发送的间隔
+	if err == nil && int(lfTime) > expiration-int(p.ResendInterval.Seconds()) {
 		return ErrSendTooMany
 	}
-	// key不存在或者验证码存在时间超过了60秒, 重新设置验证码
-	err = c.cache.Set([]byte(curKey), []byte(code), 600)
+	// key不存在或者已经过了重新发送的间隔, 重新设置验证码
+	err = c.cache.Set([]byte(curKey), []byte(code), expiration)
 	if err != nil {
-		return errors.New("系统错误")
+		return fmt.Errorf("保存验证码失败: %w", err)
 	}
-	err = c.cache.Set([]byte(cntKey), []byte{3}, 600)
+	err = c.cache.Set([]byte(cntKey), []byte(strconv.Itoa(p.MaxAttempts)), expiration)
 	if err != nil {
-		return errors.New("系统错误")
+		return fmt.Errorf("保存验证码次数失败: %w", err)
 	}
 	return nil
 }
 
-func (c *CodeLocalCache) Vertify(ctx context.Context, biz, phone, code string) (bool, error) {
-	curKey := c.key(biz, phone)
+func (c *CodeLocalCache) Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, code string) (bool, error) {
+	curKey := codeKey(biz, channel, target)
 	cntKey := curKey + ":cnt"
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	val, err := c.cache.Get([]byte(cntKey))
-	count := val[0]
-	if err != nil || count <= 0 {
+	if err == freecache.ErrNotFound {
+		// 和 redis 保持一致，没有发送过或者已经过期都当作次数耗尽
+		return false, ErrCodeVertifyTooMany
+	}
+	if err != nil {
+		return false, err
+	}
+	count, err := strconv.Atoi(string(val))
+	if err != nil {
 		return false, err
 	}
 	if count <= 0 {
@@ -122,15 +138,17 @@ func (c *CodeLocalCache) Vertify(ctx context.Context, biz, phone, code string) (
 	}
 
 	if string(val) == code {
+		// 验证通过之后不能再用
+		c.cache.Del([]byte(cntKey))
 		return true, nil
 	} else {
-		count = count - 1
-		c.cache.Set([]byte(cntKey), []byte{count}, 600)
+		// 剩下的次数沿用验证码的有效期
+		ttl, err := c.cache.TTL([]byte(cntKey))
+		if err != nil {
+			return false, err
+		}
+		c.cache.Set([]byte(cntKey), []byte(strconv.Itoa(count-1)), int(ttl))
 		return false, nil
 
 	}
 }
-
-func (cache *CodeLocalCache) key(biz, phone string) string {
-	return fmt.Sprintf("phone_code:%s:%s", biz, phone)
-}
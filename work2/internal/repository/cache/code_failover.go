@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/pkg/breaker"
+	"example/wb/pkg/logger"
+	"expvar"
+)
+
+// 降级相关的统计，通过 expvar 暴露，用来配置告警：
+// redis_error 访问 redis 出错的次数；
+// fallback_set 降级之后写到本地的验证码数量；
+// fallback_vertify 在本地验证的次数；
+// degraded 当前是否处于降级状态，1 表示降级
+var (
+	codeCacheStats    = expvar.NewMap("code_cache")
+	codeCacheDegraded = new(expvar.Int)
+)
+
+func init() {
+	codeCacheStats.Set("degraded", codeCacheDegraded)
+}
+
+// FailoverCodeCache redis 为主，redis 出错的时候降级到本地缓存，
+// 连续出错之后熔断一段时间，熔断结束之后自动探测 redis 是否恢复。
+//
+// 降级期间的一致性：
+//  1. 降级期间发出的验证码只保存在发出它的实例上，请求落到别的实例会验证失败，需要重新获取；
+//  2. 重新发送的间隔和验证次数也只在这个实例内生效；
+//  3. redis 恢复之后以 redis 为准，redis 里面有记录的时候不会再看本地。
+//     redis 里面没有记录的时候会再看一下本地，降级期间发出去的验证码在恢复之后依旧能用
+type FailoverCodeCache struct {
+	redis   CodeCache
+	local   CodeCache
+	breaker *breaker.Breaker
+	l       logger.Logger
+}
+
+func NewFailoverCodeCache(redis CodeCache, local CodeCache,
+	b *breaker.Breaker, l logger.Logger) CodeCache {
+	return &FailoverCodeCache{
+		redis:   redis,
+		local:   local,
+		breaker: b,
+		l:       l,
+	}
+}
+
+func (c *FailoverCodeCache) Set(ctx context.Context, biz string, channel domain.CodeChannel, target, code string, p domain.CodePolicy) error {
+	if c.breaker.Allow() {
+		err := c.redis.Set(ctx, biz, channel, target, code, p)
+		if !c.isRedisError(err) {
+			c.success()
+			return err
+		}
+		c.failure(err)
+	}
+	codeCacheStats.Add("fallback_set", 1)
+	return c.local.Set(ctx, biz, channel, target, code, p)
+}
+
+func (c *FailoverCodeCache) Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, code string) (bool, error) {
+	if c.breaker.Allow() {
+		ok, err := c.redis.Vertify(ctx, biz, channel, target, code)
+		if !c.isRedisError(err) {
+			c.success()
+			// 次数耗尽或者 redis 里面根本没有这个验证码，看看是不是降级期间发出去的
+			if err != ErrCodeVertifyTooMany {
+				return ok, err
+			}
+			localOk, localErr := c.local.Vertify(ctx, biz, channel, target, code)
+			if localErr == nil && localOk {
+				codeCacheStats.Add("fallback_vertify", 1)
+				return true, nil
+			}
+			return ok, err
+		}
+		c.failure(err)
+	}
+	codeCacheStats.Add("fallback_vertify", 1)
+	return c.local.Vertify(ctx, biz, channel, target, code)
+}
+
+// isRedisError 业务上的错误说明 redis 是正常的，不需要降级
+func (c *FailoverCodeCache) isRedisError(err error) bool {
+	return err != nil &&
+		err != ErrSendTooMany &&
+		err != ErrCodeVertifyTooMany &&
+		err != ErrCodeNoExpiration &&
+		// 客户端自己断开了，不算 redis 的问题
+		!errors.Is(err, context.Canceled)
+}
+
+func (c *FailoverCodeCache) success() {
+	if c.breaker.Success() {
+		codeCacheDegraded.Set(0)
+		c.l.Info("redis 已经恢复，验证码缓存退出降级模式")
+	}
+}
+
+func (c *FailoverCodeCache) failure(err error) {
+	codeCacheStats.Add("redis_error", 1)
+	if c.breaker.Failure() {
+		codeCacheDegraded.Set(1)
+		c.l.Error("redis 不可用，验证码缓存降级到本地", logger.Error(err))
+	}
+}
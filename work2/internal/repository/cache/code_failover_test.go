@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository/cache/redismock"
+	"example/wb/pkg/breaker"
+	"example/wb/pkg/logger"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/coocood/freecache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var errRedisDown = errors.New("dial tcp 127.0.0.1:6379: connect: connection refused")
+
+func evalResult(val int64, err error) *redis.Cmd {
+	cmd := redis.NewCmd(context.Background())
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	cmd.SetVal(val)
+	return cmd
+}
+
+func codeStat(key string) int64 {
+	v, ok := codeCacheStats.Get(key).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return v.Value()
+}
+
+func TestFailoverCodeCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := []string{"code:sms:login:123456"}
+	cmd := redismock.NewMockCmdable(ctrl)
+	gomock.InOrder(
+		// redis 挂了，写到本地
+		cmd.EXPECT().Eval(gomock.Any(), luaSetCode, key, gomock.Any()).
+			Return(evalResult(0, errRedisDown)),
+		// 熔断期间不会访问 redis，冷却之后的探测成功，redis 里面没有这个验证码
+		cmd.EXPECT().Eval(gomock.Any(), luaVertifyCode, key, "543210").
+			Return(evalResult(-1, nil)),
+		// 恢复之后以 redis 为准
+		cmd.EXPECT().Eval(gomock.Any(), luaSetCode, key, gomock.Any()).
+			Return(evalResult(-1, nil)),
+		cmd.EXPECT().Eval(gomock.Any(), luaVertifyCode, key, "000000").
+			Return(evalResult(-2, nil)),
+	)
+	c := NewFailoverCodeCache(NewCodeCache(cmd), NewCodeLocalCache(freecache.NewCache(1024*1024)),
+		breaker.New(1, time.Millisecond*50), logger.NewNopLogger())
+	ctx := context.Background()
+	beforeSet, beforeVertify := codeStat("fallback_set"), codeStat("fallback_vertify")
+
+	assert.NoError(t, c.Set(ctx, "login", domain.CodeChannelSMS, "123456", "543210", testCodePolicy))
+	assert.Equal(t, int64(1), codeStat("degraded"))
+	// 本地也会限制重新发送
+	assert.Equal(t, ErrSendTooMany, c.Set(ctx, "login", domain.CodeChannelSMS, "123456", "012345", testCodePolicy))
+	ok, err := c.Vertify(ctx, "login", domain.CodeChannelSMS, "123456", "000000")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, beforeSet+2, codeStat("fallback_set"))
+	assert.Equal(t, beforeVertify+1, codeStat("fallback_vertify"))
+
+	time.Sleep(time.Millisecond * 60)
+	// redis 恢复了，降级期间发出去的验证码依旧可以用
+	ok, err = c.Vertify(ctx, "login", domain.CodeChannelSMS, "123456", "543210")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), codeStat("degraded"))
+
+	assert.Equal(t, ErrSendTooMany, c.Set(ctx, "login", domain.CodeChannelSMS, "123456", "012345", testCodePolicy))
+	ok, err = c.Vertify(ctx, "login", domain.CodeChannelSMS, "123456", "000000")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFailoverCodeCache_Vertify(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) redis.Cmdable
+		// 本地有没有这个验证码
+		local bool
+
+		wantOk  bool
+		wantErr error
+	}{
+		{
+			name: "redis验证通过",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Eval(gomock.Any(), luaVertifyCode, gomock.Any(), "543210").
+					Return(evalResult(0, nil))
+				return cmd
+			},
+			wantOk: true,
+		},
+		{
+			name: "redis有记录的时候不看本地",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Eval(gomock.Any(), luaVertifyCode, gomock.Any(), "543210").
+					Return(evalResult(-2, nil))
+				return cmd
+			},
+			local: true,
+		},
+		{
+			name: "redis和本地都没有",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Eval(gomock.Any(), luaVertifyCode, gomock.Any(), "543210").
+					Return(evalResult(-1, nil))
+				return cmd
+			},
+			wantErr: ErrCodeVertifyTooMany,
+		},
+		{
+			name: "redis出错，用本地验证",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Eval(gomock.Any(), luaVertifyCode, gomock.Any(), "543210").
+					Return(evalResult(0, errRedisDown))
+				return cmd
+			},
+			local:  true,
+			wantOk: true,
+		},
+		{
+			name: "客户端断开不降级",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Eval(gomock.Any(), luaVertifyCode, gomock.Any(), "543210").
+					Return(evalResult(0, context.Canceled))
+				return cmd
+			},
+			local:   true,
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			local := NewCodeLocalCache(freecache.NewCache(1024 * 1024))
+			if tc.local {
+				assert.NoError(t, local.Set(context.Background(), "login", domain.CodeChannelSMS, "123456", "543210", testCodePolicy))
+			}
+			c := NewFailoverCodeCache(NewCodeCache(tc.mock(ctrl)), local,
+				breaker.New(3, time.Second), logger.NewNopLogger())
+			ok, err := c.Vertify(context.Background(), "login", domain.CodeChannelSMS, "123456", "543210")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantOk, ok)
+		})
+	}
+}
@@ -5,19 +5,28 @@ import (
 	_ "embed"
 	"errors"
 	"example/wb/config"
+	"example/wb/internal/domain"
 	"example/wb/internal/repository/cache/redismock"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/coocood/freecache"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
 
+var testCodePolicy = domain.CodePolicy{
+	Biz:            "login",
+	TTL:            time.Minute * 10,
+	ResendInterval: time.Minute,
+	MaxAttempts:    3,
+}
+
 func TestRedisCodeCache_Set(t *testing.T) {
 	keyFunc := func(biz, phone string) string {
-		return fmt.Sprintf("phone_code:%s:%s", biz, phone)
+		return fmt.Sprintf("code:sms:%s:%s", biz, phone)
 
 	}
 
@@ -41,7 +50,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 
 				cmd := redismock.NewMockCmdable(ctrl)
 				cmd.EXPECT().
-					Eval(gomock.Any(), luaSetCode, []string{keyFunc("login_sms", "123456")}, "512364").
+					Eval(gomock.Any(), luaSetCode, []string{keyFunc("login_sms", "123456")}, "512364", int64(600), int64(60), 3).
 					Return(rcmd)
 
 				return cmd
@@ -61,7 +70,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 
 				cmd := redismock.NewMockCmdable(ctrl)
 				cmd.EXPECT().
-					Eval(gomock.Any(), luaSetCode, []string{keyFunc("login_sms", "123456")}, "512364").
+					Eval(gomock.Any(), luaSetCode, []string{keyFunc("login_sms", "123456")}, "512364", int64(600), int64(60), 3).
 					Return(rcmd)
 
 				return cmd
@@ -81,7 +90,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 
 				cmd := redismock.NewMockCmdable(ctrl)
 				cmd.EXPECT().
-					Eval(gomock.Any(), luaSetCode, []string{keyFunc("login_sms", "123456")}, "512364").
+					Eval(gomock.Any(), luaSetCode, []string{keyFunc("login_sms", "123456")}, "512364", int64(600), int64(60), 3).
 					Return(rcmd)
 
 				return cmd
@@ -101,7 +110,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 
 				cmd := redismock.NewMockCmdable(ctrl)
 				cmd.EXPECT().
-					Eval(gomock.Any(), luaSetCode, []string{keyFunc("login_sms", "123456")}, "512364").
+					Eval(gomock.Any(), luaSetCode, []string{keyFunc("login_sms", "123456")}, "512364", int64(600), int64(60), 3).
 					Return(rcmd)
 
 				return cmd
@@ -121,7 +130,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 			redisCmd := tt.mock(ctrl)
 			redisCache := NewCodeCache(redisCmd)
 
-			err := redisCache.Set(tt.ctx, tt.biz, tt.phone, tt.code)
+			err := redisCache.Set(tt.ctx, tt.biz, domain.CodeChannelSMS, tt.phone, tt.code, testCodePolicy)
 			assert.Equal(t, tt.wantErr, err)
 		})
 
@@ -157,7 +166,7 @@ func TestRedisCodeCache_Set_e2e(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 				defer cancel()
 
-				key := "phone_code:login:123456"
+				key := "code:sms:login:123456"
 				dur, err := rdb.TTL(ctx, key).Result()
 				assert.NoError(t, err)
 				assert.True(t, dur > time.Minute*9)
@@ -178,7 +187,7 @@ func TestRedisCodeCache_Set_e2e(t *testing.T) {
 				ctx, cancel := context.WithCancel(context.Background())
 				defer cancel()
 
-				key := "phone_code:login:123456"
+				key := "code:sms:login:123456"
 				err := rdb.Set(ctx, key, "543210", 0).Err()
 				assert.NoError(t, err)
 			},
@@ -186,7 +195,7 @@ func TestRedisCodeCache_Set_e2e(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 				defer cancel()
 
-				key := "phone_code:login:123456"
+				key := "code:sms:login:123456"
 				dur, err := rdb.TTL(ctx, key).Result()
 				assert.NoError(t, err)
 				assert.True(t, dur == -1)
@@ -209,7 +218,7 @@ func TestRedisCodeCache_Set_e2e(t *testing.T) {
 				ctx, cancel := context.WithCancel(context.Background())
 				defer cancel()
 
-				key := "phone_code:login:123456"
+				key := "code:sms:login:123456"
 				err := rdb.Set(ctx, key, "543210", time.Minute*10).Err()
 				assert.NoError(t, err)
 			},
@@ -217,7 +226,7 @@ func TestRedisCodeCache_Set_e2e(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 				defer cancel()
 
-				key := "phone_code:login:123456"
+				key := "code:sms:login:123456"
 				dur, err := rdb.TTL(ctx, key).Result()
 				assert.NoError(t, err)
 				assert.True(t, dur > time.Minute*9)
@@ -241,10 +250,54 @@ func TestRedisCodeCache_Set_e2e(t *testing.T) {
 			defer tt.after(t)
 
 			c := NewCodeCache(rdb)
-			err := c.Set(tt.ctx, tt.biz, tt.phone, tt.code)
+			err := c.Set(tt.ctx, tt.biz, domain.CodeChannelSMS, tt.phone, tt.code, testCodePolicy)
 			assert.Equal(t, tt.wantErr, err)
 
 		})
 
 	}
 }
+
+func TestCodeLocalCache(t *testing.T) {
+	ctx := context.Background()
+	p := domain.CodePolicy{
+		TTL:            time.Second * 10,
+		ResendInterval: time.Second * 2,
+		MaxAttempts:    2,
+	}
+	c := NewCodeLocalCache(freecache.NewCache(1024 * 1024))
+
+	// 没有发送过
+	ok, err := c.Vertify(ctx, "login", domain.CodeChannelSMS, "123456", "543210")
+	assert.Equal(t, ErrCodeVertifyTooMany, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "login", domain.CodeChannelSMS, "123456", "543210", p))
+	// 还没有过重新发送的间隔
+	assert.Equal(t, ErrSendTooMany, c.Set(ctx, "login", domain.CodeChannelSMS, "123456", "012345", p))
+
+	// 最多验证 2 次
+	ok, err = c.Vertify(ctx, "login", domain.CodeChannelSMS, "123456", "000000")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = c.Vertify(ctx, "login", domain.CodeChannelSMS, "123456", "000000")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = c.Vertify(ctx, "login", domain.CodeChannelSMS, "123456", "543210")
+	assert.Equal(t, ErrCodeVertifyTooMany, err)
+	assert.False(t, ok)
+
+	// 不同渠道互不影响
+	ok, err = c.Vertify(ctx, "login", domain.CodeChannelEmail, "123456", "543210")
+	assert.Equal(t, ErrCodeVertifyTooMany, err)
+	assert.False(t, ok)
+
+	// 不同业务互不影响，验证通过之后不能再用
+	assert.NoError(t, c.Set(ctx, "reset", domain.CodeChannelSMS, "123456", "543210", p))
+	ok, err = c.Vertify(ctx, "reset", domain.CodeChannelSMS, "123456", "543210")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = c.Vertify(ctx, "reset", domain.CodeChannelSMS, "123456", "543210")
+	assert.Equal(t, ErrCodeVertifyTooMany, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/incr_login_fail.lua
+var luaIncrLoginFail string
+
+// LoginGuardCache 记录登录失败次数以及锁定状态
+// key 由调用者决定，例如 login:email 或者 ip:127.0.0.1
+type LoginGuardCache interface {
+	Failures(ctx context.Context, key string) (int, error)
+	IncrFailures(ctx context.Context, key string, window time.Duration) (int, error)
+	LockTTL(ctx context.Context, key string) (time.Duration, error)
+	Lock(ctx context.Context, key string, dur time.Duration) error
+	Reset(ctx context.Context, key string) error
+}
+
+type RedisLoginGuardCache struct {
+	cmd redis.Cmdable
+}
+
+func NewLoginGuardCache(cmd redis.Cmdable) LoginGuardCache {
+	return &RedisLoginGuardCache{
+		cmd: cmd,
+	}
+}
+
+func (cache *RedisLoginGuardCache) Failures(ctx context.Context, key string) (int, error) {
+	cnt, err := cache.cmd.Get(ctx, cache.failKey(key)).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return cnt, err
+}
+
+func (cache *RedisLoginGuardCache) IncrFailures(ctx context.Context, key string, window time.Duration) (int, error) {
+	return cache.cmd.Eval(ctx, luaIncrLoginFail, []string{cache.failKey(key)},
+		int64(window.Seconds())).Int()
+}
+
+// LockTTL 返回还需要锁定多久，没有锁定返回 0
+func (cache *RedisLoginGuardCache) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := cache.cmd.PTTL(ctx, cache.lockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// -2 代表 key 不存在，-1 代表没有过期时间，都当成没有锁定
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (cache *RedisLoginGuardCache) Lock(ctx context.Context, key string, dur time.Duration) error {
+	return cache.cmd.Set(ctx, cache.lockKey(key), "", dur).Err()
+}
+
+func (cache *RedisLoginGuardCache) Reset(ctx context.Context, key string) error {
+	return cache.cmd.Del(ctx, cache.failKey(key), cache.lockKey(key)).Err()
+}
+
+func (cache *RedisLoginGuardCache) failKey(key string) string {
+	return fmt.Sprintf("login_guard:fail:%s", key)
+}
+
+func (cache *RedisLoginGuardCache) lockKey(key string) string {
+	return fmt.Sprintf("login_guard:lock:%s", key)
+}
@@ -0,0 +1,10 @@
+local key = KEYS[1]
+-- 统计窗口，单位秒
+local window = tonumber(ARGV[1])
+
+local cnt = redis.call("incr", key)
+if cnt == 1 then
+    -- 第一次失败，开始一个新的统计窗口
+    redis.call("expire", key, window)
+end
+return cnt
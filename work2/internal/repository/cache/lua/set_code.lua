@@ -1,6 +1,11 @@
 local key = KEYS[1]
 local cntKey = key..":cnt"
 local val = ARGV[1]
+-- 有效期，单位秒
+local expiration = tonumber(ARGV[2])
+-- 重新发送的间隔，单位秒
+local interval = tonumber(ARGV[3])
+local attempts = tonumber(ARGV[4])
 
 local ttl = tonumber(redis.call("ttl", key))
 -- -1 是存在key但是没有过期时间
@@ -8,16 +13,14 @@ if ttl == -1 then
     return -2
 end
 
--- -2是key不存在, ttl < 540 是发送了一个验证码，但是超过了1分钟
-if ttl == -2 or ttl < 540 then
+-- -2是key不存在, ttl < expiration - interval 是发送了一个验证码，并且已经过了重新发送的间隔
+if ttl == -2 or ttl < expiration - interval then
     redis.call("set", key, val)
-    redis.call("expire", key, 600)
-    redis.call("set", cntKey, 3)
-    redis.call("expire", cntKey, 600)
+    redis.call("expire", key, expiration)
+    redis.call("set", cntKey, attempts)
+    redis.call("expire", cntKey, expiration)
     return 0
 else
-    -- 已经发送了一个验证码，但是还不到一分钟
+    -- 已经发送了一个验证码，但是还没有过重新发送的间隔
     return -1
 end
-     
-    
\ No newline at end of file
@@ -0,0 +1,13 @@
+local key = KEYS[1]
+-- 这次验证码的时间步
+local step = tonumber(ARGV[1])
+-- 过期时间，单位秒
+local ttl = tonumber(ARGV[2])
+
+local last = tonumber(redis.call("get", key))
+if last ~= nil and last >= step then
+    -- 这个时间步或者更晚的验证码已经用过了
+    return 0
+end
+redis.call("set", key, step, "EX", ttl)
+return 1
@@ -14,7 +14,7 @@ if code == expectedCode then
     redis.call("set", cntKey, 0)
     return 0
 else
-    redis.call("desc", cntKey)
+    redis.call("decr", cntKey)
     return -2
 end
 
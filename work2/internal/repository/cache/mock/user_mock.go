@@ -40,6 +40,20 @@ func (m *MockUserCache) EXPECT() *MockUserCacheMockRecorder {
 	return m.recorder
 }
 
+// Del mocks base method.
+func (m *MockUserCache) Del(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockUserCacheMockRecorder) Del(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockUserCache)(nil).Del), ctx, id)
+}
+
 // Get mocks base method.
 func (m *MockUserCache) Get(ctx context.Context, id int64) (domain.User, error) {
 	m.ctrl.T.Helper()
@@ -55,6 +69,21 @@ func (mr *MockUserCacheMockRecorder) Get(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserCache)(nil).Get), ctx, id)
 }
 
+// GetMulti mocks base method.
+func (m *MockUserCache) GetMulti(ctx context.Context, ids []int64) (map[int64]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMulti", ctx, ids)
+	ret0, _ := ret[0].(map[int64]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMulti indicates an expected call of GetMulti.
+func (mr *MockUserCacheMockRecorder) GetMulti(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMulti", reflect.TypeOf((*MockUserCache)(nil).GetMulti), ctx, ids)
+}
+
 // Set mocks base method.
 func (m *MockUserCache) Set(ctx context.Context, u domain.User) error {
 	m.ctrl.T.Helper()
@@ -68,3 +97,17 @@ func (mr *MockUserCacheMockRecorder) Set(ctx, u any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockUserCache)(nil).Set), ctx, u)
 }
+
+// SetMulti mocks base method.
+func (m *MockUserCache) SetMulti(ctx context.Context, us []domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMulti", ctx, us)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMulti indicates an expected call of SetMulti.
+func (mr *MockUserCacheMockRecorder) SetMulti(ctx, us any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMulti", reflect.TypeOf((*MockUserCache)(nil).SetMulti), ctx, us)
+}
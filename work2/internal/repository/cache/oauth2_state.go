@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example/wb/internal/domain"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrOAuth2StateNotFound = errors.New("state 不存在或者已经使用过")
+
+type OAuth2StateCache interface {
+	Set(ctx context.Context, s domain.OAuth2State, expiration time.Duration) error
+	// GetDel 取出来的同时删掉，保证一个 state 只能用一次
+	GetDel(ctx context.Context, state string) (domain.OAuth2State, error)
+}
+
+type RedisOAuth2StateCache struct {
+	cmd redis.Cmdable
+}
+
+func NewOAuth2StateCache(cmd redis.Cmdable) OAuth2StateCache {
+	return &RedisOAuth2StateCache{
+		cmd: cmd,
+	}
+}
+
+func (cache *RedisOAuth2StateCache) Set(ctx context.Context, s domain.OAuth2State, expiration time.Duration) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return cache.cmd.Set(ctx, cache.key(s.State), string(data), expiration).Err()
+}
+
+func (cache *RedisOAuth2StateCache) GetDel(ctx context.Context, state string) (domain.OAuth2State, error) {
+	data, err := cache.cmd.GetDel(ctx, cache.key(state)).Result()
+	if err == redis.Nil {
+		return domain.OAuth2State{}, ErrOAuth2StateNotFound
+	}
+	if err != nil {
+		return domain.OAuth2State{}, err
+	}
+	var s domain.OAuth2State
+	err = json.Unmarshal([]byte(data), &s)
+	return s, err
+}
+
+func (cache *RedisOAuth2StateCache) key(state string) string {
+	return fmt.Sprintf("oauth2:state:%s", state)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RoleCache 缓存用户的角色，没有角色的用户也会缓存一个空列表
+type RoleCache interface {
+	Get(ctx context.Context, uid int64) ([]string, error)
+	Set(ctx context.Context, uid int64, roles []string) error
+	Del(ctx context.Context, uid int64) error
+}
+
+type RedisRoleCache struct {
+	cmd        redis.Cmdable
+	expiration time.Duration
+}
+
+func NewRoleCache(cmd redis.Cmdable) RoleCache {
+	return &RedisRoleCache{
+		cmd:        cmd,
+		expiration: time.Minute * 10,
+	}
+}
+
+func (cache *RedisRoleCache) Get(ctx context.Context, uid int64) ([]string, error) {
+	data, err := cache.cmd.Get(ctx, cache.key(uid)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var roles []string
+	err = json.Unmarshal(data, &roles)
+	return roles, err
+}
+
+func (cache *RedisRoleCache) Set(ctx context.Context, uid int64, roles []string) error {
+	if roles == nil {
+		roles = []string{}
+	}
+	data, err := json.Marshal(roles)
+	if err != nil {
+		return err
+	}
+	return cache.cmd.Set(ctx, cache.key(uid), string(data), cache.expiration).Err()
+}
+
+func (cache *RedisRoleCache) Del(ctx context.Context, uid int64) error {
+	return cache.cmd.Del(ctx, cache.key(uid)).Err()
+}
+
+func (cache *RedisRoleCache) key(uid int64) string {
+	return fmt.Sprintf("users:roles:%d", uid)
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/use_totp_step.lua
+var luaUseTOTPStep string
+
+// TwoFactorCache 记录每个用户最后一次用过的 TOTP 时间步
+type TwoFactorCache interface {
+	// UseTOTPStep step 比记录的大才会记下来并返回 true，
+	// 同一个验证码或者更早的验证码再用一次都返回 false
+	UseTOTPStep(ctx context.Context, uid int64, step int64, ttl time.Duration) (bool, error)
+}
+
+type RedisTwoFactorCache struct {
+	cmd redis.Cmdable
+}
+
+func NewTwoFactorCache(cmd redis.Cmdable) TwoFactorCache {
+	return &RedisTwoFactorCache{
+		cmd: cmd,
+	}
+}
+
+func (cache *RedisTwoFactorCache) UseTOTPStep(ctx context.Context, uid int64, step int64, ttl time.Duration) (bool, error) {
+	res, err := cache.cmd.Eval(ctx, luaUseTOTPStep, []string{cache.key(uid)},
+		step, int64(ttl.Seconds())).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (cache *RedisTwoFactorCache) key(uid int64) string {
+	return fmt.Sprintf("two_factor:totp_step:%d", uid)
+}
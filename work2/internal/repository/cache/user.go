@@ -13,6 +13,11 @@ import (
 type UserCache interface {
 	Get(ctx context.Context, id int64) (domain.User, error)
 	Set(ctx context.Context, u domain.User) error
+	Del(ctx context.Context, id int64) error
+	// GetMulti 一次 MGET 查多个用户，只返回命中的
+	GetMulti(ctx context.Context, ids []int64) (map[int64]domain.User, error)
+	// SetMulti 用 pipeline 一次写回多个用户
+	SetMulti(ctx context.Context, us []domain.User) error
 }
 
 type RedisUserCache struct {
@@ -34,6 +39,8 @@ func (cache *RedisUserCache) Get(ctx context.Context, id int64) (domain.User, er
 	}
 	var u domain.User
 	err = json.Unmarshal([]byte(data), &u)
+	// Id 没有序列化，从 key 里面恢复
+	u.Id = id
 	return u, err
 }
 
@@ -47,6 +54,58 @@ func (cache *RedisUserCache) Set(ctx context.Context, u domain.User) error {
 	return err
 }
 
+func (cache *RedisUserCache) Del(ctx context.Context, id int64) error {
+	return cache.cmd.Del(ctx, cache.key(id)).Err()
+}
+
+func (cache *RedisUserCache) GetMulti(ctx context.Context, ids []int64) (map[int64]domain.User, error) {
+	res := make(map[int64]domain.User, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, cache.key(id))
+	}
+	vals, err := cache.cmd.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, val := range vals {
+		// 没有命中的 key 对应 nil
+		data, ok := val.(string)
+		if !ok {
+			continue
+		}
+		var u domain.User
+		// 数据坏了就当作没有命中，回数据库再查一次
+		if json.Unmarshal([]byte(data), &u) != nil {
+			continue
+		}
+		// Id 不会序列化，从 key 里面恢复
+		u.Id = ids[i]
+		res[ids[i]] = u
+	}
+	return res, nil
+}
+
+func (cache *RedisUserCache) SetMulti(ctx context.Context, us []domain.User) error {
+	if len(us) == 0 {
+		return nil
+	}
+	_, err := cache.cmd.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, u := range us {
+			data, err := json.Marshal(u)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, cache.key(u.Id), string(data), cache.expiration)
+		}
+		return nil
+	})
+	return err
+}
+
 func (cache *RedisUserCache) key(id int64) string {
 	return fmt.Sprintf("user:info:%d", id)
 }
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository/cache/redismock"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRedisUserCache_GetMulti(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) redis.Cmdable
+		ids  []int64
+
+		want    map[int64]domain.User
+		wantErr error
+	}{
+		{
+			name: "部分命中",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				res := redis.NewSliceCmd(context.Background())
+				// 没有命中的是 nil，数据坏了的也当作没有命中
+				res.SetVal([]any{`{"Id":1,"NickName":"小明"}`, nil, `{bad`})
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().MGet(gomock.Any(), "user:info:1", "user:info:2", "user:info:3").
+					Return(res)
+				return cmd
+			},
+			ids: []int64{1, 2, 3},
+			want: map[int64]domain.User{
+				1: {Id: 1, NickName: "小明"},
+			},
+		},
+		{
+			name: "redis出错",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				res := redis.NewSliceCmd(context.Background())
+				res.SetErr(errors.New("redis错误"))
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().MGet(gomock.Any(), "user:info:1").Return(res)
+				return cmd
+			},
+			ids:     []int64{1},
+			wantErr: errors.New("redis错误"),
+		},
+		{
+			name: "没有id不访问redis",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				return redismock.NewMockCmdable(ctrl)
+			},
+			want: map[int64]domain.User{},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c := NewUserCache(tc.mock(ctrl))
+			us, err := c.GetMulti(context.Background(), tc.ids)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, us)
+		})
+	}
+}
+
+func TestRedisUserCache_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	res := redis.NewStringCmd(context.Background())
+	// Id 和 Password 不会序列化
+	res.SetVal(`{"NickName":"小明"}`)
+	cmd := redismock.NewMockCmdable(ctrl)
+	cmd.EXPECT().Get(gomock.Any(), "user:info:1").Return(res)
+
+	u, err := NewUserCache(cmd).Get(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.User{Id: 1, NickName: "小明"}, u)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"example/wb/internal/domain"
 	"example/wb/internal/repository/cache"
 )
 
@@ -9,8 +10,8 @@ var ErrSendTooMany = cache.ErrSendTooMany
 var ErrCodeVertifyTooMany = cache.ErrCodeVertifyTooMany
 
 type CodeRepository interface {
-	Set(ctx context.Context, biz, phone, code string) error
-	Vertify(ctx context.Context, biz, phone, code string) (bool, error)
+	Set(ctx context.Context, biz string, channel domain.CodeChannel, target, code string, p domain.CodePolicy) error
+	Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, code string) (bool, error)
 }
 
 type CachedCodeRepository struct {
@@ -21,10 +22,10 @@ func NewCachedCodeRepository(cache cache.CodeCache) CodeRepository {
 	return &CachedCodeRepository{cache: cache}
 }
 
-func (c *CachedCodeRepository) Set(ctx context.Context, biz, phone, code string) error {
-	return c.cache.Set(ctx, biz, phone, code)
+func (c *CachedCodeRepository) Set(ctx context.Context, biz string, channel domain.CodeChannel, target, code string, p domain.CodePolicy) error {
+	return c.cache.Set(ctx, biz, channel, target, code, p)
 }
 
-func (c *CachedCodeRepository) Vertify(ctx context.Context, biz, phone, code string) (bool, error) {
-	return c.cache.Vertify(ctx, biz, phone, code)
+func (c *CachedCodeRepository) Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, code string) (bool, error) {
+	return c.cache.Vertify(ctx, biz, channel, target, code)
 }
@@ -0,0 +1,73 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ecodeclub/ekit/sqlx"
+	"gorm.io/gorm"
+)
+
+var ErrAccessTokenNotFound = gorm.ErrRecordNotFound
+
+type AccessTokenDao interface {
+	Insert(ctx context.Context, t AccessToken) (int64, error)
+	FindByHash(ctx context.Context, hash string) (AccessToken, error)
+	FindByUid(ctx context.Context, uid int64) ([]AccessToken, error)
+	// Delete 只能删除自己的 token
+	Delete(ctx context.Context, uid int64, id int64) error
+}
+
+type GORMAccessTokenDao struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenDao(db *gorm.DB) AccessTokenDao {
+	return &GORMAccessTokenDao{
+		db: db,
+	}
+}
+
+func (dao *GORMAccessTokenDao) Insert(ctx context.Context, t AccessToken) (int64, error) {
+	t.Ctime = time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Create(&t).Error
+	return t.Id, err
+}
+
+func (dao *GORMAccessTokenDao) FindByHash(ctx context.Context, hash string) (AccessToken, error) {
+	var t AccessToken
+	err := dao.db.WithContext(ctx).Where("token_hash = ?", hash).First(&t).Error
+	return t, err
+}
+
+func (dao *GORMAccessTokenDao) FindByUid(ctx context.Context, uid int64) ([]AccessToken, error) {
+	var res []AccessToken
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("id DESC").Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMAccessTokenDao) Delete(ctx context.Context, uid int64, id int64) error {
+	res := dao.db.WithContext(ctx).
+		Where("id = ? AND uid = ?", id, uid).
+		Delete(&AccessToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+type AccessToken struct {
+	Id   int64  `gorm:"primaryKey;autoIncrement"`
+	Uid  int64  `gorm:"index"`
+	Name string `gorm:"type:varchar(128)"`
+	// 明文的 sha256，token 本身是随机的，不需要加盐
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex"`
+	Hint      string `gorm:"type:varchar(32)"`
+	Scopes    sqlx.JsonColumn[[]string]
+	ExpiresAt int64
+	Ctime     int64
+}
@@ -10,6 +10,8 @@ import (
 )
 
 //go:generate mockgen -source=./article.go -package=daomocks -destination=./mocks/article.mock.go ArticleDAO
+var ErrArticleNotFound = gorm.ErrRecordNotFound
+
 type ArticleDAO interface {
 	Insert(ctx context.Context, art Article) (int64, error)
 	UpdateById(ctx context.Context, entity Article) error
@@ -18,6 +20,8 @@ type ArticleDAO interface {
 	GetByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]Article, error)
 	GetById(ctx context.Context, id int64) (Article, error)
 	GetPubById(ctx context.Context, id int64) (PublishedArticle, error)
+	// SyncStatusByAuthor 把作者所有状态为 from 的帖子改为 to，线上库同步修改
+	SyncStatusByAuthor(ctx context.Context, uid int64, from uint8, to uint8) error
 }
 
 type ArticleGORMDAO struct {
@@ -54,7 +58,7 @@ func (a *ArticleGORMDAO) SyncStatus(ctx context.Context, uid int64, id int64, st
 	now := time.Now().UnixMilli()
 	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		res := tx.Model(&Article{}).
-			Where("id = ? and author_id = ?", uid, id).
+			Where("id = ? and author_id = ?", id, uid).
 			Updates(map[string]any{
 				"utime":  now,
 				"status": status,
@@ -66,7 +70,7 @@ func (a *ArticleGORMDAO) SyncStatus(ctx context.Context, uid int64, id int64, st
 			return errors.New("ID 不对或者创作者不对")
 		}
 		return tx.Model(&PublishedArticle{}).
-			Where("id = ?", uid).
+			Where("id = ?", id).
 			Updates(map[string]any{
 				"utime":  now,
 				"status": status,
@@ -74,6 +78,27 @@ func (a *ArticleGORMDAO) SyncStatus(ctx context.Context, uid int64, id int64, st
 	})
 }
 
+func (a *ArticleGORMDAO) SyncStatusByAuthor(ctx context.Context, uid int64, from uint8, to uint8) error {
+	now := time.Now().UnixMilli()
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Article{}).
+			Where("author_id = ? AND status = ?", uid, from).
+			Updates(map[string]any{
+				"utime":  now,
+				"status": to,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&PublishedArticle{}).
+			Where("author_id = ? AND status = ?", uid, from).
+			Updates(map[string]any{
+				"utime":  now,
+				"status": to,
+			}).Error
+	})
+}
+
 func (a *ArticleGORMDAO) Sync(ctx context.Context, art Article) (int64, error) {
 	var id = art.Id
 	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
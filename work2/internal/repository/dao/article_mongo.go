@@ -24,7 +24,12 @@ func (m *MongoDBArticleDAO) GetByAuthor(ctx context.Context, uid int64, offset i
 
 // GetById implements ArticleDAO.
 func (m *MongoDBArticleDAO) GetById(ctx context.Context, id int64) (Article, error) {
-	panic("unimplemented")
+	var art Article
+	err := m.col.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&art)
+	if err == mongo.ErrNoDocuments {
+		return Article{}, ErrArticleNotFound
+	}
+	return art, err
 }
 
 // GetPubById implements ArticleDAO.
@@ -94,6 +99,24 @@ func (m *MongoDBArticleDAO) SyncStatus(ctx context.Context, uid int64, id int64,
 	return err
 }
 
+// SyncStatusByAuthor implements ArticleDAO.
+func (m *MongoDBArticleDAO) SyncStatusByAuthor(ctx context.Context, uid int64, from uint8, to uint8) error {
+	filter := bson.D{
+		{Key: "author_id", Value: uid},
+		{Key: "status", Value: from},
+	}
+	set := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: to},
+		{Key: "utime", Value: time.Now().UnixMilli()},
+	}}}
+	_, err := m.col.UpdateMany(ctx, filter, set)
+	if err != nil {
+		return err
+	}
+	_, err = m.liveCol.UpdateMany(ctx, filter, set)
+	return err
+}
+
 // UpdateById implements ArticleDao.
 func (m *MongoDBArticleDAO) UpdateById(ctx context.Context, art Article) error {
 	filter := bson.M{"id": art.Id, "author_id": art.AuthorId}
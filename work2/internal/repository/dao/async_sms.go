@@ -14,9 +14,14 @@ var ErrWaitingSMSNotFound = gorm.ErrRecordNotFound
 
 type AsyncSmsDao interface {
 	Insert(ctx context.Context, s AsyncSms) error
-	GetWaitingSMS(ctx context.Context) (AsyncSms, error)
+	// PreemptWaitingSMS 抢占最多 limit 条到了重试时间的短信，抢到的短信 lease 毫秒之内不会再被别人抢到，
+	// 抢占的实例崩溃了，lease 之后会被其他实例重新抢占
+	PreemptWaitingSMS(ctx context.Context, now int64, limit int, lease int64) ([]AsyncSms, error)
 	MarkSuccess(ctx context.Context, id int64) error
-	MarkFailed(ctx context.Context, id int64) error
+	// MarkFailed 这一次发送失败了，没有超过重试次数的话在 nextRetryAt 重试，超过了就标记为失败
+	MarkFailed(ctx context.Context, id int64, nextRetryAt int64) error
+	// MarkAbandoned 不管重试了几次，直接标记为失败
+	MarkAbandoned(ctx context.Context, id int64) error
 }
 
 const (
@@ -37,28 +42,43 @@ func NewSmsDao(db *gorm.DB) AsyncSmsDao {
 	}
 }
 
-func (dao *GORMSmsDao) GetWaitingSMS(ctx context.Context) (AsyncSms, error) {
-	var s AsyncSms
+func (dao *GORMSmsDao) PreemptWaitingSMS(ctx context.Context, now int64, limit int, lease int64) ([]AsyncSms, error) {
+	var res []AsyncSms
 	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 为了避免一些偶发性的失败，只寻找1分钟之前的异步短信发送
-		now := time.Now().UnixMilli()
-		endTime := now - time.Minute.Milliseconds()
-		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where("utime < ? and status = ?", endTime, asyncStatusWaiting).First(&s).Error
+		// SKIP LOCKED 让多个 worker 抢到不同的行，不用互相等待
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? and next_retry_at <= ?", asyncStatusWaiting, now).
+			Order("next_retry_at").
+			Limit(limit).Find(&res).Error
 		if err != nil {
 			return err
 		}
-
+		if len(res) == 0 {
+			return ErrWaitingSMSNotFound
+		}
+		ids := make([]int64, 0, len(res))
+		for _, s := range res {
+			ids = append(ids, s.Id)
+		}
 		err = tx.Model(&AsyncSms{}).
-			Where("id = ?", s.Id).
+			Where("id IN ?", ids).
 			Updates(map[string]any{
-				"retry_cnt": gorm.Expr("retry_cnt+1"),
-				"utime":     now,
+				"retry_cnt":     gorm.Expr("retry_cnt+1"),
+				"next_retry_at": now + lease,
+				"utime":         now,
 			}).Error
-		return err
+		if err != nil {
+			return err
+		}
+		// 返回更新之后的值，RetryCnt 就是算上这一次一共发送了几次
+		for i := range res {
+			res[i].RetryCnt++
+			res[i].NextRetryAt = now + lease
+			res[i].Utime = now
+		}
+		return nil
 	})
-
-	return s, err
+	return res, err
 }
 
 func (dao *GORMSmsDao) MarkSuccess(ctx context.Context, id int64) error {
@@ -71,10 +91,22 @@ func (dao *GORMSmsDao) MarkSuccess(ctx context.Context, id int64) error {
 		}).Error
 
 }
-func (dao *GORMSmsDao) MarkFailed(ctx context.Context, id int64) error {
+func (dao *GORMSmsDao) MarkFailed(ctx context.Context, id int64, nextRetryAt int64) error {
 	now := time.Now().UnixMilli()
 	return dao.db.WithContext(ctx).Model(&AsyncSms{}).
-		Where("id = ? and `retry_cnt` >= `retry_max`", id).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"utime":         now,
+			"next_retry_at": nextRetryAt,
+			"status": gorm.Expr("CASE WHEN `retry_cnt` >= `retry_max` THEN ? ELSE `status` END",
+				asyncStatusFailed),
+		}).Error
+}
+
+func (dao *GORMSmsDao) MarkAbandoned(ctx context.Context, id int64) error {
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Model(&AsyncSms{}).
+		Where("id = ?", id).
 		Updates(map[string]any{
 			"utime":  now,
 			"status": asyncStatusFailed,
@@ -100,9 +132,11 @@ type AsyncSms struct {
 	RetryCnt int
 	// 重试的最大次数
 	RetryMax int
-	Status   uint8
-	Ctime    int64
-	Utime    int64 `gorm:"index"`
+	Status   uint8 `gorm:"index:idx_status_next_retry_at,priority:1"`
+	// 下一次可以发送的时间（毫秒），失败之后按照指数退避往后推，被抢占的时候推到租约结束
+	NextRetryAt int64 `gorm:"index:idx_status_next_retry_at,priority:2"`
+	Ctime       int64
+	Utime       int64 `gorm:"index"`
 }
 
 type SmsConfig struct {
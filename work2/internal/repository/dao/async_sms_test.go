@@ -0,0 +1,90 @@
+package dao_test
+
+import (
+	"context"
+	"database/sql"
+	"example/wb/internal/repository/dao"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestGORMSmsDao_PreemptWaitingSMS(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(t *testing.T) *sql.DB
+
+		wantIds []int64
+		// 返回的是抢占之后的重试次数
+		wantRetryCnts []int
+		wantErr       error
+	}{
+		{
+			name: "抢占一批",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "retry_cnt", "retry_max", "status", "next_retry_at"}).
+					AddRow(1, 0, 3, 0, 900).
+					AddRow(2, 1, 3, 0, 1000)
+				mock.ExpectQuery("SELECT .* WHERE status = \\? and next_retry_at <= \\? ORDER BY next_retry_at LIMIT 10 FOR UPDATE SKIP LOCKED").
+					WithArgs(0, 1000).
+					WillReturnRows(rows)
+				// 租约一分钟
+				mock.ExpectExec("UPDATE .* SET .*`next_retry_at`=\\?.*WHERE id IN \\(\\?,\\?\\)").
+					WithArgs(61000, 1000, 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+				return db
+			},
+			wantIds:       []int64{1, 2},
+			wantRetryCnts: []int{1, 2},
+		},
+		{
+			name: "没有需要发送的",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT .*").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+				return db
+			},
+			wantErr: dao.ErrWaitingSMSNotFound,
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB := tt.mock(t)
+			db, err := gorm.Open(mysql.New(mysql.Config{
+				Conn:                      sqlDB,
+				SkipInitializeWithVersion: true,
+			}), &gorm.Config{
+				DisableAutomaticPing:     true,
+				DisableNestedTransaction: true,
+				SkipDefaultTransaction:   true,
+			})
+			require.NoError(t, err)
+			d := dao.NewSmsDao(db)
+			res, err := d.PreemptWaitingSMS(context.Background(), 1000, 10, 60000)
+			assert.Equal(t, tt.wantErr, err)
+			ids := make([]int64, 0, len(res))
+			retryCnts := make([]int, 0, len(res))
+			for _, s := range res {
+				ids = append(ids, s.Id)
+				retryCnts = append(retryCnts, s.RetryCnt)
+			}
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.wantIds, ids)
+				assert.Equal(t, tt.wantRetryCnts, retryCnts)
+			}
+		})
+	}
+}
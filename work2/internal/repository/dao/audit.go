@@ -0,0 +1,67 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// AuditDao 审计日志只有追加和查询，没有修改和删除
+type AuditDao interface {
+	InsertBatch(ctx context.Context, events []AuditEvent) error
+	Find(ctx context.Context, q AuditQuery) ([]AuditEvent, error)
+}
+
+type GORMAuditDao struct {
+	db *gorm.DB
+}
+
+func NewAuditDao(db *gorm.DB) AuditDao {
+	return &GORMAuditDao{
+		db: db,
+	}
+}
+
+func (dao *GORMAuditDao) InsertBatch(ctx context.Context, events []AuditEvent) error {
+	return dao.db.WithContext(ctx).Create(&events).Error
+}
+
+func (dao *GORMAuditDao) Find(ctx context.Context, q AuditQuery) ([]AuditEvent, error) {
+	db := dao.db.WithContext(ctx)
+	if q.Uid > 0 {
+		db = db.Where("uid = ?", q.Uid)
+	}
+	if q.Action != "" {
+		db = db.Where("action = ?", q.Action)
+	}
+	if q.Start > 0 {
+		db = db.Where("ctime >= ?", q.Start)
+	}
+	if q.End > 0 {
+		db = db.Where("ctime < ?", q.End)
+	}
+	var res []AuditEvent
+	err := db.Order("id DESC").Offset(q.Offset).Limit(q.Limit).Find(&res).Error
+	return res, err
+}
+
+type AuditQuery struct {
+	Uid    int64
+	Action string
+	Start  int64
+	End    int64
+	Offset int
+	Limit  int
+}
+
+type AuditEvent struct {
+	Id        int64  `gorm:"primaryKey;autoIncrement"`
+	Uid       int64  `gorm:"index:uid_ctime"`
+	Action    string `gorm:"type:varchar(64);index"`
+	Target    string `gorm:"type:varchar(256)"`
+	IP        string `gorm:"type:varchar(64)"`
+	UserAgent string `gorm:"type:varchar(512)"`
+	Success   bool
+	Detail    string `gorm:"type:varchar(512)"`
+	Ctime     int64  `gorm:"index:uid_ctime"`
+}
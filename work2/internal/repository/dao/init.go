@@ -3,5 +3,6 @@ package dao
 import "gorm.io/gorm"
 
 func InitTables(db *gorm.DB) error {
-	return db.AutoMigrate(&User{}, &AsyncSms{})
+	return db.AutoMigrate(&User{}, &AsyncSms{}, &UserTwoFactor{}, &UserIdentity{}, &WechatToken{},
+		&Article{}, &PublishedArticle{}, &UserRole{}, &AccessToken{}, &AuditEvent{})
 }
@@ -40,21 +40,6 @@ func (m *MockAsyncSmsDao) EXPECT() *MockAsyncSmsDaoMockRecorder {
 	return m.recorder
 }
 
-// GetWaitingSMS mocks base method.
-func (m *MockAsyncSmsDao) GetWaitingSMS(ctx context.Context) (dao.AsyncSms, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWaitingSMS", ctx)
-	ret0, _ := ret[0].(dao.AsyncSms)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// GetWaitingSMS indicates an expected call of GetWaitingSMS.
-func (mr *MockAsyncSmsDaoMockRecorder) GetWaitingSMS(ctx any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWaitingSMS", reflect.TypeOf((*MockAsyncSmsDao)(nil).GetWaitingSMS), ctx)
-}
-
 // Insert mocks base method.
 func (m *MockAsyncSmsDao) Insert(ctx context.Context, s dao.AsyncSms) error {
 	m.ctrl.T.Helper()
@@ -69,18 +54,32 @@ func (mr *MockAsyncSmsDaoMockRecorder) Insert(ctx, s any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockAsyncSmsDao)(nil).Insert), ctx, s)
 }
 
+// MarkAbandoned mocks base method.
+func (m *MockAsyncSmsDao) MarkAbandoned(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAbandoned", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAbandoned indicates an expected call of MarkAbandoned.
+func (mr *MockAsyncSmsDaoMockRecorder) MarkAbandoned(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAbandoned", reflect.TypeOf((*MockAsyncSmsDao)(nil).MarkAbandoned), ctx, id)
+}
+
 // MarkFailed mocks base method.
-func (m *MockAsyncSmsDao) MarkFailed(ctx context.Context, id int64) error {
+func (m *MockAsyncSmsDao) MarkFailed(ctx context.Context, id, nextRetryAt int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkFailed", ctx, id)
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id, nextRetryAt)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // MarkFailed indicates an expected call of MarkFailed.
-func (mr *MockAsyncSmsDaoMockRecorder) MarkFailed(ctx, id any) *gomock.Call {
+func (mr *MockAsyncSmsDaoMockRecorder) MarkFailed(ctx, id, nextRetryAt any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockAsyncSmsDao)(nil).MarkFailed), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockAsyncSmsDao)(nil).MarkFailed), ctx, id, nextRetryAt)
 }
 
 // MarkSuccess mocks base method.
@@ -96,3 +95,18 @@ func (mr *MockAsyncSmsDaoMockRecorder) MarkSuccess(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSuccess", reflect.TypeOf((*MockAsyncSmsDao)(nil).MarkSuccess), ctx, id)
 }
+
+// PreemptWaitingSMS mocks base method.
+func (m *MockAsyncSmsDao) PreemptWaitingSMS(ctx context.Context, now int64, limit int, lease int64) ([]dao.AsyncSms, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreemptWaitingSMS", ctx, now, limit, lease)
+	ret0, _ := ret[0].([]dao.AsyncSms)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreemptWaitingSMS indicates an expected call of PreemptWaitingSMS.
+func (mr *MockAsyncSmsDaoMockRecorder) PreemptWaitingSMS(ctx, now, limit, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreemptWaitingSMS", reflect.TypeOf((*MockAsyncSmsDao)(nil).PreemptWaitingSMS), ctx, now, limit, lease)
+}
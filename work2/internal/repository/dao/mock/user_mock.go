@@ -40,6 +40,20 @@ func (m *MockUserDao) EXPECT() *MockUserDaoMockRecorder {
 	return m.recorder
 }
 
+// Anonymize mocks base method.
+func (m *MockUserDao) Anonymize(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Anonymize", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Anonymize indicates an expected call of Anonymize.
+func (mr *MockUserDaoMockRecorder) Anonymize(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Anonymize", reflect.TypeOf((*MockUserDao)(nil).Anonymize), ctx, id)
+}
+
 // FindByEmail mocks base method.
 func (m *MockUserDao) FindByEmail(ctx context.Context, email string) (dao.User, error) {
 	m.ctrl.T.Helper()
@@ -70,6 +84,21 @@ func (mr *MockUserDaoMockRecorder) FindById(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockUserDao)(nil).FindById), ctx, id)
 }
 
+// FindByIds mocks base method.
+func (m *MockUserDao) FindByIds(ctx context.Context, ids []int64) ([]dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIds", ctx, ids)
+	ret0, _ := ret[0].([]dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIds indicates an expected call of FindByIds.
+func (mr *MockUserDaoMockRecorder) FindByIds(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIds", reflect.TypeOf((*MockUserDao)(nil).FindByIds), ctx, ids)
+}
+
 // FindByPhone mocks base method.
 func (m *MockUserDao) FindByPhone(ctx context.Context, phone string) (dao.User, error) {
 	m.ctrl.T.Helper()
@@ -100,6 +129,21 @@ func (mr *MockUserDaoMockRecorder) FindByWechat(ctx, openId any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechat", reflect.TypeOf((*MockUserDao)(nil).FindByWechat), ctx, openId)
 }
 
+// FindPendingDeletion mocks base method.
+func (m *MockUserDao) FindPendingDeletion(ctx context.Context, now int64, limit int) ([]dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPendingDeletion", ctx, now, limit)
+	ret0, _ := ret[0].([]dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPendingDeletion indicates an expected call of FindPendingDeletion.
+func (mr *MockUserDaoMockRecorder) FindPendingDeletion(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPendingDeletion", reflect.TypeOf((*MockUserDao)(nil).FindPendingDeletion), ctx, now, limit)
+}
+
 // Insert mocks base method.
 func (m *MockUserDao) Insert(ctx context.Context, u dao.User) error {
 	m.ctrl.T.Helper()
@@ -114,6 +158,34 @@ func (mr *MockUserDaoMockRecorder) Insert(ctx, u any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockUserDao)(nil).Insert), ctx, u)
 }
 
+// MarkDeleted mocks base method.
+func (m *MockUserDao) MarkDeleted(ctx context.Context, id, deleteAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeleted", ctx, id, deleteAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDeleted indicates an expected call of MarkDeleted.
+func (mr *MockUserDaoMockRecorder) MarkDeleted(ctx, id, deleteAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeleted", reflect.TypeOf((*MockUserDao)(nil).MarkDeleted), ctx, id, deleteAt)
+}
+
+// UpdateBannedAt mocks base method.
+func (m *MockUserDao) UpdateBannedAt(ctx context.Context, id, bannedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBannedAt", ctx, id, bannedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBannedAt indicates an expected call of UpdateBannedAt.
+func (mr *MockUserDaoMockRecorder) UpdateBannedAt(ctx, id, bannedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBannedAt", reflect.TypeOf((*MockUserDao)(nil).UpdateBannedAt), ctx, id, bannedAt)
+}
+
 // UpdateById mocks base method.
 func (m *MockUserDao) UpdateById(ctx context.Context, u dao.User) error {
 	m.ctrl.T.Helper()
@@ -127,3 +199,17 @@ func (mr *MockUserDaoMockRecorder) UpdateById(ctx, u any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateById", reflect.TypeOf((*MockUserDao)(nil).UpdateById), ctx, u)
 }
+
+// UpdatePassword mocks base method.
+func (m *MockUserDao) UpdatePassword(ctx context.Context, id int64, oldPassword, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, oldPassword, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserDaoMockRecorder) UpdatePassword(ctx, id, oldPassword, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserDao)(nil).UpdatePassword), ctx, id, oldPassword, password)
+}
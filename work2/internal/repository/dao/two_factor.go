@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ecodeclub/ekit/sqlx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTwoFactorNotFound = gorm.ErrRecordNotFound
+var ErrTwoFactorConflict = errors.New("两步验证数据已经被修改")
+
+type TwoFactorDao interface {
+	Upsert(ctx context.Context, t UserTwoFactor) error
+	FindByUid(ctx context.Context, uid int64) (UserTwoFactor, error)
+	Enable(ctx context.Context, uid int64) error
+	UpdateRecoveryCodes(ctx context.Context, uid int64, utime int64, codes []string) error
+}
+
+type GORMTwoFactorDao struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorDao(db *gorm.DB) TwoFactorDao {
+	return &GORMTwoFactorDao{
+		db: db,
+	}
+}
+
+// Upsert 重新绑定会覆盖掉之前的密钥和恢复码，并且需要重新验证才能开启
+func (dao *GORMTwoFactorDao) Upsert(ctx context.Context, t UserTwoFactor) error {
+	now := time.Now().UnixMilli()
+	t.Ctime = now
+	t.Utime = now
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"secret":         t.Secret,
+			"enabled":        t.Enabled,
+			"recovery_codes": t.RecoveryCodes,
+			"utime":          now,
+		}),
+	}).Create(&t).Error
+}
+
+func (dao *GORMTwoFactorDao) FindByUid(ctx context.Context, uid int64) (UserTwoFactor, error) {
+	var t UserTwoFactor
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).First(&t).Error
+	return t, err
+}
+
+func (dao *GORMTwoFactorDao) Enable(ctx context.Context, uid int64) error {
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Model(&UserTwoFactor{}).
+		Where("uid = ?", uid).
+		Updates(map[string]any{
+			"enabled": true,
+			"utime":   now,
+		}).Error
+}
+
+// UpdateRecoveryCodes 用 utime 做乐观锁，防止同一个恢复码被并发使用两次
+func (dao *GORMTwoFactorDao) UpdateRecoveryCodes(ctx context.Context, uid int64, utime int64, codes []string) error {
+	now := time.Now().UnixMilli()
+	res := dao.db.WithContext(ctx).Model(&UserTwoFactor{}).
+		Where("uid = ? AND utime = ?", uid, utime).
+		Updates(map[string]any{
+			"recovery_codes": sqlx.JsonColumn[[]string]{Val: codes, Valid: true},
+			"utime":          now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTwoFactorConflict
+	}
+	return nil
+}
+
+type UserTwoFactor struct {
+	Id  int64 `gorm:"primaryKey;autoIncrement"`
+	Uid int64 `gorm:"uniqueIndex"`
+	// base32 编码的 TOTP 密钥
+	Secret  string
+	Enabled bool
+	// 恢复码的 sha256
+	RecoveryCodes sqlx.JsonColumn[[]string]
+	Ctime         int64
+	Utime         int64
+}
@@ -12,6 +12,7 @@ import (
 
 var ErrDuplicateUser = errors.New("用户冲突")
 var ErrUserNotFound = gorm.ErrRecordNotFound
+var ErrUserDeleted = errors.New("用户已经注销")
 
 type User struct {
 	ID int64 `gorm:"primaryKey;autoIncrement"`
@@ -26,10 +27,19 @@ type User struct {
 	WechatUnionId sql.NullString
 	Password      string
 	NickName      string
+	Avatar        string
 	Birthday      time.Time `gorm:"default:(-)"`
 	Biography     string
-	CreatedAt     int64
-	UpdatedAt     int64
+	// 申请注销之后，到这个时间点（毫秒）会被匿名化，0 表示没有申请注销
+	DeleteAt int64 `gorm:"index"`
+	// 完成匿名化的时间（毫秒）
+	DeletedAt int64
+	// 被管理员封禁的时间（毫秒），0 表示没有被封禁
+	BannedAt int64
+	// 偏好的验证码渠道：sms、email、voice，空字符串表示没有设置
+	CodeChannel string
+	CreatedAt   int64
+	UpdatedAt   int64
 }
 
 type UserDao interface {
@@ -38,7 +48,20 @@ type UserDao interface {
 	FindByPhone(ctx context.Context, phone string) (User, error)
 	FindByWechat(ctx context.Context, openId string) (User, error)
 	FindById(ctx context.Context, id int64) (User, error)
+	// FindByIds 一次 IN 查询，不存在的 id 直接忽略
+	FindByIds(ctx context.Context, ids []int64) ([]User, error)
 	UpdateById(ctx context.Context, u User) error
+	// MarkDeleted 申请注销，deleteAt 之后会被匿名化
+	MarkDeleted(ctx context.Context, id int64, deleteAt int64) error
+	// FindPendingDeletion 找出已经过了冷静期，还没有匿名化的用户
+	FindPendingDeletion(ctx context.Context, now int64, limit int) ([]User, error)
+	// Anonymize 清除用户的个人信息，以及绑定的第三方身份和凭证
+	Anonymize(ctx context.Context, id int64) error
+	// UpdateBannedAt 封禁或者解封，bannedAt 为 0 表示解封
+	UpdateBannedAt(ctx context.Context, id int64, bannedAt int64) error
+	// UpdatePassword 只有数据库里面还是 oldPassword 的时候才更新成 password，
+	// 已经被别人改掉了就什么都不做
+	UpdatePassword(ctx context.Context, id int64, oldPassword, password string) error
 }
 
 type GORMUserDao struct {
@@ -78,7 +101,7 @@ func (dao *GORMUserDao) FindByPhone(ctx context.Context, phone string) (User, er
 
 func (dao *GORMUserDao) FindByWechat(ctx context.Context, openId string) (User, error) {
 	var u User
-	err := dao.db.WithContext(ctx).Where("openid=?", openId).First(&u).Error
+	err := dao.db.WithContext(ctx).Where("wechat_open_id = ?", openId).First(&u).Error
 
 	return u, err
 }
@@ -89,8 +112,104 @@ func (dao *GORMUserDao) FindById(ctx context.Context, id int64) (User, error) {
 	return u, err
 }
 
+func (dao *GORMUserDao) FindByIds(ctx context.Context, ids []int64) ([]User, error) {
+	var res []User
+	if len(ids) == 0 {
+		return res, nil
+	}
+	err := dao.db.WithContext(ctx).Where("id IN ?", ids).Find(&res).Error
+	return res, err
+}
+
 func (dao *GORMUserDao) UpdateById(ctx context.Context, u User) error {
 	err := dao.db.WithContext(ctx).Model(&u).Where("id=?", u.ID).Updates(u).Error
 
 	return err
 }
+
+func (dao *GORMUserDao) MarkDeleted(ctx context.Context, id int64, deleteAt int64) error {
+	res := dao.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND delete_at = 0", id).
+		Updates(map[string]any{
+			"delete_at":  deleteAt,
+			"updated_at": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserDeleted
+	}
+	return nil
+}
+
+func (dao *GORMUserDao) FindPendingDeletion(ctx context.Context, now int64, limit int) ([]User, error) {
+	var res []User
+	err := dao.db.WithContext(ctx).
+		Where("delete_at > 0 AND delete_at <= ? AND deleted_at = 0", now).
+		Limit(limit).Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMUserDao) Anonymize(ctx context.Context, id int64) error {
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 唯一索引的列置为 NULL，释放出来的邮箱、手机号可以重新注册
+		err := tx.Model(&User{}).Where("id = ?", id).
+			Updates(map[string]any{
+				"email":           nil,
+				"phone":           nil,
+				"wechat_open_id":  nil,
+				"wechat_union_id": nil,
+				"password":        "",
+				"nick_name":       "已注销用户",
+				"avatar":          "",
+				"birthday":        nil,
+				"biography":       "",
+				"deleted_at":      now,
+				"updated_at":      now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("uid = ?", id).Delete(&UserIdentity{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("uid = ?", id).Delete(&WechatToken{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("uid = ?", id).Delete(&AccessToken{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", id).Delete(&UserTwoFactor{}).Error
+	})
+}
+
+func (dao *GORMUserDao) UpdateBannedAt(ctx context.Context, id int64, bannedAt int64) error {
+	res := dao.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"banned_at":  bannedAt,
+			"updated_at": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (dao *GORMUserDao) UpdatePassword(ctx context.Context, id int64, oldPassword, password string) error {
+	// RowsAffected 是 0 说明读出来之后用户改过密码，不能用老密码算出来的哈希覆盖掉
+	return dao.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND password = ?", id, oldPassword).
+		Updates(map[string]any{
+			"password":   password,
+			"updated_at": time.Now().UnixMilli(),
+		}).Error
+}
@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+var ErrIdentityNotFound = gorm.ErrRecordNotFound
+var ErrDuplicateIdentity = errors.New("第三方身份已经绑定")
+
+type UserIdentityDao interface {
+	FindByProvider(ctx context.Context, provider string, subject string) (UserIdentity, error)
+	// InsertWithUser 在同一个事务里面创建用户和绑定的第三方身份
+	InsertWithUser(ctx context.Context, u User, identity UserIdentity) (int64, error)
+}
+
+type GORMUserIdentityDao struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityDao(db *gorm.DB) UserIdentityDao {
+	return &GORMUserIdentityDao{
+		db: db,
+	}
+}
+
+func (dao *GORMUserIdentityDao) FindByProvider(ctx context.Context, provider string, subject string) (UserIdentity, error) {
+	var i UserIdentity
+	err := dao.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&i).Error
+	return i, err
+}
+
+func (dao *GORMUserIdentityDao) InsertWithUser(ctx context.Context, u User, identity UserIdentity) (int64, error) {
+	now := time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&u).Error
+		if err != nil {
+			return err
+		}
+		identity.Uid = u.ID
+		identity.Ctime = now
+		identity.Utime = now
+		return tx.Create(&identity).Error
+	})
+	if me, ok := err.(*mysql.MySQLError); ok {
+		const duplicateErr uint16 = 1062
+		if me.Number == duplicateErr {
+			// 并发回调的时候，另外一个请求已经创建好了
+			return 0, ErrDuplicateIdentity
+		}
+	}
+	return u.ID, err
+}
+
+// UserIdentity 用户在第三方平台上的身份，一个用户可以绑定多个平台
+type UserIdentity struct {
+	Id       int64  `gorm:"primaryKey;autoIncrement"`
+	Uid      int64  `gorm:"index"`
+	Provider string `gorm:"type:varchar(64);uniqueIndex:provider_subject"`
+	Subject  string `gorm:"type:varchar(255);uniqueIndex:provider_subject"`
+	Email    string
+	Name     string
+	Avatar   string
+	Ctime    int64
+	Utime    int64
+}
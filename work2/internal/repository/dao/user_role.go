@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserRoleDao interface {
+	FindByUid(ctx context.Context, uid int64) ([]UserRole, error)
+	// Insert 已经有这个角色的时候什么都不做
+	Insert(ctx context.Context, r UserRole) error
+	Delete(ctx context.Context, uid int64, role string) error
+}
+
+type GORMUserRoleDao struct {
+	db *gorm.DB
+}
+
+func NewUserRoleDao(db *gorm.DB) UserRoleDao {
+	return &GORMUserRoleDao{
+		db: db,
+	}
+}
+
+func (dao *GORMUserRoleDao) FindByUid(ctx context.Context, uid int64) ([]UserRole, error) {
+	var res []UserRole
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMUserRoleDao) Insert(ctx context.Context, r UserRole) error {
+	r.Ctime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoNothing: true,
+	}).Create(&r).Error
+}
+
+func (dao *GORMUserRoleDao) Delete(ctx context.Context, uid int64, role string) error {
+	return dao.db.WithContext(ctx).
+		Where("uid = ? AND role = ?", uid, role).
+		Delete(&UserRole{}).Error
+}
+
+type UserRole struct {
+	Id    int64  `gorm:"primaryKey;autoIncrement"`
+	Uid   int64  `gorm:"uniqueIndex:uid_role"`
+	Role  string `gorm:"type:varchar(64);uniqueIndex:uid_role"`
+	Ctime int64
+}
@@ -102,3 +102,123 @@ func TestGORMUserDao_Insert(t *testing.T) {
 		})
 	}
 }
+
+func TestGORMUserDao_FindByWechat(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock   func(t *testing.T) *sql.DB
+		openId string
+
+		wantUser dao.User
+		wantErr  error
+	}{
+		{
+			name: "找到了",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				assert.NoError(t, err)
+				rows := sqlmock.NewRows([]string{"id", "wechat_open_id"}).
+					AddRow(1, "openid-1")
+				mock.ExpectQuery("SELECT \\* FROM `users` WHERE wechat_open_id = \\? .*").
+					WithArgs("openid-1").
+					WillReturnRows(rows)
+				return db
+			},
+			openId: "openid-1",
+			wantUser: dao.User{
+				ID:           1,
+				WechatOpenId: sql.NullString{String: "openid-1", Valid: true},
+			},
+		},
+		{
+			name: "没有这个用户",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				assert.NoError(t, err)
+				mock.ExpectQuery("SELECT \\* FROM `users` WHERE wechat_open_id = \\? .*").
+					WithArgs("openid-2").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				return db
+			},
+			openId:  "openid-2",
+			wantErr: dao.ErrUserNotFound,
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB := tt.mock(t)
+			db, err := gorm.Open(mysql.New(mysql.Config{
+				Conn:                      sqlDB,
+				SkipInitializeWithVersion: true,
+			}), &gorm.Config{
+				DisableAutomaticPing:     true,
+				DisableNestedTransaction: true,
+			})
+			assert.NoError(t, err)
+			u, err := dao.NewUserDao(db).FindByWechat(context.Background(), tt.openId)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantUser, u)
+		})
+	}
+}
+
+func TestGORMUserDao_UpdatePassword(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(t *testing.T) *sql.DB
+
+		wantErr error
+	}{
+		{
+			name: "更新成功",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				assert.NoError(t, err)
+				mock.ExpectExec("UPDATE `users` SET .* WHERE id = \\? AND password = \\?").
+					WithArgs("new", sqlmock.AnyArg(), int64(1), "old").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				return db
+			},
+		},
+		{
+			name: "密码已经被改掉了",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				assert.NoError(t, err)
+				mock.ExpectExec("UPDATE `users` SET .* WHERE id = \\? AND password = \\?").
+					WithArgs("new", sqlmock.AnyArg(), int64(1), "old").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				return db
+			},
+		},
+		{
+			name: "数据库错误",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				assert.NoError(t, err)
+				mock.ExpectExec("UPDATE `users` SET .* WHERE id = \\? AND password = \\?").
+					WillReturnError(errors.New("db错误"))
+				return db
+			},
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB := tt.mock(t)
+			db, err := gorm.Open(mysql.New(mysql.Config{
+				Conn:                      sqlDB,
+				SkipInitializeWithVersion: true,
+			}), &gorm.Config{
+				DisableAutomaticPing:     true,
+				SkipDefaultTransaction:   true,
+				DisableNestedTransaction: true,
+			})
+			assert.NoError(t, err)
+			err = dao.NewUserDao(db).UpdatePassword(context.Background(), 1, "old", "new")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
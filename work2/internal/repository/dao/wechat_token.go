@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrWechatTokenNotFound = gorm.ErrRecordNotFound
+
+type WechatTokenDao interface {
+	Upsert(ctx context.Context, t WechatToken) error
+	FindByUid(ctx context.Context, uid int64) (WechatToken, error)
+}
+
+type GORMWechatTokenDao struct {
+	db *gorm.DB
+}
+
+func NewWechatTokenDao(db *gorm.DB) WechatTokenDao {
+	return &GORMWechatTokenDao{
+		db: db,
+	}
+}
+
+func (dao *GORMWechatTokenDao) Upsert(ctx context.Context, t WechatToken) error {
+	now := time.Now().UnixMilli()
+	t.Ctime = now
+	t.Utime = now
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"openid":             t.Openid,
+			"access_token":       t.AccessToken,
+			"refresh_token":      t.RefreshToken,
+			"scope":              t.Scope,
+			"expires_at":         t.ExpiresAt,
+			"refresh_expires_at": t.RefreshExpiresAt,
+			"utime":              now,
+		}),
+	}).Create(&t).Error
+}
+
+func (dao *GORMWechatTokenDao) FindByUid(ctx context.Context, uid int64) (WechatToken, error) {
+	var t WechatToken
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).First(&t).Error
+	return t, err
+}
+
+// WechatToken 每个用户最新的一份微信凭证
+type WechatToken struct {
+	Id           int64 `gorm:"primaryKey;autoIncrement"`
+	Uid          int64 `gorm:"uniqueIndex"`
+	Openid       string
+	AccessToken  string
+	RefreshToken string
+	Scope        string
+	// 毫秒
+	ExpiresAt        int64
+	RefreshExpiresAt int64
+	Ctime            int64
+	Utime            int64
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository/dao"
+)
+
+var ErrIdentityNotFound = dao.ErrIdentityNotFound
+var ErrDuplicateIdentity = dao.ErrDuplicateIdentity
+
+type IdentityRepository interface {
+	FindByProvider(ctx context.Context, provider string, subject string) (domain.Identity, error)
+	// CreateWithUser 第一次用第三方登录的时候，创建一个新用户并绑定身份，返回新用户的 id
+	CreateWithUser(ctx context.Context, identity domain.Identity) (int64, error)
+}
+
+type identityRepository struct {
+	dao dao.UserIdentityDao
+}
+
+func NewIdentityRepository(dao dao.UserIdentityDao) IdentityRepository {
+	return &identityRepository{
+		dao: dao,
+	}
+}
+
+func (repo *identityRepository) FindByProvider(ctx context.Context, provider string, subject string) (domain.Identity, error) {
+	i, err := repo.dao.FindByProvider(ctx, provider, subject)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	return repo.toDomain(i), nil
+}
+
+func (repo *identityRepository) CreateWithUser(ctx context.Context, identity domain.Identity) (int64, error) {
+	// 第三方平台上的邮箱不一定可信，不写到 users 表，避免占用别人的邮箱
+	return repo.dao.InsertWithUser(ctx, dao.User{
+		NickName: identity.Name,
+	}, repo.toEntity(identity))
+}
+
+func (repo *identityRepository) toDomain(i dao.UserIdentity) domain.Identity {
+	return domain.Identity{
+		Uid:      i.Uid,
+		Provider: i.Provider,
+		Subject:  i.Subject,
+		Email:    i.Email,
+		Name:     i.Name,
+		Avatar:   i.Avatar,
+	}
+}
+
+func (repo *identityRepository) toEntity(i domain.Identity) dao.UserIdentity {
+	return dao.UserIdentity{
+		Uid:      i.Uid,
+		Provider: i.Provider,
+		Subject:  i.Subject,
+		Email:    i.Email,
+		Name:     i.Name,
+		Avatar:   i.Avatar,
+	}
+}
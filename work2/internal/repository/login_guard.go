@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"example/wb/internal/repository/cache"
+	"time"
+)
+
+type LoginGuardRepository interface {
+	Failures(ctx context.Context, key string) (int, error)
+	IncrFailures(ctx context.Context, key string, window time.Duration) (int, error)
+	LockTTL(ctx context.Context, key string) (time.Duration, error)
+	Lock(ctx context.Context, key string, dur time.Duration) error
+	Reset(ctx context.Context, key string) error
+}
+
+type CachedLoginGuardRepository struct {
+	cache cache.LoginGuardCache
+}
+
+func NewLoginGuardRepository(cache cache.LoginGuardCache) LoginGuardRepository {
+	return &CachedLoginGuardRepository{cache: cache}
+}
+
+func (repo *CachedLoginGuardRepository) Failures(ctx context.Context, key string) (int, error) {
+	return repo.cache.Failures(ctx, key)
+}
+
+func (repo *CachedLoginGuardRepository) IncrFailures(ctx context.Context, key string, window time.Duration) (int, error) {
+	return repo.cache.IncrFailures(ctx, key, window)
+}
+
+func (repo *CachedLoginGuardRepository) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	return repo.cache.LockTTL(ctx, key)
+}
+
+func (repo *CachedLoginGuardRepository) Lock(ctx context.Context, key string, dur time.Duration) error {
+	return repo.cache.Lock(ctx, key, dur)
+}
+
+func (repo *CachedLoginGuardRepository) Reset(ctx context.Context, key string) error {
+	return repo.cache.Reset(ctx, key)
+}
@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/access_token.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/access_token.go -destination=internal/repository/mock/access_token_mock.go -package=repomock
+//
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccessTokenRepository is a mock of AccessTokenRepository interface.
+type MockAccessTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessTokenRepositoryMockRecorder
+}
+
+// MockAccessTokenRepositoryMockRecorder is the mock recorder for MockAccessTokenRepository.
+type MockAccessTokenRepositoryMockRecorder struct {
+	mock *MockAccessTokenRepository
+}
+
+// NewMockAccessTokenRepository creates a new mock instance.
+func NewMockAccessTokenRepository(ctrl *gomock.Controller) *MockAccessTokenRepository {
+	mock := &MockAccessTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockAccessTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessTokenRepository) EXPECT() *MockAccessTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAccessTokenRepository) Create(ctx context.Context, t domain.AccessToken, hash string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, t, hash)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAccessTokenRepositoryMockRecorder) Create(ctx, t, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAccessTokenRepository)(nil).Create), ctx, t, hash)
+}
+
+// Delete mocks base method.
+func (m *MockAccessTokenRepository) Delete(ctx context.Context, uid, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, uid, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAccessTokenRepositoryMockRecorder) Delete(ctx, uid, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAccessTokenRepository)(nil).Delete), ctx, uid, id)
+}
+
+// FindByHash mocks base method.
+func (m *MockAccessTokenRepository) FindByHash(ctx context.Context, hash string) (domain.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByHash", ctx, hash)
+	ret0, _ := ret[0].(domain.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByHash indicates an expected call of FindByHash.
+func (mr *MockAccessTokenRepositoryMockRecorder) FindByHash(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByHash", reflect.TypeOf((*MockAccessTokenRepository)(nil).FindByHash), ctx, hash)
+}
+
+// FindByUid mocks base method.
+func (m *MockAccessTokenRepository) FindByUid(ctx context.Context, uid int64) ([]domain.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUid", ctx, uid)
+	ret0, _ := ret[0].([]domain.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUid indicates an expected call of FindByUid.
+func (mr *MockAccessTokenRepositoryMockRecorder) FindByUid(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUid", reflect.TypeOf((*MockAccessTokenRepository)(nil).FindByUid), ctx, uid)
+}
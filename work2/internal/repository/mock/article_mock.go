@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/article.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/article.go -destination=internal/repository/mock/article_mock.go -package=repomock
+//
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockArticleRepository is a mock of ArticleRepository interface.
+type MockArticleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockArticleRepositoryMockRecorder
+}
+
+// MockArticleRepositoryMockRecorder is the mock recorder for MockArticleRepository.
+type MockArticleRepositoryMockRecorder struct {
+	mock *MockArticleRepository
+}
+
+// NewMockArticleRepository creates a new mock instance.
+func NewMockArticleRepository(ctrl *gomock.Controller) *MockArticleRepository {
+	mock := &MockArticleRepository{ctrl: ctrl}
+	mock.recorder = &MockArticleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockArticleRepository) EXPECT() *MockArticleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockArticleRepository) Create(ctx context.Context, art domain.Article) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, art)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockArticleRepositoryMockRecorder) Create(ctx, art any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockArticleRepository)(nil).Create), ctx, art)
+}
+
+// GetByAuthor mocks base method.
+func (m *MockArticleRepository) GetByAuthor(ctx context.Context, uid int64, limit, offset int) ([]domain.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAuthor", ctx, uid, limit, offset)
+	ret0, _ := ret[0].([]domain.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAuthor indicates an expected call of GetByAuthor.
+func (mr *MockArticleRepositoryMockRecorder) GetByAuthor(ctx, uid, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAuthor", reflect.TypeOf((*MockArticleRepository)(nil).GetByAuthor), ctx, uid, limit, offset)
+}
+
+// GetByAuthorFromDB mocks base method.
+func (m *MockArticleRepository) GetByAuthorFromDB(ctx context.Context, uid int64, limit, offset int) ([]domain.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAuthorFromDB", ctx, uid, limit, offset)
+	ret0, _ := ret[0].([]domain.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAuthorFromDB indicates an expected call of GetByAuthorFromDB.
+func (mr *MockArticleRepositoryMockRecorder) GetByAuthorFromDB(ctx, uid, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAuthorFromDB", reflect.TypeOf((*MockArticleRepository)(nil).GetByAuthorFromDB), ctx, uid, limit, offset)
+}
+
+// GetById mocks base method.
+func (m *MockArticleRepository) GetById(ctx context.Context, id int64) (domain.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetById", ctx, id)
+	ret0, _ := ret[0].(domain.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetById indicates an expected call of GetById.
+func (mr *MockArticleRepositoryMockRecorder) GetById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockArticleRepository)(nil).GetById), ctx, id)
+}
+
+// Sync mocks base method.
+func (m *MockArticleRepository) Sync(ctx context.Context, art domain.Article) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sync", ctx, art)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sync indicates an expected call of Sync.
+func (mr *MockArticleRepositoryMockRecorder) Sync(ctx, art any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockArticleRepository)(nil).Sync), ctx, art)
+}
+
+// SyncStatus mocks base method.
+func (m *MockArticleRepository) SyncStatus(ctx context.Context, uid, id int64, status domain.ArticleStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncStatus", ctx, uid, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncStatus indicates an expected call of SyncStatus.
+func (mr *MockArticleRepositoryMockRecorder) SyncStatus(ctx, uid, id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStatus", reflect.TypeOf((*MockArticleRepository)(nil).SyncStatus), ctx, uid, id, status)
+}
+
+// SyncStatusByAuthor mocks base method.
+func (m *MockArticleRepository) SyncStatusByAuthor(ctx context.Context, uid int64, from, to domain.ArticleStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncStatusByAuthor", ctx, uid, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncStatusByAuthor indicates an expected call of SyncStatusByAuthor.
+func (mr *MockArticleRepositoryMockRecorder) SyncStatusByAuthor(ctx, uid, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStatusByAuthor", reflect.TypeOf((*MockArticleRepository)(nil).SyncStatusByAuthor), ctx, uid, from, to)
+}
+
+// Update mocks base method.
+func (m *MockArticleRepository) Update(ctx context.Context, art domain.Article) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, art)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockArticleRepositoryMockRecorder) Update(ctx, art any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockArticleRepository)(nil).Update), ctx, art)
+}
@@ -11,6 +11,7 @@ package repomock
 
 import (
 	context "context"
+	domain "example/wb/internal/domain"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,30 +41,30 @@ func (m *MockCodeRepository) EXPECT() *MockCodeRepositoryMockRecorder {
 }
 
 // Set mocks base method.
-func (m *MockCodeRepository) Set(ctx context.Context, biz, phone, code string) error {
+func (m *MockCodeRepository) Set(ctx context.Context, biz string, channel domain.CodeChannel, target, code string, p domain.CodePolicy) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Set", ctx, biz, phone, code)
+	ret := m.ctrl.Call(m, "Set", ctx, biz, channel, target, code, p)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Set indicates an expected call of Set.
-func (mr *MockCodeRepositoryMockRecorder) Set(ctx, biz, phone, code any) *gomock.Call {
+func (mr *MockCodeRepositoryMockRecorder) Set(ctx, biz, channel, target, code, p any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCodeRepository)(nil).Set), ctx, biz, phone, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCodeRepository)(nil).Set), ctx, biz, channel, target, code, p)
 }
 
 // Vertify mocks base method.
-func (m *MockCodeRepository) Vertify(ctx context.Context, biz, phone, code string) (bool, error) {
+func (m *MockCodeRepository) Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, code string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Vertify", ctx, biz, phone, code)
+	ret := m.ctrl.Call(m, "Vertify", ctx, biz, channel, target, code)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Vertify indicates an expected call of Vertify.
-func (mr *MockCodeRepositoryMockRecorder) Vertify(ctx, biz, phone, code any) *gomock.Call {
+func (mr *MockCodeRepositoryMockRecorder) Vertify(ctx, biz, channel, target, code any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vertify", reflect.TypeOf((*MockCodeRepository)(nil).Vertify), ctx, biz, phone, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vertify", reflect.TypeOf((*MockCodeRepository)(nil).Vertify), ctx, biz, channel, target, code)
 }
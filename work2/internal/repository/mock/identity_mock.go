@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/identity.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/identity.go -destination=internal/repository/mock/identity_mock.go -package=repomock
+//
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIdentityRepository is a mock of IdentityRepository interface.
+type MockIdentityRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityRepositoryMockRecorder
+}
+
+// MockIdentityRepositoryMockRecorder is the mock recorder for MockIdentityRepository.
+type MockIdentityRepositoryMockRecorder struct {
+	mock *MockIdentityRepository
+}
+
+// NewMockIdentityRepository creates a new mock instance.
+func NewMockIdentityRepository(ctrl *gomock.Controller) *MockIdentityRepository {
+	mock := &MockIdentityRepository{ctrl: ctrl}
+	mock.recorder = &MockIdentityRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityRepository) EXPECT() *MockIdentityRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateWithUser mocks base method.
+func (m *MockIdentityRepository) CreateWithUser(ctx context.Context, identity domain.Identity) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithUser", ctx, identity)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWithUser indicates an expected call of CreateWithUser.
+func (mr *MockIdentityRepositoryMockRecorder) CreateWithUser(ctx, identity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithUser", reflect.TypeOf((*MockIdentityRepository)(nil).CreateWithUser), ctx, identity)
+}
+
+// FindByProvider mocks base method.
+func (m *MockIdentityRepository) FindByProvider(ctx context.Context, provider, subject string) (domain.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByProvider", ctx, provider, subject)
+	ret0, _ := ret[0].(domain.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByProvider indicates an expected call of FindByProvider.
+func (mr *MockIdentityRepositoryMockRecorder) FindByProvider(ctx, provider, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByProvider", reflect.TypeOf((*MockIdentityRepository)(nil).FindByProvider), ctx, provider, subject)
+}
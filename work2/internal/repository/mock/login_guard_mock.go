@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/login_guard.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/login_guard.go -destination=internal/repository/mock/login_guard_mock.go -package=repomock
+//
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginGuardRepository is a mock of LoginGuardRepository interface.
+type MockLoginGuardRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginGuardRepositoryMockRecorder
+}
+
+// MockLoginGuardRepositoryMockRecorder is the mock recorder for MockLoginGuardRepository.
+type MockLoginGuardRepositoryMockRecorder struct {
+	mock *MockLoginGuardRepository
+}
+
+// NewMockLoginGuardRepository creates a new mock instance.
+func NewMockLoginGuardRepository(ctrl *gomock.Controller) *MockLoginGuardRepository {
+	mock := &MockLoginGuardRepository{ctrl: ctrl}
+	mock.recorder = &MockLoginGuardRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginGuardRepository) EXPECT() *MockLoginGuardRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Failures mocks base method.
+func (m *MockLoginGuardRepository) Failures(ctx context.Context, key string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Failures", ctx, key)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Failures indicates an expected call of Failures.
+func (mr *MockLoginGuardRepositoryMockRecorder) Failures(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Failures", reflect.TypeOf((*MockLoginGuardRepository)(nil).Failures), ctx, key)
+}
+
+// IncrFailures mocks base method.
+func (m *MockLoginGuardRepository) IncrFailures(ctx context.Context, key string, window time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrFailures", ctx, key, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrFailures indicates an expected call of IncrFailures.
+func (mr *MockLoginGuardRepositoryMockRecorder) IncrFailures(ctx, key, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrFailures", reflect.TypeOf((*MockLoginGuardRepository)(nil).IncrFailures), ctx, key, window)
+}
+
+// Lock mocks base method.
+func (m *MockLoginGuardRepository) Lock(ctx context.Context, key string, dur time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, dur)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLoginGuardRepositoryMockRecorder) Lock(ctx, key, dur any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLoginGuardRepository)(nil).Lock), ctx, key, dur)
+}
+
+// LockTTL mocks base method.
+func (m *MockLoginGuardRepository) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockTTL", ctx, key)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockTTL indicates an expected call of LockTTL.
+func (mr *MockLoginGuardRepositoryMockRecorder) LockTTL(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockTTL", reflect.TypeOf((*MockLoginGuardRepository)(nil).LockTTL), ctx, key)
+}
+
+// Reset mocks base method.
+func (m *MockLoginGuardRepository) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginGuardRepositoryMockRecorder) Reset(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginGuardRepository)(nil).Reset), ctx, key)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/oauth2_state.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/oauth2_state.go -destination=internal/repository/mock/oauth2_state_mock.go -package=repomock
+//
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOAuth2StateRepository is a mock of OAuth2StateRepository interface.
+type MockOAuth2StateRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuth2StateRepositoryMockRecorder
+}
+
+// MockOAuth2StateRepositoryMockRecorder is the mock recorder for MockOAuth2StateRepository.
+type MockOAuth2StateRepositoryMockRecorder struct {
+	mock *MockOAuth2StateRepository
+}
+
+// NewMockOAuth2StateRepository creates a new mock instance.
+func NewMockOAuth2StateRepository(ctrl *gomock.Controller) *MockOAuth2StateRepository {
+	mock := &MockOAuth2StateRepository{ctrl: ctrl}
+	mock.recorder = &MockOAuth2StateRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth2StateRepository) EXPECT() *MockOAuth2StateRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockOAuth2StateRepository) Consume(ctx context.Context, state string) (domain.OAuth2State, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, state)
+	ret0, _ := ret[0].(domain.OAuth2State)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockOAuth2StateRepositoryMockRecorder) Consume(ctx, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockOAuth2StateRepository)(nil).Consume), ctx, state)
+}
+
+// Save mocks base method.
+func (m *MockOAuth2StateRepository) Save(ctx context.Context, s domain.OAuth2State, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, s, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockOAuth2StateRepositoryMockRecorder) Save(ctx, s, expiration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOAuth2StateRepository)(nil).Save), ctx, s, expiration)
+}
@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/role.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/role.go -destination=internal/repository/mock/role_mock.go -package=repomock
+//
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoleRepository is a mock of RoleRepository interface.
+type MockRoleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleRepositoryMockRecorder
+}
+
+// MockRoleRepositoryMockRecorder is the mock recorder for MockRoleRepository.
+type MockRoleRepositoryMockRecorder struct {
+	mock *MockRoleRepository
+}
+
+// NewMockRoleRepository creates a new mock instance.
+func NewMockRoleRepository(ctrl *gomock.Controller) *MockRoleRepository {
+	mock := &MockRoleRepository{ctrl: ctrl}
+	mock.recorder = &MockRoleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleRepository) EXPECT() *MockRoleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindByUid mocks base method.
+func (m *MockRoleRepository) FindByUid(ctx context.Context, uid int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUid", ctx, uid)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUid indicates an expected call of FindByUid.
+func (mr *MockRoleRepositoryMockRecorder) FindByUid(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUid", reflect.TypeOf((*MockRoleRepository)(nil).FindByUid), ctx, uid)
+}
+
+// Grant mocks base method.
+func (m *MockRoleRepository) Grant(ctx context.Context, uid int64, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Grant", ctx, uid, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Grant indicates an expected call of Grant.
+func (mr *MockRoleRepositoryMockRecorder) Grant(ctx, uid, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Grant", reflect.TypeOf((*MockRoleRepository)(nil).Grant), ctx, uid, role)
+}
+
+// Revoke mocks base method.
+func (m *MockRoleRepository) Revoke(ctx context.Context, uid int64, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, uid, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockRoleRepositoryMockRecorder) Revoke(ctx, uid, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockRoleRepository)(nil).Revoke), ctx, uid, role)
+}
@@ -13,6 +13,7 @@ import (
 	context "context"
 	domain "example/wb/internal/domain"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -40,6 +41,20 @@ func (m *MockAsyncSmsRepository) EXPECT() *MockAsyncSmsRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Abandon mocks base method.
+func (m *MockAsyncSmsRepository) Abandon(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Abandon", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Abandon indicates an expected call of Abandon.
+func (mr *MockAsyncSmsRepositoryMockRecorder) Abandon(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Abandon", reflect.TypeOf((*MockAsyncSmsRepository)(nil).Abandon), ctx, id)
+}
+
 // Create mocks base method.
 func (m *MockAsyncSmsRepository) Create(ctx context.Context, s domain.AsyncSms) error {
 	m.ctrl.T.Helper()
@@ -55,30 +70,30 @@ func (mr *MockAsyncSmsRepositoryMockRecorder) Create(ctx, s any) *gomock.Call {
 }
 
 // PreemptWaitingSMS mocks base method.
-func (m *MockAsyncSmsRepository) PreemptWaitingSMS(ctx context.Context) (domain.AsyncSms, error) {
+func (m *MockAsyncSmsRepository) PreemptWaitingSMS(ctx context.Context, limit int, lease time.Duration) ([]domain.AsyncSms, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PreemptWaitingSMS", ctx)
-	ret0, _ := ret[0].(domain.AsyncSms)
+	ret := m.ctrl.Call(m, "PreemptWaitingSMS", ctx, limit, lease)
+	ret0, _ := ret[0].([]domain.AsyncSms)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PreemptWaitingSMS indicates an expected call of PreemptWaitingSMS.
-func (mr *MockAsyncSmsRepositoryMockRecorder) PreemptWaitingSMS(ctx any) *gomock.Call {
+func (mr *MockAsyncSmsRepositoryMockRecorder) PreemptWaitingSMS(ctx, limit, lease any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreemptWaitingSMS", reflect.TypeOf((*MockAsyncSmsRepository)(nil).PreemptWaitingSMS), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreemptWaitingSMS", reflect.TypeOf((*MockAsyncSmsRepository)(nil).PreemptWaitingSMS), ctx, limit, lease)
 }
 
 // ReportScheduleResult mocks base method.
-func (m *MockAsyncSmsRepository) ReportScheduleResult(ctx context.Context, id int64, success bool) error {
+func (m *MockAsyncSmsRepository) ReportScheduleResult(ctx context.Context, id int64, success bool, nextRetryAt time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReportScheduleResult", ctx, id, success)
+	ret := m.ctrl.Call(m, "ReportScheduleResult", ctx, id, success, nextRetryAt)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ReportScheduleResult indicates an expected call of ReportScheduleResult.
-func (mr *MockAsyncSmsRepositoryMockRecorder) ReportScheduleResult(ctx, id, success any) *gomock.Call {
+func (mr *MockAsyncSmsRepositoryMockRecorder) ReportScheduleResult(ctx, id, success, nextRetryAt any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportScheduleResult", reflect.TypeOf((*MockAsyncSmsRepository)(nil).ReportScheduleResult), ctx, id, success)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportScheduleResult", reflect.TypeOf((*MockAsyncSmsRepository)(nil).ReportScheduleResult), ctx, id, success, nextRetryAt)
 }
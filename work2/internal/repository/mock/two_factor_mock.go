@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/two_factor.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/two_factor.go -destination=internal/repository/mock/two_factor_mock.go -package=repomock
+//
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTwoFactorRepository is a mock of TwoFactorRepository interface.
+type MockTwoFactorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorRepositoryMockRecorder
+}
+
+// MockTwoFactorRepositoryMockRecorder is the mock recorder for MockTwoFactorRepository.
+type MockTwoFactorRepositoryMockRecorder struct {
+	mock *MockTwoFactorRepository
+}
+
+// NewMockTwoFactorRepository creates a new mock instance.
+func NewMockTwoFactorRepository(ctrl *gomock.Controller) *MockTwoFactorRepository {
+	mock := &MockTwoFactorRepository{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorRepository) EXPECT() *MockTwoFactorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Enable mocks base method.
+func (m *MockTwoFactorRepository) Enable(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enable", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enable indicates an expected call of Enable.
+func (mr *MockTwoFactorRepositoryMockRecorder) Enable(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enable", reflect.TypeOf((*MockTwoFactorRepository)(nil).Enable), ctx, uid)
+}
+
+// FindByUid mocks base method.
+func (m *MockTwoFactorRepository) FindByUid(ctx context.Context, uid int64) (domain.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUid", ctx, uid)
+	ret0, _ := ret[0].(domain.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUid indicates an expected call of FindByUid.
+func (mr *MockTwoFactorRepositoryMockRecorder) FindByUid(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUid", reflect.TypeOf((*MockTwoFactorRepository)(nil).FindByUid), ctx, uid)
+}
+
+// Save mocks base method.
+func (m *MockTwoFactorRepository) Save(ctx context.Context, t domain.TwoFactor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockTwoFactorRepositoryMockRecorder) Save(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockTwoFactorRepository)(nil).Save), ctx, t)
+}
+
+// UpdateRecoveryCodes mocks base method.
+func (m *MockTwoFactorRepository) UpdateRecoveryCodes(ctx context.Context, t domain.TwoFactor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecoveryCodes", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRecoveryCodes indicates an expected call of UpdateRecoveryCodes.
+func (mr *MockTwoFactorRepositoryMockRecorder) UpdateRecoveryCodes(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecoveryCodes", reflect.TypeOf((*MockTwoFactorRepository)(nil).UpdateRecoveryCodes), ctx, t)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockTwoFactorRepository) UseTOTPStep(ctx context.Context, uid, step int64, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, uid, step, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockTwoFactorRepositoryMockRecorder) UseTOTPStep(ctx, uid, step, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockTwoFactorRepository)(nil).UseTOTPStep), ctx, uid, step, ttl)
+}
@@ -12,6 +12,7 @@ package repomock
 import (
 	domain "example/wb/internal/domain"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 	context "golang.org/x/net/context"
@@ -40,6 +41,20 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Anonymize mocks base method.
+func (m *MockUserRepository) Anonymize(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Anonymize", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Anonymize indicates an expected call of Anonymize.
+func (mr *MockUserRepositoryMockRecorder) Anonymize(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Anonymize", reflect.TypeOf((*MockUserRepository)(nil).Anonymize), ctx, id)
+}
+
 // Create mocks base method.
 func (m *MockUserRepository) Create(ctx context.Context, u domain.User) error {
 	m.ctrl.T.Helper()
@@ -84,6 +99,36 @@ func (mr *MockUserRepositoryMockRecorder) FindById(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockUserRepository)(nil).FindById), ctx, id)
 }
 
+// FindByIdFromDB mocks base method.
+func (m *MockUserRepository) FindByIdFromDB(ctx context.Context, id int64) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIdFromDB", ctx, id)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIdFromDB indicates an expected call of FindByIdFromDB.
+func (mr *MockUserRepositoryMockRecorder) FindByIdFromDB(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIdFromDB", reflect.TypeOf((*MockUserRepository)(nil).FindByIdFromDB), ctx, id)
+}
+
+// FindByIds mocks base method.
+func (m *MockUserRepository) FindByIds(ctx context.Context, ids []int64) (map[int64]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIds", ctx, ids)
+	ret0, _ := ret[0].(map[int64]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIds indicates an expected call of FindByIds.
+func (mr *MockUserRepositoryMockRecorder) FindByIds(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIds", reflect.TypeOf((*MockUserRepository)(nil).FindByIds), ctx, ids)
+}
+
 // FindByPhone mocks base method.
 func (m *MockUserRepository) FindByPhone(ctx context.Context, phone string) (domain.User, error) {
 	m.ctrl.T.Helper()
@@ -114,6 +159,49 @@ func (mr *MockUserRepositoryMockRecorder) FindByWechat(ctx, openId any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechat", reflect.TypeOf((*MockUserRepository)(nil).FindByWechat), ctx, openId)
 }
 
+// FindPendingDeletion mocks base method.
+func (m *MockUserRepository) FindPendingDeletion(ctx context.Context, now time.Time, limit int) ([]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPendingDeletion", ctx, now, limit)
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPendingDeletion indicates an expected call of FindPendingDeletion.
+func (mr *MockUserRepositoryMockRecorder) FindPendingDeletion(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPendingDeletion", reflect.TypeOf((*MockUserRepository)(nil).FindPendingDeletion), ctx, now, limit)
+}
+
+// MarkDeleted mocks base method.
+func (m *MockUserRepository) MarkDeleted(ctx context.Context, id int64, deleteAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeleted", ctx, id, deleteAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDeleted indicates an expected call of MarkDeleted.
+func (mr *MockUserRepositoryMockRecorder) MarkDeleted(ctx, id, deleteAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeleted", reflect.TypeOf((*MockUserRepository)(nil).MarkDeleted), ctx, id, deleteAt)
+}
+
+// UpdateBannedAt mocks base method.
+func (m *MockUserRepository) UpdateBannedAt(ctx context.Context, id int64, bannedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBannedAt", ctx, id, bannedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBannedAt indicates an expected call of UpdateBannedAt.
+func (mr *MockUserRepositoryMockRecorder) UpdateBannedAt(ctx, id, bannedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBannedAt", reflect.TypeOf((*MockUserRepository)(nil).UpdateBannedAt), ctx, id, bannedAt)
+}
+
 // UpdateById mocks base method.
 func (m *MockUserRepository) UpdateById(ctx context.Context, u domain.User) error {
 	m.ctrl.T.Helper()
@@ -127,3 +215,17 @@ func (mr *MockUserRepositoryMockRecorder) UpdateById(ctx, u any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateById", reflect.TypeOf((*MockUserRepository)(nil).UpdateById), ctx, u)
 }
+
+// UpdatePassword mocks base method.
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id int64, oldPassword, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, oldPassword, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserRepositoryMockRecorder) UpdatePassword(ctx, id, oldPassword, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepository)(nil).UpdatePassword), ctx, id, oldPassword, password)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/wechat_token.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/wechat_token.go -destination=internal/repository/mock/wechat_token_mock.go -package=repomock
+//
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWechatTokenRepository is a mock of WechatTokenRepository interface.
+type MockWechatTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWechatTokenRepositoryMockRecorder
+}
+
+// MockWechatTokenRepositoryMockRecorder is the mock recorder for MockWechatTokenRepository.
+type MockWechatTokenRepositoryMockRecorder struct {
+	mock *MockWechatTokenRepository
+}
+
+// NewMockWechatTokenRepository creates a new mock instance.
+func NewMockWechatTokenRepository(ctrl *gomock.Controller) *MockWechatTokenRepository {
+	mock := &MockWechatTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockWechatTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWechatTokenRepository) EXPECT() *MockWechatTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindByUid mocks base method.
+func (m *MockWechatTokenRepository) FindByUid(ctx context.Context, uid int64) (domain.WechatToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUid", ctx, uid)
+	ret0, _ := ret[0].(domain.WechatToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUid indicates an expected call of FindByUid.
+func (mr *MockWechatTokenRepositoryMockRecorder) FindByUid(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUid", reflect.TypeOf((*MockWechatTokenRepository)(nil).FindByUid), ctx, uid)
+}
+
+// Save mocks base method.
+func (m *MockWechatTokenRepository) Save(ctx context.Context, t domain.WechatToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockWechatTokenRepositoryMockRecorder) Save(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockWechatTokenRepository)(nil).Save), ctx, t)
+}
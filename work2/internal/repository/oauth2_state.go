@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository/cache"
+	"time"
+)
+
+var ErrOAuth2StateNotFound = cache.ErrOAuth2StateNotFound
+
+type OAuth2StateRepository interface {
+	Save(ctx context.Context, s domain.OAuth2State, expiration time.Duration) error
+	Consume(ctx context.Context, state string) (domain.OAuth2State, error)
+}
+
+type CachedOAuth2StateRepository struct {
+	cache cache.OAuth2StateCache
+}
+
+func NewOAuth2StateRepository(cache cache.OAuth2StateCache) OAuth2StateRepository {
+	return &CachedOAuth2StateRepository{cache: cache}
+}
+
+func (repo *CachedOAuth2StateRepository) Save(ctx context.Context, s domain.OAuth2State, expiration time.Duration) error {
+	return repo.cache.Set(ctx, s, expiration)
+}
+
+func (repo *CachedOAuth2StateRepository) Consume(ctx context.Context, state string) (domain.OAuth2State, error) {
+	return repo.cache.GetDel(ctx, state)
+}
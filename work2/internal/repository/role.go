@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"example/wb/internal/repository/cache"
+	"example/wb/internal/repository/dao"
+	"example/wb/pkg/logger"
+)
+
+type RoleRepository interface {
+	FindByUid(ctx context.Context, uid int64) ([]string, error)
+	Grant(ctx context.Context, uid int64, role string) error
+	Revoke(ctx context.Context, uid int64, role string) error
+}
+
+type CachedRoleRepository struct {
+	dao   dao.UserRoleDao
+	cache cache.RoleCache
+	l     logger.Logger
+}
+
+func NewRoleRepository(dao dao.UserRoleDao, c cache.RoleCache, l logger.Logger) RoleRepository {
+	return &CachedRoleRepository{
+		dao:   dao,
+		cache: c,
+		l:     l,
+	}
+}
+
+func (repo *CachedRoleRepository) FindByUid(ctx context.Context, uid int64) ([]string, error) {
+	roles, err := repo.cache.Get(ctx, uid)
+	if err == nil {
+		return roles, nil
+	}
+	urs, err := repo.dao.FindByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	roles = make([]string, 0, len(urs))
+	for _, ur := range urs {
+		roles = append(roles, ur.Role)
+	}
+	err = repo.cache.Set(ctx, uid, roles)
+	if err != nil {
+		repo.l.Warn("缓存用户角色失败", logger.Int64("uid", uid), logger.Error(err))
+	}
+	return roles, nil
+}
+
+func (repo *CachedRoleRepository) Grant(ctx context.Context, uid int64, role string) error {
+	err := repo.dao.Insert(ctx, dao.UserRole{
+		Uid:  uid,
+		Role: role,
+	})
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedRoleRepository) Revoke(ctx context.Context, uid int64, role string) error {
+	err := repo.dao.Delete(ctx, uid, role)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
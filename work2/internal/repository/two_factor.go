@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository/cache"
+	"example/wb/internal/repository/dao"
+	"time"
+
+	"github.com/ecodeclub/ekit/sqlx"
+)
+
+var ErrTwoFactorNotFound = dao.ErrTwoFactorNotFound
+var ErrTwoFactorConflict = dao.ErrTwoFactorConflict
+
+type TwoFactorRepository interface {
+	Save(ctx context.Context, t domain.TwoFactor) error
+	FindByUid(ctx context.Context, uid int64) (domain.TwoFactor, error)
+	Enable(ctx context.Context, uid int64) error
+	UpdateRecoveryCodes(ctx context.Context, t domain.TwoFactor) error
+	// UseTOTPStep 返回 false 说明这个时间步或者更晚的验证码已经用过了
+	UseTOTPStep(ctx context.Context, uid int64, step int64, ttl time.Duration) (bool, error)
+}
+
+type twoFactorRepository struct {
+	dao   dao.TwoFactorDao
+	cache cache.TwoFactorCache
+}
+
+func NewTwoFactorRepository(dao dao.TwoFactorDao, cache cache.TwoFactorCache) TwoFactorRepository {
+	return &twoFactorRepository{
+		dao:   dao,
+		cache: cache,
+	}
+}
+
+func (repo *twoFactorRepository) Save(ctx context.Context, t domain.TwoFactor) error {
+	return repo.dao.Upsert(ctx, dao.UserTwoFactor{
+		Uid:     t.Uid,
+		Secret:  t.Secret,
+		Enabled: t.Enabled,
+		RecoveryCodes: sqlx.JsonColumn[[]string]{
+			Val:   t.RecoveryCodes,
+			Valid: true,
+		},
+	})
+}
+
+func (repo *twoFactorRepository) FindByUid(ctx context.Context, uid int64) (domain.TwoFactor, error) {
+	t, err := repo.dao.FindByUid(ctx, uid)
+	if err != nil {
+		return domain.TwoFactor{}, err
+	}
+	return domain.TwoFactor{
+		Uid:           t.Uid,
+		Secret:        t.Secret,
+		Enabled:       t.Enabled,
+		RecoveryCodes: t.RecoveryCodes.Val,
+		Utime:         t.Utime,
+	}, nil
+}
+
+func (repo *twoFactorRepository) Enable(ctx context.Context, uid int64) error {
+	return repo.dao.Enable(ctx, uid)
+}
+
+func (repo *twoFactorRepository) UpdateRecoveryCodes(ctx context.Context, t domain.TwoFactor) error {
+	return repo.dao.UpdateRecoveryCodes(ctx, t.Uid, t.Utime, t.RecoveryCodes)
+}
+
+func (repo *twoFactorRepository) UseTOTPStep(ctx context.Context, uid int64, step int64, ttl time.Duration) (bool, error) {
+	return repo.cache.UseTOTPStep(ctx, uid, step, ttl)
+}
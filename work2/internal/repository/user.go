@@ -6,6 +6,7 @@ import (
 	"example/wb/internal/repository/cache"
 	"example/wb/internal/repository/dao"
 	"log"
+	"time"
 
 	"golang.org/x/net/context"
 	"gorm.io/gorm"
@@ -17,7 +18,18 @@ type UserRepository interface {
 	FindByPhone(ctx context.Context, phone string) (domain.User, error)
 	FindByWechat(ctx context.Context, openId string) (domain.User, error)
 	FindById(ctx context.Context, id int64) (domain.User, error)
+	// FindByIdFromDB 不走缓存，缓存里面没有密码的哈希，重新验证身份这种场景要用这个
+	FindByIdFromDB(ctx context.Context, id int64) (domain.User, error)
+	// FindByIds 批量查询，key 是用户 id，不存在的用户不会出现在结果里面
+	FindByIds(ctx context.Context, ids []int64) (map[int64]domain.User, error)
 	UpdateById(ctx context.Context, u domain.User) error
+	MarkDeleted(ctx context.Context, id int64, deleteAt time.Time) error
+	FindPendingDeletion(ctx context.Context, now time.Time, limit int) ([]domain.User, error)
+	Anonymize(ctx context.Context, id int64) error
+	// UpdateBannedAt 封禁或者解封，零值表示解封
+	UpdateBannedAt(ctx context.Context, id int64, bannedAt time.Time) error
+	// UpdatePassword 两个都是哈希过的密码，数据库里面还是 oldPassword 的时候才会更新
+	UpdatePassword(ctx context.Context, id int64, oldPassword, password string) error
 }
 
 type CachedUserRepository struct {
@@ -27,6 +39,7 @@ type CachedUserRepository struct {
 
 var ErrDuplicateUser = dao.ErrDuplicateUser
 var ErrUserNotFound = dao.ErrUserNotFound
+var ErrUserDeleted = dao.ErrUserDeleted
 
 func NewCachedUserRepository(dao dao.UserDao, c cache.UserCache) UserRepository {
 	return &CachedUserRepository{
@@ -101,15 +114,130 @@ func (repo *CachedUserRepository) FindById(ctx context.Context, id int64) (domai
 	return du, nil
 }
 
+func (repo *CachedUserRepository) FindByIdFromDB(ctx context.Context, id int64) (domain.User, error) {
+	u, err := repo.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return repo.toDomain(u), nil
+}
+
+func (repo *CachedUserRepository) FindByIds(ctx context.Context, ids []int64) (map[int64]domain.User, error) {
+	ids = uniqueIds(ids)
+	res, err := repo.cache.GetMulti(ctx, ids)
+	if err != nil {
+		// redis 出问题了就全部查数据库
+		log.Println(err)
+		res = make(map[int64]domain.User, len(ids))
+	}
+	misses := make([]int64, 0, len(ids)-len(res))
+	for _, id := range ids {
+		if _, ok := res[id]; !ok {
+			misses = append(misses, id)
+		}
+	}
+	if len(misses) == 0 {
+		return res, nil
+	}
+	us, err := repo.dao.FindByIds(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]domain.User, 0, len(us))
+	for _, u := range us {
+		du := repo.toDomain(u)
+		res[du.Id] = du
+		found = append(found, du)
+	}
+	err = repo.cache.SetMulti(ctx, found)
+	if err != nil {
+		log.Println(err)
+	}
+	return res, nil
+}
+
+// uniqueIds 去重，保持原来的顺序
+func uniqueIds(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	res := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		res = append(res, id)
+	}
+	return res
+}
+
+func (repo *CachedUserRepository) MarkDeleted(ctx context.Context, id int64, deleteAt time.Time) error {
+	err := repo.dao.MarkDeleted(ctx, id, deleteAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, id)
+}
+
+func (repo *CachedUserRepository) FindPendingDeletion(ctx context.Context, now time.Time, limit int) ([]domain.User, error) {
+	us, err := repo.dao.FindPendingDeletion(ctx, now.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.User, 0, len(us))
+	for _, u := range us {
+		res = append(res, repo.toDomain(u))
+	}
+	return res, nil
+}
+
+func (repo *CachedUserRepository) Anonymize(ctx context.Context, id int64) error {
+	err := repo.dao.Anonymize(ctx, id)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, id)
+}
+
+func (repo *CachedUserRepository) UpdateBannedAt(ctx context.Context, id int64, bannedAt time.Time) error {
+	var at int64
+	if !bannedAt.IsZero() {
+		at = bannedAt.UnixMilli()
+	}
+	err := repo.dao.UpdateBannedAt(ctx, id, at)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, id)
+}
+
+func (repo *CachedUserRepository) UpdatePassword(ctx context.Context, id int64, oldPassword, password string) error {
+	err := repo.dao.UpdatePassword(ctx, id, oldPassword, password)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, id)
+}
+
 func (repo *CachedUserRepository) toDomain(u dao.User) domain.User {
+	var deleteAt, bannedAt time.Time
+	if u.DeleteAt > 0 {
+		deleteAt = time.UnixMilli(u.DeleteAt)
+	}
+	if u.BannedAt > 0 {
+		bannedAt = time.UnixMilli(u.BannedAt)
+	}
 	return domain.User{
-		Id:        u.ID,
-		NickName:  u.NickName,
-		Birthday:  u.Birthday,
-		Biography: u.Biography,
-		Email:     u.Email.String,
-		Phone:     u.Phone.String,
-		Password:  u.Password,
+		Id:          u.ID,
+		NickName:    u.NickName,
+		Avatar:      u.Avatar,
+		Birthday:    u.Birthday,
+		Biography:   u.Biography,
+		Email:       u.Email.String,
+		Phone:       u.Phone.String,
+		Password:    u.Password,
+		DeleteAt:    deleteAt,
+		BannedAt:    bannedAt,
+		CodeChannel: domain.CodeChannel(u.CodeChannel),
 		WechatInfo: domain.WechatInfo{
 			Openid:  u.WechatOpenId.String,
 			Unionid: u.WechatUnionId.String,
@@ -119,14 +247,19 @@ func (repo *CachedUserRepository) toDomain(u dao.User) domain.User {
 }
 
 func (repo *CachedUserRepository) UpdateById(ctx context.Context, u domain.User) error {
-
-	return repo.dao.UpdateById(ctx, dao.User{
-		ID:        u.Id,
-		NickName:  u.NickName,
-		Birthday:  u.Birthday,
-		Biography: u.Biography,
+	err := repo.dao.UpdateById(ctx, dao.User{
+		ID:          u.Id,
+		NickName:    u.NickName,
+		Avatar:      u.Avatar,
+		Birthday:    u.Birthday,
+		Biography:   u.Biography,
+		CodeChannel: string(u.CodeChannel),
 	})
-
+	if err != nil {
+		return err
+	}
+	// 帖子列表和详情的作者昵称、头像都从缓存里面批量取，改完要删掉
+	return repo.cache.Del(ctx, u.Id)
 }
 
 func (repo *CachedUserRepository) toEntity(u domain.User) dao.User {
@@ -149,10 +282,12 @@ func (repo *CachedUserRepository) toEntity(u domain.User) dao.User {
 			Valid:  u.WechatInfo.Unionid != "",
 		},
 
-		Password:  u.Password,
-		Birthday:  u.Birthday,
-		Biography: u.Biography,
-		NickName:  u.NickName,
+		Password:    u.Password,
+		Birthday:    u.Birthday,
+		Biography:   u.Biography,
+		NickName:    u.NickName,
+		Avatar:      u.Avatar,
+		CodeChannel: string(u.CodeChannel),
 	}
 
 }
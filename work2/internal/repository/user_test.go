@@ -124,3 +124,178 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 		})
 	}
 }
+
+func TestCachedUserRepository_FindByIds(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+
+		ids     []int64
+		want    map[int64]domain.User
+		wantErr error
+	}{
+		{
+			name: "全部命中缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				userCache := cachemock.NewMockUserCache(ctrl)
+				userCache.EXPECT().GetMulti(gomock.Any(), []int64{1, 2}).
+					Return(map[int64]domain.User{
+						1: {Id: 1, NickName: "小明"},
+						2: {Id: 2, NickName: "小红"},
+					}, nil)
+				return daomock.NewMockUserDao(ctrl), userCache
+			},
+			// 重复的 id 只查一次
+			ids: []int64{1, 2, 1},
+			want: map[int64]domain.User{
+				1: {Id: 1, NickName: "小明"},
+				2: {Id: 2, NickName: "小红"},
+			},
+		},
+		{
+			name: "部分命中，没有命中的一次查数据库再回写",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				userCache := cachemock.NewMockUserCache(ctrl)
+				userCache.EXPECT().GetMulti(gomock.Any(), []int64{1, 2, 3}).
+					Return(map[int64]domain.User{
+						1: {Id: 1, NickName: "小明"},
+					}, nil)
+				userDao := daomock.NewMockUserDao(ctrl)
+				// 3 在数据库里面也不存在
+				userDao.EXPECT().FindByIds(gomock.Any(), []int64{2, 3}).
+					Return([]dao.User{{ID: 2, NickName: "小红"}}, nil)
+				userCache.EXPECT().SetMulti(gomock.Any(), []domain.User{{Id: 2, NickName: "小红"}}).
+					Return(errors.New("redis错误"))
+				return userDao, userCache
+			},
+			ids: []int64{1, 2, 3},
+			want: map[int64]domain.User{
+				1: {Id: 1, NickName: "小明"},
+				2: {Id: 2, NickName: "小红"},
+			},
+		},
+		{
+			name: "缓存出错，全部查数据库",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				userCache := cachemock.NewMockUserCache(ctrl)
+				userCache.EXPECT().GetMulti(gomock.Any(), []int64{1, 2}).
+					Return(nil, errors.New("redis错误"))
+				userDao := daomock.NewMockUserDao(ctrl)
+				userDao.EXPECT().FindByIds(gomock.Any(), []int64{1, 2}).
+					Return([]dao.User{{ID: 1, NickName: "小明"}, {ID: 2, NickName: "小红"}}, nil)
+				userCache.EXPECT().SetMulti(gomock.Any(), gomock.Any()).Return(nil)
+				return userDao, userCache
+			},
+			ids: []int64{1, 2},
+			want: map[int64]domain.User{
+				1: {Id: 1, NickName: "小明"},
+				2: {Id: 2, NickName: "小红"},
+			},
+		},
+		{
+			name: "数据库出错",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				userCache := cachemock.NewMockUserCache(ctrl)
+				userCache.EXPECT().GetMulti(gomock.Any(), []int64{1}).
+					Return(map[int64]domain.User{}, nil)
+				userDao := daomock.NewMockUserDao(ctrl)
+				userDao.EXPECT().FindByIds(gomock.Any(), []int64{1}).
+					Return(nil, errors.New("db错误"))
+				return userDao, userCache
+			},
+			ids:     []int64{1},
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDao, userCache := tc.mock(ctrl)
+			userRepo := repository.NewCachedUserRepository(userDao, userCache)
+
+			us, err := userRepo.FindByIds(context.Background(), tc.ids)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, us)
+		})
+	}
+}
+
+func TestCachedUserRepository_FindByIdFromDB(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userDao := daomock.NewMockUserDao(ctrl)
+	userDao.EXPECT().FindById(gomock.Any(), int64(1)).
+		Return(dao.User{ID: 1, Password: "hash"}, nil)
+	// 缓存里面有这个用户也不能用，缓存里面没有密码
+	userCache := cachemock.NewMockUserCache(ctrl)
+	userCache.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+	userRepo := repository.NewCachedUserRepository(userDao, userCache)
+
+	u, err := userRepo.FindByIdFromDB(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), u.Id)
+	assert.Equal(t, "hash", u.Password)
+}
+
+func TestCachedUserRepository_UpdateById(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache)
+
+		wantErr error
+	}{
+		{
+			name: "更新成功，删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				userDao := daomock.NewMockUserDao(ctrl)
+				userDao.EXPECT().UpdateById(gomock.Any(), dao.User{ID: 1, NickName: "新昵称", Avatar: "a.png"}).
+					Return(nil)
+				userCache := cachemock.NewMockUserCache(ctrl)
+				userCache.EXPECT().Del(gomock.Any(), int64(1)).Return(nil)
+				return userDao, userCache
+			},
+		},
+		{
+			name: "更新失败，不删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				userDao := daomock.NewMockUserDao(ctrl)
+				userDao.EXPECT().UpdateById(gomock.Any(), gomock.Any()).
+					Return(errors.New("db错误"))
+				userCache := cachemock.NewMockUserCache(ctrl)
+				return userDao, userCache
+			},
+			wantErr: errors.New("db错误"),
+		},
+		{
+			name: "删除缓存失败",
+			mock: func(ctrl *gomock.Controller) (dao.UserDao, cache.UserCache) {
+				userDao := daomock.NewMockUserDao(ctrl)
+				userDao.EXPECT().UpdateById(gomock.Any(), gomock.Any()).Return(nil)
+				userCache := cachemock.NewMockUserCache(ctrl)
+				userCache.EXPECT().Del(gomock.Any(), int64(1)).Return(errors.New("redis错误"))
+				return userDao, userCache
+			},
+			wantErr: errors.New("redis错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userDao, userCache := tc.mock(ctrl)
+			userRepo := repository.NewCachedUserRepository(userDao, userCache)
+			err := userRepo.UpdateById(context.Background(), domain.User{
+				Id:       1,
+				NickName: "新昵称",
+				Avatar:   "a.png",
+			})
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
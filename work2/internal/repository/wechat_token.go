@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository/dao"
+	"time"
+)
+
+var ErrWechatTokenNotFound = dao.ErrWechatTokenNotFound
+
+type WechatTokenRepository interface {
+	Save(ctx context.Context, t domain.WechatToken) error
+	FindByUid(ctx context.Context, uid int64) (domain.WechatToken, error)
+}
+
+type wechatTokenRepository struct {
+	dao dao.WechatTokenDao
+}
+
+func NewWechatTokenRepository(dao dao.WechatTokenDao) WechatTokenRepository {
+	return &wechatTokenRepository{
+		dao: dao,
+	}
+}
+
+func (repo *wechatTokenRepository) Save(ctx context.Context, t domain.WechatToken) error {
+	return repo.dao.Upsert(ctx, dao.WechatToken{
+		Uid:              t.Uid,
+		Openid:           t.Openid,
+		AccessToken:      t.AccessToken,
+		RefreshToken:     t.RefreshToken,
+		Scope:            t.Scope,
+		ExpiresAt:        t.ExpiresAt.UnixMilli(),
+		RefreshExpiresAt: t.RefreshExpiresAt.UnixMilli(),
+	})
+}
+
+func (repo *wechatTokenRepository) FindByUid(ctx context.Context, uid int64) (domain.WechatToken, error) {
+	t, err := repo.dao.FindByUid(ctx, uid)
+	if err != nil {
+		return domain.WechatToken{}, err
+	}
+	return domain.WechatToken{
+		Uid:              t.Uid,
+		Openid:           t.Openid,
+		AccessToken:      t.AccessToken,
+		RefreshToken:     t.RefreshToken,
+		Scope:            t.Scope,
+		ExpiresAt:        time.UnixMilli(t.ExpiresAt),
+		RefreshExpiresAt: time.UnixMilli(t.RefreshExpiresAt),
+	}, nil
+}
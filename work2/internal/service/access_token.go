@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/repository"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidAccessToken = errs.New(http.StatusUnauthorized, errs.AccessTokenInvalid, "access token 无效")
+var ErrInvalidAccessTokenParam = errs.New(http.StatusBadRequest, errs.AccessTokenInvalidParam, "名字、scope 或者有效期不对")
+var ErrTooManyAccessTokens = errs.New(http.StatusBadRequest, errs.AccessTokenTooMany, "token 太多了，请先删除不用的")
+var ErrAccessTokenNotFound = errs.New(http.StatusNotFound, errs.AccessTokenNotFound, "token 不存在")
+
+// AccessTokenService 用户自己管理的 personal access token，用来写脚本调用接口
+type AccessTokenService interface {
+	// Create 返回的明文只展示这一次，数据库里面只有哈希
+	Create(ctx context.Context, t domain.AccessToken) (domain.AccessToken, string, error)
+	List(ctx context.Context, uid int64) ([]domain.AccessToken, error)
+	Revoke(ctx context.Context, uid int64, id int64) error
+	// Verify 校验明文，过期的、用户已经被封禁或者注销的都算无效
+	Verify(ctx context.Context, token string) (domain.AccessToken, error)
+}
+
+type accessTokenService struct {
+	repo     repository.AccessTokenRepository
+	userRepo repository.UserRepository
+	// 每个用户最多有多少个 token
+	maxTokens int
+	maxTTL    time.Duration
+}
+
+func NewAccessTokenService(repo repository.AccessTokenRepository,
+	userRepo repository.UserRepository) AccessTokenService {
+	return &accessTokenService{
+		repo:      repo,
+		userRepo:  userRepo,
+		maxTokens: 20,
+		maxTTL:    time.Hour * 24 * 365,
+	}
+}
+
+func (svc *accessTokenService) Create(ctx context.Context, t domain.AccessToken) (domain.AccessToken, string, error) {
+	if !svc.valid(t) {
+		return domain.AccessToken{}, "", ErrInvalidAccessTokenParam
+	}
+	ts, err := svc.repo.FindByUid(ctx, t.Uid)
+	if err != nil {
+		return domain.AccessToken{}, "", err
+	}
+	if len(ts) >= svc.maxTokens {
+		return domain.AccessToken{}, "", ErrTooManyAccessTokens
+	}
+	buf := make([]byte, 20)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return domain.AccessToken{}, "", err
+	}
+	token := domain.AccessTokenPrefix + hex.EncodeToString(buf)
+	t.Hint = token[:len(domain.AccessTokenPrefix)+4]
+	t.Ctime = time.Now()
+	t.Id, err = svc.repo.Create(ctx, t, svc.hash(token))
+	if err != nil {
+		return domain.AccessToken{}, "", err
+	}
+	return t, token, nil
+}
+
+func (svc *accessTokenService) valid(t domain.AccessToken) bool {
+	name := strings.TrimSpace(t.Name)
+	if name == "" || utf8.RuneCountInString(name) > 64 {
+		return false
+	}
+	if len(t.Scopes) == 0 {
+		return false
+	}
+	for _, s := range t.Scopes {
+		if !domain.ValidScope(s) {
+			return false
+		}
+	}
+	now := time.Now()
+	return t.ExpiresAt.After(now) && !t.ExpiresAt.After(now.Add(svc.maxTTL))
+}
+
+func (svc *accessTokenService) List(ctx context.Context, uid int64) ([]domain.AccessToken, error) {
+	return svc.repo.FindByUid(ctx, uid)
+}
+
+func (svc *accessTokenService) Revoke(ctx context.Context, uid int64, id int64) error {
+	err := svc.repo.Delete(ctx, uid, id)
+	if err == repository.ErrAccessTokenNotFound {
+		return ErrAccessTokenNotFound
+	}
+	return err
+}
+
+func (svc *accessTokenService) Verify(ctx context.Context, token string) (domain.AccessToken, error) {
+	if !strings.HasPrefix(token, domain.AccessTokenPrefix) {
+		return domain.AccessToken{}, ErrInvalidAccessToken
+	}
+	t, err := svc.repo.FindByHash(ctx, svc.hash(token))
+	if err == repository.ErrAccessTokenNotFound {
+		return domain.AccessToken{}, ErrInvalidAccessToken
+	}
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	if t.Expired(time.Now()) {
+		return domain.AccessToken{}, ErrInvalidAccessToken
+	}
+	// 封禁和申请注销的时候不会删除 token，这里要检查一下
+	u, err := svc.userRepo.FindById(ctx, t.Uid)
+	if err != nil {
+		return domain.AccessToken{}, err
+	}
+	if checkActive(u) != nil {
+		return domain.AccessToken{}, ErrInvalidAccessToken
+	}
+	return t, nil
+}
+
+// hash token 是 160 位的随机数，sha256 就足够了，也方便按照哈希查找
+func (svc *accessTokenService) hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
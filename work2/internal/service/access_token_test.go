@@ -0,0 +1,247 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAccessTokenService_Create(t *testing.T) {
+	now := time.Now()
+	valid := domain.AccessToken{
+		Uid:       1,
+		Name:      "发布脚本",
+		Scopes:    []domain.Scope{domain.ScopeArticleWrite},
+		ExpiresAt: now.Add(time.Hour * 24 * 30),
+	}
+	testCase := []struct {
+		name string
+
+		mock  func(ctrl *gomock.Controller) repository.AccessTokenRepository
+		token func() domain.AccessToken
+
+		wantErr error
+	}{
+		{
+			name: "创建成功",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomock.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(nil, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(10), nil)
+				return repo
+			},
+			token: func() domain.AccessToken {
+				return valid
+			},
+		},
+		{
+			name: "没有 scope",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				return repomock.NewMockAccessTokenRepository(ctrl)
+			},
+			token: func() domain.AccessToken {
+				t := valid
+				t.Scopes = nil
+				return t
+			},
+			wantErr: service.ErrInvalidAccessTokenParam,
+		},
+		{
+			name: "不认识的 scope",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				return repomock.NewMockAccessTokenRepository(ctrl)
+			},
+			token: func() domain.AccessToken {
+				t := valid
+				t.Scopes = []domain.Scope{"user:admin"}
+				return t
+			},
+			wantErr: service.ErrInvalidAccessTokenParam,
+		},
+		{
+			name: "有效期太长",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				return repomock.NewMockAccessTokenRepository(ctrl)
+			},
+			token: func() domain.AccessToken {
+				t := valid
+				t.ExpiresAt = now.Add(time.Hour * 24 * 400)
+				return t
+			},
+			wantErr: service.ErrInvalidAccessTokenParam,
+		},
+		{
+			name: "token 太多",
+			mock: func(ctrl *gomock.Controller) repository.AccessTokenRepository {
+				repo := repomock.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(make([]domain.AccessToken, 20), nil)
+				return repo
+			},
+			token: func() domain.AccessToken {
+				return valid
+			},
+			wantErr: service.ErrTooManyAccessTokens,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewAccessTokenService(tc.mock(ctrl), nil)
+			res, token, err := svc.Create(context.Background(), tc.token())
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, int64(10), res.Id)
+			assert.True(t, strings.HasPrefix(token, domain.AccessTokenPrefix))
+			assert.True(t, strings.HasPrefix(token, res.Hint))
+		})
+	}
+}
+
+func TestAccessTokenService_Verify(t *testing.T) {
+	const token = "wbpat_0123456789abcdef"
+	sum := sha256.Sum256([]byte(token))
+	hash := hex.EncodeToString(sum[:])
+	stored := domain.AccessToken{
+		Id:        10,
+		Uid:       1,
+		Scopes:    []domain.Scope{domain.ScopeArticleRead},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	testCase := []struct {
+		name string
+
+		mock  func(ctrl *gomock.Controller) (repository.AccessTokenRepository, repository.UserRepository)
+		token string
+
+		want    domain.AccessToken
+		wantErr error
+	}{
+		{
+			name: "校验通过",
+			mock: func(ctrl *gomock.Controller) (repository.AccessTokenRepository, repository.UserRepository) {
+				repo := repomock.NewMockAccessTokenRepository(ctrl)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).Return(stored, nil)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				return repo, userRepo
+			},
+			token: token,
+			want:  stored,
+		},
+		{
+			name: "不是 access token",
+			mock: func(ctrl *gomock.Controller) (repository.AccessTokenRepository, repository.UserRepository) {
+				return repomock.NewMockAccessTokenRepository(ctrl), repomock.NewMockUserRepository(ctrl)
+			},
+			token:   "eyJhbGciOiJIUzI1NiJ9",
+			wantErr: service.ErrInvalidAccessToken,
+		},
+		{
+			name: "已经删除",
+			mock: func(ctrl *gomock.Controller) (repository.AccessTokenRepository, repository.UserRepository) {
+				repo := repomock.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).
+					Return(domain.AccessToken{}, repository.ErrAccessTokenNotFound)
+				return repo, repomock.NewMockUserRepository(ctrl)
+			},
+			token:   token,
+			wantErr: service.ErrInvalidAccessToken,
+		},
+		{
+			name: "过期了",
+			mock: func(ctrl *gomock.Controller) (repository.AccessTokenRepository, repository.UserRepository) {
+				repo := repomock.NewMockAccessTokenRepository(ctrl)
+				expired := stored
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).Return(expired, nil)
+				return repo, repomock.NewMockUserRepository(ctrl)
+			},
+			token:   token,
+			wantErr: service.ErrInvalidAccessToken,
+		},
+		{
+			name: "用户被封禁",
+			mock: func(ctrl *gomock.Controller) (repository.AccessTokenRepository, repository.UserRepository) {
+				repo := repomock.NewMockAccessTokenRepository(ctrl)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).Return(stored, nil)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, BannedAt: time.Now()}, nil)
+				return repo, userRepo
+			},
+			token:   token,
+			wantErr: service.ErrInvalidAccessToken,
+		},
+		{
+			name: "数据库错误",
+			mock: func(ctrl *gomock.Controller) (repository.AccessTokenRepository, repository.UserRepository) {
+				repo := repomock.NewMockAccessTokenRepository(ctrl)
+				repo.EXPECT().FindByHash(gomock.Any(), hash).
+					Return(domain.AccessToken{}, errors.New("db错误"))
+				return repo, repomock.NewMockUserRepository(ctrl)
+			},
+			token:   token,
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, userRepo := tc.mock(ctrl)
+			svc := service.NewAccessTokenService(repo, userRepo)
+			res, err := svc.Verify(context.Background(), tc.token)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestAccessTokenService_CreateThenVerify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 创建时保存的哈希，校验的时候要能查出来
+	var savedHash string
+	repo := repomock.NewMockAccessTokenRepository(ctrl)
+	userRepo := repomock.NewMockUserRepository(ctrl)
+	repo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(nil, nil)
+	repo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, t domain.AccessToken, hash string) (int64, error) {
+			savedHash = hash
+			return 10, nil
+		})
+	svc := service.NewAccessTokenService(repo, userRepo)
+	created, token, err := svc.Create(context.Background(), domain.AccessToken{
+		Uid:       1,
+		Name:      "脚本",
+		Scopes:    []domain.Scope{domain.ScopeArticleRead},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, savedHash, token)
+
+	repo.EXPECT().FindByHash(gomock.Any(), savedHash).Return(created, nil)
+	userRepo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+	res, err := svc.Verify(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, created, res)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/repository"
+	"example/wb/pkg/logger"
+	"example/wb/pkg/password"
+	"net/http"
+	"time"
+)
+
+const bizDeleteAccount = "delete_account"
+
+var ErrAccountDeleted = errs.New(http.StatusForbidden, errs.UserAccountDeleted, "账号正在注销中")
+var ErrReverificationFailed = errs.New(http.StatusBadRequest, errs.UserReverificationFailed, "身份验证失败")
+var ErrNoContact = errs.New(http.StatusBadRequest, errs.UserNoContact, "没有绑定手机号或者邮箱")
+
+// AccountService 账号注销和个人数据导出
+// 注销之后先进入冷静期，冷静期内账号不能登录，帖子全部撤回；
+// 冷静期结束之后由 AnonymizeExpired 清除个人信息
+type AccountService interface {
+	Export(ctx context.Context, uid int64) (domain.AccountExport, error)
+	// SendDeletionCode 发送注销验证码，channel 为空的时候按照用户的偏好选择渠道
+	SendDeletionCode(ctx context.Context, uid int64, channel domain.CodeChannel) error
+	// RequestDeletion 重新验证身份之后申请注销，返回匿名化的时间
+	RequestDeletion(ctx context.Context, uid int64, v domain.Reverification) (time.Time, error)
+	// AnonymizeExpired 匿名化已经过了冷静期的账号，返回处理了多少个
+	AnonymizeExpired(ctx context.Context, limit int) (int, error)
+}
+
+type accountService struct {
+	userRepo     repository.UserRepository
+	articleRepo  repository.ArticleRepository
+	codeSvc      CodeService
+	twoFactorSvc TwoFactorService
+	hasher       password.Hasher
+	l            logger.Logger
+	// 冷静期
+	gracePeriod time.Duration
+	// 导出帖子的时候每一批查多少
+	batchSize int
+}
+
+func NewAccountService(userRepo repository.UserRepository,
+	articleRepo repository.ArticleRepository,
+	codeSvc CodeService,
+	twoFactorSvc TwoFactorService,
+	hasher password.Hasher,
+	l logger.Logger) AccountService {
+	return &accountService{
+		userRepo:     userRepo,
+		articleRepo:  articleRepo,
+		codeSvc:      codeSvc,
+		twoFactorSvc: twoFactorSvc,
+		hasher:       hasher,
+		l:            l,
+		gracePeriod:  time.Hour * 24 * 7,
+		batchSize:    100,
+	}
+}
+
+func (svc *accountService) Export(ctx context.Context, uid int64) (domain.AccountExport, error) {
+	u, err := svc.userRepo.FindById(ctx, uid)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+	var arts []domain.Article
+	for offset := 0; ; offset += svc.batchSize {
+		// 导出要完整的内容，不能用只有摘要的第一页缓存
+		batch, err := svc.articleRepo.GetByAuthorFromDB(ctx, uid, svc.batchSize, offset)
+		if err != nil {
+			return domain.AccountExport{}, err
+		}
+		arts = append(arts, batch...)
+		if len(batch) < svc.batchSize {
+			break
+		}
+	}
+	return domain.AccountExport{
+		Profile:  u,
+		Articles: arts,
+	}, nil
+}
+
+func (svc *accountService) SendDeletionCode(ctx context.Context, uid int64, channel domain.CodeChannel) error {
+	u, err := svc.userRepo.FindById(ctx, uid)
+	if err != nil {
+		return err
+	}
+	channel, target := u.CodeTarget(channel)
+	if target == "" {
+		return ErrNoContact
+	}
+	return svc.codeSvc.Send(ctx, bizDeleteAccount, channel, target)
+}
+
+func (svc *accountService) RequestDeletion(ctx context.Context, uid int64, v domain.Reverification) (time.Time, error) {
+	// 缓存里面没有密码的哈希，必须查数据库
+	u, err := svc.userRepo.FindByIdFromDB(ctx, uid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if u.PendingDeletion() {
+		return time.Time{}, ErrAccountDeleted
+	}
+	err = svc.reverify(ctx, u, v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	deleteAt := time.Now().Add(svc.gracePeriod)
+	err = svc.userRepo.MarkDeleted(ctx, uid, deleteAt)
+	if err == repository.ErrUserDeleted {
+		return time.Time{}, ErrAccountDeleted
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	err = svc.articleRepo.SyncStatusByAuthor(ctx, uid,
+		domain.ArticleStatusPublished, domain.ArticleStatusPrivate)
+	if err != nil {
+		// 账号已经标记注销了，帖子撤回失败只能记录下来人工处理
+		svc.l.Error("注销账号撤回帖子失败", logger.Int64("uid", uid), logger.Error(err))
+	}
+	return deleteAt, nil
+}
+
+// reverify 设置了密码的要验证密码，没有密码但是绑定了手机号或者邮箱的要验证验证码，
+// 开启了两步验证的还要额外验证 TOTP。
+// 一个因素都没有的账号（只用第三方登录的）不能通过，要先绑定手机号或者邮箱
+func (svc *accountService) reverify(ctx context.Context, u domain.User, v domain.Reverification) error {
+	factors := 0
+	switch {
+	case u.Password != "":
+		ok, err := svc.hasher.Verify(u.Password, v.Password)
+		if err != nil || !ok {
+			return ErrReverificationFailed
+		}
+		factors++
+	default:
+		channel, target := u.CodeTarget(v.CodeChannel)
+		if target == "" {
+			break
+		}
+		ok, err := svc.codeSvc.Vertify(ctx, bizDeleteAccount, channel, target, v.Code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrReverificationFailed
+		}
+		factors++
+	}
+	enabled, err := svc.twoFactorSvc.IsEnabled(ctx, u.Id)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		if factors == 0 {
+			return ErrNoContact
+		}
+		return nil
+	}
+	ok, err := svc.twoFactorSvc.Verify(ctx, u.Id, v.TwoFactorCode)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrReverificationFailed
+	}
+	return nil
+}
+
+func (svc *accountService) AnonymizeExpired(ctx context.Context, limit int) (int, error) {
+	us, err := svc.userRepo.FindPendingDeletion(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+	cnt := 0
+	for _, u := range us {
+		err = svc.userRepo.Anonymize(ctx, u.Id)
+		if err != nil {
+			// 下一轮还会再找出来
+			svc.l.Error("匿名化用户失败", logger.Int64("uid", u.Id), logger.Error(err))
+			continue
+		}
+		cnt++
+	}
+	return cnt, nil
+}
@@ -0,0 +1,266 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	svcmock "example/wb/internal/service/mocks"
+	"example/wb/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAccountService_RequestDeletion(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hello#world123"), bcrypt.DefaultCost)
+	assert.NoError(t, err)
+	pwdUser := domain.User{Id: 1, Email: "123@qq.com", Password: string(hash)}
+	phoneUser := domain.User{Id: 1, Phone: "15212345678"}
+
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (repository.UserRepository, repository.ArticleRepository,
+			service.CodeService, service.TwoFactorService)
+		v domain.Reverification
+
+		wantErr error
+	}{
+		{
+			name: "密码验证通过",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ArticleRepository,
+				service.CodeService, service.TwoFactorService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				artRepo := repomock.NewMockArticleRepository(ctrl)
+				tfSvc := svcmock.NewMockTwoFactorService(ctrl)
+				userRepo.EXPECT().FindByIdFromDB(gomock.Any(), int64(1)).Return(pwdUser, nil)
+				tfSvc.EXPECT().IsEnabled(gomock.Any(), int64(1)).Return(false, nil)
+				userRepo.EXPECT().MarkDeleted(gomock.Any(), int64(1), gomock.Any()).Return(nil)
+				artRepo.EXPECT().SyncStatusByAuthor(gomock.Any(), int64(1),
+					domain.ArticleStatus(domain.ArticleStatusPublished),
+					domain.ArticleStatus(domain.ArticleStatusPrivate)).Return(nil)
+				return userRepo, artRepo, svcmock.NewMockCodeService(ctrl), tfSvc
+			},
+			v: domain.Reverification{Password: "hello#world123"},
+		},
+		{
+			name: "密码不对",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ArticleRepository,
+				service.CodeService, service.TwoFactorService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByIdFromDB(gomock.Any(), int64(1)).Return(pwdUser, nil)
+				return userRepo, repomock.NewMockArticleRepository(ctrl),
+					svcmock.NewMockCodeService(ctrl), svcmock.NewMockTwoFactorService(ctrl)
+			},
+			v:       domain.Reverification{Password: "wrong"},
+			wantErr: service.ErrReverificationFailed,
+		},
+		{
+			name: "没有密码，验证短信，开启了两步验证",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ArticleRepository,
+				service.CodeService, service.TwoFactorService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				artRepo := repomock.NewMockArticleRepository(ctrl)
+				codeSvc := svcmock.NewMockCodeService(ctrl)
+				tfSvc := svcmock.NewMockTwoFactorService(ctrl)
+				userRepo.EXPECT().FindByIdFromDB(gomock.Any(), int64(1)).Return(phoneUser, nil)
+				codeSvc.EXPECT().Vertify(gomock.Any(), "delete_account", domain.CodeChannelSMS, "15212345678", "123456").Return(true, nil)
+				tfSvc.EXPECT().IsEnabled(gomock.Any(), int64(1)).Return(true, nil)
+				tfSvc.EXPECT().Verify(gomock.Any(), int64(1), "654321").Return(true, nil)
+				userRepo.EXPECT().MarkDeleted(gomock.Any(), int64(1), gomock.Any()).Return(nil)
+				// 撤回帖子失败不影响注销
+				artRepo.EXPECT().SyncStatusByAuthor(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).
+					Return(errors.New("db错误"))
+				return userRepo, artRepo, codeSvc, tfSvc
+			},
+			v: domain.Reverification{Code: "123456", TwoFactorCode: "654321"},
+		},
+		{
+			name: "两步验证码不对",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ArticleRepository,
+				service.CodeService, service.TwoFactorService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				tfSvc := svcmock.NewMockTwoFactorService(ctrl)
+				userRepo.EXPECT().FindByIdFromDB(gomock.Any(), int64(1)).Return(pwdUser, nil)
+				tfSvc.EXPECT().IsEnabled(gomock.Any(), int64(1)).Return(true, nil)
+				tfSvc.EXPECT().Verify(gomock.Any(), int64(1), "000000").Return(false, nil)
+				return userRepo, repomock.NewMockArticleRepository(ctrl), svcmock.NewMockCodeService(ctrl), tfSvc
+			},
+			v:       domain.Reverification{Password: "hello#world123", TwoFactorCode: "000000"},
+			wantErr: service.ErrReverificationFailed,
+		},
+		{
+			name: "已经申请过注销",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ArticleRepository,
+				service.CodeService, service.TwoFactorService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				u := pwdUser
+				u.DeleteAt = time.Now().Add(time.Hour)
+				userRepo.EXPECT().FindByIdFromDB(gomock.Any(), int64(1)).Return(u, nil)
+				return userRepo, repomock.NewMockArticleRepository(ctrl),
+					svcmock.NewMockCodeService(ctrl), svcmock.NewMockTwoFactorService(ctrl)
+			},
+			v:       domain.Reverification{Password: "hello#world123"},
+			wantErr: service.ErrAccountDeleted,
+		},
+		{
+			name: "没有任何验证因素",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ArticleRepository,
+				service.CodeService, service.TwoFactorService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				tfSvc := svcmock.NewMockTwoFactorService(ctrl)
+				// 只用微信登录的账号，没有密码也没有手机号邮箱
+				userRepo.EXPECT().FindByIdFromDB(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, WechatInfo: domain.WechatInfo{Openid: "openid"}}, nil)
+				tfSvc.EXPECT().IsEnabled(gomock.Any(), int64(1)).Return(false, nil)
+				return userRepo, repomock.NewMockArticleRepository(ctrl), svcmock.NewMockCodeService(ctrl), tfSvc
+			},
+			v:       domain.Reverification{},
+			wantErr: service.ErrNoContact,
+		},
+		{
+			name: "并发申请注销",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.ArticleRepository,
+				service.CodeService, service.TwoFactorService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				tfSvc := svcmock.NewMockTwoFactorService(ctrl)
+				userRepo.EXPECT().FindByIdFromDB(gomock.Any(), int64(1)).Return(pwdUser, nil)
+				tfSvc.EXPECT().IsEnabled(gomock.Any(), int64(1)).Return(false, nil)
+				userRepo.EXPECT().MarkDeleted(gomock.Any(), int64(1), gomock.Any()).
+					Return(repository.ErrUserDeleted)
+				return userRepo, repomock.NewMockArticleRepository(ctrl), svcmock.NewMockCodeService(ctrl), tfSvc
+			},
+			v:       domain.Reverification{Password: "hello#world123"},
+			wantErr: service.ErrAccountDeleted,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo, artRepo, codeSvc, tfSvc := tc.mock(ctrl)
+			svc := service.NewAccountService(userRepo, artRepo, codeSvc, tfSvc, newTestHasher(), logger.NewNopLogger())
+			now := time.Now()
+			deleteAt, err := svc.RequestDeletion(context.Background(), 1, tc.v)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			// 冷静期七天
+			assert.WithinDuration(t, now.Add(time.Hour*24*7), deleteAt, time.Second)
+		})
+	}
+}
+
+func TestAccountService_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := repomock.NewMockUserRepository(ctrl)
+	artRepo := repomock.NewMockArticleRepository(ctrl)
+	userRepo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{Id: 1, NickName: "小明"}, nil)
+	// 第一页满了，继续查第二页
+	firstPage := make([]domain.Article, 100)
+	artRepo.EXPECT().GetByAuthorFromDB(gomock.Any(), int64(1), 100, 0).Return(firstPage, nil)
+	artRepo.EXPECT().GetByAuthorFromDB(gomock.Any(), int64(1), 100, 100).
+		Return([]domain.Article{{Id: 101}}, nil)
+
+	svc := service.NewAccountService(userRepo, artRepo, nil, nil, nil, logger.NewNopLogger())
+	res, err := svc.Export(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "小明", res.Profile.NickName)
+	assert.Len(t, res.Articles, 101)
+}
+
+func TestAccountService_AnonymizeExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := repomock.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().FindPendingDeletion(gomock.Any(), gomock.Any(), 10).
+		Return([]domain.User{{Id: 1}, {Id: 2}}, nil)
+	userRepo.EXPECT().Anonymize(gomock.Any(), int64(1)).Return(errors.New("db错误"))
+	userRepo.EXPECT().Anonymize(gomock.Any(), int64(2)).Return(nil)
+
+	svc := service.NewAccountService(userRepo, nil, nil, nil, nil, logger.NewNopLogger())
+	cnt, err := svc.AnonymizeExpired(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cnt)
+}
+
+func TestAccountService_SendDeletionCode(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock    func(ctrl *gomock.Controller) (repository.UserRepository, service.CodeService)
+		channel domain.CodeChannel
+
+		wantErr error
+	}{
+		{
+			name: "没有指定渠道，按照用户偏好发邮件",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, service.CodeService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				codeSvc := svcmock.NewMockCodeService(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{
+					Id: 1, Phone: "15212345678", Email: "123@qq.com", CodeChannel: domain.CodeChannelEmail,
+				}, nil)
+				codeSvc.EXPECT().Send(gomock.Any(), "delete_account", domain.CodeChannelEmail, "123@qq.com").Return(nil)
+				return userRepo, codeSvc
+			},
+		},
+		{
+			name: "指定语音",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, service.CodeService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				codeSvc := svcmock.NewMockCodeService(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{
+					Id: 1, Phone: "15212345678", CodeChannel: domain.CodeChannelEmail,
+				}, nil)
+				codeSvc.EXPECT().Send(gomock.Any(), "delete_account", domain.CodeChannelVoice, "15212345678").Return(nil)
+				return userRepo, codeSvc
+			},
+			channel: domain.CodeChannelVoice,
+		},
+		{
+			name: "指定的渠道没有绑定，退回短信",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, service.CodeService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				codeSvc := svcmock.NewMockCodeService(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{
+					Id: 1, Phone: "15212345678",
+				}, nil)
+				codeSvc.EXPECT().Send(gomock.Any(), "delete_account", domain.CodeChannelSMS, "15212345678").Return(nil)
+				return userRepo, codeSvc
+			},
+			channel: domain.CodeChannelEmail,
+		},
+		{
+			name: "没有手机号也没有邮箱",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, service.CodeService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				return userRepo, svcmock.NewMockCodeService(ctrl)
+			},
+			wantErr: service.ErrNoContact,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo, codeSvc := tc.mock(ctrl)
+			svc := service.NewAccountService(userRepo, nil, codeSvc, nil, nil, logger.NewNopLogger())
+			err := svc.SendDeletionCode(context.Background(), 1, tc.channel)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/repository"
+	"net/http"
+	"time"
+)
+
+var ErrArticleNotFound = errs.New(http.StatusNotFound, errs.ArticleNotFound, "帖子不存在")
+var ErrUserNotFound = errs.New(http.StatusNotFound, errs.UserNotFound, "用户不存在")
+var ErrCannotBanAdmin = errs.New(http.StatusBadRequest, errs.UserCannotBanAdmin, "不能封禁管理员")
+
+// AdminService 管理员的操作，调用之前要先检查权限
+type AdminService interface {
+	// TakeDownArticle 下架帖子，作者之后不能再修改或者重新发表
+	TakeDownArticle(ctx context.Context, id int64) error
+	BanUser(ctx context.Context, uid int64) error
+	UnbanUser(ctx context.Context, uid int64) error
+}
+
+type adminService struct {
+	userRepo    repository.UserRepository
+	articleRepo repository.ArticleRepository
+	authzSvc    AuthzService
+}
+
+func NewAdminService(userRepo repository.UserRepository,
+	articleRepo repository.ArticleRepository,
+	authzSvc AuthzService) AdminService {
+	return &adminService{
+		userRepo:    userRepo,
+		articleRepo: articleRepo,
+		authzSvc:    authzSvc,
+	}
+}
+
+func (svc *adminService) TakeDownArticle(ctx context.Context, id int64) error {
+	art, err := svc.articleRepo.GetById(ctx, id)
+	if err == repository.ErrArticleNotFound {
+		return ErrArticleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if art.Status == domain.ArticleStatusTakenDown {
+		return nil
+	}
+	return svc.articleRepo.SyncStatus(ctx, art.Author.Id, id, domain.ArticleStatusTakenDown)
+}
+
+func (svc *adminService) BanUser(ctx context.Context, uid int64) error {
+	roles, err := svc.authzSvc.Roles(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, r := range roles {
+		// 管理员之间不能互相封禁，要先收回角色
+		if r == domain.RoleAdmin {
+			return ErrCannotBanAdmin
+		}
+	}
+	return svc.updateBannedAt(ctx, uid, time.Now())
+}
+
+func (svc *adminService) UnbanUser(ctx context.Context, uid int64) error {
+	return svc.updateBannedAt(ctx, uid, time.Time{})
+}
+
+func (svc *adminService) updateBannedAt(ctx context.Context, uid int64, bannedAt time.Time) error {
+	err := svc.userRepo.UpdateBannedAt(ctx, uid, bannedAt)
+	if err == repository.ErrUserNotFound {
+		return ErrUserNotFound
+	}
+	return err
+}
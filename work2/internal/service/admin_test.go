@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	svcmock "example/wb/internal/service/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminService_TakeDownArticle(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) repository.ArticleRepository
+
+		wantErr error
+	}{
+		{
+			name: "下架成功",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomock.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(10)).Return(domain.Article{
+					Id:     10,
+					Author: domain.Author{Id: 2},
+					Status: domain.ArticleStatusPublished,
+				}, nil)
+				repo.EXPECT().SyncStatus(gomock.Any(), int64(2), int64(10),
+					domain.ArticleStatus(domain.ArticleStatusTakenDown)).Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "已经下架了",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomock.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(10)).Return(domain.Article{
+					Id:     10,
+					Author: domain.Author{Id: 2},
+					Status: domain.ArticleStatusTakenDown,
+				}, nil)
+				return repo
+			},
+		},
+		{
+			name: "帖子不存在",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomock.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(10)).
+					Return(domain.Article{}, repository.ErrArticleNotFound)
+				return repo
+			},
+			wantErr: service.ErrArticleNotFound,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewAdminService(nil, tc.mock(ctrl), nil)
+			err := svc.TakeDownArticle(context.Background(), 10)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestAdminService_BanUser(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (repository.UserRepository, service.AuthzService)
+
+		wantErr error
+	}{
+		{
+			name: "封禁成功",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, service.AuthzService) {
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				authzSvc := svcmock.NewMockAuthzService(ctrl)
+				authzSvc.EXPECT().Roles(gomock.Any(), int64(2)).Return([]string{}, nil)
+				userRepo.EXPECT().UpdateBannedAt(gomock.Any(), int64(2), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, id int64, bannedAt time.Time) error {
+						assert.False(t, bannedAt.IsZero())
+						return nil
+					})
+				return userRepo, authzSvc
+			},
+		},
+		{
+			name: "不能封禁管理员",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, service.AuthzService) {
+				authzSvc := svcmock.NewMockAuthzService(ctrl)
+				authzSvc.EXPECT().Roles(gomock.Any(), int64(2)).Return([]string{domain.RoleAdmin}, nil)
+				return repomock.NewMockUserRepository(ctrl), authzSvc
+			},
+			wantErr: service.ErrCannotBanAdmin,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo, authzSvc := tc.mock(ctrl)
+			svc := service.NewAdminService(userRepo, nil, authzSvc)
+			err := svc.BanUser(context.Background(), 2)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
@@ -3,20 +3,27 @@ package service
 import (
 	"context"
 	"example/wb/internal/domain"
+	"example/wb/internal/errs"
 	"example/wb/internal/repository"
 	"example/wb/pkg/logger"
+	"net/http"
 )
 
+var ErrArticleTakenDown = errs.New(http.StatusForbidden, errs.ArticleTakenDown, "帖子已经被下架")
+
 type ArticleService interface {
 	Save(ctx context.Context, art domain.Article) (int64, error)
 	Publish(ctx context.Context, art domain.Article) (int64, error)
 	Withdraw(ctx context.Context, uid int64, id int64) error
 	GetByAuthor(ctx context.Context, uid int64, limit int, offset int) ([]domain.Article, error)
+	// GetById 作者查看自己的帖子，不是自己的帖子当作不存在
+	GetById(ctx context.Context, uid int64, id int64) (domain.Article, error)
 }
 
 type articleService struct {
-	repo repository.ArticleRepository
-	l    logger.Logger
+	repo     repository.ArticleRepository
+	userRepo repository.UserRepository
+	l        logger.Logger
 	// V1 写法专用, 两张不同的表再service层聚合
 	// readerRepo repository.ArticleReaderRepository
 	// authorRepo repository.ArticleAuthorRepository
@@ -24,12 +31,57 @@ type articleService struct {
 
 // GetByAuthor implements ArticleService.
 func (a *articleService) GetByAuthor(ctx context.Context, uid int64, limit int, offset int) ([]domain.Article, error) {
-	return a.repo.GetByAuthor(ctx, uid, limit, offset)
+	arts, err := a.repo.GetByAuthor(ctx, uid, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	a.fillAuthorNames(ctx, arts)
+	return arts, nil
+}
+
+// GetById implements ArticleService.
+func (a *articleService) GetById(ctx context.Context, uid int64, id int64) (domain.Article, error) {
+	art, err := a.repo.GetById(ctx, id)
+	if err == repository.ErrArticleNotFound {
+		return domain.Article{}, ErrArticleNotFound
+	}
+	if err != nil {
+		return domain.Article{}, err
+	}
+	if art.Author.Id != uid {
+		return domain.Article{}, ErrArticleNotFound
+	}
+	arts := []domain.Article{art}
+	a.fillAuthorNames(ctx, arts)
+	return arts[0], nil
+}
 
+// fillAuthorNames 批量查询作者的昵称，避免一篇帖子查一次用户。
+// 查询失败只是没有作者名称，不影响帖子本身的返回
+func (a *articleService) fillAuthorNames(ctx context.Context, arts []domain.Article) {
+	if len(arts) == 0 {
+		return
+	}
+	ids := make([]int64, 0, len(arts))
+	for _, art := range arts {
+		ids = append(ids, art.Author.Id)
+	}
+	users, err := a.userRepo.FindByIds(ctx, ids)
+	if err != nil {
+		a.l.Error("查询帖子作者失败", logger.Error(err))
+		return
+	}
+	for i := range arts {
+		arts[i].Author.Name = users[arts[i].Author.Id].NickName
+	}
 }
 
 // Publish implements ArticleService.
 func (a *articleService) Publish(ctx context.Context, art domain.Article) (int64, error) {
+	err := a.checkTakenDown(ctx, art.Id)
+	if err != nil {
+		return 0, err
+	}
 	art.Status = domain.ArticleStatusPublished
 	return a.repo.Sync(ctx, art)
 }
@@ -38,7 +90,11 @@ func (a *articleService) Publish(ctx context.Context, art domain.Article) (int64
 func (a *articleService) Save(ctx context.Context, art domain.Article) (int64, error) {
 	art.Status = domain.ArticleStatusUnpublished
 	if art.Id > 0 {
-		err := a.repo.Update(ctx, art)
+		err := a.checkTakenDown(ctx, art.Id)
+		if err != nil {
+			return 0, err
+		}
+		err = a.repo.Update(ctx, art)
 		return art.Id, err
 	}
 	return a.repo.Create(ctx, art)
@@ -46,12 +102,37 @@ func (a *articleService) Save(ctx context.Context, art domain.Article) (int64, e
 
 // Withdraw implements ArticleService.
 func (a *articleService) Withdraw(ctx context.Context, uid int64, id int64) error {
+	err := a.checkTakenDown(ctx, id)
+	if err != nil {
+		return err
+	}
 	return a.repo.SyncStatus(ctx, uid, id, domain.ArticleStatusPrivate)
 }
 
-func NewArticleService(repo repository.ArticleRepository, l logger.Logger) ArticleService {
+// checkTakenDown 被管理员下架的帖子，作者不能再修改或者重新发表
+func (a *articleService) checkTakenDown(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return nil
+	}
+	art, err := a.repo.GetById(ctx, id)
+	if err == repository.ErrArticleNotFound {
+		// 交给后面的逻辑去处理
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if art.Status == domain.ArticleStatusTakenDown {
+		return ErrArticleTakenDown
+	}
+	return nil
+}
+
+func NewArticleService(repo repository.ArticleRepository,
+	userRepo repository.UserRepository, l logger.Logger) ArticleService {
 	return &articleService{
-		repo: repo,
-		l:    l,
+		repo:     repo,
+		userRepo: userRepo,
+		l:        l,
 	}
 }
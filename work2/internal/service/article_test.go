@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	"example/wb/pkg/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestArticleService_GetByAuthor(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (repository.ArticleRepository, repository.UserRepository)
+
+		wantArts []domain.Article
+		wantErr  error
+	}{
+		{
+			name: "填充作者名称",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository, repository.UserRepository) {
+				artRepo := repomock.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetByAuthor(gomock.Any(), int64(1), 10, 0).
+					Return([]domain.Article{
+						{Id: 1, Author: domain.Author{Id: 1}},
+						{Id: 2, Author: domain.Author{Id: 1}},
+					}, nil)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				// 一次批量查询，而不是一篇帖子查一次
+				userRepo.EXPECT().FindByIds(gomock.Any(), []int64{1, 1}).
+					Return(map[int64]domain.User{1: {Id: 1, NickName: "小明"}}, nil)
+				return artRepo, userRepo
+			},
+			wantArts: []domain.Article{
+				{Id: 1, Author: domain.Author{Id: 1, Name: "小明"}},
+				{Id: 2, Author: domain.Author{Id: 1, Name: "小明"}},
+			},
+		},
+		{
+			name: "查询作者失败，不影响帖子",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository, repository.UserRepository) {
+				artRepo := repomock.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetByAuthor(gomock.Any(), int64(1), 10, 0).
+					Return([]domain.Article{{Id: 1, Author: domain.Author{Id: 1}}}, nil)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByIds(gomock.Any(), []int64{1}).
+					Return(nil, errors.New("db错误"))
+				return artRepo, userRepo
+			},
+			wantArts: []domain.Article{{Id: 1, Author: domain.Author{Id: 1}}},
+		},
+		{
+			name: "没有帖子不查作者",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository, repository.UserRepository) {
+				artRepo := repomock.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetByAuthor(gomock.Any(), int64(1), 10, 0).
+					Return([]domain.Article{}, nil)
+				return artRepo, repomock.NewMockUserRepository(ctrl)
+			},
+			wantArts: []domain.Article{},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			artRepo, userRepo := tc.mock(ctrl)
+			svc := service.NewArticleService(artRepo, userRepo, logger.NewNopLogger())
+			arts, err := svc.GetByAuthor(context.Background(), 1, 10, 0)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantArts, arts)
+		})
+	}
+}
+
+func TestArticleService_GetById(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (repository.ArticleRepository, repository.UserRepository)
+
+		wantArt domain.Article
+		wantErr error
+	}{
+		{
+			name: "查询成功",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository, repository.UserRepository) {
+				artRepo := repomock.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetById(gomock.Any(), int64(2)).
+					Return(domain.Article{Id: 2, Author: domain.Author{Id: 1}}, nil)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByIds(gomock.Any(), []int64{1}).
+					Return(map[int64]domain.User{1: {Id: 1, NickName: "小明"}}, nil)
+				return artRepo, userRepo
+			},
+			wantArt: domain.Article{Id: 2, Author: domain.Author{Id: 1, Name: "小明"}},
+		},
+		{
+			name: "不是自己的帖子",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository, repository.UserRepository) {
+				artRepo := repomock.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetById(gomock.Any(), int64(2)).
+					Return(domain.Article{Id: 2, Author: domain.Author{Id: 3}}, nil)
+				return artRepo, repomock.NewMockUserRepository(ctrl)
+			},
+			wantErr: service.ErrArticleNotFound,
+		},
+		{
+			name: "帖子不存在",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository, repository.UserRepository) {
+				artRepo := repomock.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetById(gomock.Any(), int64(2)).
+					Return(domain.Article{}, repository.ErrArticleNotFound)
+				return artRepo, repomock.NewMockUserRepository(ctrl)
+			},
+			wantErr: service.ErrArticleNotFound,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			artRepo, userRepo := tc.mock(ctrl)
+			svc := service.NewArticleService(artRepo, userRepo, logger.NewNopLogger())
+			art, err := svc.GetById(context.Background(), 1, 2)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantArt, art)
+		})
+	}
+}
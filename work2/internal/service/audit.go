@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	"example/wb/pkg/logger"
+	"time"
+)
+
+// AuditService 安全审计日志
+type AuditService interface {
+	// Record 异步写入，不会拖慢登录这些接口；队列满了会丢弃并且记录日志
+	Record(ctx context.Context, e domain.AuditEvent)
+	// Recent 用户自己最近的安全事件
+	Recent(ctx context.Context, uid int64, limit int) ([]domain.AuditEvent, error)
+	// Query 管理员按照条件查询
+	Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error)
+}
+
+type asyncAuditService struct {
+	repo   repository.AuditRepository
+	l      logger.Logger
+	events chan domain.AuditEvent
+	// 攒够一批或者到时间就写一次数据库
+	batchSize     int
+	flushInterval time.Duration
+	maxLimit      int
+}
+
+func NewAuditService(repo repository.AuditRepository, l logger.Logger) AuditService {
+	svc := &asyncAuditService{
+		repo:          repo,
+		l:             l,
+		events:        make(chan domain.AuditEvent, 4096),
+		batchSize:     100,
+		flushInterval: time.Second,
+		maxLimit:      100,
+	}
+	go svc.loop()
+	return svc
+}
+
+func (svc *asyncAuditService) Record(ctx context.Context, e domain.AuditEvent) {
+	if e.Ctime.IsZero() {
+		e.Ctime = time.Now()
+	}
+	e.UserAgent = truncate(e.UserAgent, 512)
+	e.Detail = truncate(e.Detail, 512)
+	e.Target = truncate(e.Target, 256)
+	select {
+	case svc.events <- e:
+	default:
+		svc.l.Error("审计日志队列已满，丢弃事件",
+			logger.Int64("uid", e.Uid),
+			logger.String("action", string(e.Action)))
+	}
+}
+
+func (svc *asyncAuditService) loop() {
+	ticker := time.NewTicker(svc.flushInterval)
+	defer ticker.Stop()
+	batch := make([]domain.AuditEvent, 0, svc.batchSize)
+	for {
+		select {
+		case e := <-svc.events:
+			batch = append(batch, e)
+			if len(batch) < svc.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		svc.flush(batch)
+		batch = make([]domain.AuditEvent, 0, svc.batchSize)
+	}
+}
+
+func (svc *asyncAuditService) flush(batch []domain.AuditEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	err := svc.repo.CreateBatch(ctx, batch)
+	if err != nil {
+		// 审计日志写失败不影响业务，但是要能被监控到
+		svc.l.Error("写入审计日志失败", logger.Int("cnt", len(batch)), logger.Error(err))
+	}
+}
+
+func (svc *asyncAuditService) Recent(ctx context.Context, uid int64, limit int) ([]domain.AuditEvent, error) {
+	return svc.Query(ctx, domain.AuditQuery{
+		Uid:   uid,
+		Limit: limit,
+	})
+}
+
+func (svc *asyncAuditService) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error) {
+	if q.Limit <= 0 || q.Limit > svc.maxLimit {
+		q.Limit = svc.maxLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return svc.repo.Find(ctx, q)
+}
+
+// truncate 按照字符截断，避免把多字节字符切坏
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	"example/wb/pkg/logger"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditService_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomock.NewMockAuditRepository(ctrl)
+	written := make(chan []domain.AuditEvent, 1)
+	repo.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, events []domain.AuditEvent) error {
+			written <- events
+			return nil
+		})
+	svc := service.NewAuditService(repo, logger.NewNopLogger())
+
+	start := time.Now()
+	svc.Record(context.Background(), domain.AuditEvent{
+		Uid:       1,
+		Action:    domain.AuditLogin,
+		Target:    "123@qq.com",
+		UserAgent: strings.Repeat("a", 1000),
+		Success:   true,
+	})
+	svc.Record(context.Background(), domain.AuditEvent{
+		Action: domain.AuditLogin,
+		Target: "123@qq.com",
+	})
+	// Record 不能等数据库
+	assert.Less(t, time.Since(start), time.Millisecond*100)
+
+	select {
+	case events := <-written:
+		require.Len(t, events, 2)
+		assert.Equal(t, int64(1), events[0].Uid)
+		assert.True(t, events[0].Success)
+		assert.Len(t, events[0].UserAgent, 512)
+		assert.False(t, events[0].Ctime.IsZero())
+		assert.False(t, events[1].Success)
+	case <-time.After(time.Second * 3):
+		t.Fatal("审计日志没有写入")
+	}
+}
+
+func TestAuditService_Query(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) repository.AuditRepository
+		q    domain.AuditQuery
+
+		wantErr error
+	}{
+		{
+			name: "默认条数",
+			mock: func(ctrl *gomock.Controller) repository.AuditRepository {
+				repo := repomock.NewMockAuditRepository(ctrl)
+				repo.EXPECT().Find(gomock.Any(), domain.AuditQuery{
+					Uid:   1,
+					Limit: 100,
+				}).Return(nil, nil)
+				return repo
+			},
+			q: domain.AuditQuery{Uid: 1},
+		},
+		{
+			name: "条数太多",
+			mock: func(ctrl *gomock.Controller) repository.AuditRepository {
+				repo := repomock.NewMockAuditRepository(ctrl)
+				repo.EXPECT().Find(gomock.Any(), domain.AuditQuery{
+					Action: domain.AuditUserBan,
+					Offset: 0,
+					Limit:  100,
+				}).Return(nil, nil)
+				return repo
+			},
+			q: domain.AuditQuery{Action: domain.AuditUserBan, Offset: -1, Limit: 10000},
+		},
+		{
+			name: "查询出错",
+			mock: func(ctrl *gomock.Controller) repository.AuditRepository {
+				repo := repomock.NewMockAuditRepository(ctrl)
+				repo.EXPECT().Find(gomock.Any(), domain.AuditQuery{
+					Limit: 20,
+				}).Return(nil, errors.New("db 错误"))
+				return repo
+			},
+			q:       domain.AuditQuery{Limit: 20},
+			wantErr: errors.New("db 错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := service.NewAuditService(tc.mock(ctrl), logger.NewNopLogger())
+			_, err := svc.Query(context.Background(), tc.q)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
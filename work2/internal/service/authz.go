@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+)
+
+// AuthzService 用户的角色和权限，每次请求的时候查询，
+// 这样收回角色之后马上生效，不用等 token 过期
+type AuthzService interface {
+	Roles(ctx context.Context, uid int64) ([]string, error)
+	// HasPermissions 需要同时拥有所有的权限
+	HasPermissions(ctx context.Context, uid int64, perms ...domain.Permission) (bool, error)
+	Grant(ctx context.Context, uid int64, role string) error
+	Revoke(ctx context.Context, uid int64, role string) error
+}
+
+type authzService struct {
+	repo repository.RoleRepository
+}
+
+func NewAuthzService(repo repository.RoleRepository) AuthzService {
+	return &authzService{
+		repo: repo,
+	}
+}
+
+func (svc *authzService) Roles(ctx context.Context, uid int64) ([]string, error) {
+	return svc.repo.FindByUid(ctx, uid)
+}
+
+func (svc *authzService) HasPermissions(ctx context.Context, uid int64, perms ...domain.Permission) (bool, error) {
+	roles, err := svc.repo.FindByUid(ctx, uid)
+	if err != nil {
+		return false, err
+	}
+	owned := domain.PermissionsOf(roles)
+	for _, p := range perms {
+		if _, ok := owned[p]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (svc *authzService) Grant(ctx context.Context, uid int64, role string) error {
+	return svc.repo.Grant(ctx, uid, role)
+}
+
+func (svc *authzService) Revoke(ctx context.Context, uid int64, role string) error {
+	return svc.repo.Revoke(ctx, uid, role)
+}
@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthzService_HasPermissions(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock  func(ctrl *gomock.Controller) repository.RoleRepository
+		perms []domain.Permission
+
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "管理员",
+			mock: func(ctrl *gomock.Controller) repository.RoleRepository {
+				repo := repomock.NewMockRoleRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return([]string{domain.RoleAdmin}, nil)
+				return repo
+			},
+			perms: []domain.Permission{domain.PermissionUserBan, domain.PermissionArticleTakeDown},
+			want:  true,
+		},
+		{
+			name: "普通用户",
+			mock: func(ctrl *gomock.Controller) repository.RoleRepository {
+				repo := repomock.NewMockRoleRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return([]string{}, nil)
+				return repo
+			},
+			perms: []domain.Permission{domain.PermissionUserBan},
+		},
+		{
+			name: "不认识的角色",
+			mock: func(ctrl *gomock.Controller) repository.RoleRepository {
+				repo := repomock.NewMockRoleRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return([]string{"unknown"}, nil)
+				return repo
+			},
+			perms: []domain.Permission{domain.PermissionUserBan},
+		},
+		{
+			name: "查询出错",
+			mock: func(ctrl *gomock.Controller) repository.RoleRepository {
+				repo := repomock.NewMockRoleRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(nil, errors.New("db错误"))
+				return repo
+			},
+			perms:   []domain.Permission{domain.PermissionUserBan},
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewAuthzService(tc.mock(ctrl))
+			ok, err := svc.HasPermissions(context.Background(), 1, tc.perms...)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, ok)
+		})
+	}
+}
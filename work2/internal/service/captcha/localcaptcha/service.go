@@ -0,0 +1,20 @@
+package localcaptcha
+
+import (
+	"context"
+	"example/wb/internal/service/captcha"
+	"log"
+)
+
+// LocalService 本地开发用，只要带了 ticket 就认为通过
+type LocalService struct {
+}
+
+func NewLocalService() captcha.Service {
+	return &LocalService{}
+}
+
+func (s *LocalService) Verify(ctx context.Context, ticket string, ip string) (bool, error) {
+	log.Println("人机验证 ticket：", ticket, ip)
+	return ticket != "", nil
+}
@@ -0,0 +1,75 @@
+package siteverify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Verify(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		ticket string
+		status int
+		body   string
+
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "通过",
+			ticket: "ticket-ok",
+			status: http.StatusOK,
+			body:   `{"success":true}`,
+			want:   true,
+		},
+		{
+			name:   "没有通过",
+			ticket: "ticket-bad",
+			status: http.StatusOK,
+			body:   `{"success":false,"error-codes":["invalid-input-response"]}`,
+		},
+		{
+			name:    "接口出错",
+			ticket:  "ticket-ok",
+			status:  http.StatusInternalServerError,
+			wantErr: true,
+		},
+		{
+			name:    "响应不是JSON",
+			ticket:  "ticket-ok",
+			status:  http.StatusOK,
+			body:    `<html>`,
+			wantErr: true,
+		},
+		{
+			name: "没有ticket不访问接口",
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, "secret-1", r.PostForm.Get("secret"))
+				assert.Equal(t, tc.ticket, r.PostForm.Get("response"))
+				assert.Equal(t, "127.0.0.1", r.PostForm.Get("remoteip"))
+				if tc.ticket == "" {
+					t.Error("没有ticket不应该访问接口")
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			svc := NewService(server.Client(), server.URL, "secret-1")
+			ok, err := svc.Verify(context.Background(), tc.ticket, "127.0.0.1")
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, ok)
+		})
+	}
+}
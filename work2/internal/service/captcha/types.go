@@ -0,0 +1,8 @@
+package captcha
+
+import "context"
+
+// Service 人机验证，ticket 是前端完成验证之后拿到的票据
+type Service interface {
+	Verify(ctx context.Context, ticket string, ip string) (bool, error)
+}
@@ -2,46 +2,62 @@ package service
 
 import (
 	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
 	"example/wb/internal/repository"
-	"example/wb/internal/service/sms"
-	"fmt"
 	"math/rand"
+	"net/http"
 )
 
-var ErrCodeVertifyTooMany = repository.ErrCodeVertifyTooMany
-var ErrSendTooMany = repository.ErrSendTooMany
+var ErrCodeVertifyTooMany = errs.New(http.StatusTooManyRequests, errs.CodeVerifyTooMany, "验证次数太多，请重新获取验证码")
+var ErrSendTooMany = errs.New(http.StatusTooManyRequests, errs.CodeSendTooMany, "发送太频繁，请稍后再试")
 
+// CodeService 验证码可以通过短信、语音或者邮件发送，
+// 不同渠道的验证码互相独立，用哪个渠道发送的就要用哪个渠道验证
 type CodeService interface {
-	Send(ctx context.Context, biz, phone string) error
-	Vertify(ctx context.Context, biz, phone, inputCode string) (bool, error)
+	// Send target 是手机号或者邮箱，和 channel 对应
+	Send(ctx context.Context, biz string, channel domain.CodeChannel, target string) error
+	Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, inputCode string) (bool, error)
 }
 
 type codeService struct {
-	repo repository.CodeRepository
-	sms  sms.Service
+	repo     repository.CodeRepository
+	senders  CodeSenders
+	policies *CodePolicies
 }
 
-func NewCodeService(repo repository.CodeRepository, sms sms.Service) CodeService {
+func NewCodeService(repo repository.CodeRepository, senders CodeSenders, policies *CodePolicies) CodeService {
 	return &codeService{
-		repo: repo,
-		sms:  sms,
+		repo:     repo,
+		senders:  senders,
+		policies: policies,
 	}
 
 }
 
-func (svc *codeService) Send(ctx context.Context, biz, phone string) error {
-	code := svc.generate()
-	err := svc.repo.Set(ctx, biz, phone, code)
+func (svc *codeService) Send(ctx context.Context, biz string, channel domain.CodeChannel, target string) error {
+	p, err := svc.policies.Get(biz)
+	if err != nil {
+		return errs.Internal(err)
+	}
+	sender, ok := svc.senders[channel]
+	tplId := p.Templates[channel]
+	if !ok || tplId == "" {
+		return ErrCodeChannelUnsupported
+	}
+	code := svc.generate(p)
+	err = svc.repo.Set(ctx, biz, channel, target, code, p)
+	if err == repository.ErrSendTooMany {
+		return ErrSendTooMany
+	}
 	if err != nil {
 		return err
 	}
-	// 短信的模板id，一般为常量，不进行修改
-	const tplId = "1263395"
-	return svc.sms.Send(ctx, tplId, []string{code}, phone)
+	return sender.Send(ctx, tplId, []string{code}, target)
 
 }
-func (svc *codeService) Vertify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
-	ok, err := svc.repo.Vertify(ctx, biz, phone, inputCode)
+func (svc *codeService) Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, inputCode string) (bool, error) {
+	ok, err := svc.repo.Vertify(ctx, biz, channel, target, inputCode)
 	if err == repository.ErrCodeVertifyTooMany {
 		// 屏蔽验证次数过多的错误
 		return false, nil
@@ -52,8 +68,11 @@ func (svc *codeService) Vertify(ctx context.Context, biz, phone, inputCode strin
 	return true, nil
 }
 
-func (svc *codeService) generate() string {
-	code := rand.Intn(1000000)
-	return fmt.Sprintf("%06d", code)
+func (svc *codeService) generate(p domain.CodePolicy) string {
+	code := make([]byte, p.Length)
+	for i := range code {
+		code[i] = p.Alphabet[rand.Intn(len(p.Alphabet))]
+	}
+	return string(code)
 
 }
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/pkg/limiter"
+	"net/http"
+)
+
+var ErrCodeChannelUnsupported = errs.New(http.StatusBadRequest, errs.CodeChannelUnsupported, "不支持这种验证码发送方式")
+var ErrCodeChannelLimited = errs.New(http.StatusTooManyRequests, errs.CodeChannelLimited, "验证码发送繁忙，请稍后再试或者换一种方式")
+
+// CodeSender 验证码的发送渠道，sms.Service、email.Service 和 voice.Service 都实现了这个接口
+type CodeSender interface {
+	Send(ctx context.Context, tplId string, args []string, to ...string) error
+}
+
+// CodeSenders 每个渠道一个 CodeSender，没有配置的渠道不能发送验证码
+type CodeSenders map[domain.CodeChannel]CodeSender
+
+// RateLimitCodeSender 每个渠道单独限流，一个渠道被打满了不影响其他渠道
+type RateLimitCodeSender struct {
+	svc     CodeSender
+	limiter limiter.Limiter
+	key     string
+}
+
+func NewRateLimitCodeSender(svc CodeSender, l limiter.Limiter, channel domain.CodeChannel) CodeSender {
+	return &RateLimitCodeSender{
+		svc:     svc,
+		limiter: l,
+		key:     "code_ratelimit:" + string(channel),
+	}
+}
+
+func (r *RateLimitCodeSender) Send(ctx context.Context, tplId string, args []string, to ...string) error {
+	limited, err := r.limiter.Limit(ctx, r.key)
+	if err != nil || limited {
+		// 限流器出错的时候保守一点，按照限流处理
+		return ErrCodeChannelLimited
+	}
+	return r.svc.Send(ctx, tplId, args, to...)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	smsmock "example/wb/internal/service/sms/mocks"
+	"example/wb/pkg/limiter"
+	limitmock "example/wb/pkg/limiter/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRateLimitCodeSender_Send(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (CodeSender, limiter.Limiter)
+
+		wantErr error
+	}{
+		{
+			name: "不限流",
+			mock: func(ctrl *gomock.Controller) (CodeSender, limiter.Limiter) {
+				svc := smsmock.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "code", []string{"123456"}, "123@qq.com").Return(nil)
+				l := limitmock.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "code_ratelimit:email").Return(false, nil)
+				return svc, l
+			},
+		},
+		{
+			name: "限流",
+			mock: func(ctrl *gomock.Controller) (CodeSender, limiter.Limiter) {
+				l := limitmock.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "code_ratelimit:email").Return(true, nil)
+				return smsmock.NewMockService(ctrl), l
+			},
+			wantErr: ErrCodeChannelLimited,
+		},
+		{
+			name: "限流器出错",
+			mock: func(ctrl *gomock.Controller) (CodeSender, limiter.Limiter) {
+				l := limitmock.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "code_ratelimit:email").Return(false, errors.New("redis错误"))
+				return smsmock.NewMockService(ctrl), l
+			},
+			wantErr: ErrCodeChannelLimited,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc, l := tc.mock(ctrl)
+			sender := NewRateLimitCodeSender(svc, l, domain.CodeChannelEmail)
+			err := sender.Send(context.Background(), "code", []string{"123456"}, "123@qq.com")
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
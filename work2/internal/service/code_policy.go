@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"example/wb/internal/domain"
+	"fmt"
+	"time"
+)
+
+const digits = "0123456789"
+
+var ErrUnknownCodeBiz = errors.New("没有配置这个业务的验证码策略")
+
+// DefaultCodePolicy 没有单独配置的字段都用这里的值
+var DefaultCodePolicy = domain.CodePolicy{
+	Templates: map[domain.CodeChannel]string{
+		domain.CodeChannelSMS:   "code",
+		domain.CodeChannelEmail: "code",
+		domain.CodeChannelVoice: "code",
+	},
+	Length:         6,
+	Alphabet:       digits,
+	TTL:            time.Minute * 10,
+	ResendInterval: time.Minute,
+	MaxAttempts:    3,
+}
+
+// CodePolicies 每个业务一套验证码策略，没有注册过的业务不允许发送验证码
+type CodePolicies struct {
+	policies map[string]domain.CodePolicy
+}
+
+func NewCodePolicies() *CodePolicies {
+	return &CodePolicies{
+		policies: make(map[string]domain.CodePolicy),
+	}
+}
+
+// Register p 里面的零值用 DefaultCodePolicy 补齐，模板按照渠道分别补齐
+func (c *CodePolicies) Register(p domain.CodePolicy) error {
+	if p.Biz == "" {
+		return errors.New("验证码策略缺少 biz")
+	}
+	templates := make(map[domain.CodeChannel]string, len(DefaultCodePolicy.Templates))
+	for ch, tpl := range DefaultCodePolicy.Templates {
+		templates[ch] = tpl
+	}
+	for ch, tpl := range p.Templates {
+		if !ch.Valid() {
+			return fmt.Errorf("验证码策略 %s 的渠道 %s 不存在", p.Biz, ch)
+		}
+		if tpl != "" {
+			templates[ch] = tpl
+		}
+	}
+	p.Templates = templates
+	if p.Length == 0 {
+		p.Length = DefaultCodePolicy.Length
+	}
+	if p.Alphabet == "" {
+		p.Alphabet = DefaultCodePolicy.Alphabet
+	}
+	if p.TTL == 0 {
+		p.TTL = DefaultCodePolicy.TTL
+	}
+	if p.ResendInterval == 0 {
+		p.ResendInterval = DefaultCodePolicy.ResendInterval
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultCodePolicy.MaxAttempts
+	}
+	// 缓存里面的过期时间是按秒算的
+	if p.TTL < time.Second || p.ResendInterval < time.Second || p.ResendInterval >= p.TTL {
+		return fmt.Errorf("验证码策略 %s 的有效期 %s 必须大于重新发送的间隔 %s",
+			p.Biz, p.TTL, p.ResendInterval)
+	}
+	if p.Length < 0 || p.MaxAttempts < 0 {
+		return fmt.Errorf("验证码策略 %s 的长度和验证次数不能是负数", p.Biz)
+	}
+	c.policies[p.Biz] = p
+	return nil
+}
+
+func (c *CodePolicies) Get(biz string) (domain.CodePolicy, error) {
+	p, ok := c.policies[biz]
+	if !ok {
+		return domain.CodePolicy{}, fmt.Errorf("%w: %s", ErrUnknownCodeBiz, biz)
+	}
+	return p, nil
+}
@@ -1,12 +1,233 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service/sms"
+	smsmock "example/wb/internal/service/sms/mocks"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func TestGenerate(t *testing.T) {
 	t.Log(fmt.Sprintf("%06d", rand.Intn(1000000)))
 
 }
+
+func TestCodeService_Send(t *testing.T) {
+	policies := NewCodePolicies()
+	require.NoError(t, policies.Register(domain.CodePolicy{Biz: "login"}))
+	require.NoError(t, policies.Register(domain.CodePolicy{
+		Biz: "reset",
+		Templates: map[domain.CodeChannel]string{
+			domain.CodeChannelSMS: "reset-tpl",
+		},
+		Length:   8,
+		Alphabet: "AB",
+	}))
+	isCode := func(length int, alphabet string) gomock.Matcher {
+		return gomock.Cond(func(x any) bool {
+			code, ok := x.(string)
+			if !ok || len(code) != length {
+				return false
+			}
+			for _, c := range code {
+				if !strings.ContainsRune(alphabet, c) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	testCase := []struct {
+		name string
+
+		// 只配置了短信和邮件，sms.Service 的 mock 也可以当作邮件的 CodeSender
+		mock    func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service, sms.Service)
+		biz     string
+		channel domain.CodeChannel
+		target  string
+
+		wantErr error
+	}{
+		{
+			name: "默认策略",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service, sms.Service) {
+				repo := repomock.NewMockCodeRepository(ctrl)
+				smsSvc := smsmock.NewMockService(ctrl)
+				p, _ := policies.Get("login")
+				repo.EXPECT().Set(gomock.Any(), "login", domain.CodeChannelSMS, "+8613800138000", isCode(6, digits), p).Return(nil)
+				smsSvc.EXPECT().Send(gomock.Any(), "code", gomock.Len(1), "+8613800138000").Return(nil)
+				return repo, smsSvc, smsmock.NewMockService(ctrl)
+			},
+			biz:     "login",
+			channel: domain.CodeChannelSMS,
+			target:  "+8613800138000",
+		},
+		{
+			name: "业务自己的模板和字符集",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service, sms.Service) {
+				repo := repomock.NewMockCodeRepository(ctrl)
+				smsSvc := smsmock.NewMockService(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "reset", domain.CodeChannelSMS, "+8613800138000", isCode(8, "AB"), gomock.Any()).Return(nil)
+				smsSvc.EXPECT().Send(gomock.Any(), "reset-tpl", gomock.Len(1), "+8613800138000").Return(nil)
+				return repo, smsSvc, smsmock.NewMockService(ctrl)
+			},
+			biz:     "reset",
+			channel: domain.CodeChannelSMS,
+			target:  "+8613800138000",
+		},
+		{
+			name: "邮件用默认的邮件模板",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service, sms.Service) {
+				repo := repomock.NewMockCodeRepository(ctrl)
+				emailSvc := smsmock.NewMockService(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "reset", domain.CodeChannelEmail, "123@qq.com", isCode(8, "AB"), gomock.Any()).Return(nil)
+				emailSvc.EXPECT().Send(gomock.Any(), "code", gomock.Len(1), "123@qq.com").Return(nil)
+				return repo, smsmock.NewMockService(ctrl), emailSvc
+			},
+			biz:     "reset",
+			channel: domain.CodeChannelEmail,
+			target:  "123@qq.com",
+		},
+		{
+			name: "没有配置的渠道",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service, sms.Service) {
+				return repomock.NewMockCodeRepository(ctrl), smsmock.NewMockService(ctrl), smsmock.NewMockService(ctrl)
+			},
+			biz:     "login",
+			channel: domain.CodeChannelVoice,
+			target:  "+8613800138000",
+			wantErr: ErrCodeChannelUnsupported,
+		},
+		{
+			name: "发送太频繁",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service, sms.Service) {
+				repo := repomock.NewMockCodeRepository(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", domain.CodeChannelSMS, "+8613800138000", gomock.Any(), gomock.Any()).
+					Return(repository.ErrSendTooMany)
+				return repo, smsmock.NewMockService(ctrl), smsmock.NewMockService(ctrl)
+			},
+			biz:     "login",
+			channel: domain.CodeChannelSMS,
+			target:  "+8613800138000",
+			wantErr: ErrSendTooMany,
+		},
+		{
+			name: "没有配置的业务",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service, sms.Service) {
+				return repomock.NewMockCodeRepository(ctrl), smsmock.NewMockService(ctrl), smsmock.NewMockService(ctrl)
+			},
+			biz:     "unknown",
+			channel: domain.CodeChannelSMS,
+			target:  "+8613800138000",
+			wantErr: ErrUnknownCodeBiz,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, smsSvc, emailSvc := tc.mock(ctrl)
+			svc := NewCodeService(repo, CodeSenders{
+				domain.CodeChannelSMS:   smsSvc,
+				domain.CodeChannelEmail: emailSvc,
+			}, policies)
+			err := svc.Send(context.Background(), tc.biz, tc.channel, tc.target)
+			assert.True(t, errors.Is(err, tc.wantErr), err)
+		})
+	}
+}
+
+func TestCodePolicies_Register(t *testing.T) {
+	testCase := []struct {
+		name string
+		p    domain.CodePolicy
+
+		want    domain.CodePolicy
+		wantErr bool
+	}{
+		{
+			name: "零值用默认值补齐",
+			p:    domain.CodePolicy{Biz: "login", TTL: time.Minute * 5},
+			want: domain.CodePolicy{
+				Biz: "login",
+				Templates: map[domain.CodeChannel]string{
+					domain.CodeChannelSMS:   "code",
+					domain.CodeChannelEmail: "code",
+					domain.CodeChannelVoice: "code",
+				},
+				Length:         6,
+				Alphabet:       digits,
+				TTL:            time.Minute * 5,
+				ResendInterval: time.Minute,
+				MaxAttempts:    3,
+			},
+		},
+		{
+			name: "只覆盖一个渠道的模板",
+			p: domain.CodePolicy{Biz: "login", Templates: map[domain.CodeChannel]string{
+				domain.CodeChannelEmail: "login-code",
+			}},
+			want: domain.CodePolicy{
+				Biz: "login",
+				Templates: map[domain.CodeChannel]string{
+					domain.CodeChannelSMS:   "code",
+					domain.CodeChannelEmail: "login-code",
+					domain.CodeChannelVoice: "code",
+				},
+				Length:         6,
+				Alphabet:       digits,
+				TTL:            time.Minute * 10,
+				ResendInterval: time.Minute,
+				MaxAttempts:    3,
+			},
+		},
+		{
+			name:    "不存在的渠道",
+			p:       domain.CodePolicy{Biz: "login", Templates: map[domain.CodeChannel]string{"fax": "tpl"}},
+			wantErr: true,
+		},
+		{
+			name:    "缺少biz",
+			p:       domain.CodePolicy{},
+			wantErr: true,
+		},
+		{
+			name:    "有效期比重发间隔短",
+			p:       domain.CodePolicy{Biz: "login", TTL: time.Second * 30},
+			wantErr: true,
+		},
+		{
+			name:    "有效期不到一秒",
+			p:       domain.CodePolicy{Biz: "login", TTL: time.Millisecond * 500, ResendInterval: time.Millisecond * 100},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			policies := NewCodePolicies()
+			err := policies.Register(tc.p)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			p, err := policies.Get(tc.p.Biz)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, p)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package localemail
+
+import (
+	"context"
+	"example/wb/internal/service/email"
+	"log"
+)
+
+type LocalService struct {
+}
+
+func NewLocalService() email.Service {
+	return &LocalService{}
+}
+
+func (s *LocalService) Send(ctx context.Context, tplId string, args []string, to ...string) error {
+	log.Println("邮件验证码是：", args, to)
+	return nil
+}
@@ -0,0 +1,67 @@
+package smtpemail
+
+import (
+	"bytes"
+	"context"
+	"example/wb/internal/service/email"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// Template 邮件模板，{0}、{1} 这样的占位符会按照顺序替换成参数，和短信模板的写法一样
+type Template struct {
+	Subject string
+	Body    string
+}
+
+type Service struct {
+	addr      string
+	auth      smtp.Auth
+	from      string
+	templates map[string]Template
+	// 测试的时候替换掉，不真的发邮件
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewService(addr string, auth smtp.Auth, from string, templates map[string]Template) email.Service {
+	return &Service{
+		addr:      addr,
+		auth:      auth,
+		from:      from,
+		templates: templates,
+		sendMail:  smtp.SendMail,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, to ...string) error {
+	tpl, ok := s.templates[tplId]
+	if !ok {
+		return fmt.Errorf("邮件模板 %s 不存在", tplId)
+	}
+	// net/smtp 不支持 context，只能在发送之前检查一下
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.sendMail(s.addr, s.auth, s.from, to, s.message(tpl, args, to))
+}
+
+func (s *Service) message(tpl Template, args []string, to []string) []byte {
+	pairs := make([]string, 0, len(args)*2)
+	for i, arg := range args {
+		pairs = append(pairs, "{"+strconv.Itoa(i)+"}", arg)
+	}
+	r := strings.NewReplacer(pairs...)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	// 标题里面有中文，需要编码
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", r.Replace(tpl.Subject)))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(r.Replace(tpl.Body))
+	return buf.Bytes()
+}
@@ -0,0 +1,33 @@
+package smtpemail
+
+import (
+	"context"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Send(t *testing.T) {
+	var gotTo []string
+	var gotMsg string
+	svc := NewService("smtp.example.com:587", nil, "noreply@example.com", map[string]Template{
+		"code": {Subject: "验证码 {0}", Body: "你的验证码是 {0}，{1} 分钟内有效"},
+	}).(*Service)
+	svc.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotTo = to
+		gotMsg = string(msg)
+		return nil
+	}
+
+	err := svc.Send(context.Background(), "code", []string{"123456", "10"}, "a@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a@example.com"}, gotTo)
+	assert.Contains(t, gotMsg, "To: a@example.com\r\n")
+	assert.Contains(t, gotMsg, "Subject: =?UTF-8?b?6aqM6K+B56CBIDEyMzQ1Ng==?=\r\n")
+	assert.Contains(t, gotMsg, "\r\n\r\n你的验证码是 123456，10 分钟内有效")
+
+	err = svc.Send(context.Background(), "unknown", []string{"123456"}, "a@example.com")
+	assert.EqualError(t, err, "邮件模板 unknown 不存在")
+}
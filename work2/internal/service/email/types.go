@@ -0,0 +1,8 @@
+package email
+
+import "context"
+
+// Service 和 sms.Service 的签名保持一致，tplId 是配置好的邮件模板，args 按照顺序填到模板里面
+type Service interface {
+	Send(ctx context.Context, tplId string, args []string, to ...string) error
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	"example/wb/pkg/limiter"
+	"fmt"
+	"time"
+)
+
+// LoginGuardService 登录防爆破
+// 按账号统计连续失败次数：超过 captchaAfter 次要求人机验证，
+// 超过 delayAfter 次之后每次失败都要等待 1s, 2s, 4s ... ，
+// 超过 lockAfter 次直接锁定 lockDuration。
+// 按 IP 统计失败次数：使用 limiter.Limiter 的滑动窗口，触发了就锁定这个 IP。
+type LoginGuardService interface {
+	Check(ctx context.Context, biz, account, ip string) (domain.LoginGuard, error)
+	Fail(ctx context.Context, biz, account, ip string) (domain.LoginGuard, error)
+	Reset(ctx context.Context, biz, account string) error
+}
+
+type loginGuardService struct {
+	repo      repository.LoginGuardRepository
+	ipLimiter limiter.Limiter
+
+	window       time.Duration
+	captchaAfter int
+	delayAfter   int
+	lockAfter    int
+	maxDelay     time.Duration
+	lockDuration time.Duration
+	ipLock       time.Duration
+}
+
+func NewLoginGuardService(repo repository.LoginGuardRepository, ipLimiter limiter.Limiter) LoginGuardService {
+	return &loginGuardService{
+		repo:         repo,
+		ipLimiter:    ipLimiter,
+		window:       time.Minute * 15,
+		captchaAfter: 3,
+		delayAfter:   5,
+		lockAfter:    10,
+		maxDelay:     time.Minute,
+		lockDuration: time.Minute * 30,
+		ipLock:       time.Minute * 15,
+	}
+}
+
+func (svc *loginGuardService) Check(ctx context.Context, biz, account, ip string) (domain.LoginGuard, error) {
+	accountKey := svc.accountKey(biz, account)
+	cnt, err := svc.repo.Failures(ctx, accountKey)
+	if err != nil {
+		return domain.LoginGuard{}, err
+	}
+	accountTTL, err := svc.repo.LockTTL(ctx, accountKey)
+	if err != nil {
+		return domain.LoginGuard{}, err
+	}
+	ipTTL, err := svc.repo.LockTTL(ctx, svc.ipKey(ip))
+	if err != nil {
+		return domain.LoginGuard{}, err
+	}
+	return domain.LoginGuard{
+		Failures:    cnt,
+		RetryAfter:  max(accountTTL, ipTTL),
+		NeedCaptcha: cnt >= svc.captchaAfter,
+	}, nil
+}
+
+func (svc *loginGuardService) Fail(ctx context.Context, biz, account, ip string) (domain.LoginGuard, error) {
+	accountKey := svc.accountKey(biz, account)
+	cnt, err := svc.repo.IncrFailures(ctx, accountKey, svc.window)
+	if err != nil {
+		return domain.LoginGuard{}, err
+	}
+	res := domain.LoginGuard{
+		Failures:    cnt,
+		RetryAfter:  svc.delay(cnt),
+		NeedCaptcha: cnt >= svc.captchaAfter,
+	}
+	if res.RetryAfter > 0 {
+		err = svc.repo.Lock(ctx, accountKey, res.RetryAfter)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	// 同一个 IP 换着账号试，也要限制
+	limited, err := svc.ipLimiter.Limit(ctx, svc.ipKey(ip))
+	if err != nil {
+		return res, err
+	}
+	if limited {
+		err = svc.repo.Lock(ctx, svc.ipKey(ip), svc.ipLock)
+		if err != nil {
+			return res, err
+		}
+		res.RetryAfter = max(res.RetryAfter, svc.ipLock)
+	}
+	return res, nil
+}
+
+func (svc *loginGuardService) Reset(ctx context.Context, biz, account string) error {
+	return svc.repo.Reset(ctx, svc.accountKey(biz, account))
+}
+
+// delay 第 cnt 次失败之后需要等待的时间
+func (svc *loginGuardService) delay(cnt int) time.Duration {
+	if cnt >= svc.lockAfter {
+		return svc.lockDuration
+	}
+	if cnt < svc.delayAfter {
+		return 0
+	}
+	d := time.Second << (cnt - svc.delayAfter)
+	if d > svc.maxDelay {
+		return svc.maxDelay
+	}
+	return d
+}
+
+func (svc *loginGuardService) accountKey(biz, account string) string {
+	return fmt.Sprintf("%s:%s", biz, account)
+}
+
+func (svc *loginGuardService) ipKey(ip string) string {
+	return fmt.Sprintf("ip:%s", ip)
+}
@@ -0,0 +1,154 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	"example/wb/pkg/limiter"
+	limitmock "example/wb/pkg/limiter/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLoginGuardService_Check(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) repository.LoginGuardRepository
+
+		want    domain.LoginGuard
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) repository.LoginGuardRepository {
+				repo := repomock.NewMockLoginGuardRepository(ctrl)
+				repo.EXPECT().Failures(gomock.Any(), "login_password:a@qq.com").Return(1, nil)
+				repo.EXPECT().LockTTL(gomock.Any(), "login_password:a@qq.com").Return(time.Duration(0), nil)
+				repo.EXPECT().LockTTL(gomock.Any(), "ip:127.0.0.1").Return(time.Duration(0), nil)
+				return repo
+			},
+			want: domain.LoginGuard{Failures: 1},
+		},
+		{
+			name: "需要人机验证",
+			mock: func(ctrl *gomock.Controller) repository.LoginGuardRepository {
+				repo := repomock.NewMockLoginGuardRepository(ctrl)
+				repo.EXPECT().Failures(gomock.Any(), "login_password:a@qq.com").Return(3, nil)
+				repo.EXPECT().LockTTL(gomock.Any(), "login_password:a@qq.com").Return(time.Duration(0), nil)
+				repo.EXPECT().LockTTL(gomock.Any(), "ip:127.0.0.1").Return(time.Duration(0), nil)
+				return repo
+			},
+			want: domain.LoginGuard{Failures: 3, NeedCaptcha: true},
+		},
+		{
+			name: "IP 被锁定",
+			mock: func(ctrl *gomock.Controller) repository.LoginGuardRepository {
+				repo := repomock.NewMockLoginGuardRepository(ctrl)
+				repo.EXPECT().Failures(gomock.Any(), "login_password:a@qq.com").Return(0, nil)
+				repo.EXPECT().LockTTL(gomock.Any(), "login_password:a@qq.com").Return(time.Second, nil)
+				repo.EXPECT().LockTTL(gomock.Any(), "ip:127.0.0.1").Return(time.Minute, nil)
+				return repo
+			},
+			want: domain.LoginGuard{RetryAfter: time.Minute},
+		},
+		{
+			name: "redis错误",
+			mock: func(ctrl *gomock.Controller) repository.LoginGuardRepository {
+				repo := repomock.NewMockLoginGuardRepository(ctrl)
+				repo.EXPECT().Failures(gomock.Any(), "login_password:a@qq.com").Return(0, errors.New("redis错误"))
+				return repo
+			},
+			wantErr: errors.New("redis错误"),
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewLoginGuardService(tt.mock(ctrl), limitmock.NewMockLimiter(ctrl))
+			res, err := svc.Check(context.Background(), "login_password", "a@qq.com", "127.0.0.1")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, res)
+		})
+	}
+}
+
+func TestLoginGuardService_Fail(t *testing.T) {
+	const key = "login_password:a@qq.com"
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (repository.LoginGuardRepository, limiter.Limiter)
+
+		want    domain.LoginGuard
+		wantErr error
+	}{
+		{
+			name: "第一次失败",
+			mock: func(ctrl *gomock.Controller) (repository.LoginGuardRepository, limiter.Limiter) {
+				repo := repomock.NewMockLoginGuardRepository(ctrl)
+				repo.EXPECT().IncrFailures(gomock.Any(), key, time.Minute*15).Return(1, nil)
+				l := limitmock.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "ip:127.0.0.1").Return(false, nil)
+				return repo, l
+			},
+			want: domain.LoginGuard{Failures: 1},
+		},
+		{
+			name: "开始递增等待",
+			mock: func(ctrl *gomock.Controller) (repository.LoginGuardRepository, limiter.Limiter) {
+				repo := repomock.NewMockLoginGuardRepository(ctrl)
+				repo.EXPECT().IncrFailures(gomock.Any(), key, time.Minute*15).Return(7, nil)
+				repo.EXPECT().Lock(gomock.Any(), key, time.Second*4).Return(nil)
+				l := limitmock.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "ip:127.0.0.1").Return(false, nil)
+				return repo, l
+			},
+			want: domain.LoginGuard{Failures: 7, RetryAfter: time.Second * 4, NeedCaptcha: true},
+		},
+		{
+			name: "锁定账号",
+			mock: func(ctrl *gomock.Controller) (repository.LoginGuardRepository, limiter.Limiter) {
+				repo := repomock.NewMockLoginGuardRepository(ctrl)
+				repo.EXPECT().IncrFailures(gomock.Any(), key, time.Minute*15).Return(10, nil)
+				repo.EXPECT().Lock(gomock.Any(), key, time.Minute*30).Return(nil)
+				l := limitmock.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "ip:127.0.0.1").Return(false, nil)
+				return repo, l
+			},
+			want: domain.LoginGuard{Failures: 10, RetryAfter: time.Minute * 30, NeedCaptcha: true},
+		},
+		{
+			name: "锁定IP",
+			mock: func(ctrl *gomock.Controller) (repository.LoginGuardRepository, limiter.Limiter) {
+				repo := repomock.NewMockLoginGuardRepository(ctrl)
+				repo.EXPECT().IncrFailures(gomock.Any(), key, time.Minute*15).Return(1, nil)
+				repo.EXPECT().Lock(gomock.Any(), "ip:127.0.0.1", time.Minute*15).Return(nil)
+				l := limitmock.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "ip:127.0.0.1").Return(true, nil)
+				return repo, l
+			},
+			want: domain.LoginGuard{Failures: 1, RetryAfter: time.Minute * 15},
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, l := tt.mock(ctrl)
+			svc := service.NewLoginGuardService(repo, l)
+			res, err := svc.Fail(context.Background(), "login_password", "a@qq.com", "127.0.0.1")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, res)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/access_token.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/access_token.go -package=svcmock -destination=internal/service/mocks/access_token_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccessTokenService is a mock of AccessTokenService interface.
+type MockAccessTokenService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessTokenServiceMockRecorder
+}
+
+// MockAccessTokenServiceMockRecorder is the mock recorder for MockAccessTokenService.
+type MockAccessTokenServiceMockRecorder struct {
+	mock *MockAccessTokenService
+}
+
+// NewMockAccessTokenService creates a new mock instance.
+func NewMockAccessTokenService(ctrl *gomock.Controller) *MockAccessTokenService {
+	mock := &MockAccessTokenService{ctrl: ctrl}
+	mock.recorder = &MockAccessTokenServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessTokenService) EXPECT() *MockAccessTokenServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAccessTokenService) Create(ctx context.Context, t domain.AccessToken) (domain.AccessToken, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, t)
+	ret0, _ := ret[0].(domain.AccessToken)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAccessTokenServiceMockRecorder) Create(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAccessTokenService)(nil).Create), ctx, t)
+}
+
+// List mocks base method.
+func (m *MockAccessTokenService) List(ctx context.Context, uid int64) ([]domain.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, uid)
+	ret0, _ := ret[0].([]domain.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAccessTokenServiceMockRecorder) List(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAccessTokenService)(nil).List), ctx, uid)
+}
+
+// Revoke mocks base method.
+func (m *MockAccessTokenService) Revoke(ctx context.Context, uid, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, uid, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockAccessTokenServiceMockRecorder) Revoke(ctx, uid, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAccessTokenService)(nil).Revoke), ctx, uid, id)
+}
+
+// Verify mocks base method.
+func (m *MockAccessTokenService) Verify(ctx context.Context, token string) (domain.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, token)
+	ret0, _ := ret[0].(domain.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockAccessTokenServiceMockRecorder) Verify(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockAccessTokenService)(nil).Verify), ctx, token)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/account.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/account.go -package=svcmock -destination=internal/service/mocks/account_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccountService is a mock of AccountService interface.
+type MockAccountService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountServiceMockRecorder
+}
+
+// MockAccountServiceMockRecorder is the mock recorder for MockAccountService.
+type MockAccountServiceMockRecorder struct {
+	mock *MockAccountService
+}
+
+// NewMockAccountService creates a new mock instance.
+func NewMockAccountService(ctrl *gomock.Controller) *MockAccountService {
+	mock := &MockAccountService{ctrl: ctrl}
+	mock.recorder = &MockAccountServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountService) EXPECT() *MockAccountServiceMockRecorder {
+	return m.recorder
+}
+
+// AnonymizeExpired mocks base method.
+func (m *MockAccountService) AnonymizeExpired(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeExpired", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymizeExpired indicates an expected call of AnonymizeExpired.
+func (mr *MockAccountServiceMockRecorder) AnonymizeExpired(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeExpired", reflect.TypeOf((*MockAccountService)(nil).AnonymizeExpired), ctx, limit)
+}
+
+// Export mocks base method.
+func (m *MockAccountService) Export(ctx context.Context, uid int64) (domain.AccountExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, uid)
+	ret0, _ := ret[0].(domain.AccountExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockAccountServiceMockRecorder) Export(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockAccountService)(nil).Export), ctx, uid)
+}
+
+// RequestDeletion mocks base method.
+func (m *MockAccountService) RequestDeletion(ctx context.Context, uid int64, v domain.Reverification) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestDeletion", ctx, uid, v)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestDeletion indicates an expected call of RequestDeletion.
+func (mr *MockAccountServiceMockRecorder) RequestDeletion(ctx, uid, v any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestDeletion", reflect.TypeOf((*MockAccountService)(nil).RequestDeletion), ctx, uid, v)
+}
+
+// SendDeletionCode mocks base method.
+func (m *MockAccountService) SendDeletionCode(ctx context.Context, uid int64, channel domain.CodeChannel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendDeletionCode", ctx, uid, channel)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendDeletionCode indicates an expected call of SendDeletionCode.
+func (mr *MockAccountServiceMockRecorder) SendDeletionCode(ctx, uid, channel any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendDeletionCode", reflect.TypeOf((*MockAccountService)(nil).SendDeletionCode), ctx, uid, channel)
+}
@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/admin.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/admin.go -package=svcmock -destination=internal/service/mocks/admin_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAdminService is a mock of AdminService interface.
+type MockAdminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminServiceMockRecorder
+}
+
+// MockAdminServiceMockRecorder is the mock recorder for MockAdminService.
+type MockAdminServiceMockRecorder struct {
+	mock *MockAdminService
+}
+
+// NewMockAdminService creates a new mock instance.
+func NewMockAdminService(ctrl *gomock.Controller) *MockAdminService {
+	mock := &MockAdminService{ctrl: ctrl}
+	mock.recorder = &MockAdminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminService) EXPECT() *MockAdminServiceMockRecorder {
+	return m.recorder
+}
+
+// BanUser mocks base method.
+func (m *MockAdminService) BanUser(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanUser", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BanUser indicates an expected call of BanUser.
+func (mr *MockAdminServiceMockRecorder) BanUser(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockAdminService)(nil).BanUser), ctx, uid)
+}
+
+// TakeDownArticle mocks base method.
+func (m *MockAdminService) TakeDownArticle(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeDownArticle", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TakeDownArticle indicates an expected call of TakeDownArticle.
+func (mr *MockAdminServiceMockRecorder) TakeDownArticle(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeDownArticle", reflect.TypeOf((*MockAdminService)(nil).TakeDownArticle), ctx, id)
+}
+
+// UnbanUser mocks base method.
+func (m *MockAdminService) UnbanUser(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanUser", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnbanUser indicates an expected call of UnbanUser.
+func (mr *MockAdminServiceMockRecorder) UnbanUser(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanUser", reflect.TypeOf((*MockAdminService)(nil).UnbanUser), ctx, uid)
+}
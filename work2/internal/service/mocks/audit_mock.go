@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/audit.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/audit.go -package=svcmock -destination=internal/service/mocks/audit_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditService is a mock of AuditService interface.
+type MockAuditService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditServiceMockRecorder
+}
+
+// MockAuditServiceMockRecorder is the mock recorder for MockAuditService.
+type MockAuditServiceMockRecorder struct {
+	mock *MockAuditService
+}
+
+// NewMockAuditService creates a new mock instance.
+func NewMockAuditService(ctrl *gomock.Controller) *MockAuditService {
+	mock := &MockAuditService{ctrl: ctrl}
+	mock.recorder = &MockAuditServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditService) EXPECT() *MockAuditServiceMockRecorder {
+	return m.recorder
+}
+
+// Query mocks base method.
+func (m *MockAuditService) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Query", ctx, q)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockAuditServiceMockRecorder) Query(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockAuditService)(nil).Query), ctx, q)
+}
+
+// Recent mocks base method.
+func (m *MockAuditService) Recent(ctx context.Context, uid int64, limit int) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recent", ctx, uid, limit)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recent indicates an expected call of Recent.
+func (mr *MockAuditServiceMockRecorder) Recent(ctx, uid, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recent", reflect.TypeOf((*MockAuditService)(nil).Recent), ctx, uid, limit)
+}
+
+// Record mocks base method.
+func (m *MockAuditService) Record(ctx context.Context, e domain.AuditEvent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", ctx, e)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditServiceMockRecorder) Record(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditService)(nil).Record), ctx, e)
+}
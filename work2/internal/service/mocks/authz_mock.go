@@ -0,0 +1,104 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/authz.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/authz.go -package=svcmock -destination=internal/service/mocks/authz_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthzService is a mock of AuthzService interface.
+type MockAuthzService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthzServiceMockRecorder
+}
+
+// MockAuthzServiceMockRecorder is the mock recorder for MockAuthzService.
+type MockAuthzServiceMockRecorder struct {
+	mock *MockAuthzService
+}
+
+// NewMockAuthzService creates a new mock instance.
+func NewMockAuthzService(ctrl *gomock.Controller) *MockAuthzService {
+	mock := &MockAuthzService{ctrl: ctrl}
+	mock.recorder = &MockAuthzServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthzService) EXPECT() *MockAuthzServiceMockRecorder {
+	return m.recorder
+}
+
+// Grant mocks base method.
+func (m *MockAuthzService) Grant(ctx context.Context, uid int64, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Grant", ctx, uid, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Grant indicates an expected call of Grant.
+func (mr *MockAuthzServiceMockRecorder) Grant(ctx, uid, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Grant", reflect.TypeOf((*MockAuthzService)(nil).Grant), ctx, uid, role)
+}
+
+// HasPermissions mocks base method.
+func (m *MockAuthzService) HasPermissions(ctx context.Context, uid int64, perms ...domain.Permission) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, uid}
+	for _, a := range perms {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "HasPermissions", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasPermissions indicates an expected call of HasPermissions.
+func (mr *MockAuthzServiceMockRecorder) HasPermissions(ctx, uid any, perms ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, uid}, perms...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPermissions", reflect.TypeOf((*MockAuthzService)(nil).HasPermissions), varargs...)
+}
+
+// Revoke mocks base method.
+func (m *MockAuthzService) Revoke(ctx context.Context, uid int64, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, uid, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockAuthzServiceMockRecorder) Revoke(ctx, uid, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAuthzService)(nil).Revoke), ctx, uid, role)
+}
+
+// Roles mocks base method.
+func (m *MockAuthzService) Roles(ctx context.Context, uid int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Roles", ctx, uid)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Roles indicates an expected call of Roles.
+func (mr *MockAuthzServiceMockRecorder) Roles(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Roles", reflect.TypeOf((*MockAuthzService)(nil).Roles), ctx, uid)
+}
@@ -11,6 +11,7 @@ package svcmock
 
 import (
 	context "context"
+	domain "example/wb/internal/domain"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,30 +41,30 @@ func (m *MockCodeService) EXPECT() *MockCodeServiceMockRecorder {
 }
 
 // Send mocks base method.
-func (m *MockCodeService) Send(ctx context.Context, biz, phone string) error {
+func (m *MockCodeService) Send(ctx context.Context, biz string, channel domain.CodeChannel, target string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Send", ctx, biz, phone)
+	ret := m.ctrl.Call(m, "Send", ctx, biz, channel, target)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Send indicates an expected call of Send.
-func (mr *MockCodeServiceMockRecorder) Send(ctx, biz, phone any) *gomock.Call {
+func (mr *MockCodeServiceMockRecorder) Send(ctx, biz, channel, target any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockCodeService)(nil).Send), ctx, biz, phone)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockCodeService)(nil).Send), ctx, biz, channel, target)
 }
 
 // Vertify mocks base method.
-func (m *MockCodeService) Vertify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
+func (m *MockCodeService) Vertify(ctx context.Context, biz string, channel domain.CodeChannel, target, inputCode string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Vertify", ctx, biz, phone, inputCode)
+	ret := m.ctrl.Call(m, "Vertify", ctx, biz, channel, target, inputCode)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Vertify indicates an expected call of Vertify.
-func (mr *MockCodeServiceMockRecorder) Vertify(ctx, biz, phone, inputCode any) *gomock.Call {
+func (mr *MockCodeServiceMockRecorder) Vertify(ctx, biz, channel, target, inputCode any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vertify", reflect.TypeOf((*MockCodeService)(nil).Vertify), ctx, biz, phone, inputCode)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vertify", reflect.TypeOf((*MockCodeService)(nil).Vertify), ctx, biz, channel, target, inputCode)
 }
@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/login_guard.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/login_guard.go -package=svcmock -destination=internal/service/mocks/login_guard_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginGuardService is a mock of LoginGuardService interface.
+type MockLoginGuardService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginGuardServiceMockRecorder
+}
+
+// MockLoginGuardServiceMockRecorder is the mock recorder for MockLoginGuardService.
+type MockLoginGuardServiceMockRecorder struct {
+	mock *MockLoginGuardService
+}
+
+// NewMockLoginGuardService creates a new mock instance.
+func NewMockLoginGuardService(ctrl *gomock.Controller) *MockLoginGuardService {
+	mock := &MockLoginGuardService{ctrl: ctrl}
+	mock.recorder = &MockLoginGuardServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginGuardService) EXPECT() *MockLoginGuardServiceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockLoginGuardService) Check(ctx context.Context, biz, account, ip string) (domain.LoginGuard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, biz, account, ip)
+	ret0, _ := ret[0].(domain.LoginGuard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLoginGuardServiceMockRecorder) Check(ctx, biz, account, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLoginGuardService)(nil).Check), ctx, biz, account, ip)
+}
+
+// Fail mocks base method.
+func (m *MockLoginGuardService) Fail(ctx context.Context, biz, account, ip string) (domain.LoginGuard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", ctx, biz, account, ip)
+	ret0, _ := ret[0].(domain.LoginGuard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockLoginGuardServiceMockRecorder) Fail(ctx, biz, account, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockLoginGuardService)(nil).Fail), ctx, biz, account, ip)
+}
+
+// Reset mocks base method.
+func (m *MockLoginGuardService) Reset(ctx context.Context, biz, account string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, biz, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginGuardServiceMockRecorder) Reset(ctx, biz, account any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginGuardService)(nil).Reset), ctx, biz, account)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/oauth2_state.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/oauth2_state.go -package=svcmock -destination=internal/service/mocks/oauth2_state_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOAuth2StateService is a mock of OAuth2StateService interface.
+type MockOAuth2StateService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuth2StateServiceMockRecorder
+}
+
+// MockOAuth2StateServiceMockRecorder is the mock recorder for MockOAuth2StateService.
+type MockOAuth2StateServiceMockRecorder struct {
+	mock *MockOAuth2StateService
+}
+
+// NewMockOAuth2StateService creates a new mock instance.
+func NewMockOAuth2StateService(ctrl *gomock.Controller) *MockOAuth2StateService {
+	mock := &MockOAuth2StateService{ctrl: ctrl}
+	mock.recorder = &MockOAuth2StateServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth2StateService) EXPECT() *MockOAuth2StateServiceMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockOAuth2StateService) Consume(ctx context.Context, provider, state string) (domain.OAuth2State, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, provider, state)
+	ret0, _ := ret[0].(domain.OAuth2State)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockOAuth2StateServiceMockRecorder) Consume(ctx, provider, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockOAuth2StateService)(nil).Consume), ctx, provider, state)
+}
+
+// Create mocks base method.
+func (m *MockOAuth2StateService) Create(ctx context.Context, provider, redirect string) (domain.OAuth2State, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, provider, redirect)
+	ret0, _ := ret[0].(domain.OAuth2State)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOAuth2StateServiceMockRecorder) Create(ctx, provider, redirect any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOAuth2StateService)(nil).Create), ctx, provider, redirect)
+}
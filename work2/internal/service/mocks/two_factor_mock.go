@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/two_factor.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/two_factor.go -package=svcmock -destination=internal/service/mocks/two_factor_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTwoFactorService is a mock of TwoFactorService interface.
+type MockTwoFactorService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorServiceMockRecorder
+}
+
+// MockTwoFactorServiceMockRecorder is the mock recorder for MockTwoFactorService.
+type MockTwoFactorServiceMockRecorder struct {
+	mock *MockTwoFactorService
+}
+
+// NewMockTwoFactorService creates a new mock instance.
+func NewMockTwoFactorService(ctrl *gomock.Controller) *MockTwoFactorService {
+	mock := &MockTwoFactorService{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorService) EXPECT() *MockTwoFactorServiceMockRecorder {
+	return m.recorder
+}
+
+// Enable mocks base method.
+func (m *MockTwoFactorService) Enable(ctx context.Context, uid int64, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enable", ctx, uid, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enable indicates an expected call of Enable.
+func (mr *MockTwoFactorServiceMockRecorder) Enable(ctx, uid, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enable", reflect.TypeOf((*MockTwoFactorService)(nil).Enable), ctx, uid, code)
+}
+
+// Enroll mocks base method.
+func (m *MockTwoFactorService) Enroll(ctx context.Context, uid int64) (domain.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enroll", ctx, uid)
+	ret0, _ := ret[0].(domain.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enroll indicates an expected call of Enroll.
+func (mr *MockTwoFactorServiceMockRecorder) Enroll(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enroll", reflect.TypeOf((*MockTwoFactorService)(nil).Enroll), ctx, uid)
+}
+
+// IsEnabled mocks base method.
+func (m *MockTwoFactorService) IsEnabled(ctx context.Context, uid int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEnabled", ctx, uid)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsEnabled indicates an expected call of IsEnabled.
+func (mr *MockTwoFactorServiceMockRecorder) IsEnabled(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEnabled", reflect.TypeOf((*MockTwoFactorService)(nil).IsEnabled), ctx, uid)
+}
+
+// Verify mocks base method.
+func (m *MockTwoFactorService) Verify(ctx context.Context, uid int64, code string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, uid, code)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockTwoFactorServiceMockRecorder) Verify(ctx, uid, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockTwoFactorService)(nil).Verify), ctx, uid, code)
+}
@@ -54,6 +54,21 @@ func (mr *MockUserServiceMockRecorder) Edit(ctx, u any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Edit", reflect.TypeOf((*MockUserService)(nil).Edit), ctx, u)
 }
 
+// FindByPhone mocks base method.
+func (m *MockUserService) FindByPhone(ctx context.Context, phone string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByPhone", ctx, phone)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByPhone indicates an expected call of FindByPhone.
+func (mr *MockUserServiceMockRecorder) FindByPhone(ctx, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockUserService)(nil).FindByPhone), ctx, phone)
+}
+
 // FindOrCreate mocks base method.
 func (m *MockUserService) FindOrCreate(ctx context.Context, phone string) (domain.User, error) {
 	m.ctrl.T.Helper()
@@ -69,19 +84,19 @@ func (mr *MockUserServiceMockRecorder) FindOrCreate(ctx, phone any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrCreate", reflect.TypeOf((*MockUserService)(nil).FindOrCreate), ctx, phone)
 }
 
-// FindOrCreateByWechat mocks base method.
-func (m *MockUserService) FindOrCreateByWechat(ctx context.Context, wechatInfo domain.WechatInfo) (domain.User, error) {
+// FindOrCreateByIdentity mocks base method.
+func (m *MockUserService) FindOrCreateByIdentity(ctx context.Context, identity domain.Identity) (domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindOrCreateByWechat", ctx, wechatInfo)
+	ret := m.ctrl.Call(m, "FindOrCreateByIdentity", ctx, identity)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindOrCreateByWechat indicates an expected call of FindOrCreateByWechat.
-func (mr *MockUserServiceMockRecorder) FindOrCreateByWechat(ctx, wechatInfo any) *gomock.Call {
+// FindOrCreateByIdentity indicates an expected call of FindOrCreateByIdentity.
+func (mr *MockUserServiceMockRecorder) FindOrCreateByIdentity(ctx, identity any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrCreateByWechat", reflect.TypeOf((*MockUserService)(nil).FindOrCreateByWechat), ctx, wechatInfo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrCreateByIdentity", reflect.TypeOf((*MockUserService)(nil).FindOrCreateByIdentity), ctx, identity)
 }
 
 // Login mocks base method.
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/wechat.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/wechat.go -package=svcmock -destination=internal/service/mocks/wechat_mock.go
+//
+
+// Package svcmock is a generated GoMock package.
+package svcmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWechatLoginService is a mock of WechatLoginService interface.
+type MockWechatLoginService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWechatLoginServiceMockRecorder
+}
+
+// MockWechatLoginServiceMockRecorder is the mock recorder for MockWechatLoginService.
+type MockWechatLoginServiceMockRecorder struct {
+	mock *MockWechatLoginService
+}
+
+// NewMockWechatLoginService creates a new mock instance.
+func NewMockWechatLoginService(ctrl *gomock.Controller) *MockWechatLoginService {
+	mock := &MockWechatLoginService{ctrl: ctrl}
+	mock.recorder = &MockWechatLoginServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWechatLoginService) EXPECT() *MockWechatLoginServiceMockRecorder {
+	return m.recorder
+}
+
+// AccessToken mocks base method.
+func (m *MockWechatLoginService) AccessToken(ctx context.Context, uid int64) (domain.WechatToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccessToken", ctx, uid)
+	ret0, _ := ret[0].(domain.WechatToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccessToken indicates an expected call of AccessToken.
+func (mr *MockWechatLoginServiceMockRecorder) AccessToken(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccessToken", reflect.TypeOf((*MockWechatLoginService)(nil).AccessToken), ctx, uid)
+}
+
+// Login mocks base method.
+func (m *MockWechatLoginService) Login(ctx context.Context, code string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, code)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockWechatLoginServiceMockRecorder) Login(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockWechatLoginService)(nil).Login), ctx, code)
+}
+
+// SyncProfile mocks base method.
+func (m *MockWechatLoginService) SyncProfile(ctx context.Context, uid int64) (domain.WechatProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncProfile", ctx, uid)
+	ret0, _ := ret[0].(domain.WechatProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncProfile indicates an expected call of SyncProfile.
+func (mr *MockWechatLoginServiceMockRecorder) SyncProfile(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncProfile", reflect.TypeOf((*MockWechatLoginService)(nil).SyncProfile), ctx, uid)
+}
@@ -0,0 +1,166 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"example/wb/internal/domain"
+	"example/wb/internal/service/oauth2"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type Provider struct {
+	clientId     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+
+	authURL  string
+	tokenURL string
+	apiURL   string
+}
+
+func NewProvider(clientId string, clientSecret string, redirectURL string) oauth2.Provider {
+	return &Provider{
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       http.DefaultClient,
+		authURL:      "https://github.com/login/oauth/authorize",
+		tokenURL:     "https://github.com/login/oauth/access_token",
+		apiURL:       "https://api.github.com",
+	}
+}
+
+func (p *Provider) Name() string {
+	return "github"
+}
+
+func (p *Provider) AuthURL(ctx context.Context, state oauth2.AuthState) (string, error) {
+	vals := url.Values{}
+	vals.Set("client_id", p.clientId)
+	vals.Set("redirect_uri", p.redirectURL)
+	vals.Set("scope", "read:user user:email")
+	vals.Set("state", state.State)
+	return p.authURL + "?" + vals.Encode(), nil
+}
+
+func (p *Provider) VerifyCode(ctx context.Context, code string, state oauth2.AuthState) (domain.Identity, error) {
+	token, err := p.exchange(ctx, code)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	var u userResult
+	err = p.get(ctx, token, "/user", &u)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	if u.Email == "" {
+		// 用户没有公开邮箱，需要单独查询
+		u.Email, err = p.primaryEmail(ctx, token)
+		if err != nil {
+			return domain.Identity{}, err
+		}
+	}
+	name := u.Name
+	if name == "" {
+		name = u.Login
+	}
+	return domain.Identity{
+		Provider: p.Name(),
+		Subject:  strconv.FormatInt(u.Id, 10),
+		Email:    u.Email,
+		Name:     name,
+		Avatar:   u.AvatarURL,
+	}, nil
+}
+
+func (p *Provider) exchange(ctx context.Context, code string) (string, error) {
+	vals := url.Values{}
+	vals.Set("client_id", p.clientId)
+	vals.Set("client_secret", p.clientSecret)
+	vals.Set("code", code)
+	vals.Set("redirect_uri", p.redirectURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL,
+		strings.NewReader(vals.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	httpResp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+	var res tokenResult
+	err = json.NewDecoder(httpResp.Body).Decode(&res)
+	if err != nil {
+		return "", err
+	}
+	// GitHub 出错的时候也可能返回 200
+	if res.Error != "" {
+		return "", fmt.Errorf("调用 GitHub 接口失败 error: %s, desc: %s", res.Error, res.ErrorDescription)
+	}
+	if res.AccessToken == "" {
+		return "", fmt.Errorf("调用 GitHub 接口失败 status: %d", httpResp.StatusCode)
+	}
+	return res.AccessToken, nil
+}
+
+func (p *Provider) primaryEmail(ctx context.Context, token string) (string, error) {
+	var emails []emailResult
+	err := p.get(ctx, token, "/user/emails", &emails)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			return e.Email, nil
+		}
+	}
+	return "", nil
+}
+
+func (p *Provider) get(ctx context.Context, token string, path string, val any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	httpResp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("调用 GitHub 接口 %s 失败 status: %d", path, httpResp.StatusCode)
+	}
+	return json.NewDecoder(httpResp.Body).Decode(val)
+}
+
+type tokenResult struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type userResult struct {
+	Id        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type emailResult struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
@@ -0,0 +1,135 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"example/wb/internal/domain"
+	"example/wb/internal/service/oauth2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_VerifyCode(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		// 假的 GitHub
+		handler func(t *testing.T) http.Handler
+
+		want    domain.Identity
+		wantErr bool
+	}{
+		{
+			name: "公开了邮箱",
+			handler: func(t *testing.T) http.Handler {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+					assert.NoError(t, r.ParseForm())
+					assert.Equal(t, "client", r.PostForm.Get("client_id"))
+					assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+					assert.Equal(t, "code-1", r.PostForm.Get("code"))
+					writeJSON(w, map[string]string{"access_token": "token-1", "token_type": "bearer"})
+				})
+				mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+					writeJSON(w, map[string]any{
+						"id": 123, "login": "octocat", "name": "The Octocat",
+						"email": "octocat@github.com", "avatar_url": "https://avatars/1",
+					})
+				})
+				return mux
+			},
+			want: domain.Identity{
+				Provider: "github",
+				Subject:  "123",
+				Email:    "octocat@github.com",
+				Name:     "The Octocat",
+				Avatar:   "https://avatars/1",
+			},
+		},
+		{
+			name: "没有公开邮箱",
+			handler: func(t *testing.T) http.Handler {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+					writeJSON(w, map[string]string{"access_token": "token-1"})
+				})
+				mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+					writeJSON(w, map[string]any{"id": 123, "login": "octocat"})
+				})
+				mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+					writeJSON(w, []map[string]any{
+						{"email": "other@github.com", "primary": false, "verified": true},
+						{"email": "octocat@github.com", "primary": true, "verified": true},
+					})
+				})
+				return mux
+			},
+			want: domain.Identity{
+				Provider: "github",
+				Subject:  "123",
+				Email:    "octocat@github.com",
+				Name:     "octocat",
+			},
+		},
+		{
+			name: "授权码无效",
+			handler: func(t *testing.T) http.Handler {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+					writeJSON(w, map[string]string{
+						"error":             "bad_verification_code",
+						"error_description": "The code passed is incorrect or expired.",
+					})
+				})
+				return mux
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler(t))
+			defer server.Close()
+
+			p := newTestProvider(server)
+			identity, err := p.VerifyCode(context.Background(), "code-1", oauth2.AuthState{State: "state"})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, identity)
+		})
+	}
+}
+
+func TestProvider_AuthURL(t *testing.T) {
+	p := NewProvider("client", "secret", "https://webook.com/oauth2/github/callback")
+	val, err := p.AuthURL(context.Background(), oauth2.AuthState{State: "state-1"})
+	require.NoError(t, err)
+	u, err := url.Parse(val)
+	require.NoError(t, err)
+	assert.Equal(t, "github.com", u.Host)
+	assert.Equal(t, "client", u.Query().Get("client_id"))
+	assert.Equal(t, "state-1", u.Query().Get("state"))
+	assert.Equal(t, "https://webook.com/oauth2/github/callback", u.Query().Get("redirect_uri"))
+}
+
+func newTestProvider(server *httptest.Server) *Provider {
+	p := NewProvider("client", "secret", "https://webook.com/oauth2/github/callback").(*Provider)
+	p.client = server.Client()
+	p.tokenURL = server.URL + "/login/oauth/access_token"
+	p.apiURL = server.URL
+	return p
+}
+
+func writeJSON(w http.ResponseWriter, val any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(val)
+}
@@ -0,0 +1,255 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"example/wb/internal/domain"
+	"example/wb/internal/service/oauth2"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Provider 通用的 OpenID Connect 登录
+// 通过 issuer 的 /.well-known/openid-configuration 自动发现各个端点，
+// 用 jwks_uri 里面的公钥校验 id_token，并且总是使用 PKCE
+type Provider struct {
+	name         string
+	issuer       string
+	clientId     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	client       *http.Client
+
+	mu   sync.Mutex
+	conf *discovery
+	keys map[string]any
+	// 上一次拉取 jwks 的时间，防止遇到不认识的 kid 时频繁拉取
+	keysAt          time.Time
+	refreshInterval time.Duration
+}
+
+func NewProvider(name string, issuer string,
+	clientId string, clientSecret string,
+	redirectURL string) *Provider {
+	return &Provider{
+		name:            name,
+		issuer:          strings.TrimSuffix(issuer, "/"),
+		clientId:        clientId,
+		clientSecret:    clientSecret,
+		redirectURL:     redirectURL,
+		scopes:          []string{"openid", "email", "profile"},
+		client:          http.DefaultClient,
+		refreshInterval: time.Minute,
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.name
+}
+
+func (p *Provider) AuthURL(ctx context.Context, state oauth2.AuthState) (string, error) {
+	conf, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	vals := url.Values{}
+	vals.Set("response_type", "code")
+	vals.Set("client_id", p.clientId)
+	vals.Set("redirect_uri", p.redirectURL)
+	vals.Set("scope", strings.Join(p.scopes, " "))
+	vals.Set("state", state.State)
+	vals.Set("nonce", state.Nonce)
+	vals.Set("code_challenge", oauth2.S256Challenge(state.CodeVerifier))
+	vals.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(conf.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return conf.AuthorizationEndpoint + sep + vals.Encode(), nil
+}
+
+func (p *Provider) VerifyCode(ctx context.Context, code string, state oauth2.AuthState) (domain.Identity, error) {
+	conf, err := p.discover(ctx)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	token, err := p.exchange(ctx, conf, code, state.CodeVerifier)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	claims, err := p.verifyIDToken(ctx, token.IdToken)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	if claims.Nonce != state.Nonce {
+		return domain.Identity{}, fmt.Errorf("%w: nonce 不匹配", oauth2.ErrInvalidIDToken)
+	}
+	email := claims.Email
+	if !claims.EmailVerified {
+		// 没有验证过的邮箱不可信
+		email = ""
+	}
+	return domain.Identity{
+		Provider: p.name,
+		Subject:  claims.Subject,
+		Email:    email,
+		Name:     claims.Name,
+		Avatar:   claims.Picture,
+	}, nil
+}
+
+func (p *Provider) exchange(ctx context.Context, conf *discovery, code string, verifier string) (tokenResult, error) {
+	vals := url.Values{}
+	vals.Set("grant_type", "authorization_code")
+	vals.Set("code", code)
+	vals.Set("redirect_uri", p.redirectURL)
+	vals.Set("client_id", p.clientId)
+	vals.Set("client_secret", p.clientSecret)
+	vals.Set("code_verifier", verifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, conf.TokenEndpoint,
+		strings.NewReader(vals.Encode()))
+	if err != nil {
+		return tokenResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	httpResp, err := p.client.Do(req)
+	if err != nil {
+		return tokenResult{}, err
+	}
+	defer httpResp.Body.Close()
+	var res tokenResult
+	err = json.NewDecoder(httpResp.Body).Decode(&res)
+	if err != nil {
+		return tokenResult{}, err
+	}
+	if res.Error != "" {
+		return tokenResult{}, fmt.Errorf("调用 %s 接口失败 error: %s, desc: %s", p.name, res.Error, res.ErrorDescription)
+	}
+	if httpResp.StatusCode != http.StatusOK || res.IdToken == "" {
+		return tokenResult{}, fmt.Errorf("调用 %s 接口失败 status: %d", p.name, httpResp.StatusCode)
+	}
+	return res, nil
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, tokenStr string) (idTokenClaims, error) {
+	var claims idTokenClaims
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.clientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	_, err := parser.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return idTokenClaims{}, fmt.Errorf("%w: %w", oauth2.ErrInvalidIDToken, err)
+	}
+	return claims, nil
+}
+
+// key 找到 kid 对应的公钥，找不到的时候重新拉取一次 jwks，以支持平台轮换密钥
+func (p *Provider) key(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysAt) < p.refreshInterval {
+		return nil, fmt.Errorf("找不到 kid %s 对应的公钥", kid)
+	}
+	conf, err := p.discoverLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var set jwks
+	err = p.getJSON(ctx, conf.JwksURI, &set)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = parseKeys(set)
+	p.keysAt = time.Now()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("找不到 kid %s 对应的公钥", kid)
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.discoverLocked(ctx)
+}
+
+// discoverLocked 第一次用到的时候才去拉配置，拉取成功之后缓存起来
+func (p *Provider) discoverLocked(ctx context.Context) (*discovery, error) {
+	if p.conf != nil {
+		return p.conf, nil
+	}
+	var conf discovery
+	err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &conf)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(conf.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("issuer 不匹配 want: %s, got: %s", p.issuer, conf.Issuer)
+	}
+	if conf.AuthorizationEndpoint == "" || conf.TokenEndpoint == "" || conf.JwksURI == "" {
+		return nil, fmt.Errorf("%s 的 openid-configuration 不完整", p.name)
+	}
+	p.conf = &conf
+	return p.conf, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, val any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	httpResp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 %s 失败 status: %d", u, httpResp.StatusCode)
+	}
+	return json.NewDecoder(httpResp.Body).Decode(val)
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type tokenResult struct {
+	AccessToken      string `json:"access_token"`
+	IdToken          string `json:"id_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type idTokenClaims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+	jwt.RegisteredClaims
+}
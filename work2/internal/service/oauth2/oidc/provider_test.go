@@ -0,0 +1,290 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/service/oauth2"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_VerifyCode(t *testing.T) {
+	signKey := newKey(t)
+	otherKey := newKey(t)
+	state := oauth2.AuthState{State: "state-1", Nonce: "nonce-1", CodeVerifier: "verifier-1"}
+
+	testCase := []struct {
+		name string
+
+		// 签 id_token 用的 key，以及 jwks 里面公布的 key
+		signWith  *rsa.PrivateKey
+		publish   *rsa.PrivateKey
+		claims    func(issuer string) jwt.MapClaims
+		wantErr   error
+		wantIdent domain.Identity
+	}{
+		{
+			name:     "登录成功",
+			signWith: signKey,
+			publish:  signKey,
+			claims: func(issuer string) jwt.MapClaims {
+				return validClaims(issuer)
+			},
+			wantIdent: domain.Identity{
+				Provider: "idp",
+				Subject:  "sub-1",
+				Email:    "a@b.com",
+				Name:     "Alice",
+				Avatar:   "https://pic/1",
+			},
+		},
+		{
+			name:     "邮箱没有验证",
+			signWith: signKey,
+			publish:  signKey,
+			claims: func(issuer string) jwt.MapClaims {
+				c := validClaims(issuer)
+				c["email_verified"] = false
+				return c
+			},
+			wantIdent: domain.Identity{
+				Provider: "idp",
+				Subject:  "sub-1",
+				Name:     "Alice",
+				Avatar:   "https://pic/1",
+			},
+		},
+		{
+			name:     "nonce 不对",
+			signWith: signKey,
+			publish:  signKey,
+			claims: func(issuer string) jwt.MapClaims {
+				c := validClaims(issuer)
+				c["nonce"] = "nonce-2"
+				return c
+			},
+			wantErr: oauth2.ErrInvalidIDToken,
+		},
+		{
+			name:     "aud 不对",
+			signWith: signKey,
+			publish:  signKey,
+			claims: func(issuer string) jwt.MapClaims {
+				c := validClaims(issuer)
+				c["aud"] = "other-client"
+				return c
+			},
+			wantErr: oauth2.ErrInvalidIDToken,
+		},
+		{
+			name:     "已经过期",
+			signWith: signKey,
+			publish:  signKey,
+			claims: func(issuer string) jwt.MapClaims {
+				c := validClaims(issuer)
+				c["exp"] = time.Now().Add(-time.Hour).Unix()
+				return c
+			},
+			wantErr: oauth2.ErrInvalidIDToken,
+		},
+		{
+			name:     "签名的 key 不对",
+			signWith: otherKey,
+			publish:  signKey,
+			claims: func(issuer string) jwt.MapClaims {
+				return validClaims(issuer)
+			},
+			wantErr: oauth2.ErrInvalidIDToken,
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdP(t, tt.publish)
+			defer idp.Close()
+			idp.token = func(issuer string) string {
+				return sign(t, tt.signWith, tt.claims(issuer))
+			}
+
+			p := newTestProvider(idp)
+			identity, err := p.VerifyCode(context.Background(), "code-1", state)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantIdent, identity)
+		})
+	}
+}
+
+func TestProvider_PKCE(t *testing.T) {
+	key := newKey(t)
+	idp := newFakeIdP(t, key)
+	defer idp.Close()
+	idp.token = func(issuer string) string {
+		return sign(t, key, validClaims(issuer))
+	}
+	p := newTestProvider(idp)
+
+	authURL, err := p.AuthURL(context.Background(), oauth2.AuthState{
+		State: "state-1", Nonce: "nonce-1", CodeVerifier: "verifier-1",
+	})
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", u.Path)
+	assert.Equal(t, "code", u.Query().Get("response_type"))
+	assert.Equal(t, "nonce-1", u.Query().Get("nonce"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	idp.challenge = u.Query().Get("code_challenge")
+
+	// 换成别的 verifier，IdP 会拒绝
+	_, err = p.VerifyCode(context.Background(), "code-1", oauth2.AuthState{
+		State: "state-1", Nonce: "nonce-1", CodeVerifier: "verifier-2",
+	})
+	assert.Error(t, err)
+
+	_, err = p.VerifyCode(context.Background(), "code-1", oauth2.AuthState{
+		State: "state-1", Nonce: "nonce-1", CodeVerifier: "verifier-1",
+	})
+	assert.NoError(t, err)
+}
+
+func TestProvider_KeyRotation(t *testing.T) {
+	oldKey := newKey(t)
+	rotated := newKey(t)
+	idp := newFakeIdP(t, oldKey)
+	defer idp.Close()
+	state := oauth2.AuthState{State: "state-1", Nonce: "nonce-1", CodeVerifier: "verifier-1"}
+
+	signer := oldKey
+	idp.token = func(issuer string) string {
+		return sign(t, signer, validClaims(issuer))
+	}
+	p := newTestProvider(idp)
+	_, err := p.VerifyCode(context.Background(), "code-1", state)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), idp.jwksHits.Load())
+
+	// IdP 轮换了密钥，刚拉取过 jwks，不会马上再拉
+	signer = rotated
+	idp.publish = rotated
+	_, err = p.VerifyCode(context.Background(), "code-1", state)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), idp.jwksHits.Load())
+
+	// 过了刷新间隔，遇到新的 kid 会重新拉取
+	p.keysAt = time.Now().Add(-time.Hour)
+	_, err = p.VerifyCode(context.Background(), "code-1", state)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), idp.jwksHits.Load())
+}
+
+type fakeIdP struct {
+	*httptest.Server
+	publish   *rsa.PrivateKey
+	token     func(issuer string) string
+	challenge string
+	jwksHits  atomic.Int32
+}
+
+func newFakeIdP(t *testing.T, publish *rsa.PrivateKey) *fakeIdP {
+	idp := &fakeIdP{publish: publish}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksHits.Add(1)
+		pub := idp.publish.PublicKey
+		writeJSON(w, jwks{Keys: []jwk{{
+			Kty: "RSA",
+			Kid: kid(idp.publish),
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "authorization_code", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "client", r.PostForm.Get("client_id"))
+		if idp.challenge != "" &&
+			oauth2.S256Challenge(r.PostForm.Get("code_verifier")) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeJSON(w, map[string]string{
+			"access_token": "token-1",
+			"id_token":     idp.token(idp.URL),
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	return idp
+}
+
+func newTestProvider(idp *fakeIdP) *Provider {
+	p := NewProvider("idp", idp.URL, "client", "secret", "https://webook.com/oauth2/idp/callback")
+	p.client = idp.Client()
+	return p
+}
+
+func validClaims(issuer string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            issuer,
+		"sub":            "sub-1",
+		"aud":            "client",
+		"exp":            now.Add(time.Minute * 5).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          "nonce-1",
+		"email":          "a@b.com",
+		"email_verified": true,
+		"name":           "Alice",
+		"picture":        "https://pic/1",
+	}
+}
+
+func newKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func kid(key *rsa.PrivateKey) string {
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()[:8])
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid(key)
+	val, err := token.SignedString(key)
+	require.NoError(t, err)
+	return val
+}
+
+func writeJSON(w http.ResponseWriter, val any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(val)
+}
@@ -0,0 +1,49 @@
+// Package oauth2 第三方登录
+// 微信比较特殊，单独放在 wechat 子包里面；
+// 其余标准的 OAuth2 / OIDC 平台都实现 Provider 接口
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"example/wb/internal/domain"
+)
+
+var ErrInvalidIDToken = errors.New("id_token 不合法")
+
+type Provider interface {
+	// Name 平台名字，同时也是路由的一部分，例如 /oauth2/github/callback
+	Name() string
+	// AuthURL 构造跳转到第三方平台的授权地址
+	AuthURL(ctx context.Context, state AuthState) (string, error)
+	// VerifyCode 用授权码换取第三方平台上的身份
+	VerifyCode(ctx context.Context, code string, state AuthState) (domain.Identity, error)
+}
+
+// AuthState 一次授权流程中，跳转前后需要保持一致的数据
+type AuthState struct {
+	State string
+	// OIDC 用来防止 id_token 重放
+	Nonce string
+	// PKCE 的 code_verifier，不支持 PKCE 的平台会忽略
+	CodeVerifier string
+}
+
+// GenerateVerifier 生成 RFC 7636 中的 code_verifier
+func GenerateVerifier() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// S256Challenge 根据 code_verifier 计算 S256 方式的 code_challenge
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
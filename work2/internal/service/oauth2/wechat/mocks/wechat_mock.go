@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/oauth2/wechat/types.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/oauth2/wechat/types.go -package=wechatmock -destination=internal/service/oauth2/wechat/mocks/wechat_mock.go
+//
+
+// Package wechatmock is a generated GoMock package.
+package wechatmock
+
+import (
+	context "context"
+	domain "example/wb/internal/domain"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// AUthURL mocks base method.
+func (m *MockService) AUthURL(ctx context.Context, state string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AUthURL", ctx, state)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AUthURL indicates an expected call of AUthURL.
+func (mr *MockServiceMockRecorder) AUthURL(ctx, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AUthURL", reflect.TypeOf((*MockService)(nil).AUthURL), ctx, state)
+}
+
+// Refresh mocks base method.
+func (m *MockService) Refresh(ctx context.Context, token domain.WechatToken) (domain.WechatToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, token)
+	ret0, _ := ret[0].(domain.WechatToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockServiceMockRecorder) Refresh(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockService)(nil).Refresh), ctx, token)
+}
+
+// UserInfo mocks base method.
+func (m *MockService) UserInfo(ctx context.Context, token domain.WechatToken) (domain.WechatProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserInfo", ctx, token)
+	ret0, _ := ret[0].(domain.WechatProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserInfo indicates an expected call of UserInfo.
+func (mr *MockServiceMockRecorder) UserInfo(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInfo", reflect.TypeOf((*MockService)(nil).UserInfo), ctx, token)
+}
+
+// VerifyCode mocks base method.
+func (m *MockService) VerifyCode(ctx context.Context, code string) (domain.WechatInfo, domain.WechatToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyCode", ctx, code)
+	ret0, _ := ret[0].(domain.WechatInfo)
+	ret1, _ := ret[1].(domain.WechatToken)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// VerifyCode indicates an expected call of VerifyCode.
+func (mr *MockServiceMockRecorder) VerifyCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyCode", reflect.TypeOf((*MockService)(nil).VerifyCode), ctx, code)
+}
+
+// MockerrResult is a mock of errResult interface.
+type MockerrResult struct {
+	ctrl     *gomock.Controller
+	recorder *MockerrResultMockRecorder
+}
+
+// MockerrResultMockRecorder is the mock recorder for MockerrResult.
+type MockerrResultMockRecorder struct {
+	mock *MockerrResult
+}
+
+// NewMockerrResult creates a new mock instance.
+func NewMockerrResult(ctrl *gomock.Controller) *MockerrResult {
+	mock := &MockerrResult{ctrl: ctrl}
+	mock.recorder = &MockerrResultMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockerrResult) EXPECT() *MockerrResultMockRecorder {
+	return m.recorder
+}
+
+// errInfo mocks base method.
+func (m *MockerrResult) errInfo() (int, string) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "errInfo")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(string)
+	return ret0, ret1
+}
+
+// errInfo indicates an expected call of errInfo.
+func (mr *MockerrResultMockRecorder) errInfo() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "errInfo", reflect.TypeOf((*MockerrResult)(nil).errInfo))
+}
@@ -7,17 +7,26 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type Service interface {
 	AUthURL(ctx context.Context, state string) (string, error)
-	VerifyCode(ctx context.Context, code string) (domain.WechatInfo, error)
+	// VerifyCode 用授权码换取 access_token
+	VerifyCode(ctx context.Context, code string) (domain.WechatInfo, domain.WechatToken, error)
+	// Refresh 用 refresh_token 换一个新的 access_token
+	Refresh(ctx context.Context, token domain.WechatToken) (domain.WechatToken, error)
+	// UserInfo 获取昵称和头像，需要 snsapi_login 或者 snsapi_userinfo 授权
+	UserInfo(ctx context.Context, token domain.WechatToken) (domain.WechatProfile, error)
 }
 
 var redirectURL = url.PathEscape(`https://`)
 
 const authURLPattern = `https://open.weixin.qq.com/connect/qrconnect?appid=%s&redirect_uri=%s&response_type=code&scope=snsapi_login&state=%s#wechat_redirect`
 
+// refresh_token 的有效期是三十天
+const refreshTokenExpiration = time.Hour * 24 * 30
+
 type service struct {
 	appId     string
 	appSecret string
@@ -36,49 +45,118 @@ func (s *service) AUthURL(ctx context.Context, state string) (string, error) {
 	return fmt.Sprintf(authURLPattern, s.appId, redirectURL, state), nil
 
 }
-func (s *service) VerifyCode(ctx context.Context, code string) (domain.WechatInfo, error) {
+func (s *service) VerifyCode(ctx context.Context, code string) (domain.WechatInfo, domain.WechatToken, error) {
 	accessTokenUrl := fmt.Sprintf(`https://api.weixin.qq.com/sns/oauth2/access_token?appid=%s&secret=%s&code=%s&grant_type=authorization_code`,
 		s.appId, s.appSecret, code)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, accessTokenUrl, nil)
+	var res Result
+	err := s.get(ctx, accessTokenUrl, &res)
+	if err != nil {
+		return domain.WechatInfo{}, domain.WechatToken{}, err
+	}
+	return domain.WechatInfo{
+		Unionid: res.Unionid,
+		Openid:  res.Openid,
+	}, s.toToken(res, time.Now()), nil
+
+}
+
+func (s *service) Refresh(ctx context.Context, token domain.WechatToken) (domain.WechatToken, error) {
+	refreshUrl := fmt.Sprintf(`https://api.weixin.qq.com/sns/oauth2/refresh_token?appid=%s&grant_type=refresh_token&refresh_token=%s`,
+		s.appId, url.QueryEscape(token.RefreshToken))
+	var res Result
+	err := s.get(ctx, refreshUrl, &res)
+	if err != nil {
+		return domain.WechatToken{}, err
+	}
+	newToken := s.toToken(res, time.Now())
+	newToken.Uid = token.Uid
+	// 刷新不会延长 refresh_token 的有效期
+	newToken.RefreshExpiresAt = token.RefreshExpiresAt
+	return newToken, nil
+}
+
+func (s *service) UserInfo(ctx context.Context, token domain.WechatToken) (domain.WechatProfile, error) {
+	userInfoUrl := fmt.Sprintf(`https://api.weixin.qq.com/sns/userinfo?access_token=%s&openid=%s`,
+		url.QueryEscape(token.AccessToken), url.QueryEscape(token.Openid))
+	var res UserInfoResult
+	err := s.get(ctx, userInfoUrl, &res)
+	if err != nil {
+		return domain.WechatProfile{}, err
+	}
+	return domain.WechatProfile{
+		NickName: res.NickName,
+		Avatar:   res.HeadImgURL,
+	}, nil
+}
+
+// get 微信的接口出错的时候也是返回 200，需要看 errcode
+func (s *service) get(ctx context.Context, u string, val errResult) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return domain.WechatInfo{}, err
+		return err
 	}
 	httpResp, err := s.client.Do(req)
 	if err != nil {
-		return domain.WechatInfo{}, err
+		return err
 	}
-	var res Result
-	err = json.NewDecoder(httpResp.Body).Decode(&res)
+	defer httpResp.Body.Close()
+	err = json.NewDecoder(httpResp.Body).Decode(val)
 	if err != nil {
 		// 转Json出错
-		return domain.WechatInfo{}, err
+		return err
 	}
-	if res.ErrCode != 0 {
-		return domain.WechatInfo{},
-			fmt.Errorf("调用微信接口失败 errcode: %d, errmsg: %s", res.ErrCode, res.ErrMsg)
+	if code, msg := val.errInfo(); code != 0 {
+		return fmt.Errorf("调用微信接口失败 errcode: %d, errmsg: %s", code, msg)
 	}
+	return nil
+}
 
-	return domain.WechatInfo{
-		Unionid: res.Unionid,
-		Openid:  res.Openid,
-	}, nil
+func (s *service) toToken(res Result, now time.Time) domain.WechatToken {
+	return domain.WechatToken{
+		Openid:           res.Openid,
+		AccessToken:      res.AccessToken,
+		RefreshToken:     res.RefreshToken,
+		Scope:            res.Scope,
+		ExpiresAt:        now.Add(time.Duration(res.ExpiresIn) * time.Second),
+		RefreshExpiresAt: now.Add(refreshTokenExpiration),
+	}
+}
+
+type errResult interface {
+	errInfo() (int, string)
+}
 
+type ErrResult struct {
+	// 错误返回
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (r *ErrResult) errInfo() (int, string) {
+	return r.ErrCode, r.ErrMsg
 }
 
 type Result struct {
 	// 接口调用凭证
-	AccessToken string
+	AccessToken string `json:"access_token"`
 	// access_token接口调用凭证超时时间，单位（秒）
 	ExpiresIn int64 `json:"expires_in"`
 	// 用户刷新access_token
-	RefreshToken string `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
 	// 授权用户唯一标识
 	Openid string `json:"openid"`
 	// 用户授权的作用域，使用逗号（,）分隔
-	Scope string `json:""scope`
+	Scope string `json:"scope"`
 	// 当且仅当该网站应用已获得该用户的userinfo授权时，才会出现该字段。
 	Unionid string `json:"unionid"`
-	// 错误返回
-	ErrCode int    `json:"errcode"`
-	ErrMsg  string `json:"errmsg"`
+	ErrResult
+}
+
+type UserInfoResult struct {
+	Openid   string `json:"openid"`
+	NickName string `json:"nickname"`
+	// 用户头像，用户没有头像时该项为空
+	HeadImgURL string `json:"headimgurl"`
+	Unionid    string `json:"unionid"`
+	ErrResult
 }
@@ -0,0 +1,186 @@
+package wechat
+
+import (
+	"context"
+	"encoding/json"
+	"example/wb/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_VerifyCode(t *testing.T) {
+	testCase := []struct {
+		name    string
+		handler http.HandlerFunc
+
+		wantInfo  domain.WechatInfo
+		wantToken domain.WechatToken
+		wantErr   bool
+	}{
+		{
+			name: "换取成功",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/sns/oauth2/access_token", r.URL.Path)
+				assert.Equal(t, "app-id", r.URL.Query().Get("appid"))
+				assert.Equal(t, "app-secret", r.URL.Query().Get("secret"))
+				assert.Equal(t, "code-1", r.URL.Query().Get("code"))
+				writeJSON(w, map[string]any{
+					"access_token":  "access-1",
+					"expires_in":    7200,
+					"refresh_token": "refresh-1",
+					"openid":        "openid-1",
+					"scope":         "snsapi_login",
+					"unionid":       "unionid-1",
+				})
+			},
+			wantInfo: domain.WechatInfo{Openid: "openid-1", Unionid: "unionid-1"},
+			wantToken: domain.WechatToken{
+				Openid:       "openid-1",
+				AccessToken:  "access-1",
+				RefreshToken: "refresh-1",
+				Scope:        "snsapi_login",
+			},
+		},
+		{
+			name: "授权码无效",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, map[string]any{"errcode": 40029, "errmsg": "invalid code"})
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, closeFn := newTestService(tt.handler)
+			defer closeFn()
+
+			now := time.Now()
+			info, token, err := svc.VerifyCode(context.Background(), "code-1")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantInfo, info)
+			assert.WithinDuration(t, now.Add(time.Hour*2), token.ExpiresAt, time.Second)
+			assert.WithinDuration(t, now.Add(time.Hour*24*30), token.RefreshExpiresAt, time.Second)
+			token.ExpiresAt = time.Time{}
+			token.RefreshExpiresAt = time.Time{}
+			assert.Equal(t, tt.wantToken, token)
+		})
+	}
+}
+
+func TestService_Refresh(t *testing.T) {
+	svc, closeFn := newTestService(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sns/oauth2/refresh_token", r.URL.Path)
+		assert.Equal(t, "refresh_token", r.URL.Query().Get("grant_type"))
+		assert.Equal(t, "refresh-1", r.URL.Query().Get("refresh_token"))
+		writeJSON(w, map[string]any{
+			"access_token":  "access-2",
+			"expires_in":    7200,
+			"refresh_token": "refresh-1",
+			"openid":        "openid-1",
+			"scope":         "snsapi_login",
+		})
+	})
+	defer closeFn()
+
+	refreshExpiresAt := time.Now().Add(time.Hour * 24)
+	token, err := svc.Refresh(context.Background(), domain.WechatToken{
+		Uid:              1,
+		Openid:           "openid-1",
+		AccessToken:      "access-1",
+		RefreshToken:     "refresh-1",
+		RefreshExpiresAt: refreshExpiresAt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), token.Uid)
+	assert.Equal(t, "access-2", token.AccessToken)
+	// refresh_token 的有效期不会被延长
+	assert.Equal(t, refreshExpiresAt, token.RefreshExpiresAt)
+	assert.True(t, token.ExpiresAt.After(time.Now().Add(time.Hour)))
+}
+
+func TestService_UserInfo(t *testing.T) {
+	testCase := []struct {
+		name    string
+		handler http.HandlerFunc
+
+		want    domain.WechatProfile
+		wantErr bool
+	}{
+		{
+			name: "获取成功",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/sns/userinfo", r.URL.Path)
+				assert.Equal(t, "access-1", r.URL.Query().Get("access_token"))
+				assert.Equal(t, "openid-1", r.URL.Query().Get("openid"))
+				writeJSON(w, map[string]any{
+					"openid":     "openid-1",
+					"nickname":   "小明",
+					"headimgurl": "https://thirdwx.qlogo.cn/1",
+				})
+			},
+			want: domain.WechatProfile{NickName: "小明", Avatar: "https://thirdwx.qlogo.cn/1"},
+		},
+		{
+			name: "access_token 过期",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, map[string]any{"errcode": 42001, "errmsg": "access_token expired"})
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, closeFn := newTestService(tt.handler)
+			defer closeFn()
+
+			profile, err := svc.UserInfo(context.Background(), domain.WechatToken{
+				Openid:      "openid-1",
+				AccessToken: "access-1",
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, profile)
+		})
+	}
+}
+
+// newTestService 把发往微信的请求都转到本地的 httptest 上
+func newTestService(handler http.HandlerFunc) (*service, func()) {
+	server := httptest.NewServer(handler)
+	target, _ := url.Parse(server.URL)
+	client := server.Client()
+	client.Transport = rewriteTransport{target: target, next: client.Transport}
+	svc := NewService("app-id", "app-secret").(*service)
+	svc.client = client
+	return svc, server.Close
+}
+
+type rewriteTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (r rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	return r.next.RoundTrip(req)
+}
+
+func writeJSON(w http.ResponseWriter, val any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(val)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/repository"
+	"example/wb/internal/service/oauth2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	uuid "github.com/lithammer/shortuuid/v4"
+)
+
+var ErrInvalidOAuth2State = errs.New(http.StatusBadRequest, errs.OAuth2InvalidState, "非法请求")
+var ErrInvalidRedirect = errs.New(http.StatusBadRequest, errs.OAuth2InvalidRedirect, "跳转地址不合法")
+
+// OAuth2StateService 第三方登录的 state 保存在服务端，
+// 有过期时间，并且回调的时候只能使用一次
+type OAuth2StateService interface {
+	// Create 生成一个新的 state，redirect 是登录成功之后要跳转的站内地址
+	Create(ctx context.Context, provider string, redirect string) (domain.OAuth2State, error)
+	Consume(ctx context.Context, provider string, state string) (domain.OAuth2State, error)
+}
+
+type oauth2StateService struct {
+	repo       repository.OAuth2StateRepository
+	expiration time.Duration
+}
+
+func NewOAuth2StateService(repo repository.OAuth2StateRepository) OAuth2StateService {
+	return &oauth2StateService{
+		repo:       repo,
+		expiration: time.Minute * 10,
+	}
+}
+
+func (svc *oauth2StateService) Create(ctx context.Context, provider string, redirect string) (domain.OAuth2State, error) {
+	if !svc.validRedirect(redirect) {
+		return domain.OAuth2State{}, ErrInvalidRedirect
+	}
+	verifier, err := oauth2.GenerateVerifier()
+	if err != nil {
+		return domain.OAuth2State{}, err
+	}
+	s := domain.OAuth2State{
+		State:        uuid.New(),
+		Provider:     provider,
+		Nonce:        uuid.New(),
+		CodeVerifier: verifier,
+		Redirect:     redirect,
+	}
+	err = svc.repo.Save(ctx, s, svc.expiration)
+	if err != nil {
+		return domain.OAuth2State{}, err
+	}
+	return s, nil
+}
+
+func (svc *oauth2StateService) Consume(ctx context.Context, provider string, state string) (domain.OAuth2State, error) {
+	if state == "" {
+		return domain.OAuth2State{}, ErrInvalidOAuth2State
+	}
+	s, err := svc.repo.Consume(ctx, state)
+	if err == repository.ErrOAuth2StateNotFound {
+		return domain.OAuth2State{}, ErrInvalidOAuth2State
+	}
+	if err != nil {
+		return domain.OAuth2State{}, err
+	}
+	// 在 A 平台申请的 state 不能拿到 B 平台的回调里面用
+	if s.Provider != provider {
+		return domain.OAuth2State{}, ErrInvalidOAuth2State
+	}
+	return s, nil
+}
+
+// validRedirect 只允许站内的相对路径，防止被用来做开放重定向。
+// 浏览器会去掉 tab、换行这些控制字符，还会把 \ 当成 /，
+// /\t/evil.com 和 /\evil.com 最后都会变成 //evil.com，所以一律拒绝
+func (svc *oauth2StateService) validRedirect(redirect string) bool {
+	if redirect == "" {
+		return true
+	}
+	if strings.ContainsFunc(redirect, func(r rune) bool {
+		return r == '\\' || unicode.IsControl(r)
+	}) {
+		return false
+	}
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") {
+		return false
+	}
+	u, err := url.Parse(redirect)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
@@ -0,0 +1,218 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOAuth2StateService_Create(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) repository.OAuth2StateRepository
+
+		redirect string
+		wantErr  error
+	}{
+		{
+			name: "保存成功",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomock.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Save(gomock.Any(), gomock.Any(), time.Minute*10).
+					DoAndReturn(func(ctx context.Context, s domain.OAuth2State, exp time.Duration) error {
+						assert.NotEmpty(t, s.State)
+						assert.NotEmpty(t, s.Nonce)
+						assert.NotEmpty(t, s.CodeVerifier)
+						assert.Equal(t, "github", s.Provider)
+						assert.Equal(t, "/profile", s.Redirect)
+						return nil
+					})
+				return repo
+			},
+			redirect: "/profile",
+		},
+		{
+			name: "站外地址",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "https://evil.com/profile",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "协议相对地址",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "//evil.com/profile",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "反斜杠",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "/\\evil.com",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "中间有反斜杠",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "/a\\..\\\\evil.com",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "tab",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "/\t/evil.com",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "换行",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "/\n/evil.com",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "回车",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "/\r/evil.com",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "javascript",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "javascript:alert(1)",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "没有斜杠开头",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			redirect: "evil.com/profile",
+			wantErr:  service.ErrInvalidRedirect,
+		},
+		{
+			name: "带query的站内地址",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomock.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Save(gomock.Any(), gomock.Any(), time.Minute*10).Return(nil)
+				return repo
+			},
+			redirect: "/article/1?from=login#top",
+		},
+		{
+			name: "redis错误",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomock.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Save(gomock.Any(), gomock.Any(), time.Minute*10).
+					Return(errors.New("redis错误"))
+				return repo
+			},
+			wantErr: errors.New("redis错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewOAuth2StateService(tc.mock(ctrl))
+			_, err := svc.Create(context.Background(), "github", tc.redirect)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestOAuth2StateService_Consume(t *testing.T) {
+	stored := domain.OAuth2State{
+		State:    "state-1",
+		Provider: "github",
+		Redirect: "/profile",
+	}
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) repository.OAuth2StateRepository
+
+		provider string
+		state    string
+
+		want    domain.OAuth2State
+		wantErr error
+	}{
+		{
+			name: "校验成功",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomock.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Consume(gomock.Any(), "state-1").Return(stored, nil)
+				return repo
+			},
+			provider: "github",
+			state:    "state-1",
+			want:     stored,
+		},
+		{
+			name: "不存在或者已经用过",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomock.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Consume(gomock.Any(), "state-1").
+					Return(domain.OAuth2State{}, repository.ErrOAuth2StateNotFound)
+				return repo
+			},
+			provider: "github",
+			state:    "state-1",
+			wantErr:  service.ErrInvalidOAuth2State,
+		},
+		{
+			name: "其他平台的 state",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomock.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Consume(gomock.Any(), "state-1").Return(stored, nil)
+				return repo
+			},
+			provider: "wechat",
+			state:    "state-1",
+			wantErr:  service.ErrInvalidOAuth2State,
+		},
+		{
+			name: "没有 state",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomock.NewMockOAuth2StateRepository(ctrl)
+			},
+			provider: "github",
+			wantErr:  service.ErrInvalidOAuth2State,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewOAuth2StateService(tc.mock(ctrl))
+			s, err := svc.Consume(context.Background(), tc.provider, tc.state)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, s)
+		})
+	}
+}
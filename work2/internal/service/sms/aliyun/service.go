@@ -0,0 +1,201 @@
+package aliyun
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example/wb/internal/service/sms"
+)
+
+const DefaultEndpoint = "https://dysmsapi.aliyuncs.com"
+
+// SMSService 直接调用阿里云短信的 RPC 接口，没有引入阿里云的 SDK。
+// 签名算法参考 https://help.aliyun.com/document_detail/101343.html
+type SMSService struct {
+	client          *http.Client
+	endpoint        string
+	accessKeyId     string
+	accessKeySecret string
+	signName        string
+	// templates 逻辑上的模板名对应的阿里云模板 code，例如 code 对应 SMS_1263395
+	templates sms.Templates
+	// 阿里云的模板参数是具名的，key 是模板 code，value 按照顺序对应 Send 的 args。
+	// 没有配置的模板只能有一个参数，名字是 code
+	paramNames map[string][]string
+	now        func() time.Time
+}
+
+func NewService(client *http.Client, endpoint string,
+	accessKeyId, accessKeySecret, signName string,
+	templates sms.Templates, paramNames map[string][]string) sms.Service {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	return &SMSService{
+		client:          client,
+		endpoint:        strings.TrimSuffix(endpoint, "/"),
+		accessKeyId:     accessKeyId,
+		accessKeySecret: accessKeySecret,
+		signName:        signName,
+		templates:       templates,
+		paramNames:      paramNames,
+		now:             time.Now,
+	}
+}
+
+type response struct {
+	Code      string `json:"Code"`
+	Message   string `json:"Message"`
+	RequestId string `json:"RequestId"`
+	BizId     string `json:"BizId"`
+}
+
+func (svc *SMSService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	tplId, err := svc.templates.Resolve(provider, tplId)
+	if err != nil {
+		return err
+	}
+	tplParam, err := svc.templateParam(tplId, args)
+	if err != nil {
+		return err
+	}
+	nonce, err := svc.nonce()
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("AccessKeyId", svc.accessKeyId)
+	params.Set("Action", "SendSms")
+	params.Set("Format", "JSON")
+	params.Set("PhoneNumbers", strings.Join(numbers, ","))
+	params.Set("SignName", svc.signName)
+	params.Set("SignatureMethod", "HMAC-SHA1")
+	params.Set("SignatureNonce", nonce)
+	params.Set("SignatureVersion", "1.0")
+	params.Set("TemplateCode", tplId)
+	params.Set("TemplateParam", tplParam)
+	params.Set("Timestamp", svc.now().UTC().Format("2006-01-02T15:04:05Z"))
+	params.Set("Version", "2017-05-25")
+	params.Set("Signature", sign(http.MethodPost, params, svc.accessKeySecret))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, svc.endpoint+"/",
+		strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return sms.WrapError(provider, err)
+	}
+	defer resp.Body.Close()
+	var res response
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		// 网关出问题的时候返回的可能不是 JSON
+		kind := sms.KindUnknown
+		if resp.StatusCode >= http.StatusInternalServerError {
+			kind = sms.KindProviderDown
+		}
+		return &sms.Error{
+			Kind:     kind,
+			Provider: provider,
+			Code:     strconv.Itoa(resp.StatusCode),
+			Msg:      "解析阿里云响应失败",
+			Err:      err,
+		}
+	}
+	if res.Code != "OK" {
+		return sms.NewError(kindOf(res.Code), provider, res.Code,
+			fmt.Sprintf("%s，requestId：%s", res.Message, res.RequestId))
+	}
+	return nil
+}
+
+const provider = "aliyun"
+
+// kindOf 参考 https://help.aliyun.com/document_detail/101346.html
+func kindOf(code string) sms.ErrorKind {
+	switch code {
+	case "isv.BUSINESS_LIMIT_CONTROL", "isv.DAY_LIMIT_CONTROL", "isv.MOBILE_COUNT_OVER_LIMIT",
+		"Throttling.User", "Throttling.Api":
+		return sms.KindThrottled
+	case "isv.MOBILE_NUMBER_ILLEGAL", "isv.BLACK_KEY_CONTROL_LIMIT", "isv.DENY_IP_RANGE":
+		return sms.KindInvalidNumber
+	case "isv.SMS_TEMPLATE_ILLEGAL", "isv.TEMPLATE_MISSING_PARAMETERS", "isv.TEMPLATE_PARAMS_ILLEGAL",
+		"isv.PARAM_LENGTH_LIMIT", "isv.SMS_SIGNATURE_ILLEGAL", "isv.SMS_SIGN_ILLEGAL",
+		"isv.INVALID_PARAMETERS", "isv.INVALID_JSON_PARAM":
+		return sms.KindTemplateRejected
+	case "InvalidAccessKeyId.NotFound", "InvalidAccessKeyId.Inactive", "SignatureDoesNotMatch",
+		"isp.RAM_PERMISSION_DENY", "isv.ACCOUNT_NOT_EXISTS", "isv.ACCOUNT_ABNORMAL":
+		return sms.KindAuthFailure
+	case "isp.SYSTEM_ERROR", "isv.OUT_OF_SERVICE", "isv.AMOUNT_NOT_ENOUGH", "ServiceUnavailable":
+		return sms.KindProviderDown
+	default:
+		return sms.KindUnknown
+	}
+}
+
+func (svc *SMSService) templateParam(tplId string, args []string) (string, error) {
+	names, ok := svc.paramNames[tplId]
+	if !ok {
+		names = []string{"code"}
+	}
+	if len(names) != len(args) {
+		return "", sms.NewError(sms.KindTemplateRejected, provider, "",
+			fmt.Sprintf("阿里云短信模板 %s 需要 %d 个参数，实际传了 %d 个", tplId, len(names), len(args)))
+	}
+	param := make(map[string]string, len(args))
+	for i, name := range names {
+		param[name] = args[i]
+	}
+	val, err := json.Marshal(param)
+	return string(val), err
+}
+
+func (svc *SMSService) nonce() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	return hex.EncodeToString(buf), err
+}
+
+// sign 参数按照 key 排序之后拼起来，用 AccessKeySecret& 做 HMAC-SHA1
+func sign(method string, params url.Values, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k == "Signature" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, percentEncode(k)+"="+percentEncode(params.Get(k)))
+	}
+	stringToSign := method + "&" + percentEncode("/") + "&" + percentEncode(strings.Join(pairs, "&"))
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// percentEncode 阿里云要求的是 RFC 3986 的编码，和 url.QueryEscape 有几个字符不一样
+func percentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	s = strings.ReplaceAll(s, "%7E", "~")
+	return s
+}
@@ -0,0 +1,122 @@
+package aliyun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example/wb/internal/service/sms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMSService_Send(t *testing.T) {
+	templates := sms.Templates{"code": "SMS_1", "greet": "SMS_2"}
+	testCase := []struct {
+		name string
+
+		paramNames map[string][]string
+		tplId      string
+		args       []string
+		// 服务端返回的 Code
+		code string
+
+		wantParam string
+		wantErr   bool
+		wantKind  sms.ErrorKind
+	}{
+		{
+			name:      "发送成功",
+			tplId:     "code",
+			args:      []string{"123456"},
+			code:      "OK",
+			wantParam: `{"code":"123456"}`,
+		},
+		{
+			name:       "配置了参数名",
+			paramNames: map[string][]string{"SMS_2": {"name", "code"}},
+			tplId:      "greet",
+			args:       []string{"小明", "123456"},
+			code:       "OK",
+			wantParam:  `{"code":"123456","name":"小明"}`,
+		},
+		{
+			name:     "参数个数不对",
+			tplId:    "code",
+			args:     []string{"123456", "5"},
+			wantErr:  true,
+			wantKind: sms.KindTemplateRejected,
+		},
+		{
+			name:     "没有配置模板",
+			tplId:    "SMS_1263395",
+			args:     []string{"123456"},
+			wantErr:  true,
+			wantKind: sms.KindTemplateRejected,
+		},
+		{
+			name:      "阿里云返回失败",
+			tplId:     "code",
+			args:      []string{"123456"},
+			code:      "isv.BUSINESS_LIMIT_CONTROL",
+			wantParam: `{"code":"123456"}`,
+			wantErr:   true,
+			wantKind:  sms.KindThrottled,
+		},
+		{
+			name:      "号码不对",
+			tplId:     "code",
+			args:      []string{"123456"},
+			code:      "isv.MOBILE_NUMBER_ILLEGAL",
+			wantParam: `{"code":"123456"}`,
+			wantErr:   true,
+			wantKind:  sms.KindInvalidNumber,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, "SendSms", r.PostForm.Get("Action"))
+				assert.Equal(t, "key-id", r.PostForm.Get("AccessKeyId"))
+				assert.Equal(t, "+8613800138000,+8613800138001", r.PostForm.Get("PhoneNumbers"))
+				assert.Equal(t, "小微书", r.PostForm.Get("SignName"))
+				// 逻辑上的模板名要换成阿里云的模板 code
+				assert.Equal(t, templates[tc.tplId], r.PostForm.Get("TemplateCode"))
+				assert.Equal(t, tc.wantParam, r.PostForm.Get("TemplateParam"))
+				// 服务端用同样的算法重新算一遍签名
+				assert.Equal(t, sign(http.MethodPost, r.PostForm, "key-secret"), r.PostForm.Get("Signature"))
+				_ = json.NewEncoder(w).Encode(response{Code: tc.code, Message: "msg", RequestId: "req-1"})
+			}))
+			defer server.Close()
+
+			svc := NewService(server.Client(), server.URL, "key-id", "key-secret", "小微书", templates, tc.paramNames)
+			err := svc.Send(context.Background(), tc.tplId, tc.args, "+8613800138000", "+8613800138001")
+			assert.Equal(t, tc.wantErr, err != nil, err)
+			assert.Equal(t, tc.wantKind, sms.KindOf(err))
+		})
+	}
+}
+
+func TestSMSService_Send_ProviderDown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html>502 Bad Gateway</html>"))
+	}))
+	svc := NewService(server.Client(), server.URL, "key-id", "key-secret", "小微书",
+		sms.Templates{"code": "SMS_1"}, nil)
+	err := svc.Send(context.Background(), "code", []string{"123456"}, "+8613800138000")
+	assert.Equal(t, sms.KindProviderDown, sms.KindOf(err))
+
+	// 连接不上
+	server.Close()
+	err = svc.Send(context.Background(), "code", []string{"123456"}, "+8613800138000")
+	assert.Equal(t, sms.KindProviderDown, sms.KindOf(err))
+}
+
+func TestPercentEncode(t *testing.T) {
+	assert.Equal(t, "a%20b%2A~%2F", percentEncode("a b*~/"))
+}
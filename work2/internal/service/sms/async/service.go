@@ -5,8 +5,8 @@ import (
 	"example/wb/internal/domain"
 	"example/wb/internal/repository"
 	"example/wb/internal/service/sms"
-	"example/wb/internal/service/sms/ratelimit"
 	"log"
+	"math/rand"
 	"sync"
 	"time"
 )
@@ -16,13 +16,58 @@ type Service struct {
 	repo     repository.AsyncSmsRepository
 	durTimes []int
 	mu       sync.Mutex
+
+	// 同时有几个 worker 在抢占发送
+	workers int
+	// 每个 worker 一次抢占多少条
+	batchSize int
+	// 抢占之后多久没有结果，其他 worker 可以重新抢占，要比一批发送完的时间长
+	lease time.Duration
+	// 第一次重试的间隔，之后每次翻倍，最多 maxBackoff
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	// 没有需要发送的短信的时候休息多久
+	idle time.Duration
+}
+
+type Option func(s *Service)
+
+// WithWorkers n 个 worker，每个 worker 一次抢占 batchSize 条
+func WithWorkers(n int, batchSize int) Option {
+	return func(s *Service) {
+		s.workers = n
+		s.batchSize = batchSize
+	}
+}
+
+// WithBackoff 第 n 次失败之后等 base*2^(n-1)，不超过 max，再加上随机抖动
+func WithBackoff(base, max time.Duration) Option {
+	return func(s *Service) {
+		s.baseBackoff = base
+		s.maxBackoff = max
+	}
+}
+
+func WithLease(lease time.Duration) Option {
+	return func(s *Service) {
+		s.lease = lease
+	}
 }
 
 func NewService(svc sms.Service,
-	repo repository.AsyncSmsRepository) *Service {
+	repo repository.AsyncSmsRepository, opts ...Option) *Service {
 	res := &Service{
-		svc:  svc,
-		repo: repo,
+		svc:         svc,
+		repo:        repo,
+		workers:     4,
+		batchSize:   10,
+		lease:       time.Minute,
+		baseBackoff: time.Second * 30,
+		maxBackoff:  time.Minute * 10,
+		idle:        time.Second * 5,
+	}
+	for _, opt := range opts {
+		opt(res)
 	}
 	go func() {
 		res.StartAsyncCycle()
@@ -30,46 +75,89 @@ func NewService(svc sms.Service,
 	return res
 }
 
-// 原理：抢占式调度
+// 原理：抢占式调度，多个 worker 各自批量抢占，用数据库的行锁保证不会抢到同一条
 func (s *Service) StartAsyncCycle() {
 	// 防止测试时，偶发性的失败（原理未知）
 	time.Sleep(time.Second * 3)
-	for {
-		s.AsyncSend()
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				s.AsyncSend()
+			}
+		}()
 	}
-
+	wg.Wait()
 }
 
+// AsyncSend 抢占一批，逐条发送
 func (s *Service) AsyncSend() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 
-	as, err := s.repo.PreemptWaitingSMS(ctx)
+	ass, err := s.repo.PreemptWaitingSMS(ctx, s.batchSize, s.lease)
 	cancel()
 	switch err {
 	case nil:
-		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		err = s.svc.Send(ctx, as.TplId, as.Args, as.Numbers...)
-
-		if err != nil {
-			log.Printf("执行异步发送短信失败, err: %s, id: %d", err, as.Id)
-		}
-		res := err == nil
-
-		err = s.repo.ReportScheduleResult(ctx, as.Id, res)
-		if err != nil {
-			log.Printf("执行异步发送短信成功,但是数据库标记失败 err: %s, id: %d", err, as.Id)
+		for _, as := range ass {
+			s.sendOne(as)
 		}
 	case repository.ErrWaitingSMSNotFound:
-		// 数据库里面没有发送失败的消息，可以考虑自由设置休息时间
-		time.Sleep(time.Second * 5)
+		// 数据库里面没有需要发送的消息，可以考虑自由设置休息时间
+		time.Sleep(s.idle)
 	default:
 		log.Printf("抢占异步发送短信失败, err: %s", err)
-		time.Sleep(time.Second * 5)
+		time.Sleep(s.idle)
 	}
 
 }
 
+func (s *Service) sendOne(as domain.AsyncSms) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	err := s.svc.Send(ctx, as.TplId, as.Args, as.Numbers...)
+	cancel()
+
+	if err != nil {
+		log.Printf("执行异步发送短信失败, err: %s, id: %d", err, as.Id)
+	}
+	// 发送可能已经把超时用完了，写数据库单独计时
+	dbCtx, dbCancel := context.WithTimeout(context.Background(), time.Second)
+	defer dbCancel()
+	if sms.Rejected(err) {
+		// 号码不对、模板没有审核通过之类的，重试也没用
+		err = s.repo.Abandon(dbCtx, as.Id)
+		if err != nil {
+			log.Printf("放弃异步发送短信, 但是数据库标记失败 err: %s, id: %d", err, as.Id)
+		}
+		return
+	}
+	res := err == nil
+
+	// 抢占的时候已经加过一了，这是第 RetryCnt 次发送
+	err = s.repo.ReportScheduleResult(dbCtx, as.Id, res, time.Now().Add(s.backoff(as.RetryCnt)))
+	if err != nil {
+		log.Printf("执行异步发送短信成功,但是数据库标记失败 err: %s, id: %d", err, as.Id)
+	}
+}
+
+// backoff 第 attempt 次（从 1 开始）失败之后等多久。
+// 指数退避之后在 [d/2, d) 里面取随机值，避免同一批失败的短信在同一时刻一起重试
+func (s *Service) backoff(attempt int) time.Duration {
+	d := s.baseBackoff
+	for i := 1; i < attempt && d < s.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > s.maxBackoff {
+		d = s.maxBackoff
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
 func (s *Service) needAsync(curTime int, threshold int) bool {
 	var mean int
 	s.mu.Lock()
@@ -93,14 +181,22 @@ func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers
 	err := s.svc.Send(ctx, tplId, args, numbers...)
 	end := time.Now().UnixMilli()
 	durTime := int(end - start)
+	slow := s.needAsync(durTime, 500)
+	if err == nil {
+		return nil
+	}
 
-	if err == ratelimit.ErrSMSLimitRate || s.needAsync(durTime, 500) {
+	// 超时、限流、服务商不可用的，过一会儿再发；
+	// 服务商响应慢的时候，除了明确被拒绝的，也都转异步
+	if sms.Retryable(err) || slow && !sms.Rejected(err) {
 		return s.repo.Create(ctx,
 			domain.AsyncSms{
 				TplId:    tplId,
 				Args:     args,
 				Numbers:  numbers,
 				RetryMax: 3,
+				// 同步发送已经失败了一次
+				NextRetryAt: time.Now().Add(s.backoff(1)),
 			},
 		)
 
@@ -2,12 +2,14 @@ package async
 
 import (
 	"context"
+	"example/wb/internal/domain"
 	"example/wb/internal/repository"
 	repomock "example/wb/internal/repository/mock"
 	"example/wb/internal/service/sms"
 	smsmock "example/wb/internal/service/sms/mocks"
 	"example/wb/internal/service/sms/ratelimit"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -46,6 +48,33 @@ func TestAsyncSMSService_Send(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "服务商不可用存入数据库",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository) {
+				smsSvc := smsmock.NewMockService(ctrl)
+				smsSvc.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(sms.NewError(sms.KindProviderDown, "aliyun", "isp.SYSTEM_ERROR", ""))
+
+				repo := repomock.NewMockAsyncSmsRepository(ctrl)
+				repo.EXPECT().Create(gomock.Any(), gomock.Cond(func(x any) bool {
+					// 按照第一次失败退避
+					d := time.Until(x.(domain.AsyncSms).NextRetryAt)
+					return d > time.Second*9 && d <= time.Second*20
+				})).Return(nil)
+				return smsSvc, repo
+			},
+			wantErr: nil,
+		},
+		{
+			name: "号码不对直接返回",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository) {
+				smsSvc := smsmock.NewMockService(ctrl)
+				smsSvc.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(sms.NewError(sms.KindInvalidNumber, "aliyun", "isv.MOBILE_NUMBER_ILLEGAL", ""))
+				return smsSvc, repomock.NewMockAsyncSmsRepository(ctrl)
+			},
+			wantErr: sms.NewError(sms.KindInvalidNumber, "aliyun", "isv.MOBILE_NUMBER_ILLEGAL", ""),
+		},
 	}
 	for _, tt := range testCase {
 		t.Run(tt.name, func(t *testing.T) {
@@ -53,7 +82,8 @@ func TestAsyncSMSService_Send(t *testing.T) {
 			defer ctrl.Finish()
 
 			smsSvc, smsRepo := tt.mock(ctrl)
-			asyncSvc := NewService(smsSvc, smsRepo)
+			// 不用 NewService，避免启动后台的异步发送
+			asyncSvc := &Service{svc: smsSvc, repo: smsRepo, baseBackoff: time.Second * 20, maxBackoff: time.Minute}
 			err := asyncSvc.Send(context.Background(), "1", []string{"12"}, "324")
 			assert.Equal(t, tt.wantErr, err)
 
@@ -61,6 +91,93 @@ func TestAsyncSMSService_Send(t *testing.T) {
 	}
 }
 
+func TestAsyncSMSService_AsyncSend(t *testing.T) {
+	// 抢占出来的 RetryCnt 已经加过一了，这是第二次发送
+	as := domain.AsyncSms{Id: 1, TplId: "1", Args: []string{"12"}, Numbers: []string{"324"}, RetryMax: 3, RetryCnt: 2}
+	as2 := domain.AsyncSms{Id: 2, TplId: "1", Args: []string{"12"}, Numbers: []string{"325"}, RetryMax: 3, RetryCnt: 1}
+	// 第二次失败之后等 20s 到 40s，按照第三次算的话是 40s 到 80s
+	secondRetry := gomock.Cond(func(x any) bool {
+		d := time.Until(x.(time.Time))
+		return d > time.Second*19 && d < time.Second*40
+	})
+	testCase := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository)
+	}{
+		{
+			name: "发送成功",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository) {
+				smsSvc := smsmock.NewMockService(ctrl)
+				smsSvc.EXPECT().Send(gomock.Any(), "1", []string{"12"}, "324").Return(nil)
+				repo := repomock.NewMockAsyncSmsRepository(ctrl)
+				repo.EXPECT().PreemptWaitingSMS(gomock.Any(), 10, time.Minute).Return([]domain.AsyncSms{as}, nil)
+				repo.EXPECT().ReportScheduleResult(gomock.Any(), int64(1), true, gomock.Any()).Return(nil)
+				return smsSvc, repo
+			},
+		},
+		{
+			name: "一批里面一个超时，退避之后重试",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository) {
+				smsSvc := smsmock.NewMockService(ctrl)
+				smsSvc.EXPECT().Send(gomock.Any(), "1", []string{"12"}, "324").
+					Return(context.DeadlineExceeded)
+				smsSvc.EXPECT().Send(gomock.Any(), "1", []string{"12"}, "325").Return(nil)
+				repo := repomock.NewMockAsyncSmsRepository(ctrl)
+				repo.EXPECT().PreemptWaitingSMS(gomock.Any(), 10, time.Minute).
+					Return([]domain.AsyncSms{as, as2}, nil)
+				repo.EXPECT().ReportScheduleResult(gomock.Any(), int64(1), false, secondRetry).Return(nil)
+				repo.EXPECT().ReportScheduleResult(gomock.Any(), int64(2), true, gomock.Any()).Return(nil)
+				return smsSvc, repo
+			},
+		},
+		{
+			name: "模板被拒绝，放弃",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository) {
+				smsSvc := smsmock.NewMockService(ctrl)
+				smsSvc.EXPECT().Send(gomock.Any(), "1", []string{"12"}, "324").
+					Return(sms.NewError(sms.KindTemplateRejected, "tencent", "FailedOperation.TemplateIncorrectOrUnapproved", ""))
+				repo := repomock.NewMockAsyncSmsRepository(ctrl)
+				repo.EXPECT().PreemptWaitingSMS(gomock.Any(), 10, time.Minute).Return([]domain.AsyncSms{as}, nil)
+				repo.EXPECT().Abandon(gomock.Any(), int64(1)).Return(nil)
+				return smsSvc, repo
+			},
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			smsSvc, smsRepo := tt.mock(ctrl)
+			asyncSvc := &Service{svc: smsSvc, repo: smsRepo, batchSize: 10, lease: time.Minute,
+				baseBackoff: time.Second * 20, maxBackoff: time.Minute * 10}
+			asyncSvc.AsyncSend()
+		})
+	}
+}
+
+func TestAsyncSMSService_Backoff(t *testing.T) {
+	s := &Service{baseBackoff: time.Second * 10, maxBackoff: time.Minute}
+	testCase := []struct {
+		attempt int
+		// 抖动之后在 [want/2, want) 之间
+		want time.Duration
+	}{
+		{attempt: 1, want: time.Second * 10},
+		{attempt: 2, want: time.Second * 20},
+		{attempt: 3, want: time.Second * 40},
+		{attempt: 4, want: time.Minute},
+		{attempt: 10, want: time.Minute},
+	}
+	for _, tc := range testCase {
+		for i := 0; i < 100; i++ {
+			d := s.backoff(tc.attempt)
+			assert.GreaterOrEqual(t, d, tc.want/2)
+			assert.Less(t, d, tc.want)
+		}
+	}
+}
+
 // func TestAsyncSMSService_AsyncSend(t *testing.T) {
 // 	testCase := []struct {
 // 		name    string
@@ -0,0 +1,167 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ErrorKind 各个服务商的错误码五花八门，统一归到这几类里面，
+// 装饰器按照分类决定是重试、换一个服务商还是直接放弃
+type ErrorKind uint8
+
+const (
+	// KindUnknown 没有办法归类的错误，包括不是服务商返回的错误
+	KindUnknown ErrorKind = iota
+	// KindTimeout 请求服务商超时
+	KindTimeout
+	// KindThrottled 被限流了，包括我们自己的限流和服务商的频率限制
+	KindThrottled
+	// KindInvalidNumber 手机号不对，换谁发都没用
+	KindInvalidNumber
+	// KindTemplateRejected 模板或者签名没有审核通过、参数不对
+	KindTemplateRejected
+	// KindAuthFailure 密钥错了、没有权限
+	KindAuthFailure
+	// KindProviderDown 服务商内部错误、欠费停机或者网络不通
+	KindProviderDown
+)
+
+var kindNames = map[ErrorKind]string{
+	KindUnknown:          "unknown",
+	KindTimeout:          "timeout",
+	KindThrottled:        "throttled",
+	KindInvalidNumber:    "invalid_number",
+	KindTemplateRejected: "template_rejected",
+	KindAuthFailure:      "auth_failure",
+	KindProviderDown:     "provider_down",
+}
+
+func (k ErrorKind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorKind(%d)", k)
+}
+
+// ParseErrorKind 配置里面用 String 返回的名字
+func ParseErrorKind(name string) (ErrorKind, bool) {
+	for k, n := range kindNames {
+		if n == name {
+			return k, true
+		}
+	}
+	return KindUnknown, false
+}
+
+// Retryable 过一会儿在同一个服务商上再试一次可能就成功了
+func (k ErrorKind) Retryable() bool {
+	return k == KindTimeout || k == KindThrottled || k == KindProviderDown
+}
+
+// Rejected 服务商明确拒绝了，同一个服务商怎么重试都没用
+func (k ErrorKind) Rejected() bool {
+	return k == KindInvalidNumber || k == KindTemplateRejected || k == KindAuthFailure
+}
+
+// Failover 换一个服务商可能就成功了，只有手机号不对的时候没有必要换
+func (k ErrorKind) Failover() bool {
+	return k != KindInvalidNumber
+}
+
+// Error 服务商把自己的错误码转换成 Error 返回
+type Error struct {
+	Kind ErrorKind
+	// Provider 哪个服务商返回的，自己的装饰器返回的时候为空
+	Provider string
+	// Code 服务商原始的错误码
+	Code string
+	Msg  string
+	Err  error
+}
+
+func NewError(kind ErrorKind, provider, code, msg string) *Error {
+	return &Error{
+		Kind:     kind,
+		Provider: provider,
+		Code:     code,
+		Msg:      msg,
+	}
+}
+
+// WrapError 请求服务商的时候出的错，例如网络错误，按照 KindOf 归类
+func WrapError(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+	kind := KindOf(err)
+	if kind == KindUnknown {
+		// 请求都没有发出去，当作服务商不可用
+		kind = KindProviderDown
+	}
+	return &Error{
+		Kind:     kind,
+		Provider: provider,
+		Msg:      "请求服务商失败",
+		Err:      err,
+	}
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	if e.Provider != "" {
+		sb.WriteString(e.Provider)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(e.Msg)
+	fmt.Fprintf(&sb, "（%s", e.Kind)
+	if e.Code != "" {
+		fmt.Fprintf(&sb, "，code：%s", e.Code)
+	}
+	sb.WriteString("）")
+	if e.Err != nil {
+		sb.WriteString("：")
+		sb.WriteString(e.Err.Error())
+	}
+	return sb.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf 找出 err 的分类，没有包装成 Error 的超时和网络错误也能识别
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return KindUnknown
+	}
+	var se *Error
+	if errors.As(err, &se) {
+		return se.Kind
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTimeout
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		if ne.Timeout() {
+			return KindTimeout
+		}
+		return KindProviderDown
+	}
+	return KindUnknown
+}
+
+func Retryable(err error) bool {
+	return err != nil && KindOf(err).Retryable()
+}
+
+func Rejected(err error) bool {
+	return err != nil && KindOf(err).Rejected()
+}
+
+func ShouldFailover(err error) bool {
+	return err != nil && KindOf(err).Failover()
+}
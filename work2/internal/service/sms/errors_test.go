@@ -0,0 +1,90 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	testCase := []struct {
+		name string
+		err  error
+
+		want ErrorKind
+	}{
+		{
+			name: "服务商返回的错误",
+			err:  NewError(KindInvalidNumber, "aliyun", "isv.MOBILE_NUMBER_ILLEGAL", "号码不对"),
+			want: KindInvalidNumber,
+		},
+		{
+			name: "包装了一层",
+			err:  fmt.Errorf("发送失败：%w", NewError(KindThrottled, "tencent", "LimitExceeded", "")),
+			want: KindThrottled,
+		},
+		{
+			name: "context超时",
+			err:  context.DeadlineExceeded,
+			want: KindTimeout,
+		},
+		{
+			name: "http client超时",
+			err:  &url.Error{Op: "Post", URL: "http://localhost", Err: &net.DNSError{IsTimeout: true}},
+			want: KindTimeout,
+		},
+		{
+			name: "连接不上",
+			err:  &url.Error{Op: "Post", URL: "http://localhost", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			want: KindProviderDown,
+		},
+		{
+			name: "其他错误",
+			err:  errors.New("token 不对"),
+			want: KindUnknown,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, KindOf(tc.err))
+		})
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	err := WrapError("aliyun", errors.New("EOF"))
+	assert.Equal(t, KindProviderDown, KindOf(err))
+	assert.True(t, Retryable(err))
+	assert.Nil(t, WrapError("aliyun", nil))
+
+	err = WrapError("aliyun", context.DeadlineExceeded)
+	assert.Equal(t, KindTimeout, KindOf(err))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestParseErrorKind(t *testing.T) {
+	for k, name := range kindNames {
+		got, ok := ParseErrorKind(name)
+		assert.True(t, ok)
+		assert.Equal(t, k, got)
+	}
+	_, ok := ParseErrorKind("bad")
+	assert.False(t, ok)
+}
+
+func TestTemplates_Resolve(t *testing.T) {
+	templates := NewTemplates([]TemplateMapping{{Name: "code", Id: "SMS_1263395"}})
+	id, err := templates.Resolve("aliyun", "code")
+	assert.NoError(t, err)
+	assert.Equal(t, "SMS_1263395", id)
+
+	// 没有配置的模板交给 failover 换服务商
+	_, err = templates.Resolve("aliyun", "1263395")
+	assert.Equal(t, KindTemplateRejected, KindOf(err))
+	assert.True(t, ShouldFailover(err))
+}
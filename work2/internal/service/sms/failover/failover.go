@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"example/wb/internal/service/sms"
+	"fmt"
 	"log"
 )
 
@@ -16,14 +17,24 @@ func NewFailOverSMSService(svcs []sms.Service) *FailOverSMSService {
 
 }
 
+var ErrAllProvidersFailed = errors.New("轮询了所有服务商, 但是都失败了")
+
+// Send 按照顺序一个个试，手机号不对这种换谁都没用的错误直接返回。
+// 都失败了的时候返回的错误同时包含 ErrAllProvidersFailed 和最后一个服务商的错误，
+// 上层可以用 sms.KindOf 判断要不要重试
 func (f FailOverSMSService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	var lastErr error
 	for _, svc := range f.svcs {
 		err := svc.Send(ctx, tplId, args, numbers...)
 		if err == nil {
 			return nil
 		}
+		if !sms.ShouldFailover(err) {
+			return err
+		}
 		log.Println(err)
+		lastErr = err
 	}
-	return errors.New("轮询了所有服务商, 但是都失败了")
+	return fmt.Errorf("%w: %w", ErrAllProvidersFailed, lastErr)
 
 }
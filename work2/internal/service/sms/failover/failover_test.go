@@ -17,7 +17,8 @@ func TestFailOverSMSService_Send(t *testing.T) {
 
 		mock func(ctrl *gomock.Controller) []sms.Service
 
-		wantErr error
+		wantErr  error
+		wantKind sms.ErrorKind
 	}{
 		// TODO: Add test cases.
 		{
@@ -48,7 +49,7 @@ func TestFailOverSMSService_Send(t *testing.T) {
 					svcs = append(svcs, svc)
 					if len(svcs) == 2 {
 						svc.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-							Return(errors.New("轮询了所有服务商, 但是都失败了"))
+							Return(sms.NewError(sms.KindProviderDown, "aliyun", "isp.SYSTEM_ERROR", ""))
 						return svcs
 					} else {
 						svc.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
@@ -56,7 +57,20 @@ func TestFailOverSMSService_Send(t *testing.T) {
 					}
 				}
 			},
-			wantErr: errors.New("轮询了所有服务商, 但是都失败了"),
+			wantErr:  ErrAllProvidersFailed,
+			wantKind: sms.KindProviderDown,
+		},
+		{
+			name: "号码不对，不再换服务商",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmock.NewMockService(ctrl)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(sms.NewError(sms.KindInvalidNumber, "aliyun", "isv.MOBILE_NUMBER_ILLEGAL", ""))
+				svc1 := smsmock.NewMockService(ctrl)
+				return []sms.Service{svc0, svc1}
+			},
+			wantErr:  sms.NewError(sms.KindInvalidNumber, "aliyun", "isv.MOBILE_NUMBER_ILLEGAL", ""),
+			wantKind: sms.KindInvalidNumber,
 		},
 	}
 	for _, tt := range testCase {
@@ -67,7 +81,12 @@ func TestFailOverSMSService_Send(t *testing.T) {
 			svcs := tt.mock(ctrl)
 			fo := NewFailOverSMSService(svcs)
 			err := fo.Send(context.Background(), "1", []string{"1"}, "213")
-			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == ErrAllProvidersFailed {
+				assert.ErrorIs(t, err, ErrAllProvidersFailed)
+			} else {
+				assert.Equal(t, tt.wantErr, err)
+			}
+			assert.Equal(t, tt.wantKind, sms.KindOf(err))
 		})
 	}
 }
@@ -40,18 +40,19 @@ func (t *TimeOutFailoverSMSService) Send(ctx context.Context, tplId string, args
 	}
 	svc := t.svcs[idx]
 	err := svc.Send(ctx, tplId, args, numbers...)
-	switch err {
-	case nil:
+	if err == nil {
 		// 连续超时
 		atomic.StoreInt32(&t.cnt, 0)
 		return nil
-	case context.DeadlineExceeded:
+	}
+	switch sms.KindOf(err) {
+	case sms.KindTimeout:
 		atomic.AddInt32(&t.cnt, 1)
+	case sms.KindProviderDown, sms.KindAuthFailure:
+		// 服务商已经不可用了，或者我们的账号有问题，不用等到连续超时，下一次直接切换
+		atomic.StoreInt32(&t.cnt, t.threshold)
 	default:
-		// 遇到了错误，但是又不是超时错误，这个时候，你要考虑怎么搞
-		// 我可以增加，也可以不增加
-		// 如果强调一定是超时，那么就不增加
-		// 如果是EOF之类的错误，你还可以考虑直接切换
+		// 号码不对、模板不对这种是请求本身的问题，和服务商无关，不计数
 	}
 	return err
 }
@@ -91,6 +91,40 @@ func TestTimeOutFailoverSMSService_Send(t *testing.T) {
 			wantCnt: 0,
 			wantErr: errors.New("0"),
 		},
+		{
+			name: "服务商不可用，下一次直接切换",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmock.NewMockService(ctrl)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(sms.NewError(sms.KindProviderDown, "aliyun", "isp.SYSTEM_ERROR", ""))
+				svc1 := smsmock.NewMockService(ctrl)
+				return []sms.Service{svc0, svc1}
+			},
+			idx:       0,
+			cnt:       0,
+			threshold: 5,
+
+			wantIdx: 0,
+			wantCnt: 5,
+			wantErr: sms.NewError(sms.KindProviderDown, "aliyun", "isp.SYSTEM_ERROR", ""),
+		},
+		{
+			name: "号码不对，不计数",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmock.NewMockService(ctrl)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(sms.NewError(sms.KindInvalidNumber, "aliyun", "isv.MOBILE_NUMBER_ILLEGAL", ""))
+				svc1 := smsmock.NewMockService(ctrl)
+				return []sms.Service{svc0, svc1}
+			},
+			idx:       0,
+			cnt:       2,
+			threshold: 5,
+
+			wantIdx: 0,
+			wantCnt: 2,
+			wantErr: sms.NewError(sms.KindInvalidNumber, "aliyun", "isv.MOBILE_NUMBER_ILLEGAL", ""),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
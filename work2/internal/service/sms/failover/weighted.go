@@ -0,0 +1,188 @@
+package failover
+
+import (
+	"context"
+	"example/wb/internal/service/sms"
+	"example/wb/pkg/breaker"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// WeightedProvider 参与负载均衡的服务商，Weight 是配置的权重
+type WeightedProvider struct {
+	Name   string
+	Svc    sms.Service
+	Weight int
+}
+
+// WeightedSMSService 平滑加权轮询（和 nginx 的 upstream 一样），按照权重把流量分摊到各个服务商。
+// 服务商出错或者变慢的时候降低它的有效权重，之后每一轮选择加回 1，慢慢恢复到配置的权重；
+// 每个服务商还有自己的熔断器，连续失败之后一段时间内不再选它。
+// 一次发送失败了会换一个还没试过的服务商，手机号不对这种换谁都没用的直接返回
+type WeightedSMSService struct {
+	mu    sync.Mutex
+	nodes []*weightedNode
+	// 成功了但是耗时超过这个值，也要降低权重
+	slowThreshold time.Duration
+}
+
+type weightedNode struct {
+	name   string
+	svc    sms.Service
+	weight int
+	// 有效权重，出错的时候减少，每一轮选择加 1，最多恢复到 weight
+	effective int
+	// 平滑加权轮询的当前权重
+	current int
+	breaker *breaker.Breaker
+}
+
+type WeightedOption func(w *WeightedSMSService)
+
+// WithSlowThreshold 发送耗时超过 d 的时候降低服务商的权重
+func WithSlowThreshold(d time.Duration) WeightedOption {
+	return func(w *WeightedSMSService) {
+		w.slowThreshold = d
+	}
+}
+
+// WithProviderBreaker 每个服务商连续失败 threshold 次之后熔断，cooldown 之后再探测
+func WithProviderBreaker(threshold int, cooldown time.Duration) WeightedOption {
+	return func(w *WeightedSMSService) {
+		for _, n := range w.nodes {
+			n.breaker = breaker.New(threshold, cooldown)
+		}
+	}
+}
+
+func NewWeightedSMSService(providers []WeightedProvider, opts ...WeightedOption) *WeightedSMSService {
+	nodes := make([]*weightedNode, 0, len(providers))
+	for _, p := range providers {
+		nodes = append(nodes, &weightedNode{
+			name:      p.Name,
+			svc:       p.Svc,
+			weight:    p.Weight,
+			effective: p.Weight,
+			breaker:   breaker.New(5, time.Second*30),
+		})
+	}
+	res := &WeightedSMSService{
+		nodes:         nodes,
+		slowThreshold: time.Second,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+func (w *WeightedSMSService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	tried := make(map[*weightedNode]struct{}, len(w.nodes))
+	var lastErr error
+	for {
+		n := w.pick(tried)
+		if n == nil {
+			break
+		}
+		tried[n] = struct{}{}
+		start := time.Now()
+		err := n.svc.Send(ctx, tplId, args, numbers...)
+		if err != nil && ctx.Err() != nil {
+			// 调用方自己取消了或者超时了，不是服务商的问题：
+			// 不扣权重，不换服务商，半开的探测名额也要放掉
+			n.breaker.Cancel()
+			return err
+		}
+		w.feedback(n, err, time.Since(start))
+		if err == nil {
+			return nil
+		}
+		if !sms.ShouldFailover(err) {
+			return err
+		}
+		log.Println(err)
+		lastErr = err
+	}
+	if lastErr == nil {
+		// 所有服务商都熔断了
+		return &sms.Error{Kind: sms.KindProviderDown, Msg: "所有服务商都熔断了", Err: ErrAllProvidersFailed}
+	}
+	return fmt.Errorf("%w: %w", ErrAllProvidersFailed, lastErr)
+}
+
+// pick 在没有试过、熔断器允许的服务商里面按照平滑加权轮询选一个，没有可选的返回 nil
+func (w *WeightedSMSService) pick(tried map[*weightedNode]struct{}) *weightedNode {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for {
+		var best *weightedNode
+		total := 0
+		for _, n := range w.nodes {
+			if _, ok := tried[n]; ok {
+				continue
+			}
+			n.current += n.effective
+			total += n.effective
+			if n.effective < n.weight {
+				n.effective++
+			}
+			if best == nil || n.current > best.current {
+				best = n
+			}
+		}
+		if best == nil {
+			return nil
+		}
+		best.current -= total
+		if best.breaker.Allow() {
+			return best
+		}
+		// 熔断了，这一次就当已经试过了
+		tried[best] = struct{}{}
+	}
+}
+
+// feedback 根据这一次的结果调整有效权重和熔断器
+func (w *WeightedSMSService) feedback(n *weightedNode, err error, dur time.Duration) {
+	if err != nil && !healthError(err) {
+		// 号码、模板的问题和服务商的健康状况无关
+		n.breaker.Success()
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case err != nil:
+		n.breaker.Failure()
+		n.penalize(2)
+	case dur > w.slowThreshold:
+		n.breaker.Success()
+		n.penalize(4)
+	default:
+		n.breaker.Success()
+	}
+}
+
+// penalize 有效权重减去 weight/divisor，至少减 1
+func (n *weightedNode) penalize(divisor int) {
+	delta := n.weight / divisor
+	if delta < 1 {
+		delta = 1
+	}
+	n.effective -= delta
+	if n.effective < 0 {
+		n.effective = 0
+	}
+}
+
+// healthError 能够说明服务商不健康的错误
+func healthError(err error) bool {
+	switch sms.KindOf(err) {
+	case sms.KindInvalidNumber, sms.KindTemplateRejected:
+		return false
+	default:
+		return true
+	}
+}
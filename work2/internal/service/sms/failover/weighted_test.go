@@ -0,0 +1,231 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/service/sms"
+	smsmock "example/wb/internal/service/sms/mocks"
+	"example/wb/pkg/breaker"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var errProviderDown = sms.NewError(sms.KindProviderDown, "aliyun", "isp.SYSTEM_ERROR", "")
+
+// scheduled 按照 schedule 依次返回，schedule 用完之后一直成功；记录被调用的次数
+func scheduled(ctrl *gomock.Controller, name string, calls *[]string, schedule ...error) sms.Service {
+	svc := smsmock.NewMockService(ctrl)
+	i := 0
+	svc.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, tplId string, args []string, numbers ...string) error {
+			*calls = append(*calls, name)
+			defer func() { i++ }()
+			if i < len(schedule) {
+				return schedule[i]
+			}
+			return nil
+		}).AnyTimes()
+	return svc
+}
+
+func TestWeightedSMSService_Smooth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var calls []string
+	w := NewWeightedSMSService([]WeightedProvider{
+		{Name: "a", Svc: scheduled(ctrl, "a", &calls), Weight: 5},
+		{Name: "b", Svc: scheduled(ctrl, "b", &calls), Weight: 1},
+		{Name: "c", Svc: scheduled(ctrl, "c", &calls), Weight: 1},
+	})
+	for i := 0; i < 7; i++ {
+		assert.NoError(t, w.Send(context.Background(), "1", []string{"1"}, "123"))
+	}
+	// 平滑加权轮询不会连续 5 次都选 a
+	assert.Equal(t, []string{"a", "a", "b", "a", "c", "a", "a"}, calls)
+}
+
+func TestWeightedSMSService_Send(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		// a 的权重比 b 大，第一次总是先选 a
+		mock func(ctrl *gomock.Controller, calls *[]string) (sms.Service, sms.Service)
+
+		wantCalls []string
+		wantErr   error
+		wantKind  sms.ErrorKind
+	}{
+		{
+			name: "第一个失败，换一个成功",
+			mock: func(ctrl *gomock.Controller, calls *[]string) (sms.Service, sms.Service) {
+				return scheduled(ctrl, "a", calls, errProviderDown), scheduled(ctrl, "b", calls)
+			},
+			wantCalls: []string{"a", "b"},
+		},
+		{
+			name: "号码不对，不换服务商",
+			mock: func(ctrl *gomock.Controller, calls *[]string) (sms.Service, sms.Service) {
+				return scheduled(ctrl, "a", calls, sms.NewError(sms.KindInvalidNumber, "aliyun", "", "")),
+					scheduled(ctrl, "b", calls)
+			},
+			wantCalls: []string{"a"},
+			wantErr:   sms.NewError(sms.KindInvalidNumber, "aliyun", "", ""),
+			wantKind:  sms.KindInvalidNumber,
+		},
+		{
+			name: "全部失败",
+			mock: func(ctrl *gomock.Controller, calls *[]string) (sms.Service, sms.Service) {
+				return scheduled(ctrl, "a", calls, errProviderDown),
+					scheduled(ctrl, "b", calls, context.DeadlineExceeded)
+			},
+			wantCalls: []string{"a", "b"},
+			wantErr:   ErrAllProvidersFailed,
+			wantKind:  sms.KindTimeout,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var calls []string
+			a, b := tc.mock(ctrl, &calls)
+			w := NewWeightedSMSService([]WeightedProvider{
+				{Name: "a", Svc: a, Weight: 10},
+				{Name: "b", Svc: b, Weight: 1},
+			})
+			err := w.Send(context.Background(), "1", []string{"1"}, "123")
+			if tc.wantErr == ErrAllProvidersFailed {
+				assert.ErrorIs(t, err, ErrAllProvidersFailed)
+			} else {
+				assert.Equal(t, tc.wantErr, err)
+			}
+			assert.Equal(t, tc.wantKind, sms.KindOf(err))
+			assert.Equal(t, tc.wantCalls, calls)
+		})
+	}
+}
+
+// a 前三次失败，权重降下来之后流量转到 b，之后慢慢恢复到配置的权重
+func TestWeightedSMSService_Recover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var calls []string
+	w := NewWeightedSMSService([]WeightedProvider{
+		{Name: "a", Svc: scheduled(ctrl, "a", &calls, errProviderDown, errProviderDown, errProviderDown), Weight: 8},
+		{Name: "b", Svc: scheduled(ctrl, "b", &calls), Weight: 2},
+	}, WithProviderBreaker(100, time.Minute))
+	a := w.nodes[0]
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, w.Send(context.Background(), "1", []string{"1"}, "123"))
+	}
+	assert.Less(t, a.effective, a.weight)
+
+	calls = nil
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, w.Send(context.Background(), "1", []string{"1"}, "123"))
+	}
+	assert.Equal(t, a.weight, a.effective)
+	// 恢复之后最后 10 次按照 8:2 分配
+	cnt := 0
+	for _, c := range calls[len(calls)-10:] {
+		if c == "a" {
+			cnt++
+		}
+	}
+	assert.Equal(t, 8, cnt)
+}
+
+// 连续失败之后熔断，熔断期间不再调用
+func TestWeightedSMSService_Breaker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var calls []string
+	down := make([]error, 100)
+	for i := range down {
+		down[i] = errProviderDown
+	}
+	w := NewWeightedSMSService([]WeightedProvider{
+		{Name: "a", Svc: scheduled(ctrl, "a", &calls, down...), Weight: 10},
+		{Name: "b", Svc: scheduled(ctrl, "b", &calls), Weight: 1},
+	}, WithProviderBreaker(2, time.Minute))
+
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, w.Send(context.Background(), "1", []string{"1"}, "123"))
+	}
+	cnt := 0
+	for _, c := range calls {
+		if c == "a" {
+			cnt++
+		}
+	}
+	assert.Equal(t, 2, cnt)
+}
+
+// 成功了但是很慢，也要降低权重
+func TestWeightedSMSService_Slow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	slow := smsmock.NewMockService(ctrl)
+	slow.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, tplId string, args []string, numbers ...string) error {
+			time.Sleep(time.Millisecond * 5)
+			return nil
+		})
+	w := NewWeightedSMSService([]WeightedProvider{
+		{Name: "a", Svc: slow, Weight: 8},
+	}, WithSlowThreshold(time.Millisecond))
+	assert.NoError(t, w.Send(context.Background(), "1", []string{"1"}, "123"))
+	assert.Equal(t, 6, w.nodes[0].effective)
+}
+
+func TestWeightedSMSService_AllOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var calls []string
+	w := NewWeightedSMSService([]WeightedProvider{
+		{Name: "a", Svc: scheduled(ctrl, "a", &calls, errProviderDown), Weight: 1},
+	}, WithProviderBreaker(1, time.Minute))
+	err := w.Send(context.Background(), "1", []string{"1"}, "123")
+	assert.True(t, errors.Is(err, ErrAllProvidersFailed))
+	// 熔断之后一个服务商都不能用
+	err = w.Send(context.Background(), "1", []string{"1"}, "123")
+	assert.True(t, errors.Is(err, ErrAllProvidersFailed))
+	assert.Equal(t, sms.KindProviderDown, sms.KindOf(err))
+	assert.Equal(t, []string{"a"}, calls)
+}
+
+// 调用方取消了，不换服务商，也不算服务商不健康
+func TestWeightedSMSService_Canceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls []string
+	a := smsmock.NewMockService(ctrl)
+	a.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, tplId string, args []string, numbers ...string) error {
+			calls = append(calls, "a")
+			cancel()
+			return sms.WrapError("aliyun", ctx.Err())
+		})
+	w := NewWeightedSMSService([]WeightedProvider{
+		{Name: "a", Svc: a, Weight: 10},
+		{Name: "b", Svc: scheduled(ctrl, "b", &calls), Weight: 1},
+	}, WithProviderBreaker(1, time.Minute))
+	err := w.Send(ctx, "1", []string{"1"}, "123")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"a"}, calls)
+	node := w.nodes[0]
+	assert.Equal(t, node.weight, node.effective)
+	assert.Equal(t, breaker.Closed, node.breaker.State())
+}
@@ -0,0 +1,237 @@
+package httpsms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"example/wb/internal/service/sms"
+)
+
+// Config 通用的 HTTP 短信网关，URL、Body 和 Header 的值都是 text/template 模板，可以用的数据：
+// .TplId 网关的模板 id（已经按照 Templates 转换过），.Args 模板参数，.Numbers 手机号；
+// 除了内置函数之外还有 join（join .Numbers ","）和 json（把值编码成 JSON）
+type Config struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	// Templates 逻辑上的模板名对应的网关模板 id
+	Templates []sms.TemplateMapping `json:"templates"`
+	// CodePath 响应 JSON 里面表示结果的字段，用点分隔，例如 result.code；
+	// 为空的时候只看 HTTP 状态码是不是 2xx
+	CodePath string `json:"codePath"`
+	// MessagePath 响应 JSON 里面的错误信息，只用来拼接错误
+	MessagePath string `json:"messagePath"`
+	// SuccessCodes CodePath 取出来的值在这里面的才算发送成功
+	SuccessCodes []string `json:"successCodes"`
+	// ErrorCodes 失败的 code 怎么归类，key 是 sms.ErrorKind 的名字，例如 throttled、invalid_number；
+	// 没有配置的 code 归到 unknown
+	ErrorCodes map[string][]string `json:"errorCodes"`
+}
+
+type SMSService struct {
+	client    *http.Client
+	templates sms.Templates
+	method    string
+	url       *template.Template
+	body      *template.Template
+	headers   map[string]*template.Template
+	codePath  []string
+	msgPath   []string
+	success   map[string]struct{}
+	kinds     map[string]sms.ErrorKind
+}
+
+// data 模板里面可以用的数据
+type data struct {
+	TplId   string
+	Args    []string
+	Numbers []string
+}
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(v any) (string, error) {
+		val, err := json.Marshal(v)
+		return string(val), err
+	},
+}
+
+// NewService 模板写错了或者配置了 CodePath 却没有 SuccessCodes 的时候返回 error
+func NewService(client *http.Client, cfg Config) (sms.Service, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("短信网关缺少 url")
+	}
+	if cfg.CodePath != "" && len(cfg.SuccessCodes) == 0 {
+		return nil, errors.New("短信网关配置了 codePath 但是没有配置 successCodes")
+	}
+	method := strings.ToUpper(cfg.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	urlTpl, err := template.New("url").Funcs(funcs).Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("短信网关 url 模板错误：%w", err)
+	}
+	bodyTpl, err := template.New("body").Funcs(funcs).Parse(cfg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("短信网关 body 模板错误：%w", err)
+	}
+	headers := make(map[string]*template.Template, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		headers[k], err = template.New(k).Funcs(funcs).Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("短信网关 header %s 模板错误：%w", k, err)
+		}
+	}
+	success := make(map[string]struct{}, len(cfg.SuccessCodes))
+	for _, c := range cfg.SuccessCodes {
+		success[c] = struct{}{}
+	}
+	kinds := make(map[string]sms.ErrorKind)
+	for name, codes := range cfg.ErrorCodes {
+		kind, ok := sms.ParseErrorKind(name)
+		if !ok {
+			return nil, fmt.Errorf("短信网关的错误分类 %s 不存在", name)
+		}
+		for _, c := range codes {
+			kinds[c] = kind
+		}
+	}
+	return &SMSService{
+		client:    client,
+		templates: sms.NewTemplates(cfg.Templates),
+		method:    method,
+		url:       urlTpl,
+		body:      bodyTpl,
+		headers:   headers,
+		codePath:  splitPath(cfg.CodePath),
+		msgPath:   splitPath(cfg.MessagePath),
+		success:   success,
+		kinds:     kinds,
+	}, nil
+}
+
+func (svc *SMSService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	tplId, err := svc.templates.Resolve(provider, tplId)
+	if err != nil {
+		return err
+	}
+	d := data{TplId: tplId, Args: args, Numbers: numbers}
+	u, err := render(svc.url, d)
+	if err != nil {
+		return err
+	}
+	body, err := render(svc.body, d)
+	if err != nil {
+		return err
+	}
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, svc.method, u, reader)
+	if err != nil {
+		return err
+	}
+	for k, tpl := range svc.headers {
+		val, err := render(tpl, d)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(k, val)
+	}
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return sms.WrapError(provider, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return sms.WrapError(provider, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return sms.NewError(statusKind(resp.StatusCode), provider,
+			strconv.Itoa(resp.StatusCode), string(respBody))
+	}
+	if len(svc.codePath) == 0 {
+		return nil
+	}
+	var res any
+	dec := json.NewDecoder(bytes.NewReader(respBody))
+	// 数字类型的 code 保持原样，不要变成 1e+06 这种
+	dec.UseNumber()
+	err = dec.Decode(&res)
+	if err != nil {
+		return &sms.Error{Provider: provider, Msg: "解析短信网关响应失败", Err: err}
+	}
+	code := lookup(res, svc.codePath)
+	if _, ok := svc.success[code]; ok {
+		return nil
+	}
+	return sms.NewError(svc.kinds[code], provider, code, lookup(res, svc.msgPath))
+}
+
+const provider = "http"
+
+// statusKind 网关没有返回业务 code 的时候，只能按照 HTTP 状态码归类
+func statusKind(status int) sms.ErrorKind {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return sms.KindThrottled
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return sms.KindAuthFailure
+	case status == http.StatusGatewayTimeout:
+		return sms.KindTimeout
+	case status >= http.StatusInternalServerError:
+		return sms.KindProviderDown
+	default:
+		return sms.KindUnknown
+	}
+}
+
+func render(tpl *template.Template, d data) (string, error) {
+	var buf strings.Builder
+	err := tpl.Execute(&buf, d)
+	if err != nil {
+		return "", fmt.Errorf("短信网关模板 %s 执行失败：%w", tpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// lookup 按照路径一层一层往下找，找不到的时候返回空字符串
+func lookup(v any, path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	for _, key := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+		v = m[key]
+	}
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,151 @@
+package httpsms
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example/wb/internal/service/sms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMSService_Send(t *testing.T) {
+	jsonCfg := Config{
+		URL: "/send?tpl={{urlquery .TplId}}",
+		Headers: map[string]string{
+			"Content-Type":  "application/json",
+			"Authorization": "Bearer token",
+		},
+		Body:         `{"to":{{json .Numbers}},"params":{{json .Args}}}`,
+		Templates:    []sms.TemplateMapping{{Name: "code", Id: "tpl 1"}},
+		CodePath:     "result.code",
+		MessagePath:  "result.msg",
+		SuccessCodes: []string{"0"},
+		ErrorCodes: map[string][]string{
+			"throttled":      {"1002"},
+			"invalid_number": {"1003", "1004"},
+		},
+	}
+	testCase := []struct {
+		name string
+
+		cfg Config
+		// 服务端返回的状态码和 body
+		status   int
+		respBody string
+
+		wantURL  string
+		wantBody string
+		wantErr  bool
+		wantKind sms.ErrorKind
+	}{
+		{
+			name:     "发送成功",
+			cfg:      jsonCfg,
+			status:   http.StatusOK,
+			respBody: `{"result":{"code":0,"msg":"ok"}}`,
+			wantURL:  "/send?tpl=tpl+1",
+			wantBody: `{"to":["+8613800138000"],"params":["123456"]}`,
+		},
+		{
+			name:     "网关返回的code不是成功",
+			cfg:      jsonCfg,
+			status:   http.StatusOK,
+			respBody: `{"result":{"code":1001,"msg":"余额不足"}}`,
+			wantURL:  "/send?tpl=tpl+1",
+			wantBody: `{"to":["+8613800138000"],"params":["123456"]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "按照配置归类错误码",
+			cfg:      jsonCfg,
+			status:   http.StatusOK,
+			respBody: `{"result":{"code":1004,"msg":"号码格式不对"}}`,
+			wantURL:  "/send?tpl=tpl+1",
+			wantBody: `{"to":["+8613800138000"],"params":["123456"]}`,
+			wantErr:  true,
+			wantKind: sms.KindInvalidNumber,
+		},
+		{
+			name:     "http状态码不是2xx",
+			cfg:      jsonCfg,
+			status:   http.StatusBadGateway,
+			wantURL:  "/send?tpl=tpl+1",
+			wantBody: `{"to":["+8613800138000"],"params":["123456"]}`,
+			wantErr:  true,
+			wantKind: sms.KindProviderDown,
+		},
+		{
+			name:     "网关限流",
+			cfg:      jsonCfg,
+			status:   http.StatusTooManyRequests,
+			wantURL:  "/send?tpl=tpl+1",
+			wantBody: `{"to":["+8613800138000"],"params":["123456"]}`,
+			wantErr:  true,
+			wantKind: sms.KindThrottled,
+		},
+		{
+			name: "只看状态码的GET网关",
+			cfg: Config{
+				Method:    "get",
+				URL:       `/send?to={{join .Numbers ","}}&code={{index .Args 0}}`,
+				Templates: []sms.TemplateMapping{{Name: "code", Id: "tpl 1"}},
+			},
+			status:   http.StatusOK,
+			respBody: "success",
+			wantURL:  "/send?to=+8613800138000&code=123456",
+		},
+		{
+			name: "没有配置模板不请求网关",
+			cfg: Config{
+				URL: "/send",
+			},
+			wantErr:  true,
+			wantKind: sms.KindTemplateRejected,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.wantURL == "" {
+					t.Error("不应该请求网关")
+				}
+				assert.Equal(t, tc.wantURL, r.URL.RequestURI())
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantBody, string(body))
+				if tc.cfg.Headers != nil {
+					assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.respBody))
+			}))
+			defer server.Close()
+
+			cfg := tc.cfg
+			// 表里面只写了路径，加上测试服务器的地址
+			cfg.URL = server.URL + cfg.URL
+			svc, err := NewService(server.Client(), cfg)
+			require.NoError(t, err)
+			err = svc.Send(context.Background(), "code", []string{"123456"}, "+8613800138000")
+			assert.Equal(t, tc.wantErr, err != nil, err)
+			assert.Equal(t, tc.wantKind, sms.KindOf(err))
+		})
+	}
+}
+
+func TestNewService(t *testing.T) {
+	_, err := NewService(http.DefaultClient, Config{URL: "{{.Bad"})
+	assert.Error(t, err)
+	_, err = NewService(http.DefaultClient, Config{URL: "http://localhost", CodePath: "code"})
+	assert.Error(t, err)
+	_, err = NewService(http.DefaultClient, Config{})
+	assert.Error(t, err)
+	_, err = NewService(http.DefaultClient, Config{URL: "http://localhost",
+		ErrorCodes: map[string][]string{"bad": {"1"}}})
+	assert.Error(t, err)
+}
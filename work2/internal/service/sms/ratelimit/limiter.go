@@ -2,12 +2,12 @@ package ratelimit
 
 import (
 	"context"
-	"errors"
 	"example/wb/internal/service/sms"
 	"example/wb/pkg/limiter"
 )
 
-var ErrSMSLimitRate = errors.New("短信发送频繁，触发了限流")
+// ErrSMSLimitRate 是 sms.KindThrottled，上层可以按照分类决定要不要转异步
+var ErrSMSLimitRate = sms.NewError(sms.KindThrottled, "", "", "短信发送频繁，触发了限流")
 
 // 此种情况的svc需要实现sms.Service
 // 接口中的所有方法
@@ -40,7 +40,8 @@ func NewRateLimitSMSService(svc sms.Service, l limiter.Limiter) sms.Service {
 func (r *RateLimitSMSService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
 	limited, err := r.limiter.Limit(ctx, r.key)
 	if err != nil {
-		return ErrSMSLimitRate
+		// 限流器本身出错了，不知道有没有超过限制，交给上层处理
+		return err
 	}
 	if limited {
 		return ErrSMSLimitRate
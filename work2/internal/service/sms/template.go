@@ -0,0 +1,33 @@
+package sms
+
+import "fmt"
+
+// TemplateMapping 业务里面用的是逻辑上的模板名，例如 code，
+// 每个服务商审核通过的模板 ID 都不一样，要分别配置
+type TemplateMapping struct {
+	Name string `json:"name"`
+	Id   string `json:"id"`
+}
+
+// Templates key 是逻辑上的模板名，value 是服务商的模板 ID。
+// viper 会把 map 的 key 转成小写，所以配置里面用 TemplateMapping 的列表
+type Templates map[string]string
+
+func NewTemplates(mappings []TemplateMapping) Templates {
+	res := make(Templates, len(mappings))
+	for _, m := range mappings {
+		res[m.Name] = m.Id
+	}
+	return res
+}
+
+// Resolve 找到服务商的模板 ID。没有配置的模板当作模板被拒绝，
+// 交给 failover 换一个配置了这个模板的服务商
+func (t Templates) Resolve(provider string, name string) (string, error) {
+	id, ok := t[name]
+	if !ok || id == "" {
+		return "", NewError(KindTemplateRejected, provider, "",
+			fmt.Sprintf("没有配置模板 %s", name))
+	}
+	return id, nil
+}
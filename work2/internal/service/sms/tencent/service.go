@@ -2,7 +2,7 @@ package tencent
 
 import (
 	"context"
-	"fmt"
+	"strings"
 
 	smsl "example/wb/internal/service/sms"
 
@@ -14,17 +14,19 @@ import (
 )
 
 type SMSSerivce struct {
-	client   *sms.Client
-	appId    string
-	signName string
+	client    *sms.Client
+	appId     string
+	signName  string
+	templates smsl.Templates
 }
 
-func NewService(client *sms.Client, appId string, signName string) smsl.Service {
+func NewService(client *sms.Client, appId string, signName string, templates smsl.Templates) smsl.Service {
 
 	return &SMSSerivce{
-		client:   client,
-		appId:    appId,
-		signName: signName,
+		client:    client,
+		appId:     appId,
+		signName:  signName,
+		templates: templates,
 	}
 }
 
@@ -43,6 +45,10 @@ func NewSMSClient() *sms.Client {
 }
 
 func (svc *SMSSerivce) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	tplId, err := svc.templates.Resolve(provider, tplId)
+	if err != nil {
+		return err
+	}
 	// crd := common.NewCredential("SecreId", "SecretKey")
 
 	// cpf := profile.NewClientProfile()
@@ -86,14 +92,12 @@ func (svc *SMSSerivce) Send(ctx context.Context, tplId string, args []string, nu
 	zap.L().Debug("请求腾讯SendSMS",
 		zap.Any("req", request), zap.Any("resp", response))
 	// 处理异常
-	if _, ok := err.(*errors.TencentCloudSDKError); ok {
-		fmt.Printf("An API error has returned: %s", err)
-		return nil
+	if se, ok := err.(*errors.TencentCloudSDKError); ok {
+		return smsl.NewError(kindOf(se.Code), provider, se.Code, se.Message)
 	}
-	// 非SDK异常，直接失败。实际代码中可以加入其他的处理。
+	// 非SDK异常，一般是网络问题
 	if err != nil {
-		fmt.Printf("An API error has returned: %s", err)
-		return nil
+		return smsl.WrapError(provider, err)
 	}
 	for _, statusPtr := range response.Response.SendStatusSet {
 		if statusPtr == nil {
@@ -102,10 +106,49 @@ func (svc *SMSSerivce) Send(ctx context.Context, tplId string, args []string, nu
 		}
 		status := *statusPtr
 		if status.Code == nil || *(status.Code) != "Ok" {
-			return fmt.Errorf("发送短信失败，code：%s，msg：%s", *status.Code, *status.Message)
-
+			var code, msg string
+			if status.Code != nil {
+				code = *status.Code
+			}
+			if status.Message != nil {
+				msg = *status.Message
+			}
+			return smsl.NewError(kindOf(code), provider, code, msg)
 		}
 
 	}
 	return nil
 }
+
+const provider = "tencent"
+
+// kindOf 腾讯云的错误码是分层的，前缀就能看出大类，
+// 参考 https://cloud.tencent.com/document/product/382/55981
+func kindOf(code string) smsl.ErrorKind {
+	switch {
+	case strings.HasPrefix(code, "AuthFailure"),
+		strings.HasPrefix(code, "UnauthorizedOperation"),
+		code == "FailedOperation.SignatureIncorrectOrUnapproved":
+		return smsl.KindAuthFailure
+	case strings.HasPrefix(code, "LimitExceeded"),
+		code == "RequestLimitExceeded":
+		return smsl.KindThrottled
+	case code == "InvalidParameterValue.IncorrectPhoneNumber",
+		code == "FailedOperation.PhoneNumberInBlacklist",
+		code == "UnsupportedOperation.ContainDomesticAndInternationalPhoneNumber",
+		code == "UnsupportedOperation.UnsupportedRegion":
+		return smsl.KindInvalidNumber
+	case strings.HasPrefix(code, "FailedOperation.Template"),
+		code == "InvalidParameterValue.TemplateParameterFormatError",
+		code == "InvalidParameterValue.TemplateParameterLengthLimit",
+		code == "FailedOperation.MissingTemplateToModify":
+		return smsl.KindTemplateRejected
+	case strings.HasPrefix(code, "InternalError"),
+		code == "FailedOperation.InsufficientBalanceInSmsPackage",
+		code == "ResourceUnavailable",
+		code == "ServiceUnavailable":
+		return smsl.KindProviderDown
+	default:
+		return smsl.KindUnknown
+	}
+}
@@ -0,0 +1,28 @@
+package tencent
+
+import (
+	"testing"
+
+	smsl "example/wb/internal/service/sms"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	testCase := map[string]smsl.ErrorKind{
+		"AuthFailure.SecretIdNotFound":                    smsl.KindAuthFailure,
+		"FailedOperation.SignatureIncorrectOrUnapproved":  smsl.KindAuthFailure,
+		"LimitExceeded.PhoneNumberDailyLimit":             smsl.KindThrottled,
+		"RequestLimitExceeded":                            smsl.KindThrottled,
+		"InvalidParameterValue.IncorrectPhoneNumber":      smsl.KindInvalidNumber,
+		"FailedOperation.TemplateIncorrectOrUnapproved":   smsl.KindTemplateRejected,
+		"InternalError.Timeout":                           smsl.KindProviderDown,
+		"FailedOperation.InsufficientBalanceInSmsPackage": smsl.KindProviderDown,
+		"Whatever": smsl.KindUnknown,
+	}
+	for code, want := range testCase {
+		t.Run(code, func(t *testing.T) {
+			assert.Equal(t, want, kindOf(code))
+		})
+	}
+}
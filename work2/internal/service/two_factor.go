@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/repository"
+	"example/wb/pkg/totp"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrTwoFactorEnabled = errs.New(http.StatusBadRequest, errs.TwoFactorEnabled, "两步验证已经开启")
+var ErrTwoFactorNotEnrolled = errs.New(http.StatusBadRequest, errs.TwoFactorNotEnrolled, "请先绑定两步验证")
+var ErrInvalidTwoFactorCode = errs.New(http.StatusBadRequest, errs.TwoFactorInvalidCode, "验证码不对，请重新输入")
+
+const (
+	totpIssuer        = "webook"
+	recoveryCodeCount = 10
+)
+
+type TwoFactorService interface {
+	// Enroll 生成新的密钥和恢复码，此时还没有开启，需要调用 Enable 验证一次
+	Enroll(ctx context.Context, uid int64) (domain.TOTPEnrollment, error)
+	Enable(ctx context.Context, uid int64, code string) error
+	IsEnabled(ctx context.Context, uid int64) (bool, error)
+	// Verify 校验 TOTP 验证码，或者一个还没用过的恢复码
+	Verify(ctx context.Context, uid int64, code string) (bool, error)
+}
+
+type twoFactorService struct {
+	repo     repository.TwoFactorRepository
+	userRepo repository.UserRepository
+	totp     *totp.TOTP
+}
+
+func NewTwoFactorService(repo repository.TwoFactorRepository,
+	userRepo repository.UserRepository) TwoFactorService {
+	return &twoFactorService{
+		repo:     repo,
+		userRepo: userRepo,
+		totp:     totp.New(),
+	}
+}
+
+func (svc *twoFactorService) Enroll(ctx context.Context, uid int64) (domain.TOTPEnrollment, error) {
+	tf, err := svc.repo.FindByUid(ctx, uid)
+	switch err {
+	case nil:
+		if tf.Enabled {
+			return domain.TOTPEnrollment{}, ErrTwoFactorEnabled
+		}
+	case repository.ErrTwoFactorNotFound:
+	default:
+		return domain.TOTPEnrollment{}, err
+	}
+	u, err := svc.userRepo.FindById(ctx, uid)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	hashed := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := svc.recoveryCode()
+		if err != nil {
+			return domain.TOTPEnrollment{}, err
+		}
+		codes = append(codes, code)
+		hashed = append(hashed, svc.hash(code))
+	}
+	err = svc.repo.Save(ctx, domain.TwoFactor{
+		Uid:           uid,
+		Secret:        secret,
+		RecoveryCodes: hashed,
+	})
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	return domain.TOTPEnrollment{
+		Secret:        secret,
+		URI:           svc.totp.URI(totpIssuer, svc.account(u), secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (svc *twoFactorService) Enable(ctx context.Context, uid int64, code string) error {
+	tf, err := svc.repo.FindByUid(ctx, uid)
+	if err == repository.ErrTwoFactorNotFound {
+		return ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if tf.Enabled {
+		return ErrTwoFactorEnabled
+	}
+	// 开启的时候只接受 TOTP，确认用户的 App 已经绑定成功
+	step, ok := svc.totp.Match(tf.Secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	ok, err = svc.repo.UseTOTPStep(ctx, uid, step, svc.totp.Window())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return svc.repo.Enable(ctx, uid)
+}
+
+func (svc *twoFactorService) IsEnabled(ctx context.Context, uid int64) (bool, error) {
+	tf, err := svc.repo.FindByUid(ctx, uid)
+	if err == repository.ErrTwoFactorNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+func (svc *twoFactorService) Verify(ctx context.Context, uid int64, code string) (bool, error) {
+	tf, err := svc.repo.FindByUid(ctx, uid)
+	if err == repository.ErrTwoFactorNotFound {
+		return false, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return false, err
+	}
+	if !tf.Enabled {
+		return false, ErrTwoFactorNotEnrolled
+	}
+	// 误差窗口内同一个验证码会一直有效，每个用户只接受比上次更晚的时间步，
+	// 被别人看到或者截获的验证码就不能再用一次
+	if step, ok := svc.totp.Match(tf.Secret, code, time.Now()); ok {
+		return svc.repo.UseTOTPStep(ctx, uid, step, svc.totp.Window())
+	}
+	// 不是 TOTP，再试试恢复码，用过的恢复码要删掉
+	hashed := svc.hash(code)
+	for i, val := range tf.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(val), []byte(hashed)) != 1 {
+			continue
+		}
+		tf.RecoveryCodes = append(append([]string{}, tf.RecoveryCodes[:i]...), tf.RecoveryCodes[i+1:]...)
+		err = svc.repo.UpdateRecoveryCodes(ctx, tf)
+		if err == repository.ErrTwoFactorConflict {
+			// 并发使用了同一个恢复码，只能有一个成功
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (svc *twoFactorService) account(u domain.User) string {
+	if u.Email != "" {
+		return u.Email
+	}
+	if u.Phone != "" {
+		return u.Phone
+	}
+	return strconv.FormatInt(u.Id, 10)
+}
+
+// recoveryCode 生成形如 abcde-fghij 的恢复码
+func (svc *twoFactorService) recoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func (svc *twoFactorService) hash(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,251 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	"example/wb/pkg/totp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTwoFactorService_Verify(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	now := time.Now()
+	code, err := totp.New().Code(secret, now)
+	assert.NoError(t, err)
+	step := now.Unix() / 30
+	hash := func(code string) string {
+		sum := sha256.Sum256([]byte(code))
+		return hex.EncodeToString(sum[:])
+	}
+
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) repository.TwoFactorRepository
+
+		code string
+
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "TOTP 正确",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{Uid: 1, Secret: secret, Enabled: true}, nil)
+				repo.EXPECT().UseTOTPStep(gomock.Any(), int64(1), step, time.Second*90).
+					Return(true, nil)
+				return repo
+			},
+			code: code,
+			want: true,
+		},
+		{
+			name: "TOTP 已经用过了",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{Uid: 1, Secret: secret, Enabled: true}, nil)
+				repo.EXPECT().UseTOTPStep(gomock.Any(), int64(1), step, time.Second*90).
+					Return(false, nil)
+				return repo
+			},
+			code: code,
+			want: false,
+		},
+		{
+			name: "记录时间步失败",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{Uid: 1, Secret: secret, Enabled: true}, nil)
+				repo.EXPECT().UseTOTPStep(gomock.Any(), int64(1), step, time.Second*90).
+					Return(false, errors.New("redis错误"))
+				return repo
+			},
+			code:    code,
+			want:    false,
+			wantErr: errors.New("redis错误"),
+		},
+		{
+			name: "恢复码正确",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{
+						Uid:           1,
+						Secret:        secret,
+						Enabled:       true,
+						RecoveryCodes: []string{hash("aaaaa-bbbbb"), hash("ccccc-ddddd")},
+						Utime:         123,
+					}, nil)
+				// 用过的恢复码要删掉
+				repo.EXPECT().UpdateRecoveryCodes(gomock.Any(), domain.TwoFactor{
+					Uid:           1,
+					Secret:        secret,
+					Enabled:       true,
+					RecoveryCodes: []string{hash("ccccc-ddddd")},
+					Utime:         123,
+				}).Return(nil)
+				return repo
+			},
+			code: "AAAAA-BBBBB",
+			want: true,
+		},
+		{
+			name: "恢复码被并发使用",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{
+						Uid:           1,
+						Secret:        secret,
+						Enabled:       true,
+						RecoveryCodes: []string{hash("aaaaa-bbbbb")},
+					}, nil)
+				repo.EXPECT().UpdateRecoveryCodes(gomock.Any(), gomock.Any()).
+					Return(repository.ErrTwoFactorConflict)
+				return repo
+			},
+			code: "aaaaa-bbbbb",
+			want: false,
+		},
+		{
+			name: "验证码不对",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{
+						Uid:           1,
+						Secret:        secret,
+						Enabled:       true,
+						RecoveryCodes: []string{hash("aaaaa-bbbbb")},
+					}, nil)
+				return repo
+			},
+			code: "000000",
+			want: false,
+		},
+		{
+			name: "没有开启",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{Uid: 1, Secret: secret}, nil)
+				return repo
+			},
+			code:    code,
+			wantErr: service.ErrTwoFactorNotEnrolled,
+		},
+		{
+			name: "数据库错误",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{}, errors.New("db错误"))
+				return repo
+			},
+			code:    code,
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewTwoFactorService(tt.mock(ctrl), repomock.NewMockUserRepository(ctrl))
+			ok, err := svc.Verify(context.Background(), 1, tt.code)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestTwoFactorService_Enable(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	now := time.Now()
+	code, err := totp.New().Code(secret, now)
+	assert.NoError(t, err)
+	step := now.Unix() / 30
+
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) repository.TwoFactorRepository
+
+		code string
+
+		wantErr error
+	}{
+		{
+			name: "开启成功",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{Uid: 1, Secret: secret}, nil)
+				repo.EXPECT().UseTOTPStep(gomock.Any(), int64(1), step, time.Second*90).
+					Return(true, nil)
+				repo.EXPECT().Enable(gomock.Any(), int64(1)).Return(nil)
+				return repo
+			},
+			code: code,
+		},
+		{
+			name: "验证码已经用过了",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{Uid: 1, Secret: secret}, nil)
+				repo.EXPECT().UseTOTPStep(gomock.Any(), int64(1), step, time.Second*90).
+					Return(false, nil)
+				return repo
+			},
+			code:    code,
+			wantErr: service.ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "验证码不对",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{Uid: 1, Secret: secret}, nil)
+				return repo
+			},
+			code:    "000000",
+			wantErr: service.ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "没有绑定",
+			mock: func(ctrl *gomock.Controller) repository.TwoFactorRepository {
+				repo := repomock.NewMockTwoFactorRepository(ctrl)
+				repo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.TwoFactor{}, repository.ErrTwoFactorNotFound)
+				return repo
+			},
+			code:    code,
+			wantErr: service.ErrTwoFactorNotEnrolled,
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewTwoFactorService(tt.mock(ctrl), repomock.NewMockUserRepository(ctrl))
+			err := svc.Enable(context.Background(), 1, tt.code)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -2,48 +2,56 @@ package service
 
 import (
 	"context"
-	"errors"
 	"example/wb/internal/domain"
+	"example/wb/internal/errs"
 	"example/wb/internal/repository"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
+	"example/wb/pkg/logger"
+	"example/wb/pkg/password"
+	"net/http"
 )
 
-var ErrDuplicateUser = repository.ErrDuplicateUser
-var ErrInvalidUserOrPassword = errors.New("用户名或者密码不对")
+var ErrDuplicateUser = errs.New(http.StatusConflict, errs.UserDuplicateEmail, "邮箱已经被注册")
+var ErrInvalidUserOrPassword = errs.New(http.StatusBadRequest, errs.UserInvalidOrPassword, "用户名或者密码不对")
+var ErrUserBanned = errs.New(http.StatusForbidden, errs.UserBanned, "账号已被封禁")
 
 type UserService interface {
 	SignUp(ctx context.Context, u domain.User) error
+	// Login 账号存在但是不能登录的时候，返回的 User 里面只有 Id，用来记审计日志
 	Login(ctx context.Context, u domain.User) (domain.User, error)
 	FindOrCreate(ctx context.Context, phone string) (domain.User, error)
-	FindOrCreateByWechat(ctx context.Context, wechatInfo domain.WechatInfo) (domain.User, error)
+	// FindByPhone 只查不创建
+	FindByPhone(ctx context.Context, phone string) (domain.User, error)
+	FindOrCreateByIdentity(ctx context.Context, identity domain.Identity) (domain.User, error)
 	Edit(ctx context.Context, u domain.User) error
 	Profile(ctx context.Context, id int64) (domain.User, error)
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo         repository.UserRepository
+	identityRepo repository.IdentityRepository
+	hasher       password.Hasher
+	l            logger.Logger
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
+func NewUserService(repo repository.UserRepository, identityRepo repository.IdentityRepository,
+	hasher password.Hasher, l logger.Logger) UserService {
 	return &userService{
-		repo: repo,
+		repo:         repo,
+		identityRepo: identityRepo,
+		hasher:       hasher,
+		l:            l,
 	}
 }
 
 func (svc *userService) SignUp(ctx context.Context, u domain.User) error {
-	bcryptd, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	hash, err := svc.hasher.Hash(u.Password)
 	if err != nil {
-		return errors.New("系统错误")
-
+		return errs.Internal(err)
 	}
-	u.Password = string(bcryptd)
+	u.Password = hash
 	err = svc.repo.Create(ctx, u)
-	if err == ErrDuplicateUser {
-		return err
+	if err == repository.ErrDuplicateUser {
+		return ErrDuplicateUser
 	}
 	return err
 }
@@ -54,13 +62,35 @@ func (svc *userService) Login(ctx context.Context, u domain.User) (domain.User,
 		return user, ErrInvalidUserOrPassword
 	}
 	if err != nil {
-		return user, errors.New("系统错误")
+		return user, errs.Internal(err)
+	}
+	// 没有设置密码或者哈希格式不对，都当作密码不对
+	ok, err := svc.hasher.Verify(user.Password, u.Password)
+	if err != nil || !ok {
+		return domain.User{Id: user.Id}, ErrInvalidUserOrPassword
+	}
+	err = checkActive(user)
+	if err != nil {
+		return domain.User{Id: user.Id}, err
+	}
+	svc.rehash(ctx, user.Id, user.Password, u.Password)
+	return user, nil
+}
+
+// rehash 只有登录的时候才拿得到明文，趁这个机会把老的算法或者参数升级上去。
+// 升级失败不影响这次登录，下次登录再试
+func (svc *userService) rehash(ctx context.Context, uid int64, hash, pwd string) {
+	if !svc.hasher.NeedsRehash(hash) {
+		return
+	}
+	newHash, err := svc.hasher.Hash(pwd)
+	if err == nil {
+		// 登录的同时用户可能刚好改了密码，只替换读出来的那个哈希
+		err = svc.repo.UpdatePassword(ctx, uid, hash, newHash)
 	}
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(u.Password))
 	if err != nil {
-		return domain.User{}, ErrInvalidUserOrPassword
+		svc.l.Error("升级密码哈希失败", logger.Int64("uid", uid), logger.Error(err))
 	}
-	return user, err
 }
 
 func (svc *userService) FindOrCreate(ctx context.Context, phone string) (domain.User, error) {
@@ -70,7 +100,13 @@ func (svc *userService) FindOrCreate(ctx context.Context, phone string) (domain.
 		// 有两种情况
 		// 1. err == nil, u是可用的
 		// 2. err != nil, 系统错误
-		return u, err
+		if err == nil {
+			err = checkActive(u)
+		}
+		if err != nil {
+			return domain.User{}, err
+		}
+		return u, nil
 	}
 	// 用户没有找到
 	err = svc.repo.Create(ctx, domain.User{
@@ -78,7 +114,7 @@ func (svc *userService) FindOrCreate(ctx context.Context, phone string) (domain.
 	})
 	// 有两种错误用户, 唯一索引冲出（phone）
 	// 一种是err != nil, 系统错误
-	if err != nil && err != ErrDuplicateUser {
+	if err != nil && err != repository.ErrDuplicateUser {
 		return domain.User{}, err
 	}
 	// 要么err == nil, err==ErrDuplicateUser, 代表用户存在
@@ -87,44 +123,67 @@ func (svc *userService) FindOrCreate(ctx context.Context, phone string) (domain.
 
 }
 
-func (svc *userService) FindOrCreateByWechat(ctx context.Context, wechatInfo domain.WechatInfo) (domain.User, error) {
+func (svc *userService) FindByPhone(ctx context.Context, phone string) (domain.User, error) {
+	return svc.repo.FindByPhone(ctx, phone)
+}
 
-	// 先找一下数据库，我们认为大部分用户都存在
-	u, err := svc.repo.FindByWechat(ctx, wechatInfo.Openid)
-	if err != repository.ErrUserNotFound {
-		return u, err
+func (svc *userService) FindOrCreateByIdentity(ctx context.Context, identity domain.Identity) (domain.User, error) {
+	// 先找一下绑定关系，我们认为大部分用户都存在
+	i, err := svc.identityRepo.FindByProvider(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return svc.activeUser(ctx, i.Uid)
 	}
-	// 用户没有找到
-	// json格式wechatInfo
-	zap.L().Info("新用户", zap.Any("wechatInfo", wechatInfo))
-	err = svc.repo.Create(ctx, domain.User{
-		WechatInfo: wechatInfo,
-	})
-	// 有两种错误用户, 唯一索引冲出（phone）
-	// 一种是err != nil, 系统错误
-	if err != nil && err != ErrDuplicateUser {
+	if err != repository.ErrIdentityNotFound {
 		return domain.User{}, err
 	}
-	// 要么err == nil, err==ErrDuplicateUser, 代表用户存在
-	// 主从延迟，理论上来讲，强制走主库
-	return svc.repo.FindByWechat(ctx, wechatInfo.Openid)
+	uid, err := svc.identityRepo.CreateWithUser(ctx, identity)
+	if err == nil {
+		return domain.User{Id: uid}, nil
+	}
+	if err != repository.ErrDuplicateIdentity {
+		return domain.User{}, err
+	}
+	// 并发回调的时候别的请求已经创建好了，强制走主库再查一次
+	i, err = svc.identityRepo.FindByProvider(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return domain.User{Id: i.Uid}, nil
 }
 
-func (svc *userService) Edit(ctx context.Context, u domain.User) error {
-	sess := sessions.Default(ctx.(*gin.Context))
-	uid := sess.Get("userId")
-	me, ok := uid.(int64)
-	if !ok {
-		return errors.New("系统出错")
+// activeUser 已经绑定过的用户，要检查一下还能不能登录
+func (svc *userService) activeUser(ctx context.Context, uid int64) (domain.User, error) {
+	u, err := svc.repo.FindById(ctx, uid)
+	if err != nil {
+		return domain.User{}, err
 	}
-	u.Id = me
+	err = checkActive(u)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return domain.User{Id: uid}, nil
+}
+
+// checkActive 冷静期内或者被封禁的账号都不允许登录
+func checkActive(u domain.User) error {
+	if u.PendingDeletion() {
+		return ErrAccountDeleted
+	}
+	if u.Banned() {
+		return ErrUserBanned
+	}
+	return nil
+}
+
+// Edit u.Id 由调用者从登录信息里面取出来，不能相信前端传的
+func (svc *userService) Edit(ctx context.Context, u domain.User) error {
 	return svc.repo.UpdateById(ctx, u)
 }
 
 func (svc *userService) Profile(ctx context.Context, id int64) (domain.User, error) {
 	u, err := svc.repo.FindById(ctx, id)
 	if err != nil {
-		return domain.User{}, errors.New("系统出错")
+		return domain.User{}, errs.Internal(err)
 	}
 	return u, nil
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"example/wb/internal/domain"
+	"example/wb/internal/errs"
 	"example/wb/internal/repository"
 	repomock "example/wb/internal/repository/mock"
 	"example/wb/internal/service"
+	"example/wb/pkg/logger"
+	"example/wb/pkg/password"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -23,7 +28,26 @@ func TestPasswordEncrypt(t *testing.T) {
 
 }
 
+// newTestHasher 和线上一样新密码用 argon2id，参数小一点，不然测试跑得太慢
+func newTestHasher() password.Hasher {
+	return password.NewVersioned(password.NewArgon2id(password.Argon2Params{
+		Memory:      1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}), password.NewBcrypt(bcrypt.DefaultCost))
+}
+
 func TestUserService_Login(t *testing.T) {
+	hasher := newTestHasher()
+	argonHash, err := hasher.Hash("dioa@W524")
+	assert.NoError(t, err)
+	isArgon2id := gomock.Cond(func(x any) bool {
+		hash, ok := x.(string)
+		return ok && strings.HasPrefix(hash, "$argon2id$")
+	})
+
 	testCase := []struct {
 		name string
 
@@ -36,16 +60,19 @@ func TestUserService_Login(t *testing.T) {
 		wantUser domain.User
 	}{
 		{
-			name: "登录成功",
+			name: "登录成功，老的bcrypt哈希升级成argon2id",
 
 			mock: func(ctrl *gomock.Controller) repository.UserRepository {
 				repo := repomock.NewMockUserRepository(ctrl)
 				repo.EXPECT().
 					FindByEmail(gomock.Any(), "12321@qq.com").
 					Return(domain.User{
+						Id:       1,
 						Email:    "12321@qq.com",
 						Password: "$2a$10$wkH/UH/yQix09TOq.CIE3O3b.m84KuROb3/E90GU9QYHistAjbTCm",
 					}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), int64(1),
+					"$2a$10$wkH/UH/yQix09TOq.CIE3O3b.m84KuROb3/E90GU9QYHistAjbTCm", isArgon2id).Return(nil)
 				return repo
 			},
 
@@ -57,6 +84,54 @@ func TestUserService_Login(t *testing.T) {
 			wantErr: nil,
 
 			wantUser: domain.User{
+				Id:       1,
+				Email:    "12321@qq.com",
+				Password: "$2a$10$wkH/UH/yQix09TOq.CIE3O3b.m84KuROb3/E90GU9QYHistAjbTCm",
+			},
+		},
+		{
+			name: "登录成功，已经是最新的哈希",
+
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomock.NewMockUserRepository(ctrl)
+				repo.EXPECT().
+					FindByEmail(gomock.Any(), "12321@qq.com").
+					Return(domain.User{Id: 1, Email: "12321@qq.com", Password: argonHash}, nil)
+				return repo
+			},
+
+			u: domain.User{
+				Email:    "12321@qq.com",
+				Password: "dioa@W524",
+			},
+
+			wantUser: domain.User{Id: 1, Email: "12321@qq.com", Password: argonHash},
+		},
+		{
+			name: "升级哈希失败不影响登录",
+
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomock.NewMockUserRepository(ctrl)
+				repo.EXPECT().
+					FindByEmail(gomock.Any(), "12321@qq.com").
+					Return(domain.User{
+						Id:       1,
+						Email:    "12321@qq.com",
+						Password: "$2a$10$wkH/UH/yQix09TOq.CIE3O3b.m84KuROb3/E90GU9QYHistAjbTCm",
+					}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), int64(1),
+					"$2a$10$wkH/UH/yQix09TOq.CIE3O3b.m84KuROb3/E90GU9QYHistAjbTCm", isArgon2id).
+					Return(errors.New("db错误"))
+				return repo
+			},
+
+			u: domain.User{
+				Email:    "12321@qq.com",
+				Password: "dioa@W524",
+			},
+
+			wantUser: domain.User{
+				Id:       1,
 				Email:    "12321@qq.com",
 				Password: "$2a$10$wkH/UH/yQix09TOq.CIE3O3b.m84KuROb3/E90GU9QYHistAjbTCm",
 			},
@@ -77,7 +152,7 @@ func TestUserService_Login(t *testing.T) {
 				Password: "dioa@W524",
 			},
 
-			wantErr: errors.New("系统错误"),
+			wantErr: errs.Internal(errors.New("db错误")),
 
 			wantUser: domain.User{},
 		},
@@ -104,6 +179,45 @@ func TestUserService_Login(t *testing.T) {
 
 			wantUser: domain.User{},
 		},
+		{
+			name: "argon2id密码不对",
+
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomock.NewMockUserRepository(ctrl)
+				repo.EXPECT().
+					FindByEmail(gomock.Any(), "12321@qq.com").
+					Return(domain.User{Id: 1, Email: "12321@qq.com", Password: argonHash}, nil)
+				return repo
+			},
+
+			u: domain.User{
+				Email:    "12321@qq.com",
+				Password: "dioa@W52334",
+			},
+
+			wantErr: service.ErrInvalidUserOrPassword,
+			// 登录失败也要记到这个用户的审计日志里面
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "手机号注册的用户没有密码",
+
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomock.NewMockUserRepository(ctrl)
+				repo.EXPECT().
+					FindByEmail(gomock.Any(), "12321@qq.com").
+					Return(domain.User{Id: 1, Email: "12321@qq.com"}, nil)
+				return repo
+			},
+
+			u: domain.User{
+				Email:    "12321@qq.com",
+				Password: "dioa@W524",
+			},
+
+			wantErr:  service.ErrInvalidUserOrPassword,
+			wantUser: domain.User{Id: 1},
+		},
 	}
 
 	for _, tc := range testCase {
@@ -112,7 +226,7 @@ func TestUserService_Login(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := tc.mock(ctrl)
-			svc := service.NewUserService(repo)
+			svc := service.NewUserService(repo, nil, hasher, logger.NewNopLogger())
 
 			var c context.Context
 			u, err := svc.Login(c, tc.u)
@@ -125,3 +239,143 @@ func TestUserService_Login(t *testing.T) {
 	}
 
 }
+
+func TestUserService_FindOrCreateByIdentity(t *testing.T) {
+	identity := domain.Identity{
+		Provider: "github",
+		Subject:  "123",
+		Name:     "octocat",
+	}
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (repository.UserRepository, repository.IdentityRepository)
+
+		wantErr  error
+		wantUser domain.User
+	}{
+		{
+			name: "已经绑定过",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IdentityRepository) {
+				repo := repomock.NewMockIdentityRepository(ctrl)
+				repo.EXPECT().FindByProvider(gomock.Any(), "github", "123").
+					Return(domain.Identity{Uid: 1, Provider: "github", Subject: "123"}, nil)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				return userRepo, repo
+			},
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "账号正在注销",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IdentityRepository) {
+				repo := repomock.NewMockIdentityRepository(ctrl)
+				repo.EXPECT().FindByProvider(gomock.Any(), "github", "123").
+					Return(domain.Identity{Uid: 1, Provider: "github", Subject: "123"}, nil)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(1)).
+					Return(domain.User{Id: 1, DeleteAt: time.Now().Add(time.Hour)}, nil)
+				return userRepo, repo
+			},
+			wantErr: service.ErrAccountDeleted,
+		},
+		{
+			name: "新用户",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IdentityRepository) {
+				repo := repomock.NewMockIdentityRepository(ctrl)
+				repo.EXPECT().FindByProvider(gomock.Any(), "github", "123").
+					Return(domain.Identity{}, repository.ErrIdentityNotFound)
+				repo.EXPECT().CreateWithUser(gomock.Any(), identity).Return(int64(2), nil)
+				return repomock.NewMockUserRepository(ctrl), repo
+			},
+			wantUser: domain.User{Id: 2},
+		},
+		{
+			name: "并发创建",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IdentityRepository) {
+				repo := repomock.NewMockIdentityRepository(ctrl)
+				repo.EXPECT().FindByProvider(gomock.Any(), "github", "123").
+					Return(domain.Identity{}, repository.ErrIdentityNotFound)
+				repo.EXPECT().CreateWithUser(gomock.Any(), identity).
+					Return(int64(0), repository.ErrDuplicateIdentity)
+				repo.EXPECT().FindByProvider(gomock.Any(), "github", "123").
+					Return(domain.Identity{Uid: 3, Provider: "github", Subject: "123"}, nil)
+				return repomock.NewMockUserRepository(ctrl), repo
+			},
+			wantUser: domain.User{Id: 3},
+		},
+		{
+			name: "数据库错误",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.IdentityRepository) {
+				repo := repomock.NewMockIdentityRepository(ctrl)
+				repo.EXPECT().FindByProvider(gomock.Any(), "github", "123").
+					Return(domain.Identity{}, errors.New("db错误"))
+				return repomock.NewMockUserRepository(ctrl), repo
+			},
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo, identityRepo := tc.mock(ctrl)
+			svc := service.NewUserService(userRepo, identityRepo, newTestHasher(), logger.NewNopLogger())
+			u, err := svc.FindOrCreateByIdentity(context.Background(), identity)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, u)
+		})
+	}
+}
+
+func TestUserService_Edit(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+
+		u domain.User
+
+		wantErr error
+	}{
+		{
+			name: "保存验证码渠道",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomock.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateById(gomock.Any(), domain.User{
+					Id:          1,
+					NickName:    "昵称",
+					CodeChannel: domain.CodeChannelEmail,
+				}).Return(nil)
+				return repo
+			},
+			u: domain.User{
+				Id:          1,
+				NickName:    "昵称",
+				CodeChannel: domain.CodeChannelEmail,
+			},
+		},
+		{
+			name: "数据库错误",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomock.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateById(gomock.Any(), gomock.Any()).
+					Return(errors.New("db错误"))
+				return repo
+			},
+			u:       domain.User{Id: 1, CodeChannel: domain.CodeChannelSMS},
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := service.NewUserService(tc.mock(ctrl), nil, nil, logger.NewNopLogger())
+			err := svc.Edit(context.Background(), tc.u)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
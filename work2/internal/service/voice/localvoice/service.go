@@ -0,0 +1,19 @@
+package localvoice
+
+import (
+	"context"
+	"example/wb/internal/service/voice"
+	"log"
+)
+
+type LocalService struct {
+}
+
+func NewLocalService() voice.Service {
+	return &LocalService{}
+}
+
+func (s *LocalService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	log.Println("语音验证码是：", args, numbers)
+	return nil
+}
@@ -0,0 +1,8 @@
+package voice
+
+import "context"
+
+// Service 语音验证码，打电话把模板念给用户听，签名和 sms.Service 保持一致
+type Service interface {
+	Send(ctx context.Context, tplId string, args []string, numbers ...string) error
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/repository"
+	"example/wb/internal/service/oauth2/wechat"
+	"example/wb/pkg/logger"
+	"net/http"
+	"time"
+)
+
+var ErrInvalidWechatCode = errs.New(http.StatusBadRequest, errs.OAuth2InvalidCode, "授权码有误")
+var ErrWechatTokenExpired = errs.New(http.StatusUnauthorized, errs.OAuth2TokenExpired, "微信授权已经过期，需要重新授权")
+
+// WechatLoginService 微信扫码登录，并且保存每个用户的微信凭证
+type WechatLoginService interface {
+	// Login 第一次登录会创建用户，并且用微信的昵称和头像填充资料
+	Login(ctx context.Context, code string) (domain.User, error)
+	// AccessToken 获得用户可用的 access_token，快要过期的时候会先刷新
+	AccessToken(ctx context.Context, uid int64) (domain.WechatToken, error)
+	// SyncProfile 用户在微信上改了昵称头像之后，重新同步过来
+	SyncProfile(ctx context.Context, uid int64) (domain.WechatProfile, error)
+}
+
+type wechatLoginService struct {
+	svc       wechat.Service
+	userRepo  repository.UserRepository
+	tokenRepo repository.WechatTokenRepository
+	l         logger.Logger
+	// 提前多久刷新 access_token
+	refreshBefore time.Duration
+}
+
+func NewWechatLoginService(svc wechat.Service,
+	userRepo repository.UserRepository,
+	tokenRepo repository.WechatTokenRepository,
+	l logger.Logger) WechatLoginService {
+	return &wechatLoginService{
+		svc:           svc,
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		l:             l,
+		refreshBefore: time.Minute * 5,
+	}
+}
+
+func (s *wechatLoginService) Login(ctx context.Context, code string) (domain.User, error) {
+	info, token, err := s.svc.VerifyCode(ctx, code)
+	if err != nil {
+		return domain.User{}, ErrInvalidWechatCode.Wrap(err)
+	}
+	u, err := s.findOrCreate(ctx, info, token)
+	if err != nil {
+		return domain.User{}, err
+	}
+	token.Uid = u.Id
+	err = s.tokenRepo.Save(ctx, token)
+	if err != nil {
+		// 凭证没保存下来不影响登录，下次登录会覆盖
+		s.l.Error("保存微信凭证失败", logger.Int64("uid", u.Id), logger.Error(err))
+	}
+	return u, nil
+}
+
+func (s *wechatLoginService) findOrCreate(ctx context.Context, info domain.WechatInfo, token domain.WechatToken) (domain.User, error) {
+	// 先找一下数据库，我们认为大部分用户都存在
+	u, err := s.userRepo.FindByWechat(ctx, info.Openid)
+	if err == nil {
+		err = checkActive(u)
+		if err != nil {
+			return domain.User{}, err
+		}
+		return u, nil
+	}
+	if err != repository.ErrUserNotFound {
+		return domain.User{}, err
+	}
+	u = domain.User{
+		WechatInfo: info,
+	}
+	profile, err := s.svc.UserInfo(ctx, token)
+	if err != nil {
+		// 拿不到昵称头像也允许注册，用户可以自己再改
+		s.l.Warn("获取微信用户信息失败", logger.String("openid", info.Openid), logger.Error(err))
+	} else {
+		u.NickName = profile.NickName
+		u.Avatar = profile.Avatar
+	}
+	err = s.userRepo.Create(ctx, u)
+	// 唯一索引冲突，说明并发登录的时候另外一个请求已经创建好了
+	if err != nil && err != repository.ErrDuplicateUser {
+		return domain.User{}, err
+	}
+	// 主从延迟，理论上来讲，强制走主库
+	return s.userRepo.FindByWechat(ctx, info.Openid)
+}
+
+func (s *wechatLoginService) AccessToken(ctx context.Context, uid int64) (domain.WechatToken, error) {
+	token, err := s.tokenRepo.FindByUid(ctx, uid)
+	if err != nil {
+		return domain.WechatToken{}, err
+	}
+	now := time.Now()
+	if now.Add(s.refreshBefore).Before(token.ExpiresAt) {
+		return token, nil
+	}
+	if !now.Before(token.RefreshExpiresAt) {
+		return domain.WechatToken{}, ErrWechatTokenExpired
+	}
+	token, err = s.svc.Refresh(ctx, token)
+	if err != nil {
+		return domain.WechatToken{}, err
+	}
+	err = s.tokenRepo.Save(ctx, token)
+	if err != nil {
+		return domain.WechatToken{}, err
+	}
+	return token, nil
+}
+
+func (s *wechatLoginService) SyncProfile(ctx context.Context, uid int64) (domain.WechatProfile, error) {
+	token, err := s.AccessToken(ctx, uid)
+	if err == repository.ErrWechatTokenNotFound {
+		// 没有用微信登录过
+		return domain.WechatProfile{}, ErrWechatTokenExpired
+	}
+	if err != nil {
+		return domain.WechatProfile{}, err
+	}
+	profile, err := s.svc.UserInfo(ctx, token)
+	if err != nil {
+		return domain.WechatProfile{}, err
+	}
+	err = s.userRepo.UpdateById(ctx, domain.User{
+		Id:       uid,
+		NickName: profile.NickName,
+		Avatar:   profile.Avatar,
+	})
+	if err != nil {
+		return domain.WechatProfile{}, err
+	}
+	return profile, nil
+}
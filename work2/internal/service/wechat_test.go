@@ -0,0 +1,274 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	repomock "example/wb/internal/repository/mock"
+	"example/wb/internal/service"
+	"example/wb/internal/service/oauth2/wechat"
+	wechatmock "example/wb/internal/service/oauth2/wechat/mocks"
+	"example/wb/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWechatLoginService_Login(t *testing.T) {
+	info := domain.WechatInfo{Openid: "openid-1", Unionid: "unionid-1"}
+	token := domain.WechatToken{Openid: "openid-1", AccessToken: "access-1", RefreshToken: "refresh-1"}
+	savedToken := token
+	savedToken.Uid = 1
+
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository)
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "老用户",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository) {
+				svc := wechatmock.NewMockService(ctrl)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				svc.EXPECT().VerifyCode(gomock.Any(), "code-1").Return(info, token, nil)
+				userRepo.EXPECT().FindByWechat(gomock.Any(), "openid-1").
+					Return(domain.User{Id: 1, NickName: "老名字"}, nil)
+				tokenRepo.EXPECT().Save(gomock.Any(), savedToken).Return(nil)
+				return svc, userRepo, tokenRepo
+			},
+			wantUser: domain.User{Id: 1, NickName: "老名字"},
+		},
+		{
+			name: "新用户，同步昵称头像",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository) {
+				svc := wechatmock.NewMockService(ctrl)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				svc.EXPECT().VerifyCode(gomock.Any(), "code-1").Return(info, token, nil)
+				userRepo.EXPECT().FindByWechat(gomock.Any(), "openid-1").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				svc.EXPECT().UserInfo(gomock.Any(), token).
+					Return(domain.WechatProfile{NickName: "小明", Avatar: "https://avatar/1"}, nil)
+				userRepo.EXPECT().Create(gomock.Any(), domain.User{
+					NickName:   "小明",
+					Avatar:     "https://avatar/1",
+					WechatInfo: info,
+				}).Return(nil)
+				userRepo.EXPECT().FindByWechat(gomock.Any(), "openid-1").
+					Return(domain.User{Id: 1, NickName: "小明", Avatar: "https://avatar/1"}, nil)
+				tokenRepo.EXPECT().Save(gomock.Any(), savedToken).Return(nil)
+				return svc, userRepo, tokenRepo
+			},
+			wantUser: domain.User{Id: 1, NickName: "小明", Avatar: "https://avatar/1"},
+		},
+		{
+			name: "新用户，获取昵称失败也能注册",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository) {
+				svc := wechatmock.NewMockService(ctrl)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				svc.EXPECT().VerifyCode(gomock.Any(), "code-1").Return(info, token, nil)
+				userRepo.EXPECT().FindByWechat(gomock.Any(), "openid-1").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				svc.EXPECT().UserInfo(gomock.Any(), token).
+					Return(domain.WechatProfile{}, errors.New("微信错误"))
+				userRepo.EXPECT().Create(gomock.Any(), domain.User{WechatInfo: info}).Return(nil)
+				userRepo.EXPECT().FindByWechat(gomock.Any(), "openid-1").
+					Return(domain.User{Id: 1}, nil)
+				tokenRepo.EXPECT().Save(gomock.Any(), savedToken).Return(nil)
+				return svc, userRepo, tokenRepo
+			},
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "授权码有误",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository) {
+				svc := wechatmock.NewMockService(ctrl)
+				svc.EXPECT().VerifyCode(gomock.Any(), "code-1").
+					Return(domain.WechatInfo{}, domain.WechatToken{}, errors.New("invalid code"))
+				return svc, repomock.NewMockUserRepository(ctrl), repomock.NewMockWechatTokenRepository(ctrl)
+			},
+			wantErr: service.ErrInvalidWechatCode,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc, userRepo, tokenRepo := tc.mock(ctrl)
+			loginSvc := service.NewWechatLoginService(svc, userRepo, tokenRepo, logger.NewNopLogger())
+			u, err := loginSvc.Login(context.Background(), "code-1")
+			assert.True(t, errors.Is(err, tc.wantErr), err)
+			assert.Equal(t, tc.wantUser, u)
+		})
+	}
+}
+
+func TestWechatLoginService_AccessToken(t *testing.T) {
+	now := time.Now()
+	fresh := domain.WechatToken{
+		Uid:              1,
+		AccessToken:      "access-1",
+		RefreshToken:     "refresh-1",
+		ExpiresAt:        now.Add(time.Hour),
+		RefreshExpiresAt: now.Add(time.Hour * 24),
+	}
+	expiring := fresh
+	expiring.ExpiresAt = now.Add(time.Minute)
+	refreshed := fresh
+	refreshed.AccessToken = "access-2"
+	refreshed.ExpiresAt = now.Add(time.Hour * 2)
+	dead := expiring
+	dead.RefreshExpiresAt = now.Add(-time.Hour)
+
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (wechat.Service, repository.WechatTokenRepository)
+
+		want    domain.WechatToken
+		wantErr error
+	}{
+		{
+			name: "还没过期",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.WechatTokenRepository) {
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				tokenRepo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(fresh, nil)
+				return wechatmock.NewMockService(ctrl), tokenRepo
+			},
+			want: fresh,
+		},
+		{
+			name: "快要过期，先刷新",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.WechatTokenRepository) {
+				svc := wechatmock.NewMockService(ctrl)
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				tokenRepo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(expiring, nil)
+				svc.EXPECT().Refresh(gomock.Any(), expiring).Return(refreshed, nil)
+				tokenRepo.EXPECT().Save(gomock.Any(), refreshed).Return(nil)
+				return svc, tokenRepo
+			},
+			want: refreshed,
+		},
+		{
+			name: "refresh_token 也过期了",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.WechatTokenRepository) {
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				tokenRepo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(dead, nil)
+				return wechatmock.NewMockService(ctrl), tokenRepo
+			},
+			wantErr: service.ErrWechatTokenExpired,
+		},
+		{
+			name: "刷新失败",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.WechatTokenRepository) {
+				svc := wechatmock.NewMockService(ctrl)
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				tokenRepo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(expiring, nil)
+				svc.EXPECT().Refresh(gomock.Any(), expiring).
+					Return(domain.WechatToken{}, errors.New("微信错误"))
+				return svc, tokenRepo
+			},
+			wantErr: errors.New("微信错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc, tokenRepo := tc.mock(ctrl)
+			loginSvc := service.NewWechatLoginService(svc, repomock.NewMockUserRepository(ctrl), tokenRepo, logger.NewNopLogger())
+			token, err := loginSvc.AccessToken(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, token)
+		})
+	}
+}
+
+func TestWechatLoginService_SyncProfile(t *testing.T) {
+	now := time.Now()
+	expiring := domain.WechatToken{
+		Uid:              1,
+		AccessToken:      "access-1",
+		RefreshToken:     "refresh-1",
+		ExpiresAt:        now.Add(time.Minute),
+		RefreshExpiresAt: now.Add(time.Hour * 24),
+	}
+	refreshed := expiring
+	refreshed.AccessToken = "access-2"
+	refreshed.ExpiresAt = now.Add(time.Hour * 2)
+	profile := domain.WechatProfile{NickName: "小明", Avatar: "https://wx.qlogo.cn/1"}
+
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository)
+
+		want    domain.WechatProfile
+		wantErr error
+	}{
+		{
+			name: "刷新之后同步",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository) {
+				svc := wechatmock.NewMockService(ctrl)
+				userRepo := repomock.NewMockUserRepository(ctrl)
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				tokenRepo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(expiring, nil)
+				svc.EXPECT().Refresh(gomock.Any(), expiring).Return(refreshed, nil)
+				tokenRepo.EXPECT().Save(gomock.Any(), refreshed).Return(nil)
+				// 用刷新之后的 access_token
+				svc.EXPECT().UserInfo(gomock.Any(), refreshed).Return(profile, nil)
+				userRepo.EXPECT().UpdateById(gomock.Any(), domain.User{
+					Id:       1,
+					NickName: "小明",
+					Avatar:   "https://wx.qlogo.cn/1",
+				}).Return(nil)
+				return svc, userRepo, tokenRepo
+			},
+			want: profile,
+		},
+		{
+			name: "没有用微信登录过",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository) {
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				tokenRepo.EXPECT().FindByUid(gomock.Any(), int64(1)).
+					Return(domain.WechatToken{}, repository.ErrWechatTokenNotFound)
+				return wechatmock.NewMockService(ctrl), repomock.NewMockUserRepository(ctrl), tokenRepo
+			},
+			wantErr: service.ErrWechatTokenExpired,
+		},
+		{
+			name: "获取用户信息失败",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, repository.UserRepository, repository.WechatTokenRepository) {
+				svc := wechatmock.NewMockService(ctrl)
+				tokenRepo := repomock.NewMockWechatTokenRepository(ctrl)
+				tokenRepo.EXPECT().FindByUid(gomock.Any(), int64(1)).Return(refreshed, nil)
+				svc.EXPECT().UserInfo(gomock.Any(), refreshed).
+					Return(domain.WechatProfile{}, errors.New("微信错误"))
+				return svc, repomock.NewMockUserRepository(ctrl), tokenRepo
+			},
+			wantErr: errors.New("微信错误"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc, userRepo, tokenRepo := tc.mock(ctrl)
+			loginSvc := service.NewWechatLoginService(svc, userRepo, tokenRepo, logger.NewNopLogger())
+			profile, err := loginSvc.SyncProfile(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, profile)
+		})
+	}
+}
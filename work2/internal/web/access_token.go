@@ -0,0 +1,129 @@
+package web
+
+import (
+	"example/wb/internal/domain"
+	"example/wb/internal/i18n"
+	"example/wb/internal/service"
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/ginx"
+	"example/wb/pkg/logger"
+	"strconv"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTokenHandler 管理 personal access token，只能用 JWT 登录之后操作
+type AccessTokenHandler struct {
+	svc      service.AccessTokenService
+	auditSvc service.AuditService
+	l        logger.Logger
+}
+
+func NewAccessTokenHandler(svc service.AccessTokenService, auditSvc service.AuditService,
+	l logger.Logger) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		svc:      svc,
+		auditSvc: auditSvc,
+		l:        l,
+	}
+}
+
+func (h *AccessTokenHandler) RegisterRoutes(g *gin.Engine) {
+	tg := g.Group("/user/tokens")
+	tg.POST("", ginx.WrapBodyAndClaims(h.l, h.Create))
+	tg.GET("", ginx.WrapClaims(h.l, h.List))
+	tg.POST("/revoke", ginx.WrapBodyAndClaims(h.l, h.Revoke))
+}
+
+type AccessTokenVo struct {
+	Id        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Hint      string   `json:"hint"`
+	ExpiresAt int64    `json:"expires_at"`
+	Ctime     int64    `json:"ctime"`
+	// 只有创建的时候有
+	Token string `json:"token,omitempty"`
+}
+
+type CreateAccessTokenReq struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// 有效期，单位是天
+	ExpiresIn int `json:"expires_in"`
+}
+
+func (h *AccessTokenHandler) Create(ctx *gin.Context, req CreateAccessTokenReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	t, token, err := h.svc.Create(ctx, domain.AccessToken{
+		Uid:  uc.Id,
+		Name: req.Name,
+		Scopes: slice.Map[string, domain.Scope](req.Scopes, func(idx int, src string) domain.Scope {
+			return domain.Scope(src)
+		}),
+		ExpiresAt: time.Now().Add(time.Hour * 24 * time.Duration(req.ExpiresIn)),
+	})
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     uc.Id,
+		Action:  domain.AuditAccessTokenCreate,
+		Target:  req.Name,
+		Success: err == nil,
+		Detail:  errDetail(err),
+	})
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	vo := h.toVo(t)
+	vo.Token = token
+	return ginx.Result{
+		Msg:  i18n.T(ctx, i18n.MsgAccessTokenCreated),
+		Data: vo,
+	}, nil
+}
+
+func (h *AccessTokenHandler) List(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	ts, err := h.svc.List(ctx, uc.Id)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{
+		Data: slice.Map[domain.AccessToken, AccessTokenVo](ts, func(idx int, src domain.AccessToken) AccessTokenVo {
+			return h.toVo(src)
+		}),
+	}, nil
+}
+
+type RevokeAccessTokenReq struct {
+	Id int64 `json:"id"`
+}
+
+func (h *AccessTokenHandler) Revoke(ctx *gin.Context, req RevokeAccessTokenReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Revoke(ctx, uc.Id, req.Id)
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     uc.Id,
+		Action:  domain.AuditAccessTokenRevoke,
+		Target:  strconv.FormatInt(req.Id, 10),
+		Success: err == nil,
+		Detail:  errDetail(err),
+	})
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{
+		Msg: i18n.T(ctx, i18n.MsgOK),
+	}, nil
+}
+
+func (h *AccessTokenHandler) toVo(t domain.AccessToken) AccessTokenVo {
+	return AccessTokenVo{
+		Id:   t.Id,
+		Name: t.Name,
+		Scopes: slice.Map[domain.Scope, string](t.Scopes, func(idx int, src domain.Scope) string {
+			return string(src)
+		}),
+		Hint:      t.Hint,
+		ExpiresAt: t.ExpiresAt.UnixMilli(),
+		Ctime:     t.Ctime.UnixMilli(),
+	}
+}
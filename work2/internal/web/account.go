@@ -0,0 +1,199 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"example/wb/internal/domain"
+	"example/wb/internal/i18n"
+	"example/wb/internal/service"
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/ginx"
+	"example/wb/pkg/logger"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler 个人数据导出和账号注销
+type AccountHandler struct {
+	ijwt.Handler
+	svc      service.AccountService
+	auditSvc service.AuditService
+	l        logger.Logger
+}
+
+func NewAccountHandler(svc service.AccountService, hdl ijwt.Handler,
+	auditSvc service.AuditService, l logger.Logger) *AccountHandler {
+	return &AccountHandler{
+		Handler:  hdl,
+		svc:      svc,
+		auditSvc: auditSvc,
+		l:        l,
+	}
+}
+
+func (h *AccountHandler) RegisterRoutes(g *gin.Engine) {
+	// 导出和注销都不允许用 personal access token
+	ug := g.Group("/user")
+	ug.GET("/export", ginx.WrapClaims(h.l, h.Export))
+	ug.POST("/delete/code/send", ginx.WrapClaims(h.l, h.SendDeletionCode))
+	ug.POST("/delete", ginx.WrapBodyAndClaims(h.l, h.Delete))
+}
+
+type ProfileVo struct {
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	NickName    string `json:"nickname"`
+	Avatar      string `json:"avatar"`
+	Birthday    string `json:"birthday"`
+	Biography   string `json:"biography"`
+	CodeChannel string `json:"code_channel"`
+}
+
+type AccountExportVo struct {
+	Profile  ProfileVo      `json:"profile"`
+	Articles []ArticleVo    `json:"articles"`
+	Sessions []ijwt.Session `json:"sessions"`
+}
+
+// Export 默认返回 JSON，format=zip 的时候打包成 zip 下载
+func (h *AccountHandler) Export(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	data, err := h.svc.Export(ctx, uc.Id)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	sessions, err := h.Sessions(ctx, uc.Id)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	vo := h.toExportVo(data, sessions)
+	if ctx.Query("format") != "zip" {
+		return ginx.Result{
+			Data: vo,
+		}, nil
+	}
+	archive, err := h.zip(vo)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	ctx.Header("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="export-%s.zip"`, time.Now().Format("20060102150405")))
+	ctx.Data(http.StatusOK, "application/zip", archive)
+	// 已经直接写了文件，ginx 不会再写 Result
+	return ginx.Result{}, nil
+}
+
+func (h *AccountHandler) toExportVo(data domain.AccountExport, sessions []ijwt.Session) AccountExportVo {
+	u := data.Profile
+	var birthday string
+	if !u.Birthday.IsZero() {
+		birthday = u.Birthday.Format(time.DateOnly)
+	}
+	return AccountExportVo{
+		Profile: ProfileVo{
+			Email:       u.Email,
+			Phone:       u.Phone,
+			NickName:    u.NickName,
+			Avatar:      u.Avatar,
+			Birthday:    birthday,
+			Biography:   u.Biography,
+			CodeChannel: string(u.CodeChannel),
+		},
+		Articles: slice.Map[domain.Article, ArticleVo](data.Articles, func(idx int, src domain.Article) ArticleVo {
+			return toArticleVo(src)
+		}),
+		Sessions: sessions,
+	}
+}
+
+// zip 每一部分单独一个 JSON 文件
+func (h *AccountHandler) zip(vo AccountExportVo) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		val  any
+	}{
+		{name: "profile.json", val: vo.Profile},
+		{name: "articles.json", val: vo.Articles},
+		{name: "sessions.json", val: vo.Sessions},
+	}
+	for _, f := range files {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(f.val)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SendDeletionCode channel 可以指定发送的渠道，不指定的时候按照用户的偏好
+func (h *AccountHandler) SendDeletionCode(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	channel := domain.CodeChannel(ctx.Query("channel"))
+	if channel != "" && !channel.Valid() {
+		return ginx.Result{}, service.ErrCodeChannelUnsupported
+	}
+	err := h.svc.SendDeletionCode(ctx, uc.Id, channel)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{
+		Msg: i18n.T(ctx, i18n.MsgCodeSent),
+	}, nil
+}
+
+type DeleteAccountReq struct {
+	Password      string             `json:"password"`
+	Code          string             `json:"code"`
+	CodeChannel   domain.CodeChannel `json:"code_channel" binding:"omitempty,oneof=sms email voice"`
+	TwoFactorCode string             `json:"two_factor_code"`
+}
+
+// Delete 重新验证身份之后申请注销，冷静期结束之后个人信息会被清除
+func (h *AccountHandler) Delete(ctx *gin.Context, req DeleteAccountReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	deleteAt, err := h.svc.RequestDeletion(ctx, uc.Id, domain.Reverification{
+		Password:      req.Password,
+		Code:          req.Code,
+		CodeChannel:   req.CodeChannel,
+		TwoFactorCode: req.TwoFactorCode,
+	})
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     uc.Id,
+		Action:  domain.AuditAccountDelete,
+		Success: err == nil,
+		Detail:  errDetail(err),
+	})
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	// 所有设备上的登录态都要失效
+	_, err = h.ClearAllTokens(ctx, uc.Id)
+	if err != nil {
+		// 账号已经不能登录了，剩下的 token 最多活到过期
+		h.l.Error("注销账号清除登录态失败", logger.Error(err), logger.Int64("uid", uc.Id))
+	}
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     uc.Id,
+		Action:  domain.AuditSessionsRevoke,
+		Success: err == nil,
+		Detail:  "account_delete",
+	})
+	return ginx.Result{
+		Msg:  i18n.T(ctx, i18n.MsgDeletionRequested),
+		Data: deleteAt.UnixMilli(),
+	}, nil
+}
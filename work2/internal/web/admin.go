@@ -0,0 +1,102 @@
+package web
+
+import (
+	"example/wb/internal/domain"
+	"example/wb/internal/i18n"
+	"example/wb/internal/service"
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/internal/web/middleware"
+	"example/wb/pkg/ginx"
+	"example/wb/pkg/logger"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理后台，每个接口都要声明需要的权限
+type AdminHandler struct {
+	ijwt.Handler
+	svc      service.AdminService
+	authz    *middleware.AuthzMiddlewareBuilder
+	auditSvc service.AuditService
+	l        logger.Logger
+}
+
+func NewAdminHandler(svc service.AdminService, authzSvc service.AuthzService,
+	hdl ijwt.Handler, auditSvc service.AuditService, l logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		Handler:  hdl,
+		svc:      svc,
+		authz:    middleware.NewAuthzMiddlewareBuilder(authzSvc, l),
+		auditSvc: auditSvc,
+		l:        l,
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(g *gin.Engine) {
+	ag := g.Group("/admin")
+	ag.POST("/article/take_down", h.authz.Require(domain.PermissionArticleTakeDown), ginx.WrapBodyAndClaims(h.l, h.TakeDownArticle))
+	ag.POST("/user/ban", h.authz.Require(domain.PermissionUserBan), ginx.WrapBodyAndClaims(h.l, h.BanUser))
+	ag.POST("/user/unban", h.authz.Require(domain.PermissionUserBan), ginx.WrapBodyAndClaims(h.l, h.UnbanUser))
+}
+
+type TakeDownArticleReq struct {
+	Id int64 `json:"id"`
+}
+
+func (h *AdminHandler) TakeDownArticle(ctx *gin.Context, req TakeDownArticleReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.TakeDownArticle(ctx, req.Id)
+	h.audit(ctx, uc.Id, domain.AuditArticleTakeDown, req.Id, err)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	h.l.Info("管理员下架帖子", logger.Int64("admin", uc.Id), logger.Int64("aid", req.Id))
+	return ginx.Result{
+		Msg: i18n.T(ctx, i18n.MsgOK),
+	}, nil
+}
+
+type BanUserReq struct {
+	Uid int64 `json:"uid"`
+}
+
+// BanUser 封禁之后用户所有的登录态都会失效
+func (h *AdminHandler) BanUser(ctx *gin.Context, req BanUserReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.BanUser(ctx, req.Uid)
+	h.audit(ctx, uc.Id, domain.AuditUserBan, req.Uid, err)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	h.l.Info("管理员封禁用户", logger.Int64("admin", uc.Id), logger.Int64("uid", req.Uid))
+	_, err = h.ClearAllTokens(ctx, req.Uid)
+	if err != nil {
+		// 已经不能再登录了，剩下的 token 最多活到过期
+		h.l.Error("封禁用户清除登录态失败", logger.Error(err), logger.Int64("uid", req.Uid))
+	}
+	return ginx.Result{
+		Msg: i18n.T(ctx, i18n.MsgOK),
+	}, nil
+}
+
+func (h *AdminHandler) UnbanUser(ctx *gin.Context, req BanUserReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.UnbanUser(ctx, req.Uid)
+	h.audit(ctx, uc.Id, domain.AuditUserUnban, req.Uid, err)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	h.l.Info("管理员解封用户", logger.Int64("admin", uc.Id), logger.Int64("uid", req.Uid))
+	return ginx.Result{
+		Msg: i18n.T(ctx, i18n.MsgOK),
+	}, nil
+}
+
+// audit 管理员的操作都要留痕，Uid 是管理员，Target 是被操作的对象
+func (h *AdminHandler) audit(ctx *gin.Context, admin int64, action domain.AuditAction, target int64, err error) {
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     admin,
+		Action:  action,
+		Target:  strconv.FormatInt(target, 10),
+		Success: err == nil,
+		Detail:  errDetail(err),
+	})
+}
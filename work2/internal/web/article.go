@@ -2,10 +2,13 @@ package web
 
 import (
 	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/i18n"
 	"example/wb/internal/service"
 	"example/wb/internal/web/jwt"
+	"example/wb/pkg/ginx"
 	"example/wb/pkg/logger"
-	"net/http"
+	"strconv"
 
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/gin-gonic/gin"
@@ -24,164 +27,95 @@ func NewArticleHandler(svc service.ArticleService, l logger.Logger) *ArticleHand
 }
 
 func (h *ArticleHandler) RegisterRoutes(g *gin.Engine) {
+	// 这些路由都接受 personal access token，scope 在 ioc 的登录中间件里面声明
 	ag := g.Group("/article")
-	ag.POST("/publish", h.Publish)
-	ag.POST("/edit", h.Edit)
-	ag.POST("/withdraw", h.Withdraw)
+	ag.POST("/publish", ginx.WrapBodyAndClaims(h.l, h.Publish))
+	ag.POST("/edit", ginx.WrapBodyAndClaims(h.l, h.Edit))
+	ag.POST("/withdraw", ginx.WrapBodyAndClaims(h.l, h.Withdraw))
 
 	// 创作者接口
-	g.POST("/detail/:id", h.Detail)
-	g.POST("/list", h.List)
+	g.POST("/detail/:id", ginx.WrapClaims(h.l, h.Detail))
+	g.POST("/list", ginx.WrapBodyAndClaims(h.l, h.List))
 
 }
 
-func (h *ArticleHandler) Detail(ctx *gin.Context) {
-
-}
-
-func (h *ArticleHandler) List(ctx *gin.Context) {
-	var page Page
-	if err := ctx.Bind(&page); err != nil {
-		return
+func (h *ArticleHandler) Detail(ctx *gin.Context, uc jwt.UserClaims) (ginx.Result, error) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.Result{}, errs.ErrInvalidParam.Wrap(err)
 	}
+	art, err := h.svc.GetById(ctx, uc.Id, id)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{
+		Data: toArticleVo(art),
+	}, nil
+}
 
-	uc := ctx.MustGet("user").(jwt.UserClaims)
+func (h *ArticleHandler) List(ctx *gin.Context, page Page, uc jwt.UserClaims) (ginx.Result, error) {
 	arts, err := h.svc.GetByAuthor(ctx, uc.Id, page.Limit, page.Offset)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		h.l.Error("查找文章列表失败",
-			logger.Error(err),
-			logger.Int64("uid", uc.Id),
-			logger.Int("limit", page.Limit),
-			logger.Int("offset", page.Offset),
-		)
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{
+	return ginx.Result{
 		Data: slice.Map[domain.Article, ArticleVo](arts, func(idx int, src domain.Article) ArticleVo {
-			return ArticleVo{
-				Id:       src.Id,
-				Title:    src.Title,
-				Content:  src.Content,
-				AuthorId: src.Author.Id,
-				// AuthorName: src.Author.Name, // 正常来说列表不需要作者名称
-				Status: uint8(src.Status),
-				Ctime:  src.Ctime,
-				Utime:  src.Utime,
-			}
+			return toArticleVo(src)
 		}),
-	})
+	}, nil
 
 }
 
-func (h *ArticleHandler) Withdraw(ctx *gin.Context) {
-	type Req struct {
-		Id int64 `json:"id"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
-	}
-	uc := ctx.MustGet("user").(jwt.UserClaims)
+type WithdrawReq struct {
+	Id int64 `json:"id"`
+}
+
+func (h *ArticleHandler) Withdraw(ctx *gin.Context, req WithdrawReq, uc jwt.UserClaims) (ginx.Result, error) {
 	err := h.svc.Withdraw(ctx, uc.Id, req.Id)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		h.l.Error("撤回文章失败",
-			logger.Int64("uid", uc.Id),
-			logger.Int64("aid", req.Id),
-		)
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "OK",
-	})
+	return ginx.Result{
+		Msg: i18n.T(ctx, i18n.MsgOK),
+	}, nil
 }
 
-func (h *ArticleHandler) Edit(ctx *gin.Context) {
-	type Req struct {
-		Id      int64
-		Title   string `json:"title"`
-		Content string `json:"content"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
-	}
+type ArticleReq struct {
+	Id      int64
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
 
-	uc, ok := ctx.MustGet("user").(jwt.UserClaims)
-	if !ok {
-		// 正常来说用户不会到这里，可以在这里进行监控
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		h.l.Error("未发现用户的 session 信息")
-		return
-	}
-	// 跳过检测输入数据
-	// 调用svc的代码
-	id, err := h.svc.Save(ctx, domain.Article{
+func (req ArticleReq) toDomain(uid int64) domain.Article {
+	return domain.Article{
 		Id:      req.Id,
 		Title:   req.Title,
 		Content: req.Content,
 		Author: domain.Author{
-			Id: uc.Id,
+			Id: uid,
 		},
-	})
+	}
+}
+
+func (h *ArticleHandler) Edit(ctx *gin.Context, req ArticleReq, uc jwt.UserClaims) (ginx.Result, error) {
+	// 跳过检测输入数据
+	// 调用svc的代码
+	id, err := h.svc.Save(ctx, req.toDomain(uc.Id))
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		h.l.Error("保存帖子失败",
-			logger.Int64("uid", uc.Id),
-			logger.Error(err),
-		)
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{
+	return ginx.Result{
 		Data: id,
-	})
+	}, nil
 }
 
-func (h *ArticleHandler) Publish(ctx *gin.Context) {
-	type Req struct {
-		Id      int64
-		Title   string `json:"title"`
-		Content string `json:"content"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
-	}
-	uc := ctx.MustGet("user").(jwt.UserClaims)
-	id, err := h.svc.Publish(ctx, domain.Article{
-		Id:      req.Id,
-		Title:   req.Title,
-		Content: req.Content,
-		Author: domain.Author{
-			Id: uc.Id,
-		},
-	})
+func (h *ArticleHandler) Publish(ctx *gin.Context, req ArticleReq, uc jwt.UserClaims) (ginx.Result, error) {
+	id, err := h.svc.Publish(ctx, req.toDomain(uc.Id))
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		h.l.Error("发表文章失败",
-			logger.Int64("uid", uc.Id),
-			logger.Error(err),
-		)
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{
+	return ginx.Result{
 		Data: id,
-	})
+	}, nil
 
 }
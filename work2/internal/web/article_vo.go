@@ -1,6 +1,7 @@
 package web
 
 import (
+	"example/wb/internal/domain"
 	"time"
 )
 
@@ -15,3 +16,16 @@ type ArticleVo struct {
 	Utime      time.Time `json:"utime,omitempty"`
 	Ctime      time.Time `json:"ctime,omitempty"`
 }
+
+func toArticleVo(art domain.Article) ArticleVo {
+	return ArticleVo{
+		Id:         art.Id,
+		Title:      art.Title,
+		Content:    art.Content,
+		AuthorId:   art.Author.Id,
+		AuthorName: art.Author.Name,
+		Status:     uint8(art.Status),
+		Ctime:      art.Ctime,
+		Utime:      art.Utime,
+	}
+}
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/service"
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/internal/web/middleware"
+	"example/wb/pkg/ginx"
+	"example/wb/pkg/logger"
+	"strconv"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler 用户查看自己最近的安全事件，管理员查询所有人的审计日志
+type AuditHandler struct {
+	svc   service.AuditService
+	authz *middleware.AuthzMiddlewareBuilder
+	l     logger.Logger
+}
+
+func NewAuditHandler(svc service.AuditService, authzSvc service.AuthzService, l logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		svc:   svc,
+		authz: middleware.NewAuthzMiddlewareBuilder(authzSvc, l),
+		l:     l,
+	}
+}
+
+func (h *AuditHandler) RegisterRoutes(g *gin.Engine) {
+	g.GET("/user/security/activity", ginx.WrapClaims(h.l, h.Recent))
+	g.GET("/admin/audit", h.authz.Require(domain.PermissionAuditRead), ginx.Wrap(h.l, h.Query))
+}
+
+type AuditEventVo struct {
+	Id        int64  `json:"id"`
+	Uid       int64  `json:"uid"`
+	Action    string `json:"action"`
+	Target    string `json:"target"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Success   bool   `json:"success"`
+	Detail    string `json:"detail"`
+	Ctime     int64  `json:"ctime"`
+}
+
+func (h *AuditHandler) Recent(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	events, err := h.svc.Recent(ctx, uc.Id, limit)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{
+		Data: h.toVos(events),
+	}, nil
+}
+
+// Query 条件都放在 query 里面，start 和 end 是毫秒时间戳
+func (h *AuditHandler) Query(ctx *gin.Context) (ginx.Result, error) {
+	var q domain.AuditQuery
+	var err error
+	parseInt64 := func(key string) int64 {
+		val := ctx.Query(key)
+		if val == "" || err != nil {
+			return 0
+		}
+		var res int64
+		res, err = strconv.ParseInt(val, 10, 64)
+		return res
+	}
+	q.Uid = parseInt64("uid")
+	start, end := parseInt64("start"), parseInt64("end")
+	q.Offset = int(parseInt64("offset"))
+	q.Limit = int(parseInt64("limit"))
+	if err != nil {
+		return ginx.Result{}, errs.ErrInvalidParam.Wrap(err)
+	}
+	if start > 0 {
+		q.Start = time.UnixMilli(start)
+	}
+	if end > 0 {
+		q.End = time.UnixMilli(end)
+	}
+	q.Action = domain.AuditAction(ctx.Query("action"))
+	events, err := h.svc.Query(ctx, q)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{
+		Data: h.toVos(events),
+	}, nil
+}
+
+func (h *AuditHandler) toVos(events []domain.AuditEvent) []AuditEventVo {
+	return slice.Map[domain.AuditEvent, AuditEventVo](events, func(idx int, src domain.AuditEvent) AuditEventVo {
+		return AuditEventVo{
+			Id:        src.Id,
+			Uid:       src.Uid,
+			Action:    string(src.Action),
+			Target:    src.Target,
+			IP:        src.IP,
+			UserAgent: src.UserAgent,
+			Success:   src.Success,
+			Detail:    src.Detail,
+			Ctime:     src.Ctime.UnixMilli(),
+		}
+	})
+}
+
+// recordAudit 补上请求的 IP 和 UA，写入是异步的，不影响接口耗时
+func recordAudit(ctx *gin.Context, svc service.AuditService, e domain.AuditEvent) {
+	e.IP = ctx.ClientIP()
+	e.UserAgent = ctx.Request.UserAgent()
+	svc.Record(ctx, e)
+}
+
+// errDetail 失败的时候把原因放到审计日志里面
+func errDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
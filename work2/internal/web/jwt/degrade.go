@@ -0,0 +1,146 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"time"
+
+	"example/wb/pkg/breaker"
+	"example/wb/pkg/logger"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/redis/go-redis/v9"
+)
+
+// 注销 ssid 的时候发布到这个 channel，每个实例都记到本地，
+// redis 不可用的时候靠本地的记录拒绝已经注销的 ssid
+const revokedChannel = "users:ssid:revoked"
+
+var (
+	ErrSessionRevoked = errors.New("token 无效")
+	// ErrRedisUnavailable 熔断器打开的时候直接返回，不再访问 redis
+	ErrRedisUnavailable = errors.New("redis 不可用")
+
+	// 降级相关的统计，通过 expvar 暴露，用来配置告警：
+	// redis_error 检查 ssid 的时候 redis 出错的次数；
+	// breaker_open 熔断器打开的次数；
+	// degraded_pass redis 不可用时放行的请求数；
+	// local_revoked 被本地注销记录拒绝的请求数
+	sessionStats = expvar.NewMap("jwt_session")
+)
+
+// Subscriber 订阅注销消息，*redis.Client 就实现了这个接口。
+// redis.Cmdable 里面没有 Subscribe，所以单独定义
+type Subscriber interface {
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+type Option func(h *RedisJWTHandler)
+
+// WithFailOpen redis 不可用的时候放行本地没有注销记录的 ssid，
+// 默认是拒绝，也就是所有已登录的请求都会返回 401
+func WithFailOpen(failOpen bool) Option {
+	return func(h *RedisJWTHandler) {
+		h.failOpen = failOpen
+	}
+}
+
+// WithBreaker 连续失败 threshold 次之后熔断 cooldown 这么久
+func WithBreaker(threshold int, cooldown time.Duration) Option {
+	return func(h *RedisJWTHandler) {
+		h.breaker = breaker.New(threshold, cooldown)
+	}
+}
+
+// WithRevokedCacheSize 本地最多记多少个最近注销的 ssid，size 不合法的时候保留默认的大小
+func WithRevokedCacheSize(size int) Option {
+	return func(h *RedisJWTHandler) {
+		revoked, err := lru.New(size)
+		if err != nil {
+			return
+		}
+		h.revoked = revoked
+	}
+}
+
+// WithRevokeSubscriber 订阅其他实例的注销消息，会启动一个 goroutine
+func WithRevokeSubscriber(sub Subscriber) Option {
+	return func(h *RedisJWTHandler) {
+		h.sub = sub
+	}
+}
+
+func WithLogger(l logger.Logger) Option {
+	return func(h *RedisJWTHandler) {
+		h.l = l
+	}
+}
+
+// checkSessionInRedis 经过熔断器访问 redis，出错或者熔断的时候按照配置降级
+func (h *RedisJWTHandler) checkSessionInRedis(ctx context.Context, ssid string) error {
+	if !h.breaker.Allow() {
+		return h.degrade(ErrRedisUnavailable)
+	}
+	cnt, err := h.client.Exists(ctx, h.ssidKey(ssid)).Result()
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			// 客户端自己断开了，不算 redis 的问题，但是要放掉探测名额
+			h.breaker.Cancel()
+			return err
+		}
+		sessionStats.Add("redis_error", 1)
+		if h.breaker.Failure() {
+			sessionStats.Add("breaker_open", 1)
+			h.l.Error("redis 不可用，JWT 会话校验进入降级模式",
+				logger.Error(err),
+				logger.Bool("fail_open", h.failOpen))
+		}
+		return h.degrade(err)
+	}
+	if h.breaker.Success() {
+		h.l.Info("redis 已经恢复，JWT 会话校验退出降级模式")
+	}
+	if cnt > 0 {
+		h.revoked.Add(ssid, struct{}{})
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// degrade 走到这里的时候本地的注销记录已经检查过了
+func (h *RedisJWTHandler) degrade(err error) error {
+	if !h.failOpen {
+		return err
+	}
+	sessionStats.Add("degraded_pass", 1)
+	return nil
+}
+
+// revokeLocal 记到本地并且通知其他实例，通知失败不影响注销本身
+func (h *RedisJWTHandler) revokeLocal(ctx context.Context, ssids ...string) {
+	for _, ssid := range ssids {
+		h.revoked.Add(ssid, struct{}{})
+		err := h.client.Publish(ctx, revokedChannel, ssid).Err()
+		if err != nil {
+			h.l.Warn("发布 ssid 注销消息失败", logger.Error(err), logger.String("ssid", ssid))
+		}
+	}
+}
+
+func (h *RedisJWTHandler) isRevokedLocally(ssid string) bool {
+	if h.revoked.Contains(ssid) {
+		sessionStats.Add("local_revoked", 1)
+		return true
+	}
+	return false
+}
+
+// watchRevoked go-redis 的 PubSub 断线之后会自己重连，这里不需要重试
+func (h *RedisJWTHandler) watchRevoked(ctx context.Context) {
+	ps := h.sub.Subscribe(ctx, revokedChannel)
+	defer ps.Close()
+	for msg := range ps.Channel() {
+		h.revoked.Add(msg.Payload, struct{}{})
+	}
+}
@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/repository/cache/redismock"
+	"example/wb/pkg/breaker"
+	"expvar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var errRedisDown = errors.New("dial tcp 127.0.0.1:6379: connect: connection refused")
+
+func existsCmd(val int64, err error) *redis.IntCmd {
+	cmd := redis.NewIntCmd(context.Background())
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	cmd.SetVal(val)
+	return cmd
+}
+
+func statValue(key string) int64 {
+	v, ok := sessionStats.Get(key).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return v.Value()
+}
+
+func TestRedisJWTHandler_CheckSession(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) redis.Cmdable
+		opts []Option
+		// 本地已经记录的注销
+		revoked []string
+
+		wantErr error
+	}{
+		{
+			name: "正常登录",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").Return(existsCmd(0, nil))
+				return cmd
+			},
+		},
+		{
+			name: "已经注销",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").Return(existsCmd(1, nil))
+				return cmd
+			},
+			wantErr: ErrSessionRevoked,
+		},
+		{
+			name: "本地记录注销，不访问redis",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				return redismock.NewMockCmdable(ctrl)
+			},
+			revoked: []string{"ssid-1"},
+			wantErr: ErrSessionRevoked,
+		},
+		{
+			name: "redis不可用，默认拒绝",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").Return(existsCmd(0, errRedisDown))
+				return cmd
+			},
+			wantErr: errRedisDown,
+		},
+		{
+			name: "redis不可用，降级放行",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").Return(existsCmd(0, errRedisDown))
+				return cmd
+			},
+			opts: []Option{WithFailOpen(true)},
+		},
+		{
+			name: "redis不可用，降级也拒绝本地注销的",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				return redismock.NewMockCmdable(ctrl)
+			},
+			opts:    []Option{WithFailOpen(true)},
+			revoked: []string{"ssid-1"},
+			wantErr: ErrSessionRevoked,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hdl := NewJwtHandler(tc.mock(ctrl), tc.opts...).(*RedisJWTHandler)
+			for _, ssid := range tc.revoked {
+				hdl.revoked.Add(ssid, struct{}{})
+			}
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			err := hdl.CheckSession(ctx, "ssid-1")
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+// 连续失败之后熔断，不再访问 redis；冷却之后探测成功就恢复
+func TestRedisJWTHandler_CheckSession_Breaker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd := redismock.NewMockCmdable(ctrl)
+	gomock.InOrder(
+		cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").
+			Return(existsCmd(0, errRedisDown)).Times(2),
+		// 冷却之后的探测
+		cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").
+			Return(existsCmd(0, nil)),
+		cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-2").
+			Return(existsCmd(1, nil)),
+	)
+	hdl := NewJwtHandler(cmd, WithFailOpen(true),
+		WithBreaker(2, time.Millisecond*50)).(*RedisJWTHandler)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	before := statValue("degraded_pass")
+	for i := 0; i < 5; i++ {
+		// 熔断期间 redis 一次都不会被访问，mock 会检查调用次数
+		assert.NoError(t, hdl.CheckSession(ctx, "ssid-1"))
+	}
+	assert.Equal(t, breaker.Open, hdl.breaker.State())
+	assert.Equal(t, before+5, statValue("degraded_pass"))
+
+	time.Sleep(time.Millisecond * 60)
+	assert.NoError(t, hdl.CheckSession(ctx, "ssid-1"))
+	assert.Equal(t, breaker.Closed, hdl.breaker.State())
+	// 恢复之后重新以 redis 为准
+	assert.Equal(t, ErrSessionRevoked, hdl.CheckSession(ctx, "ssid-2"))
+}
+
+// 注销的时候记到本地，并且通知其他实例
+func TestRedisJWTHandler_ClearToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd := redismock.NewMockCmdable(ctrl)
+	cmd.EXPECT().Set(gomock.Any(), "users:ssid:ssid-1", "", time.Hour*24*7).
+		Return(redis.NewStatusCmd(context.Background()))
+	pubCmd := redis.NewIntCmd(context.Background())
+	// 通知失败也不影响注销
+	pubCmd.SetErr(errRedisDown)
+	cmd.EXPECT().Publish(gomock.Any(), revokedChannel, "ssid-1").Return(pubCmd)
+	cmd.EXPECT().Del(gomock.Any(), "users:refresh:ssid-1").
+		Return(redis.NewIntCmd(context.Background()))
+	cmd.EXPECT().HDel(gomock.Any(), "users:sessions:1", "ssid-1").
+		Return(redis.NewIntCmd(context.Background()))
+
+	hdl := NewJwtHandler(cmd, WithFailOpen(true)).(*RedisJWTHandler)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Set("user", UserClaims{Id: 1, Ssid: "ssid-1"})
+	assert.NoError(t, hdl.ClearToken(ctx))
+
+	// redis 挂了以后，这个 ssid 依旧会被拒绝
+	assert.Equal(t, ErrSessionRevoked, hdl.CheckSession(ctx, "ssid-1"))
+}
+
+// 半开时候的探测请求被客户端取消，不能一直占着探测名额
+func TestRedisJWTHandler_CheckSession_ProbeCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd := redismock.NewMockCmdable(ctrl)
+	gomock.InOrder(
+		cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").
+			Return(existsCmd(0, errRedisDown)),
+		// 冷却之后的探测被取消了
+		cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").
+			Return(existsCmd(0, context.Canceled)),
+		// 下一个请求重新探测
+		cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").
+			Return(existsCmd(0, nil)),
+	)
+	hdl := NewJwtHandler(cmd, WithFailOpen(true),
+		WithBreaker(1, time.Millisecond*50)).(*RedisJWTHandler)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	assert.NoError(t, hdl.CheckSession(ctx, "ssid-1"))
+	assert.Equal(t, breaker.Open, hdl.breaker.State())
+
+	time.Sleep(time.Millisecond * 60)
+	assert.Equal(t, context.Canceled, hdl.CheckSession(ctx, "ssid-1"))
+	assert.NoError(t, hdl.CheckSession(ctx, "ssid-1"))
+	assert.Equal(t, breaker.Closed, hdl.breaker.State())
+}
+
+// 不合法的大小保留默认的本地注销记录，不能是 nil
+func TestWithRevokedCacheSize(t *testing.T) {
+	hdl := NewJwtHandler(nil, WithRevokedCacheSize(0)).(*RedisJWTHandler)
+	assert.NotNil(t, hdl.revoked)
+	assert.False(t, hdl.isRevokedLocally("ssid-1"))
+}
@@ -0,0 +1,16 @@
+-- 用户所有登录会话，field 是 ssid
+local key = KEYS[1]
+local expiration = tonumber(ARGV[1])
+local ssidPrefix = ARGV[2]
+local refreshPrefix = ARGV[3]
+-- 通知所有实例把 ssid 记到本地
+local channel = ARGV[4]
+
+local ssids = redis.call("hkeys", key)
+for _, ssid in ipairs(ssids) do
+    redis.call("set", ssidPrefix .. ssid, "", "EX", expiration)
+    redis.call("del", refreshPrefix .. ssid)
+    redis.call("publish", channel, ssid)
+end
+redis.call("del", key)
+return #ssids
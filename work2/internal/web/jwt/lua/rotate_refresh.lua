@@ -0,0 +1,24 @@
+-- 当前 ssid 下唯一有效的 refresh token 的 jti
+local key = KEYS[1]
+-- ssid 被注销的标记
+local ssidKey = KEYS[2]
+local jti = ARGV[1]
+local newJti = ARGV[2]
+local expiration = tonumber(ARGV[3])
+
+local cur = redis.call("get", key)
+if cur == false then
+    -- 没有记录，refresh token 已经过期或者 ssid 已经被注销
+    return -2
+end
+
+if cur ~= jti then
+    -- 一个已经用过的 refresh token 又被拿来用了，可能被盗用
+    -- 整个 ssid 都作废
+    redis.call("del", key)
+    redis.call("set", ssidKey, "", "EX", expiration)
+    return -1
+end
+
+redis.call("set", key, newJti, "EX", expiration)
+return 0
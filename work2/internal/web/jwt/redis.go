@@ -1,20 +1,39 @@
 package jwt
 
 import (
+	"context"
+	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"sort"
 	"strings"
 	"time"
 
+	"example/wb/pkg/breaker"
+	"example/wb/pkg/logger"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/redis/go-redis/v9"
 )
 
 var JWTtoken = []byte("mY2gT5iP0xZ9eX7tZ5eU9zI4lW0xP0wI")
 var RCJWTkey = []byte("mY2gT5iP0xZ9eX7tZ5eU9zI4lixxP0wI")
+var PreAuthKey = []byte("mY2gT5iP0xZ9eX7tZ5eU9zI4pa0xP0wI")
+
+var (
+	//go:embed lua/rotate_refresh.lua
+	luaRotateRefresh string
+	//go:embed lua/revoke_all.lua
+	luaRevokeAll string
+
+	ErrRefreshTokenReused  = errors.New("refresh token 被重复使用")
+	ErrRefreshTokenInvalid = errors.New("refresh token 无效")
+	ErrPreAuthTokenUsed    = errors.New("pre-auth token 已经被使用")
+)
 
 type UserClaims struct {
 	Id   int64
@@ -28,41 +47,73 @@ type RefreshClaims struct {
 	jwt.RegisteredClaims
 }
 
+// Session 一次登录，对应一个 ssid
+type Session struct {
+	Ssid      string `json:"ssid"`
+	Ctime     int64  `json:"ctime"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+// PreAuthClaims 密码校验通过，但是还没有完成两步验证
+type PreAuthClaims struct {
+	Uid int64
+	jwt.RegisteredClaims
+}
+
 type RedisJWTHandler struct {
 	client       redis.Cmdable
 	JwtMethod    jwt.SigningMethod
 	rcExpiration time.Duration
+	paExpiration time.Duration
+
+	// redis 不可用的时候是否放行
+	failOpen bool
+	breaker  *breaker.Breaker
+	// 最近注销的 ssid，redis 不可用的时候也能拒绝
+	revoked *lru.Cache
+	sub     Subscriber
+	l       logger.Logger
 }
 
-func NewJwtHandler(client redis.Cmdable) Handler {
-	return &RedisJWTHandler{
+func NewJwtHandler(client redis.Cmdable, opts ...Option) Handler {
+	revoked, _ := lru.New(10000)
+	h := &RedisJWTHandler{
 		client:       client,
 		JwtMethod:    jwt.SigningMethodHS512,
 		rcExpiration: time.Hour * 24 * 7,
+		paExpiration: time.Minute * 5,
+		breaker:      breaker.New(5, time.Second*10),
+		revoked:      revoked,
+		l:            logger.NewNopLogger(),
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.sub != nil {
+		go h.watchRevoked(context.Background())
+	}
+	return h
 }
 
-// CheckSession implements Handler.
+// CheckSession 先看本地的注销记录，再去 redis 确认。
+// redis 出错或者熔断的时候，按照 WithFailOpen 的配置决定是否放行
 func (h *RedisJWTHandler) CheckSession(ctx *gin.Context, ssid string) error {
-	cnt, err := h.client.Exists(ctx, fmt.Sprintf("users:ssid:%s", ssid)).Result()
-	if err != nil {
-		return err
-	}
-	if cnt > 0 {
-		return errors.New("token 无效")
+	if h.isRevokedLocally(ssid) {
+		return ErrSessionRevoked
 	}
-	return nil
+	return h.checkSessionInRedis(ctx, ssid)
 }
 
+// ExtractToken 没有带或者格式不对的时候返回空字符串，由调用者决定是否拒绝，
+// 例如可选登录的路由就允许匿名访问
 func (h *RedisJWTHandler) ExtractToken(ctx *gin.Context) string {
 	authCode := ctx.Request.Header.Get("Authorization")
 	if authCode == "" {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return ""
 	}
 	segs := strings.Split(authCode, " ")
 	if len(segs) != 2 {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return ""
 	}
 	tokenStr := segs[1]
@@ -72,15 +123,85 @@ func (h *RedisJWTHandler) ClearToken(ctx *gin.Context) error {
 	ctx.Header("x-jwt-token", "")
 	ctx.Header("x-refresh-token", "")
 	uc := ctx.MustGet("user").(UserClaims)
-	return h.client.Set(ctx,
-		fmt.Sprintf("users:ssid:%s", uc.Ssid), "", h.rcExpiration).Err()
+	err := h.client.Set(ctx, h.ssidKey(uc.Ssid), "", h.rcExpiration).Err()
+	if err != nil {
+		return err
+	}
+	h.revokeLocal(ctx, uc.Ssid)
+	err = h.client.Del(ctx, h.refreshKey(uc.Ssid)).Err()
+	if err != nil {
+		return err
+	}
+	return h.client.HDel(ctx, h.sessionsKey(uc.Id), uc.Ssid).Err()
+}
+
+// ClearAllTokens 注销用户所有的 ssid，返回注销了多少个
+// 注销消息在脚本里面发布，本实例也会通过订阅收到
+func (h *RedisJWTHandler) ClearAllTokens(ctx *gin.Context, uid int64) (int, error) {
+	return h.client.Eval(ctx, luaRevokeAll, []string{h.sessionsKey(uid)},
+		int64(h.rcExpiration.Seconds()), h.ssidKey(""), h.refreshKey(""), revokedChannel).Int()
 }
 
+// Sessions 用户当前所有的登录会话
+func (h *RedisJWTHandler) Sessions(ctx *gin.Context, uid int64) ([]Session, error) {
+	vals, err := h.client.HGetAll(ctx, h.sessionsKey(uid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Session, 0, len(vals))
+	for _, val := range vals {
+		var s Session
+		err = json.Unmarshal([]byte(val), &s)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Ctime > res[j].Ctime
+	})
+	return res, nil
+}
+
+// SetRefreshToken 给 ssid 签发一个新的 refresh token
+// 同时在 redis 里面记录它的 jti，同一个 ssid 只有最新的 jti 是有效的
 func (h *RedisJWTHandler) SetRefreshToken(ctx *gin.Context, uid int64, ssid string) error {
+	jti := uuid.New().String()
+	err := h.client.Set(ctx, h.refreshKey(ssid), jti, h.rcExpiration).Err()
+	if err != nil {
+		return err
+	}
+	return h.setRefreshToken(ctx, uid, ssid, jti)
+}
+
+// RotateRefreshToken 用旧的 refresh token 换一个新的
+// 如果旧的 refresh token 已经被用过，说明它可能被盗用了，
+// 这时整个 ssid 都会被注销，并返回 ErrRefreshTokenReused
+func (h *RedisJWTHandler) RotateRefreshToken(ctx *gin.Context, rc RefreshClaims) error {
+	newJti := uuid.New().String()
+	res, err := h.client.Eval(ctx, luaRotateRefresh,
+		[]string{h.refreshKey(rc.Ssid), h.ssidKey(rc.Ssid)},
+		rc.ID, newJti, int64(h.rcExpiration.Seconds())).Int()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case -1:
+		h.revokeLocal(ctx, rc.Ssid)
+		return ErrRefreshTokenReused
+	case -2:
+		return ErrRefreshTokenInvalid
+	default:
+		return h.setRefreshToken(ctx, rc.Uid, rc.Ssid, newJti)
+	}
+}
+
+func (h *RedisJWTHandler) setRefreshToken(ctx *gin.Context, uid int64, ssid string, jti string) error {
 	claims := RefreshClaims{
 		Uid:  uid,
 		Ssid: ssid,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(h.rcExpiration)),
 		},
 	}
@@ -100,6 +221,15 @@ func (h *RedisJWTHandler) SetLoginToken(ctx *gin.Context, uid int64) error {
 	if err != nil {
 		return err
 	}
+	err = h.addSession(ctx, uid, Session{
+		Ssid:      ssid,
+		Ctime:     time.Now().UnixMilli(),
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	})
+	if err != nil {
+		return err
+	}
 	err = h.SetJWTToken(ctx, uid, ssid)
 	if err != nil {
 		return err
@@ -125,3 +255,61 @@ func (h *RedisJWTHandler) SetJWTToken(ctx *gin.Context, uid int64, ssid string)
 	ctx.Header("x-jwt-token", ss)
 	return nil
 }
+
+// SetPreAuthToken 开启了两步验证的用户，密码登录之后只拿到这个短期的 token
+func (h *RedisJWTHandler) SetPreAuthToken(ctx *gin.Context, uid int64) error {
+	now := time.Now()
+	token := jwt.NewWithClaims(h.JwtMethod, PreAuthClaims{
+		Uid: uid,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(h.paExpiration)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	})
+	tokenStr, err := token.SignedString(PreAuthKey)
+	if err != nil {
+		return err
+	}
+	ctx.Header("x-pre-auth-token", tokenStr)
+	return nil
+}
+
+// ConsumePreAuthToken pre-auth token 只能换一次正式的 token
+func (h *RedisJWTHandler) ConsumePreAuthToken(ctx *gin.Context, pc PreAuthClaims) error {
+	ok, err := h.client.SetNX(ctx, fmt.Sprintf("users:preauth:%s", pc.ID), "", h.paExpiration).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPreAuthTokenUsed
+	}
+	return nil
+}
+
+// addSession 记录用户的登录会话，用来一次性注销所有登录
+func (h *RedisJWTHandler) addSession(ctx *gin.Context, uid int64, s Session) error {
+	val, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	key := h.sessionsKey(uid)
+	err = h.client.HSet(ctx, key, s.Ssid, string(val)).Err()
+	if err != nil {
+		return err
+	}
+	// 最近一次登录之后再过 rcExpiration，所有的 ssid 都已经过期了
+	return h.client.Expire(ctx, key, h.rcExpiration).Err()
+}
+
+func (h *RedisJWTHandler) ssidKey(ssid string) string {
+	return fmt.Sprintf("users:ssid:%s", ssid)
+}
+
+func (h *RedisJWTHandler) refreshKey(ssid string) string {
+	return fmt.Sprintf("users:refresh:%s", ssid)
+}
+
+func (h *RedisJWTHandler) sessionsKey(uid int64) string {
+	return fmt.Sprintf("users:sessions:%d", uid)
+}
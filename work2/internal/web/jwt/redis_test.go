@@ -0,0 +1,177 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"example/wb/internal/repository/cache/redismock"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRedisJWTHandler_RotateRefreshToken(t *testing.T) {
+	keys := []string{"users:refresh:ssid-1", "users:ssid:ssid-1"}
+	testCase := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) redis.Cmdable
+
+		rc RefreshClaims
+
+		wantErr    error
+		wantHeader bool
+	}{
+		{
+			name: "轮换成功",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				rcmd := redis.NewCmd(context.Background())
+				rcmd.SetVal(int64(0))
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().
+					Eval(gomock.Any(), luaRotateRefresh, keys, "jti-1", gomock.Any(), int64(604800)).
+					Return(rcmd)
+				return cmd
+			},
+			rc: RefreshClaims{
+				Uid:              1,
+				Ssid:             "ssid-1",
+				RegisteredClaims: jwt.RegisteredClaims{ID: "jti-1"},
+			},
+			wantHeader: true,
+		},
+		{
+			name: "重复使用",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				rcmd := redis.NewCmd(context.Background())
+				rcmd.SetVal(int64(-1))
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().
+					Eval(gomock.Any(), luaRotateRefresh, keys, "jti-old", gomock.Any(), int64(604800)).
+					Return(rcmd)
+				// 通知其他实例这个 ssid 已经注销
+				cmd.EXPECT().Publish(gomock.Any(), revokedChannel, "ssid-1").
+					Return(redis.NewIntCmd(context.Background()))
+				return cmd
+			},
+			rc: RefreshClaims{
+				Uid:              1,
+				Ssid:             "ssid-1",
+				RegisteredClaims: jwt.RegisteredClaims{ID: "jti-old"},
+			},
+			wantErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "ssid 不存在",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				rcmd := redis.NewCmd(context.Background())
+				rcmd.SetVal(int64(-2))
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().
+					Eval(gomock.Any(), luaRotateRefresh, keys, "", gomock.Any(), int64(604800)).
+					Return(rcmd)
+				return cmd
+			},
+			rc: RefreshClaims{
+				Uid:  1,
+				Ssid: "ssid-1",
+			},
+			wantErr: ErrRefreshTokenInvalid,
+		},
+		{
+			name: "redis错误",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				rcmd := redis.NewCmd(context.Background())
+				rcmd.SetErr(errors.New("redis错误"))
+				cmd := redismock.NewMockCmdable(ctrl)
+				cmd.EXPECT().
+					Eval(gomock.Any(), luaRotateRefresh, keys, "jti-1", gomock.Any(), int64(604800)).
+					Return(rcmd)
+				return cmd
+			},
+			rc: RefreshClaims{
+				Uid:              1,
+				Ssid:             "ssid-1",
+				RegisteredClaims: jwt.RegisteredClaims{ID: "jti-1"},
+			},
+			wantErr: errors.New("redis错误"),
+		},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hdl := NewJwtHandler(tt.mock(ctrl))
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+
+			err := hdl.RotateRefreshToken(ctx, tt.rc)
+			assert.Equal(t, tt.wantErr, err)
+
+			tokenStr := recorder.Header().Get("x-refresh-token")
+			if !tt.wantHeader {
+				assert.Empty(t, tokenStr)
+				return
+			}
+			var rc RefreshClaims
+			_, err = jwt.ParseWithClaims(tokenStr, &rc, func(t *jwt.Token) (interface{}, error) {
+				return RCJWTkey, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rc.Uid, rc.Uid)
+			assert.Equal(t, tt.rc.Ssid, rc.Ssid)
+			// 换出来的是一个新的 jti
+			assert.NotEmpty(t, rc.ID)
+			assert.NotEqual(t, tt.rc.ID, rc.ID)
+			assert.True(t, rc.ExpiresAt.After(time.Now().Add(time.Hour*24*6)))
+		})
+	}
+}
+
+func TestRedisJWTHandler_ClearAllTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rcmd := redis.NewCmd(context.Background())
+	rcmd.SetVal(int64(2))
+	cmd := redismock.NewMockCmdable(ctrl)
+	cmd.EXPECT().
+		Eval(gomock.Any(), luaRevokeAll, []string{"users:sessions:1"},
+			int64(604800), "users:ssid:", "users:refresh:", revokedChannel).
+		Return(rcmd)
+
+	hdl := NewJwtHandler(cmd)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	cnt, err := hdl.ClearAllTokens(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cnt)
+}
+
+func TestRedisJWTHandler_Sessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rcmd := redis.NewMapStringStringCmd(context.Background())
+	rcmd.SetVal(map[string]string{
+		"ssid-1": `{"ssid":"ssid-1","ctime":100,"ip":"127.0.0.1","user_agent":"chrome"}`,
+		"ssid-2": `{"ssid":"ssid-2","ctime":200,"ip":"127.0.0.2","user_agent":"safari"}`,
+	})
+	cmd := redismock.NewMockCmdable(ctrl)
+	cmd.EXPECT().HGetAll(gomock.Any(), "users:sessions:1").Return(rcmd)
+
+	hdl := NewJwtHandler(cmd)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	sessions, err := hdl.Sessions(ctx, 1)
+	assert.NoError(t, err)
+	// 最近的登录排在前面
+	assert.Equal(t, []Session{
+		{Ssid: "ssid-2", Ctime: 200, IP: "127.0.0.2", UserAgent: "safari"},
+		{Ssid: "ssid-1", Ctime: 100, IP: "127.0.0.1", UserAgent: "chrome"},
+	}, sessions)
+}
@@ -4,9 +4,14 @@ import "github.com/gin-gonic/gin"
 
 type Handler interface {
 	ClearToken(ctx *gin.Context) error
+	ClearAllTokens(ctx *gin.Context, uid int64) (int, error)
+	Sessions(ctx *gin.Context, uid int64) ([]Session, error)
 	ExtractToken(ctx *gin.Context) string
 	SetLoginToken(ctx *gin.Context, uid int64) error
 	SetJWTToken(ctx *gin.Context, uid int64, ssid string) error
 	SetRefreshToken(ctx *gin.Context, uid int64, ssid string) error
+	RotateRefreshToken(ctx *gin.Context, rc RefreshClaims) error
 	CheckSession(ctx *gin.Context, ssid string) error
+	SetPreAuthToken(ctx *gin.Context, uid int64) error
+	ConsumePreAuthToken(ctx *gin.Context, pc PreAuthClaims) error
 }
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/i18n"
+	"example/wb/pkg/ginx"
+	"example/wb/pkg/logger"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	guardBizPassword  = "login_password"
+	guardBizSMS       = "login_sms"
+	guardBizTwoFactor = "login_2fa"
+)
+
+var (
+	errLoginLocked     = errs.New(http.StatusTooManyRequests, errs.UserLoginLocked, "尝试次数过多，请稍后再试")
+	errCaptchaRequired = errs.New(http.StatusBadRequest, errs.UserCaptchaRequired, "请先完成人机验证")
+)
+
+type RetryAfterVo struct {
+	// 还需要等待的秒数
+	RetryAfter int64 `json:"retry_after"`
+}
+
+// checkLoginGuard 登录之前检查是否被锁定，以及是否需要人机验证
+// 返回 false 代表已经写回了响应，调用者直接返回即可
+func (h *UserHandler) checkLoginGuard(ctx *gin.Context, biz, account, captchaTicket string) bool {
+	state, err := h.guardSvc.Check(ctx, biz, account, ctx.ClientIP())
+	if err != nil {
+		// redis 出问题的时候放行，不影响正常登录，但是要告警
+		h.l.Error("检查登录防护失败", logger.Error(err), logger.String("biz", biz))
+		return true
+	}
+	if state.RetryAfter > 0 {
+		h.writeLocked(ctx, state)
+		return false
+	}
+	if !state.NeedCaptcha {
+		return true
+	}
+	ok, err := h.captchaSvc.Verify(ctx, captchaTicket, ctx.ClientIP())
+	if err != nil {
+		ginx.RenderError(ctx, h.l, err)
+		return false
+	}
+	if !ok {
+		ginx.RenderError(ctx, h.l, errCaptchaRequired)
+		return false
+	}
+	return true
+}
+
+// failLoginGuard 记录一次失败，如果因此被锁定了就写回响应并返回 false
+func (h *UserHandler) failLoginGuard(ctx *gin.Context, biz, account string) bool {
+	state, err := h.guardSvc.Fail(ctx, biz, account, ctx.ClientIP())
+	if err != nil {
+		h.l.Error("记录登录失败次数失败", logger.Error(err), logger.String("biz", biz))
+		return true
+	}
+	if state.RetryAfter > 0 {
+		h.l.Warn("登录失败次数过多，已锁定",
+			logger.String("biz", biz),
+			logger.String("account", account),
+			logger.String("ip", ctx.ClientIP()),
+			logger.Int("failures", state.Failures),
+		)
+		h.writeLocked(ctx, state)
+		return false
+	}
+	return true
+}
+
+func (h *UserHandler) resetLoginGuard(ctx *gin.Context, biz, account string) {
+	err := h.guardSvc.Reset(ctx, biz, account)
+	if err != nil {
+		h.l.Error("重置登录失败次数失败", logger.Error(err), logger.String("biz", biz))
+	}
+}
+
+func (h *UserHandler) writeLocked(ctx *gin.Context, state domain.LoginGuard) {
+	secs := int64(math.Ceil(state.RetryAfter.Seconds()))
+	ctx.Header("Retry-After", strconv.FormatInt(secs, 10))
+	// 锁定的时候还要告诉前端需要等待多久，不能直接用 ginx.RenderError
+	ctx.JSON(errLoginLocked.Status, Result{
+		Code: errLoginLocked.Code,
+		Msg:  i18n.T(ctx, i18n.MsgLoginLockedRetry, secs),
+		Data: RetryAfterVo{RetryAfter: secs},
+	})
+}
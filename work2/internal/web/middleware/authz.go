@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"example/wb/internal/domain"
+	"example/wb/internal/service"
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthzMiddlewareBuilder 路由声明自己需要的权限，要放在登录校验的后面
+//
+//	g.POST("/ban", b.Require(domain.PermissionUserBan), h.Ban)
+type AuthzMiddlewareBuilder struct {
+	svc service.AuthzService
+	l   logger.Logger
+}
+
+func NewAuthzMiddlewareBuilder(svc service.AuthzService, l logger.Logger) *AuthzMiddlewareBuilder {
+	return &AuthzMiddlewareBuilder{
+		svc: svc,
+		l:   l,
+	}
+}
+
+// Require 必须同时拥有 perms 里面的所有权限
+func (b *AuthzMiddlewareBuilder) Require(perms ...domain.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		val, ok := ctx.Get("user")
+		if !ok {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		uc, ok := val.(ijwt.UserClaims)
+		if !ok {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ok, err := b.svc.HasPermissions(ctx, uc.Id, perms...)
+		if err != nil {
+			// 查不到权限的时候一律拒绝
+			b.l.Error("查询用户权限失败", logger.Int64("uid", uc.Id), logger.Error(err))
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+	}
+}
@@ -2,10 +2,14 @@ package middleware
 
 import (
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"example/wb/internal/domain"
+	"example/wb/internal/service"
 	ijwt "example/wb/internal/web/jwt"
 
 	"github.com/gin-contrib/sessions"
@@ -13,8 +17,23 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var (
+	errNoToken      = errors.New("没有带 token")
+	errInvalidToken = errors.New("token 不合法")
+	// 路由没有用 AccessTokenRoute 声明，不能用 personal access token 访问
+	errScopeNotDeclared = errors.New("路由不接受 personal access token")
+	errScopeDenied      = errors.New("personal access token 没有这个 scope")
+)
+
 type LoginMiddlewareBuilder struct {
 	ijwt.Handler
+	tokenSvc service.AccessTokenService
+	// 接受 personal access token 的路由，key 是 method 加上 gin 的路由，例如 POST /detail/:id
+	scopes map[string]domain.Scope
+	// 不需要登录的路由
+	ignores []pathPattern
+	// 登录了就带上 claims，没有登录也放行的路由
+	optionals []pathPattern
 }
 
 func NewLoginMiddlewareBuilder(hdl ijwt.Handler) *LoginMiddlewareBuilder {
@@ -23,10 +42,46 @@ func NewLoginMiddlewareBuilder(hdl ijwt.Handler) *LoginMiddlewareBuilder {
 	}
 }
 
+// AllowAccessToken 除了 JWT 之外，也接受 personal access token
+func (m *LoginMiddlewareBuilder) AllowAccessToken(svc service.AccessTokenService) *LoginMiddlewareBuilder {
+	m.tokenSvc = svc
+	return m
+}
+
+// AccessTokenRoute personal access token 默认什么都不能访问，只有这里声明过的路由才放行，
+// 并且 token 要有对应的 scope。管理 token、注销账号这些敏感操作不声明就只能用 JWT 登录。
+// path 和注册路由的时候写法一样，例如 /detail/:id
+func (m *LoginMiddlewareBuilder) AccessTokenRoute(method, path string, scope domain.Scope) *LoginMiddlewareBuilder {
+	if m.scopes == nil {
+		m.scopes = make(map[string]domain.Scope)
+	}
+	m.scopes[method+" "+path] = scope
+	return m
+}
+
+// IgnorePaths 这些路由不需要登录，支持三种写法：
+// 完全匹配 /user/login；
+// 参数 /article/pub/:id，:id 匹配一段路径；
+// 前缀 /oauth2/*，* 只能放在最后，匹配剩下的所有路径
+func (m *LoginMiddlewareBuilder) IgnorePaths(paths ...string) *LoginMiddlewareBuilder {
+	for _, p := range paths {
+		m.ignores = append(m.ignores, compilePattern(p))
+	}
+	return m
+}
+
+// OptionalPaths 这些路由登录和不登录都可以访问，例如帖子详情、热榜，
+// 写法和 IgnorePaths 一样
+func (m *LoginMiddlewareBuilder) OptionalPaths(paths ...string) *LoginMiddlewareBuilder {
+	for _, p := range paths {
+		m.optionals = append(m.optionals, compilePattern(p))
+	}
+	return m
+}
+
 func (m *LoginMiddlewareBuilder) CheckLogin() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		path := ctx.Request.URL.Path
-		if path == "/user/signup" || path == "/user/login" {
+		if matchAny(m.ignores, ctx.Request.URL.Path) {
 			return
 		}
 		sess := sessions.Default(ctx)
@@ -58,52 +113,101 @@ func (m *LoginMiddlewareBuilder) CheckLogin() gin.HandlerFunc {
 	}
 }
 
+// CheckJWTLogin 全局使用，除了 IgnorePaths 和 OptionalPaths 之外的路由都必须登录
 func (m *LoginMiddlewareBuilder) CheckJWTLogin() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		path := ctx.Request.URL.Path
-		if path == "/user/signup" ||
-			path == "/user/login" ||
-			path == "/user/hello" ||
-			path == "/user/login_sms/code/send" ||
-			path == "/user/login_sms" ||
-			path == "/oauth2/wechat/authurl" ||
-			path == "/oauth2/wechat/callback" {
+		switch {
+		case matchAny(m.ignores, path):
+			return
+		case matchAny(m.optionals, path):
+			m.tryLogin(ctx)
 			return
 		}
-		tokenStr := m.ExtractToken(ctx)
+		m.requireLogin(ctx)
+	}
+}
 
-		var uc ijwt.UserClaims
+// Required 给路由分组单独使用，例如默认都公开的服务里面
+// server.Group("/creator", builder.Required())，不看 IgnorePaths
+func (m *LoginMiddlewareBuilder) Required() gin.HandlerFunc {
+	return m.requireLogin
+}
 
-		token, err := jwt.ParseWithClaims(tokenStr, &uc, func(t *jwt.Token) (interface{}, error) {
-			return ijwt.JWTtoken, nil
-		})
-		if err != nil {
-			// token 解析不出
-			ctx.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
+// Optional 给路由分组单独使用，带了合法的 token 就设置 claims，
+// 没有带或者 token 不对都当作匿名用户放行
+func (m *LoginMiddlewareBuilder) Optional() gin.HandlerFunc {
+	return m.tryLogin
+}
 
-		if !token.Valid {
-			// token 解析出来不对
-			ctx.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
-		err = m.CheckSession(ctx, uc.Ssid)
-		if err != nil {
-			// token无效或者redis有问题
-			// 过于严格
-			ctx.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
-		// 可以兼容redis异常时的情况
-		// 同时要做好监控有没有error
-		// 通常情况下使用这种写法
-		// if cnt > 0 {
-		// 	ctx.AbortWithStatus(http.StatusUnauthorized)
-		// 	return
-		// }
+func (m *LoginMiddlewareBuilder) requireLogin(ctx *gin.Context) {
+	err := m.login(ctx)
+	switch {
+	case err == nil:
+	case errors.Is(err, errScopeNotDeclared), errors.Is(err, errScopeDenied):
+		ctx.AbortWithStatus(http.StatusForbidden)
+	default:
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+	}
+}
+
+func (m *LoginMiddlewareBuilder) tryLogin(ctx *gin.Context) {
+	// 匿名访问很常见，没必要记录；token 是对的但是路由不接受，不能当作匿名用户放过去
+	err := m.login(ctx)
+	if errors.Is(err, errScopeNotDeclared) || errors.Is(err, errScopeDenied) {
+		ctx.AbortWithStatus(http.StatusForbidden)
+	}
+}
+
+// login 校验请求带的 JWT 或者 personal access token，通过之后把 claims 放进 ctx
+func (m *LoginMiddlewareBuilder) login(ctx *gin.Context) error {
+	tokenStr := m.ExtractToken(ctx)
+	if tokenStr == "" {
+		return errNoToken
+	}
+	if m.tokenSvc != nil && strings.HasPrefix(tokenStr, domain.AccessTokenPrefix) {
+		return m.checkAccessToken(ctx, tokenStr)
+	}
+
+	var uc ijwt.UserClaims
 
-		ctx.Set("user", uc)
+	token, err := jwt.ParseWithClaims(tokenStr, &uc, func(t *jwt.Token) (interface{}, error) {
+		return ijwt.JWTtoken, nil
+	})
+	if err != nil {
+		// token 解析不出
+		return err
+	}
 
+	if !token.Valid {
+		// token 解析出来不对
+		return errInvalidToken
+	}
+	// ssid 已经注销；或者 redis 不可用并且没有开启降级放行
+	err = m.CheckSession(ctx, uc.Ssid)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set("user", uc)
+	return nil
+}
+
+func (m *LoginMiddlewareBuilder) checkAccessToken(ctx *gin.Context, tokenStr string) error {
+	t, err := m.tokenSvc.Verify(ctx, tokenStr)
+	if err != nil {
+		return err
+	}
+	// 默认拒绝，只有声明了 scope 的路由才接受 personal access token。
+	// 全局中间件执行的时候路由已经匹配好了，FullPath 就是注册的路由
+	scope, ok := m.scopes[ctx.Request.Method+" "+ctx.FullPath()]
+	if !ok {
+		return errScopeNotDeclared
+	}
+	if !t.HasScope(scope) {
+		return errScopeDenied
 	}
+	// 后面的 handler 不需要关心是怎么登录的
+	ctx.Set("user", ijwt.UserClaims{Id: t.Uid})
+	return nil
 }
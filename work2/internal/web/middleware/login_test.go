@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"example/wb/internal/domain"
+	svcmock "example/wb/internal/service/mocks"
+	ijwt "example/wb/internal/web/jwt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPathPattern_Match(t *testing.T) {
+	testCase := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{name: "完全匹配", pattern: "/user/login", path: "/user/login", want: true},
+		{name: "末尾斜杠", pattern: "/user/login", path: "/user/login/", want: true},
+		{name: "多一段", pattern: "/user/login", path: "/user/login/2fa", want: false},
+		{name: "参数", pattern: "/article/pub/:id", path: "/article/pub/123", want: true},
+		{name: "参数不能为空", pattern: "/article/pub/:id", path: "/article/pub/", want: false},
+		{name: "参数只匹配一段", pattern: "/article/pub/:id", path: "/article/pub/1/2", want: false},
+		{name: "前缀", pattern: "/oauth2/*", path: "/oauth2/wechat/callback", want: true},
+		{name: "gin风格前缀", pattern: "/oauth2/*path", path: "/oauth2/github/authurl", want: true},
+		{name: "前缀不匹配", pattern: "/oauth2/*", path: "/oauth3/wechat", want: false},
+		{name: "前缀和参数", pattern: "/:biz/hot/*", path: "/article/hot/daily", want: true},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, compilePattern(tc.pattern).match(tc.path))
+		})
+	}
+}
+
+func TestLoginMiddlewareBuilder_CheckJWTLogin(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		path  string
+		token string
+
+		wantCode   int
+		wantClaims bool
+	}{
+		{
+			name:     "公开的路由",
+			path:     "/user/login",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "公开的前缀",
+			path:     "/oauth2/wechat/callback",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "没有登录",
+			path:     "/user/profile",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token不对",
+			path:     "/user/profile",
+			token:    "Bearer abc",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "可选登录的匿名用户",
+			path:     "/article/pub/123",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "可选登录的token不对也当作匿名",
+			path:     "/article/pub/123",
+			token:    "Bearer abc",
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewLoginMiddlewareBuilder(ijwt.NewJwtHandler(nil)).
+				IgnorePaths("/user/login", "/oauth2/*").
+				OptionalPaths("/article/pub/:id")
+			server := gin.New()
+			server.Use(m.CheckJWTLogin())
+			var hasClaims bool
+			server.Any("/*path", func(ctx *gin.Context) {
+				_, hasClaims = ctx.Get("user")
+				ctx.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", tc.token)
+			}
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantClaims, hasClaims)
+		})
+	}
+}
+
+func TestLoginMiddlewareBuilder_Group(t *testing.T) {
+	m := NewLoginMiddlewareBuilder(ijwt.NewJwtHandler(nil))
+	server := gin.New()
+	// 没有全局的登录校验，分组自己选择
+	server.GET("/hot", m.Optional(), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	server.GET("/creator/list", m.Required(), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/hot", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/creator/list", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestLoginMiddlewareBuilder_AccessToken(t *testing.T) {
+	const token = domain.AccessTokenPrefix + "abc"
+	testCase := []struct {
+		name string
+
+		path string
+
+		wantCode int
+	}{
+		{
+			name:     "声明了scope并且token有这个scope",
+			path:     "/article/list",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "声明了scope但是token没有这个scope",
+			path:     "/article/publish",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "路由参数按照注册的写法匹配",
+			path:     "/article/detail/123",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "没有声明scope的路由默认拒绝",
+			path:     "/user/tokens",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "可选登录的路由也不能当作匿名用户放过去",
+			path:     "/article/pub/123",
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tokenSvc := svcmock.NewMockAccessTokenService(ctrl)
+			tokenSvc.EXPECT().Verify(gomock.Any(), token).Return(domain.AccessToken{
+				Uid:    1,
+				Scopes: []domain.Scope{domain.ScopeArticleRead},
+			}, nil)
+			m := NewLoginMiddlewareBuilder(ijwt.NewJwtHandler(nil)).
+				AllowAccessToken(tokenSvc).
+				AccessTokenRoute(http.MethodPost, "/article/list", domain.ScopeArticleRead).
+				AccessTokenRoute(http.MethodPost, "/article/publish", domain.ScopeArticleWrite).
+				AccessTokenRoute(http.MethodPost, "/article/detail/:id", domain.ScopeArticleRead).
+				OptionalPaths("/article/pub/:id")
+			server := gin.New()
+			server.Use(m.CheckJWTLogin())
+			ok := func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			}
+			server.POST("/article/list", ok)
+			server.POST("/article/publish", ok)
+			server.POST("/article/detail/:id", ok)
+			server.POST("/user/tokens", ok)
+			server.POST("/article/pub/:id", ok)
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import "strings"
+
+// pathPattern 按照 / 切分之后逐段比较，
+// :name 匹配任意一段，最后一段是 * 的时候匹配剩下的所有路径
+type pathPattern struct {
+	segs   []string
+	prefix bool
+}
+
+func compilePattern(p string) pathPattern {
+	segs := strings.Split(strings.Trim(p, "/"), "/")
+	last := segs[len(segs)-1]
+	if last == "*" || strings.HasPrefix(last, "*") {
+		return pathPattern{segs: segs[:len(segs)-1], prefix: true}
+	}
+	return pathPattern{segs: segs}
+}
+
+func (p pathPattern) match(path string) bool {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segs) < len(p.segs) || (!p.prefix && len(segs) != len(p.segs)) {
+		return false
+	}
+	for i, seg := range p.segs {
+		if strings.HasPrefix(seg, ":") {
+			if segs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != segs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(patterns []pathPattern, path string) bool {
+	for _, p := range patterns {
+		if p.match(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package web
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/i18n"
+	"example/wb/internal/service"
+	"example/wb/internal/service/oauth2"
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/ginx"
+	"example/wb/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errOAuth2Denied      = errs.New(http.StatusBadRequest, errs.OAuth2Denied, "授权失败")
+	errOAuth2InvalidCode = errs.New(http.StatusBadRequest, errs.OAuth2InvalidCode, "授权码有误")
+)
+
+// OAuth2Handler 除微信之外的第三方登录，每个 Provider 挂载一组路由
+// /oauth2/<name>/authurl 和 /oauth2/<name>/callback
+type OAuth2Handler struct {
+	ijwt.Handler
+	providers map[string]oauth2.Provider
+	userSvc   service.UserService
+	stateSvc  service.OAuth2StateService
+	auditSvc  service.AuditService
+	l         logger.Logger
+}
+
+func NewOAuth2Handler(providers []oauth2.Provider,
+	hdl ijwt.Handler,
+	l logger.Logger,
+	userSvc service.UserService,
+	stateSvc service.OAuth2StateService,
+	auditSvc service.AuditService) *OAuth2Handler {
+	m := make(map[string]oauth2.Provider, len(providers))
+	for _, p := range providers {
+		m[p.Name()] = p
+	}
+	return &OAuth2Handler{
+		Handler:   hdl,
+		providers: m,
+		userSvc:   userSvc,
+		stateSvc:  stateSvc,
+		auditSvc:  auditSvc,
+		l:         l,
+	}
+}
+
+func (o *OAuth2Handler) RegisterRoutes(server *gin.Engine) {
+	for name, p := range o.providers {
+		g := server.Group("/oauth2/" + name)
+		g.GET("/authurl", ginx.Wrap(o.l, o.AuthURL(p)))
+		g.Any("/callback", ginx.Wrap(o.l, o.CallBack(p)))
+	}
+}
+
+func (o *OAuth2Handler) AuthURL(p oauth2.Provider) func(ctx *gin.Context) (ginx.Result, error) {
+	return func(ctx *gin.Context) (ginx.Result, error) {
+		s, err := o.stateSvc.Create(ctx, p.Name(), ctx.Query("redirect"))
+		if err != nil {
+			return ginx.Result{}, err
+		}
+		setStateCookie(ctx, p.Name(), s.State)
+		val, err := p.AuthURL(ctx, o.authState(s))
+		if err != nil {
+			return ginx.Result{}, err
+		}
+		return ginx.Result{
+			Data: val,
+		}, nil
+	}
+}
+
+func (o *OAuth2Handler) CallBack(p oauth2.Provider) func(ctx *gin.Context) (ginx.Result, error) {
+	return func(ctx *gin.Context) (ginx.Result, error) {
+		state := ctx.Query("state")
+		if err := checkStateCookie(ctx, p.Name(), state); err != nil {
+			return ginx.Result{}, err
+		}
+		s, err := o.stateSvc.Consume(ctx, p.Name(), state)
+		if err != nil {
+			return ginx.Result{}, err
+		}
+		code := ctx.Query("code")
+		if code == "" {
+			// 用户在第三方平台上拒绝了授权
+			return ginx.Result{}, errOAuth2Denied
+		}
+		identity, err := p.VerifyCode(ctx, code, o.authState(s))
+		if err != nil {
+			o.l.Warn("第三方授权码校验失败", logger.String("provider", p.Name()), logger.Error(err))
+			return ginx.Result{}, errOAuth2InvalidCode.Wrap(err)
+		}
+		u, err := o.userSvc.FindOrCreateByIdentity(ctx, identity)
+		detail := p.Name()
+		if err != nil {
+			detail = p.Name() + ": " + err.Error()
+		}
+		recordAudit(ctx, o.auditSvc, domain.AuditEvent{
+			Uid:     u.Id,
+			Action:  domain.AuditLogin,
+			Target:  identity.Subject,
+			Success: err == nil,
+			Detail:  detail,
+		})
+		if err != nil {
+			return ginx.Result{}, err
+		}
+		err = o.SetLoginToken(ctx, u.Id)
+		if err != nil {
+			return ginx.Result{}, err
+		}
+		return ginx.Result{
+			Msg:  i18n.T(ctx, i18n.MsgOK),
+			Data: s.Redirect,
+		}, nil
+	}
+}
+
+func (o *OAuth2Handler) authState(s domain.OAuth2State) oauth2.AuthState {
+	return oauth2.AuthState{
+		State:        s.State,
+		Nonce:        s.Nonce,
+		CodeVerifier: s.CodeVerifier,
+	}
+}
+
+const stateCookieName = "oauth2_state"
+
+// setStateCookie 把 state 的哈希写到 cookie 里面，回调的时候要求一致，
+// 这样 state 就和发起登录的浏览器绑定了，别人拿自己的 state 和授权码构造的回调链接没有用。
+// 回调是第三方平台跳转回来的跨站请求，所以只能用 Lax
+func setStateCookie(ctx *gin.Context, provider string, state string) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(stateCookieName, stateHash(state), 600,
+		"/oauth2/"+provider, "", true, true)
+}
+
+func checkStateCookie(ctx *gin.Context, provider string, state string) error {
+	val, err := ctx.Cookie(stateCookieName)
+	// 不管是否通过都只能用一次
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(stateCookieName, "", -1, "/oauth2/"+provider, "", true, true)
+	if err != nil || state == "" ||
+		subtle.ConstantTimeCompare([]byte(val), []byte(stateHash(state))) != 1 {
+		return service.ErrInvalidOAuth2State
+	}
+	return nil
+}
+
+func stateHash(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,7 +1,38 @@
 package web
 
-type Result struct {
-	Code int
-	Msg  string
-	Data any
+import (
+	"errors"
+	"example/wb/internal/errs"
+	"example/wb/internal/i18n"
+	"example/wb/pkg/ginx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Result 和 ginx 的保持一致，直接写响应的地方也用同一个格式
+type Result = ginx.Result
+
+func init() {
+	ginx.SetErrorRenderer(errorRenderer{})
+}
+
+// errorRenderer 让 ginx 按照 errs 的错误码和 i18n 的语言写错误响应
+type errorRenderer struct{}
+
+func (errorRenderer) Render(ctx *gin.Context, err error) (int, int, string) {
+	var e *errs.Error
+	switch {
+	case errors.As(err, &e):
+	case errors.Is(err, ginx.ErrInvalidParam):
+		e = errs.ErrInvalidParam
+	case errors.Is(err, ginx.ErrUnauthorized):
+		e = errs.ErrUnauthorized
+	default:
+		e = errs.From(err)
+	}
+	return e.Status, e.Code, i18n.Error(ctx, e)
+}
+
+func (errorRenderer) Locale(ctx *gin.Context) string {
+	return i18n.Locale(ctx)
 }
@@ -1,68 +1,78 @@
 package web
 
 import (
+	"errors"
 	"example/wb/internal/domain"
+	"example/wb/internal/errs"
+	"example/wb/internal/i18n"
 	"example/wb/internal/service"
+	"example/wb/internal/service/captcha"
 	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/ginx"
 	"example/wb/pkg/logger"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/dlclark/regexp2"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-const (
-	emailRegex = "^[a-z0-9A-Z]+[-|a-z0-9A-Z._]+@([a-z0-9A-Z]+(-[a-z0-9A-Z]+)?\\.)+[a-z]{2,}$"
-	// 至少一个小写字母、至少一个大写字母、至少一个数字和至少一个特殊字符
-	passwordRegex = `^(?=.*[a-z])(?=.*[A-Z])(?=.*\d)(?=.*[@!%*?&])[A-Za-z\d@!%*?&]{8,}$`
-	birthdayRegex = `\d{4}-\d{1,2}-\d{1,2}`
-	bizLogin      = "login"
-)
+const bizLogin = "login"
+
+var errWrongCode = errs.New(http.StatusBadRequest, errs.CodeInvalid, "验证码不对，请重新输入")
 
-var ErrSendTooMany = service.ErrSendTooMany
-var ErrCodeVertifyTooMany = service.ErrCodeVertifyTooMany
-var ErrDuplicateUser = service.ErrDuplicateUser
+// errTwoFactorPending 只在审计日志里面用，密码对了但是还没有通过两步验证，不算登录成功
+var errTwoFactorPending = errors.New("2fa_pending")
 
 type UserHandler struct {
 	ijwt.Handler
-	EmailRegexExp    *regexp2.Regexp
-	PasswordRegexExp *regexp2.Regexp
-	BirthdayRegexExp *regexp2.Regexp
-	svc              service.UserService
-	codeSvc          service.CodeService
-	l                logger.Logger
+	svc          service.UserService
+	codeSvc      service.CodeService
+	twoFactorSvc service.TwoFactorService
+	guardSvc     service.LoginGuardService
+	captchaSvc   captcha.Service
+	auditSvc     service.AuditService
+	l            logger.Logger
 }
 
 func NewUserHandler(svc service.UserService,
 	hdl ijwt.Handler,
 	l logger.Logger,
-	codeSvc service.CodeService) *UserHandler {
+	codeSvc service.CodeService,
+	twoFactorSvc service.TwoFactorService,
+	guardSvc service.LoginGuardService,
+	captchaSvc captcha.Service,
+	auditSvc service.AuditService) *UserHandler {
 	return &UserHandler{
-		EmailRegexExp:    regexp2.MustCompile(emailRegex, regexp2.None),
-		PasswordRegexExp: regexp2.MustCompile(passwordRegex, regexp2.None),
-		BirthdayRegexExp: regexp2.MustCompile(birthdayRegex, regexp2.None),
-		svc:              svc,
-		codeSvc:          codeSvc,
-		Handler:          hdl,
-		l:                l,
+		svc:          svc,
+		codeSvc:      codeSvc,
+		twoFactorSvc: twoFactorSvc,
+		guardSvc:     guardSvc,
+		captchaSvc:   captchaSvc,
+		auditSvc:     auditSvc,
+		Handler:      hdl,
+		l:            l,
 	}
 }
 
 func (h *UserHandler) RegisterRoutes(g *gin.Engine) {
 	ug := g.Group("/user")
 	ug.GET("/hello", h.Hello)
-	ug.POST("/signup", h.SignUp)
-	// ug.POST("/login", h.Login)
-	ug.POST("/login", h.LoginJWT)
-	ug.POST("/logout", h.LogoutJWT)
-	ug.POST("/login_sms/code/send", h.SendSMSLoginCode)
-	ug.POST("/login_sms", h.LoginSMS)
-	ug.POST("/refresh", h.RefreshToken)
-	ug.POST("/edit", h.Edit)
-	ug.GET("/profile", h.Profile)
+	ug.POST("/signup", ginx.WrapBody(h.l, h.SignUp))
+	// ug.POST("/login", ginx.WrapBody(h.l, h.Login))
+	ug.POST("/login", ginx.WrapBody(h.l, h.LoginJWT))
+	ug.POST("/login/2fa", ginx.WrapBody(h.l, h.LoginTwoFactor))
+	ug.POST("/logout", ginx.WrapClaims(h.l, h.LogoutJWT))
+	ug.POST("/login_sms/code/send", ginx.WrapBody(h.l, h.SendSMSLoginCode))
+	ug.POST("/login_sms", ginx.WrapBody(h.l, h.LoginSMS))
+	ug.POST("/refresh", ginx.Wrap(h.l, h.RefreshToken))
+	ug.POST("/edit", ginx.WrapBodyAndClaims(h.l, h.Edit))
+	ug.GET("/profile", ginx.WrapClaims(h.l, h.Profile))
+	// 两步验证只能用 JWT 登录之后修改
+	ug.POST("/2fa/totp/enroll", ginx.WrapClaims(h.l, h.EnrollTOTP))
+	ug.POST("/2fa/totp/enable", ginx.WrapBodyAndClaims(h.l, h.EnableTOTP))
 }
 
 func (h *UserHandler) Hello(ctx *gin.Context) {
@@ -71,368 +81,365 @@ func (h *UserHandler) Hello(ctx *gin.Context) {
 
 }
 
-func (h *UserHandler) SignUp(ctx *gin.Context) {
-	type SignUpReq struct {
-		Email           string `json:"email"`
-		Password        string `json:"password"`
-		ConfirmPassword string `json:"confirmpassword"`
-	}
-	var sr SignUpReq
-	if err := ctx.Bind(&sr); err != nil {
-		return
-	}
-	isEmail, err := h.EmailRegexExp.MatchString(sr.Email)
+// SignUpReq 校验规则见 ginx 里面注册的 validator
+type SignUpReq struct {
+	Email           string `json:"email" binding:"required,email"`
+	Password        string `json:"password" binding:"required,password"`
+	ConfirmPassword string `json:"confirmpassword" binding:"required,eqfield=Password"`
+}
+
+func (h *UserHandler) SignUp(ctx *gin.Context, req SignUpReq) (ginx.Result, error) {
+	err := h.svc.SignUp(ctx.Request.Context(), domain.User{
+		Email:    req.Email,
+		Password: req.Password,
+	})
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
-	}
-	if !isEmail {
-		ctx.String(http.StatusOK, "email不合法")
-		return
+		return ginx.Result{}, err
 	}
-	if sr.Password != sr.ConfirmPassword {
-		ctx.String(http.StatusOK, "两次密码不同")
-		return
-	}
-	isPasswd, err := h.PasswordRegexExp.MatchString(sr.Password)
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgSignUpSuccess)}, nil
+}
+
+type LoginReq struct {
+	Email    string `json:"email"`
+	Passowrd string `json:"password"`
+	// 失败次数过多之后需要带上人机验证的票据
+	Captcha string `json:"captcha"`
+}
+
+func (h *UserHandler) Login(ctx *gin.Context, req LoginReq) (ginx.Result, error) {
+	u, err := h.svc.Login(ctx, domain.User{
+		Email:    req.Email,
+		Password: req.Passowrd,
+	})
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
+		return ginx.Result{}, err
 	}
-	if !isPasswd {
-		ctx.String(http.StatusOK, "密码不合法")
-		return
-	}
-	err = h.svc.SignUp(ctx.Request.Context(), domain.User{
-		Email:    sr.Email,
-		Password: sr.Password,
+	sess := sessions.Default(ctx)
+	sess.Set("userId", u.Id)
+	sess.Options(sessions.Options{
+		MaxAge: 600,
 	})
-	switch err {
-	case ErrDuplicateUser:
-		ctx.String(http.StatusOK, ErrDuplicateUser.Error())
-	case nil:
-		ctx.String(http.StatusOK, "注册成功")
-	default:
-		ctx.String(http.StatusOK, "系统错误")
+	err = sess.Save()
+	if err != nil {
+		return ginx.Result{}, err
 	}
-
-	defer h.LogPrint(err)
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLoginSuccess)}, nil
 }
 
-func (h *UserHandler) Login(ctx *gin.Context) {
-	type Req struct {
-		Email    string `json:"email"`
-		Passowrd string `json:"password"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
+func (h *UserHandler) LoginJWT(ctx *gin.Context, req LoginReq) (ginx.Result, error) {
+	// 登录防护已经写回了响应的时候，直接返回空的 Result，ginx 不会再写一次
+	if !h.checkLoginGuard(ctx, guardBizPassword, req.Email, req.Captcha) {
+		return ginx.Result{}, nil
 	}
 	u, err := h.svc.Login(ctx, domain.User{
 		Email:    req.Email,
 		Password: req.Passowrd,
 	})
-	switch err {
-	case nil:
-		sess := sessions.Default(ctx)
-		sess.Set("userId", u.Id)
-		sess.Options(sessions.Options{
-			MaxAge: 600,
-		})
-		err = sess.Save()
+	if err != nil {
+		h.auditLogin(ctx, u.Id, req.Email, "password", err)
+	}
+	if errors.Is(err, service.ErrInvalidUserOrPassword) &&
+		!h.failLoginGuard(ctx, guardBizPassword, req.Email) {
+		return ginx.Result{}, nil
+	}
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	h.resetLoginGuard(ctx, guardBizPassword, req.Email)
+	enabled, err := h.twoFactorSvc.IsEnabled(ctx, u.Id)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	if enabled {
+		// 密码泄露但是两步验证没过的时候，用户也能在安全记录里面看到，
+		// 登录成功等 LoginTwoFactor 验证通过之后再记
+		h.auditLogin(ctx, u.Id, req.Email, "password", errTwoFactorPending)
+		// 开启了两步验证，先给一个短期的 pre-auth token
+		// 前端带着它和验证码去 /user/login/2fa 换正式的 token
+		err = h.SetPreAuthToken(ctx, u.Id)
 		if err != nil {
-			ctx.String(http.StatusOK, "系统错误")
-			return
+			return ginx.Result{}, err
 		}
-		ctx.String(http.StatusOK, "登录成功")
-	case service.ErrInvalidUserOrPassword:
-		ctx.String(http.StatusOK, "用户名或者密码不对")
-	default:
-		ctx.String(http.StatusOK, "系统错误")
+		return ginx.Result{Msg: i18n.T(ctx, i18n.MsgTwoFactorRequired)}, nil
 	}
+	err = h.SetLoginToken(ctx, u.Id)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	h.auditLogin(ctx, u.Id, req.Email, "password", nil)
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLoginSuccess)}, nil
+}
 
-	defer h.LogPrint(err)
-
+type LoginTwoFactorReq struct {
+	Code    string `json:"code"`
+	Captcha string `json:"captcha"`
 }
 
-func (h *UserHandler) LoginJWT(ctx *gin.Context) {
-	type Req struct {
-		Email    string `json:"email"`
-		Passowrd string `json:"password"`
+// LoginTwoFactor 用 pre-auth token 和两步验证码换正式的 token
+func (h *UserHandler) LoginTwoFactor(ctx *gin.Context, req LoginTwoFactorReq) (ginx.Result, error) {
+	// 约定前端将 pre-auth token 放入到 authorization 里面带上
+	tokenStr := h.ExtractToken(ctx)
+	var pc ijwt.PreAuthClaims
+	token, err := jwt.ParseWithClaims(tokenStr, &pc, func(t *jwt.Token) (interface{}, error) {
+		return ijwt.PreAuthKey, nil
+	})
+	if err != nil || token == nil || !token.Valid {
+		return ginx.Result{}, errs.ErrUnauthorized
 	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
+	account := strconv.FormatInt(pc.Uid, 10)
+	if !h.checkLoginGuard(ctx, guardBizTwoFactor, account, req.Captcha) {
+		return ginx.Result{}, nil
 	}
-	u, err := h.svc.Login(ctx, domain.User{
-		Email:    req.Email,
-		Password: req.Passowrd,
-	})
-	switch err {
-	case nil:
-		err := h.SetLoginToken(ctx, u.Id)
-		if err != nil {
-			ctx.String(http.StatusOK, "系统错误")
-			return
+	ok, err := h.twoFactorSvc.Verify(ctx, pc.Uid, req.Code)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	if !ok {
+		h.auditLogin(ctx, pc.Uid, account, "2fa", service.ErrInvalidTwoFactorCode)
+		if !h.failLoginGuard(ctx, guardBizTwoFactor, account) {
+			return ginx.Result{}, nil
 		}
-		ctx.String(http.StatusOK, "登录成功")
-	case service.ErrInvalidUserOrPassword:
-		ctx.String(http.StatusOK, "用户名或者密码不对")
-	default:
-		ctx.String(http.StatusOK, "系统错误")
+		return ginx.Result{}, service.ErrInvalidTwoFactorCode
 	}
+	h.resetLoginGuard(ctx, guardBizTwoFactor, account)
+	err = h.ConsumePreAuthToken(ctx, pc)
+	if err != nil {
+		return ginx.Result{}, errs.ErrUnauthorized.Wrap(err)
+	}
+	err = h.SetLoginToken(ctx, pc.Uid)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	h.auditLogin(ctx, pc.Uid, account, "2fa", nil)
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLoginSuccess)}, nil
+}
+
+func (h *UserHandler) EnrollTOTP(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	en, err := h.twoFactorSvc.Enroll(ctx, uc.Id)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	type Resp struct {
+		Secret        string   `json:"secret"`
+		URI           string   `json:"uri"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	// 恢复码只在这里展示一次
+	return ginx.Result{
+		Data: Resp{
+			Secret:        en.Secret,
+			URI:           en.URI,
+			RecoveryCodes: en.RecoveryCodes,
+		},
+	}, nil
+}
 
-	defer h.LogPrint(err)
+type EnableTOTPReq struct {
+	Code string `json:"code"`
+}
 
+func (h *UserHandler) EnableTOTP(ctx *gin.Context, req EnableTOTPReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.twoFactorSvc.Enable(ctx, uc.Id, req.Code)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     uc.Id,
+		Action:  domain.AuditTwoFactorEnable,
+		Success: true,
+	})
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgTwoFactorEnabled)}, nil
 }
 
-func (h *UserHandler) LogoutJWT(ctx *gin.Context) {
+func (h *UserHandler) LogoutJWT(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
 	err := h.ClearToken(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "退出成功",
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     uc.Id,
+		Action:  domain.AuditLogout,
+		Success: true,
 	})
-
-	defer h.LogPrint(err)
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLogoutSuccess)}, nil
 }
 
-func (h *UserHandler) RefreshToken(ctx *gin.Context) {
+func (h *UserHandler) RefreshToken(ctx *gin.Context) (ginx.Result, error) {
 	// 约定前端将refreshtoken放入到authorization里面带上
 	tokenStr := h.ExtractToken(ctx)
 	var rc ijwt.RefreshClaims
 	token, err := jwt.ParseWithClaims(tokenStr, &rc, func(t *jwt.Token) (interface{}, error) {
 		return ijwt.RCJWTkey, nil
 	})
-	if err != nil {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return
-	}
-	if token == nil || !token.Valid {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return
+	if err != nil || token == nil || !token.Valid {
+		return ginx.Result{}, errs.ErrUnauthorized
 	}
 	err = h.CheckSession(ctx, rc.Ssid)
 	if err != nil {
 		// token无效或者redis有问题
 		// 过于严格
-		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return
+		return ginx.Result{}, errs.ErrUnauthorized.Wrap(err)
+	}
+	// 每次刷新都换一个新的 refresh token
+	err = h.RotateRefreshToken(ctx, rc)
+	if err == ijwt.ErrRefreshTokenReused {
+		// 旧的 refresh token 又被用了一次，要么是攻击者，要么是用户本人拿着被盗的旧 token
+		// 整个 ssid 已经被注销了，两边都需要重新登录
+		h.l.Warn("refresh token 被重复使用，可能被盗用",
+			logger.Int64("uid", rc.Uid),
+			logger.String("ssid", rc.Ssid),
+			logger.String("jti", rc.ID),
+			logger.String("ip", ctx.ClientIP()),
+		)
+		recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+			Uid:    rc.Uid,
+			Action: domain.AuditRefresh,
+			Target: rc.Ssid,
+			Detail: "refresh token 被重复使用，会话已注销",
+		})
+	}
+	if err != nil {
+		return ginx.Result{}, errs.ErrUnauthorized.Wrap(err)
 	}
 	err = h.SetJWTToken(ctx, rc.Uid, rc.Ssid)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "OK",
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     rc.Uid,
+		Action:  domain.AuditRefresh,
+		Target:  rc.Ssid,
+		Success: true,
 	})
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgOK)}, nil
+}
 
-	defer h.LogPrint(err)
-
+type SendSMSCodeReq struct {
+	Phone string `json:"phone" binding:"required,phone"`
+	// Channel 短信或者语音，默认短信
+	Channel domain.CodeChannel `json:"channel" binding:"omitempty,oneof=sms voice"`
 }
 
-func (h *UserHandler) SendSMSLoginCode(ctx *gin.Context) {
-	type Req struct {
-		Phone string `json:"phone"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		// 会帮助我们写回一个400的错误
-		return
-	}
-	if req.Phone == "" {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 4,
-			Msg:  "请输入手机号码",
-		})
-		return
+// phoneChannel 手机号登录只能用短信或者语音，没有指定的时候用短信
+func phoneChannel(c domain.CodeChannel) domain.CodeChannel {
+	if c == "" {
+		return domain.CodeChannelSMS
 	}
+	return c
+}
 
-	err := h.codeSvc.Send(ctx, bizLogin, req.Phone)
-	switch err {
-	case nil:
-		ctx.JSON(http.StatusOK, Result{
-			Msg: "发送成功",
-		})
-	case ErrSendTooMany:
+func (h *UserHandler) SendSMSLoginCode(ctx *gin.Context, req SendSMSCodeReq) (ginx.Result, error) {
+	req.Phone = ginx.NormalizePhone(req.Phone)
+	err := h.codeSvc.Send(ctx, bizLogin, phoneChannel(req.Channel), req.Phone)
+	if errors.Is(err, service.ErrSendTooMany) {
 		// 少数出现这种错误，是可以接受的
 		// 但是频繁出现，就代表有人在搞你的系统
 		h.l.Warn("频繁发送验证码")
-		ctx.JSON(http.StatusOK, Result{
-			Code: 4,
-			Msg:  "短信发送太频繁，请稍后再试",
-		})
-	default:
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		// 补日志
 	}
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgCodeSent)}, nil
+}
 
-	defer h.LogPrint(err)
+type LoginSMSReq struct {
+	Phone   string `json:"phone" binding:"required,phone"`
+	Code    string `json:"code" binding:"required"`
+	Captcha string `json:"captcha"`
+	// Channel 要和发送验证码的时候一致
+	Channel domain.CodeChannel `json:"channel" binding:"omitempty,oneof=sms voice"`
 }
 
-func (h *UserHandler) LoginSMS(ctx *gin.Context) {
-	type Req struct {
-		Phone string `json:"phone"`
-		Code  string `json:"code"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
+func (h *UserHandler) LoginSMS(ctx *gin.Context, req LoginSMSReq) (ginx.Result, error) {
+	// 验证码、登录保护和账号都按照 E.164 来，同一个号码不同写法不会变成两个账号
+	req.Phone = ginx.NormalizePhone(req.Phone)
+	if !h.checkLoginGuard(ctx, guardBizSMS, req.Phone, req.Captcha) {
+		return ginx.Result{}, nil
 	}
-	ok, err := h.codeSvc.Vertify(ctx, bizLogin, req.Phone, req.Code)
+	ok, err := h.codeSvc.Vertify(ctx, bizLogin, phoneChannel(req.Channel), req.Phone, req.Code)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统异常",
-		})
-		h.l.Error("手机验证码验证失败", logger.Field{
-			Key: "错误信息",
-			Val: err,
-		})
-		return
+		return ginx.Result{}, err
 	}
 	if !ok {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 4,
-			Msg:  "验证码不对，请重新输入",
-		})
-		return
+		// 验证码不对也要记到这个手机号的账号下面，用户才能在安全记录里面看到
+		u, _ := h.svc.FindByPhone(ctx, req.Phone)
+		h.auditLogin(ctx, u.Id, req.Phone, "sms", errWrongCode)
+		if !h.failLoginGuard(ctx, guardBizSMS, req.Phone) {
+			return ginx.Result{}, nil
+		}
+		return ginx.Result{}, errWrongCode
 	}
+	h.resetLoginGuard(ctx, guardBizSMS, req.Phone)
 	u, err := h.svc.FindOrCreate(ctx, req.Phone)
+	h.auditLogin(ctx, u.Id, req.Phone, "sms", err)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
+		return ginx.Result{}, err
 	}
 	err = h.SetLoginToken(ctx, u.Id)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "登录成功",
-	})
-
-	defer h.LogPrint(err)
-
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLoginSuccess)}, nil
 }
 
-func (h *UserHandler) Profile(ctx *gin.Context) {
-
-	uc, exists := ctx.Get("user")
-	if !exists {
-		ctx.String(http.StatusOK, "系统错误")
-		return
-	}
-
-	me, ok := uc.(ijwt.UserClaims)
-	if !ok {
-		ctx.String(http.StatusOK, "系统错误")
+func (h *UserHandler) Profile(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	u, err := h.svc.Profile(ctx, uc.Id)
+	if err != nil {
+		return ginx.Result{}, err
 	}
-	u, err := h.svc.Profile(ctx, me.Id)
-
 	type Resp struct {
-		NickName  string
-		Birthday  string
-		Biography string
-	}
-	switch err {
-	case nil:
-		ctx.JSONP(http.StatusOK, Resp{
-			NickName:  u.NickName,
-			Birthday:  u.Birthday.Format(time.DateOnly),
-			Biography: u.Biography,
-		})
-	default:
-		ctx.String(http.StatusOK, "系统出错")
-	}
-
-	defer h.LogPrint(err)
+		NickName    string
+		Avatar      string
+		Birthday    string
+		Biography   string
+		CodeChannel string
+	}
+	return ginx.Result{
+		Data: Resp{
+			NickName:    u.NickName,
+			Avatar:      u.Avatar,
+			Birthday:    u.Birthday.Format(time.DateOnly),
+			Biography:   u.Biography,
+			CodeChannel: string(u.CodeChannel),
+		},
+	}, nil
 }
 
-func (h *UserHandler) Edit(ctx *gin.Context) {
-
-	type EditReq struct {
-		NickName  string `json:"nickname"`
-		Birthday  string `json:"birthday"`
-		Biography string `json:"biography"`
-	}
-
-	var req EditReq
-
-	if err := ctx.Bind(&req); err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
-	}
-	const (
-		nickLength      int = 50
-		biographyLength int = 300
-	)
-	if len(req.NickName) >= nickLength {
-		ctx.String(http.StatusOK, "昵称太长, 超过了50个字符")
-		return
-	}
-	if len(req.NickName) == 0 {
-		ctx.String(http.StatusOK, "昵称不能为空")
-		return
-	}
-	if len(req.Biography) >= biographyLength {
-		ctx.String(http.StatusOK, "个人简介的字数太长，超过了300个字符, 当前字符长度为", len(req.Biography))
-		return
-	}
-	//
-	birthday, err := time.Parse(time.DateOnly, req.Birthday)
-	// ok, err := h.BirthdayRegexExp.MatchString(req.Birthday)
-	if err != nil {
-		ctx.String(http.StatusOK, "日期格式不对, 请输入yyyy-mm-dd的格式")
-		return
-	}
-	uc, exists := ctx.Get("user")
-	if !exists {
-		ctx.String(http.StatusOK, "系统错误")
-		return
-	}
+type EditReq struct {
+	NickName  string `json:"nickname" binding:"required,maxrunes=50"`
+	Birthday  string `json:"birthday" binding:"required,dateonly"`
+	Biography string `json:"biography" binding:"maxrunes=300"`
+	// CodeChannel 偏好的验证码渠道，不填就不修改
+	CodeChannel domain.CodeChannel `json:"code_channel" binding:"omitempty,oneof=sms email voice"`
+}
 
-	me, ok := uc.(ijwt.UserClaims)
-	if !ok {
-		ctx.String(http.StatusOK, "系统错误")
-	}
-	// sess := sessions.Default(ctx)
-	// uid := sess.Get("userId")
-	// me, ok := uid.(int64)
-	// if !ok {
-	// 	ctx.String(http.StatusOK, "系统出错")
-	// }
-	// t := carbon.Parse(req.Birthday).ToStdTime()
-	err = h.svc.Edit(ctx, domain.User{
-		Id:        me.Id,
-		NickName:  req.NickName,
-		Birthday:  birthday,
-		Biography: req.Biography,
+func (h *UserHandler) Edit(ctx *gin.Context, req EditReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	// 格式已经在绑定的时候校验过了
+	birthday, _ := time.Parse(time.DateOnly, req.Birthday)
+	err := h.svc.Edit(ctx, domain.User{
+		Id:          uc.Id,
+		NickName:    req.NickName,
+		Birthday:    birthday,
+		Biography:   req.Biography,
+		CodeChannel: req.CodeChannel,
 	})
-	switch err {
-	case nil:
-		ctx.String(http.StatusOK, "编辑成功")
-	default:
-		ctx.String(http.StatusOK, "系统出错")
+	if err != nil {
+		return ginx.Result{}, err
 	}
-
-	defer h.LogPrint(err)
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgEditSuccess)}, nil
 }
 
-func (h *UserHandler) LogPrint(err error) {
+// auditLogin 登录成功和失败都要记下来，失败的原因放在 detail 里面
+func (h *UserHandler) auditLogin(ctx *gin.Context, uid int64, account string, method string, err error) {
+	detail := method
 	if err != nil {
-		h.l.Debug(err.Error())
-	}
+		detail = method + ": " + err.Error()
+	}
+	recordAudit(ctx, h.auditSvc, domain.AuditEvent{
+		Uid:     uid,
+		Action:  domain.AuditLogin,
+		Target:  account,
+		Success: err == nil,
+		Detail:  detail,
+	})
 }
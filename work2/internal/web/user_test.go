@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"example/wb/internal/domain"
 	"example/wb/internal/service"
+	"example/wb/internal/service/captcha/localcaptcha"
 	svcmock "example/wb/internal/service/mocks"
 	"example/wb/internal/web"
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/logger"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -59,7 +62,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 
 			},
 			wantCode: http.StatusOK,
-			wantBody: "注册成功",
+			wantBody: `{"Code":0,"Msg":"注册成功","Data":null}`,
 		},
 		{
 			name: "Bind出错",
@@ -86,7 +89,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 
 			},
 			wantCode: http.StatusBadRequest,
-			wantBody: "",
+			wantBody: `{"Code":400001,"Msg":"参数错误","Data":null}`,
 		},
 		{
 			name: "邮箱出错",
@@ -112,8 +115,8 @@ func TestUserHandler_SignUp(t *testing.T) {
 				return req
 
 			},
-			wantCode: http.StatusOK,
-			wantBody: "email不合法",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"Code":400001,"Msg":"email必须是一个有效的邮箱","Data":[{"field":"email","msg":"email必须是一个有效的邮箱"}]}`,
 		},
 		{
 			name: "密码格式不对",
@@ -139,8 +142,8 @@ func TestUserHandler_SignUp(t *testing.T) {
 				return req
 
 			},
-			wantCode: http.StatusOK,
-			wantBody: "密码不合法",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"Code":400001,"Msg":"password至少8位，需要包含大小写字母、数字和特殊字符","Data":[{"field":"password","msg":"password至少8位，需要包含大小写字母、数字和特殊字符"}]}`,
 		},
 		{
 			name: "两次密码不同",
@@ -166,8 +169,32 @@ func TestUserHandler_SignUp(t *testing.T) {
 				return req
 
 			},
-			wantCode: http.StatusOK,
-			wantBody: "两次密码不同",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"Code":400001,"Msg":"confirmpassword必须等于password","Data":[{"field":"confirmpassword","msg":"confirmpassword必须等于password"}]}`,
+		},
+		{
+			name: "英文的错误信息",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				userSvc := svcmock.NewMockUserService(ctrl)
+				codeSvc := svcmock.NewMockCodeService(ctrl)
+				return userSvc, codeSvc
+			},
+			reqBuilder: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "/user/signup", bytes.NewReader([]byte(`
+				{
+					"email": "14526qq.com",
+					"password": "helLo@dsf46",
+					"confirmPassword": "helLo@dsf46"
+				}
+				`)))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Accept-Language", "en-US,en;q=0.9,zh;q=0.8")
+				assert.NoError(t, err)
+				return req
+
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"Code":400001,"Msg":"email must be a valid email address","Data":[{"field":"email","msg":"email must be a valid email address"}]}`,
 		},
 	}
 
@@ -178,7 +205,8 @@ func TestUserHandler_SignUp(t *testing.T) {
 
 			// 利用mock生成Service
 			userSvc, codeSvc := tc.mock(ctrl)
-			hdl := web.NewUserHandler(userSvc, codeSvc)
+			hdl := web.NewUserHandler(userSvc, nil, logger.NewNopLogger(), codeSvc, svcmock.NewMockTwoFactorService(ctrl),
+				svcmock.NewMockLoginGuardService(ctrl), localcaptcha.NewLocalService(), svcmock.NewMockAuditService(ctrl))
 
 			// 注册路由
 			server := gin.Default()
@@ -238,3 +266,90 @@ func TestUserHandler_SignUp(t *testing.T) {
 
 // 	}
 // }
+
+// TestUserHandler_LoginJWT_Audit 开启了两步验证的时候，密码对了不能记成登录成功
+func TestUserHandler_LoginJWT_Audit(t *testing.T) {
+	testCases := []struct {
+		name string
+
+		mock func(ctrl *gomock.Controller) (service.UserService, service.TwoFactorService, service.LoginGuardService)
+
+		wantCode  int
+		wantEvent domain.AuditEvent
+	}{
+		{
+			name: "密码对了还要两步验证",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.TwoFactorService, service.LoginGuardService) {
+				userSvc := svcmock.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), gomock.Any()).Return(domain.User{Id: 1}, nil)
+				twoFactorSvc := svcmock.NewMockTwoFactorService(ctrl)
+				twoFactorSvc.EXPECT().IsEnabled(gomock.Any(), int64(1)).Return(true, nil)
+				guardSvc := svcmock.NewMockLoginGuardService(ctrl)
+				guardSvc.EXPECT().Check(gomock.Any(), gomock.Any(), "1@qq.com", gomock.Any()).
+					Return(domain.LoginGuard{}, nil)
+				guardSvc.EXPECT().Reset(gomock.Any(), gomock.Any(), "1@qq.com").Return(nil)
+				return userSvc, twoFactorSvc, guardSvc
+			},
+			wantCode: http.StatusOK,
+			wantEvent: domain.AuditEvent{
+				Uid:     1,
+				Action:  domain.AuditLogin,
+				Target:  "1@qq.com",
+				Success: false,
+				Detail:  "password: 2fa_pending",
+			},
+		},
+		{
+			name: "密码不对",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.TwoFactorService, service.LoginGuardService) {
+				userSvc := svcmock.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), gomock.Any()).
+					Return(domain.User{Id: 1}, service.ErrInvalidUserOrPassword)
+				guardSvc := svcmock.NewMockLoginGuardService(ctrl)
+				guardSvc.EXPECT().Check(gomock.Any(), gomock.Any(), "1@qq.com", gomock.Any()).
+					Return(domain.LoginGuard{}, nil)
+				guardSvc.EXPECT().Fail(gomock.Any(), gomock.Any(), "1@qq.com", gomock.Any()).
+					Return(domain.LoginGuard{Failures: 1}, nil)
+				return userSvc, svcmock.NewMockTwoFactorService(ctrl), guardSvc
+			},
+			wantCode: http.StatusBadRequest,
+			wantEvent: domain.AuditEvent{
+				Uid:     1,
+				Action:  domain.AuditLogin,
+				Target:  "1@qq.com",
+				Success: false,
+				Detail:  "password: " + service.ErrInvalidUserOrPassword.Error(),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, twoFactorSvc, guardSvc := tc.mock(ctrl)
+			auditSvc := svcmock.NewMockAuditService(ctrl)
+			var event domain.AuditEvent
+			auditSvc.EXPECT().Record(gomock.Any(), gomock.Any()).
+				Do(func(_ any, e domain.AuditEvent) {
+					event = e
+				})
+			hdl := web.NewUserHandler(userSvc, ijwt.NewJwtHandler(nil), logger.NewNopLogger(),
+				svcmock.NewMockCodeService(ctrl), twoFactorSvc, guardSvc,
+				localcaptcha.NewLocalService(), auditSvc)
+			server := gin.New()
+			hdl.RegisterRoutes(server)
+			req := httptest.NewRequest(http.MethodPost, "/user/login",
+				bytes.NewReader([]byte(`{"email":"1@qq.com","password":"helLo@dsf46"}`)))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			event.IP, event.UserAgent = "", ""
+			assert.Equal(t, tc.wantEvent, event)
+		})
+	}
+}
@@ -1,141 +1,120 @@
 package web
 
 import (
+	"errors"
+	"example/wb/internal/domain"
+	"example/wb/internal/i18n"
 	"example/wb/internal/service"
 	"example/wb/internal/service/oauth2/wechat"
 	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/ginx"
 	"example/wb/pkg/logger"
-	"fmt"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	uuid "github.com/lithammer/shortuuid/v4"
 )
 
 type OAuth2WechatHandler struct {
 	ijwt.Handler
-	svc             wechat.Service
-	userSvc         service.UserService
-	key             []byte
-	cookieStateName string
-	l               logger.Logger
+	svc      wechat.Service
+	loginSvc service.WechatLoginService
+	stateSvc service.OAuth2StateService
+	auditSvc service.AuditService
+	l        logger.Logger
 }
 
 func (o *OAuth2WechatHandler) RegisterRoutes(server *gin.Engine) {
 	g := server.Group("/oauth2/wechat")
-	g.GET("/authurl", o.Auth2Url)
-	g.Any("/callback", o.CallBack)
-
+	g.GET("/authurl", ginx.Wrap(o.l, o.Auth2Url))
+	g.Any("/callback", ginx.Wrap(o.l, o.CallBack))
+	// 需要登录，不在 /oauth2 下面
+	server.POST("/user/wechat/sync", ginx.WrapClaims(o.l, o.SyncProfile))
 }
 
 func NewOAuth2WechatHandler(svc wechat.Service,
 	hdl ijwt.Handler,
 	l logger.Logger,
-	userSvc service.UserService) *OAuth2WechatHandler {
+	loginSvc service.WechatLoginService,
+	stateSvc service.OAuth2StateService,
+	auditSvc service.AuditService) *OAuth2WechatHandler {
 	return &OAuth2WechatHandler{
-		svc:             svc,
-		userSvc:         userSvc,
-		key:             []byte("mY2gT5iP0xZ9eX7tZ5eU9zI4no0xP0wI"),
-		cookieStateName: "jwt-state",
-		Handler:         hdl,
-		l:               l,
+		svc:      svc,
+		loginSvc: loginSvc,
+		stateSvc: stateSvc,
+		auditSvc: auditSvc,
+		Handler:  hdl,
+		l:        l,
 	}
 
 }
 
-func (o *OAuth2WechatHandler) Auth2Url(ctx *gin.Context) {
-	state := uuid.New()
-	val, err := o.svc.AUthURL(ctx, state)
+func (o *OAuth2WechatHandler) Auth2Url(ctx *gin.Context) (ginx.Result, error) {
+	state, err := o.stateSvc.Create(ctx, "wechat", ctx.Query("redirect"))
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	setStateCookie(ctx, "wechat", state.State)
+	val, err := o.svc.AUthURL(ctx, state.State)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "构造跳转URL失败",
-		})
+		return ginx.Result{}, err
 	}
-	o.setStateCookie(ctx, state)
-	ctx.JSON(http.StatusOK, Result{
+	return ginx.Result{
 		Data: val,
-	})
+	}, nil
 	// ctx.Redirect(http.StatusFound, val)
 }
 
-func (o *OAuth2WechatHandler) CallBack(ctx *gin.Context) {
-	err := o.verifyState(ctx)
-	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 4,
-			Msg:  "非法请求",
-		})
-		return
+func (o *OAuth2WechatHandler) CallBack(ctx *gin.Context) (ginx.Result, error) {
+	if err := checkStateCookie(ctx, "wechat", ctx.Query("state")); err != nil {
+		return ginx.Result{}, err
 	}
-	// 校验不校验都可以
-	code := ctx.Query("code")
-	// state := ctx.Query("state")
-	wechatInfo, err := o.svc.VerifyCode(ctx, code)
-	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 4,
-			Msg:  "授权码有误",
-		})
-		return
-	}
-	u, err := o.userSvc.FindOrCreateByWechat(ctx, wechatInfo)
+	state, err := o.stateSvc.Consume(ctx, "wechat", ctx.Query("state"))
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		return
+		return ginx.Result{}, err
 	}
-	err = o.SetLoginToken(ctx, u.Id)
+	code := ctx.Query("code")
+	u, err := o.loginSvc.Login(ctx, code)
+	detail := "wechat"
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		detail = "wechat: " + err.Error()
 	}
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "OK",
+	recordAudit(ctx, o.auditSvc, domain.AuditEvent{
+		Uid:     u.Id,
+		Action:  domain.AuditLogin,
+		Target:  u.WechatInfo.Openid,
+		Success: err == nil,
+		Detail:  detail,
 	})
-
-}
-
-func (h *OAuth2WechatHandler) verifyState(ctx *gin.Context) error {
-	state := ctx.Query("state")
-
-	ck, err := ctx.Cookie(h.cookieStateName)
-	if err != nil {
-		return fmt.Errorf("无法获得cookie %w", err)
+	if errors.Is(err, service.ErrInvalidWechatCode) {
+		o.l.Warn("微信授权码校验失败", logger.Error(err))
 	}
-	var sc StateClaims
-	_, err = jwt.ParseWithClaims(ck, &sc, func(t *jwt.Token) (interface{}, error) {
-		return h.key, nil
-	})
 	if err != nil {
-		return fmt.Errorf("解析token失败 %w", err)
+		return ginx.Result{}, err
 	}
-	if state != sc.state {
-		// state不匹配
-		return fmt.Errorf("state不匹配")
+	err = o.SetLoginToken(ctx, u.Id)
+	if err != nil {
+		return ginx.Result{}, err
 	}
-	return nil
+	return ginx.Result{
+		Msg:  i18n.T(ctx, i18n.MsgOK),
+		Data: state.Redirect,
+	}, nil
 }
 
-func (h *OAuth2WechatHandler) setStateCookie(ctx *gin.Context, state string) error {
-
-	claims := StateClaims{
-		state: state,
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
-	tokenStr, err := token.SignedString(h.key)
+// SyncProfile 重新从微信同步昵称和头像，access_token 快过期的时候会先刷新
+func (o *OAuth2WechatHandler) SyncProfile(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	profile, err := o.loginSvc.SyncProfile(ctx, uc.Id)
 	if err != nil {
-		return err
+		return ginx.Result{}, err
 	}
-	// secure 是否使用https
-	ctx.SetCookie(h.cookieStateName, tokenStr, 600,
-		"/oauth2/wechat/callback", "", false, true)
-	return nil
+	return ginx.Result{
+		Data: WechatProfileVo{
+			NickName: profile.NickName,
+			Avatar:   profile.Avatar,
+		},
+	}, nil
 }
 
-type StateClaims struct {
-	jwt.RegisteredClaims
-	state string
+type WechatProfileVo struct {
+	NickName string `json:"nickname"`
+	Avatar   string `json:"avatar"`
 }
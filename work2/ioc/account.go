@@ -0,0 +1,44 @@
+package ioc
+
+import (
+	"context"
+	"example/wb/internal/repository"
+	"example/wb/internal/service"
+	"example/wb/pkg/logger"
+	"example/wb/pkg/password"
+	"time"
+)
+
+// InitAccountService 顺便启动一个定时任务，把过了冷静期的账号匿名化
+func InitAccountService(userRepo repository.UserRepository,
+	articleRepo repository.ArticleRepository,
+	codeSvc service.CodeService,
+	twoFactorSvc service.TwoFactorService,
+	hasher password.Hasher,
+	l logger.Logger) service.AccountService {
+	svc := service.NewAccountService(userRepo, articleRepo, codeSvc, twoFactorSvc, hasher, l)
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			anonymizeExpired(svc, l)
+		}
+	}()
+	return svc
+}
+
+func anonymizeExpired(svc service.AccountService, l logger.Logger) {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		cnt, err := svc.AnonymizeExpired(ctx, 100)
+		cancel()
+		if err != nil {
+			l.Error("匿名化注销账号失败", logger.Error(err))
+			return
+		}
+		// 不满一批说明已经处理完了
+		if cnt < 100 {
+			return
+		}
+	}
+}
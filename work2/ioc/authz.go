@@ -0,0 +1,49 @@
+package ioc
+
+import (
+	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/repository"
+	"example/wb/internal/service"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// InitAuthzService 顺便创建配置里面的初始管理员，没有配置邮箱的时候跳过
+func InitAuthzService(roleRepo repository.RoleRepository,
+	userRepo repository.UserRepository,
+	userSvc service.UserService) service.AuthzService {
+	svc := service.NewAuthzService(roleRepo)
+	type Config struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	var cfg Config
+	err := viper.UnmarshalKey("admin", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.Email == "" {
+		return svc
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	// 已经注册过的不会修改密码，只是补上管理员角色
+	err = userSvc.SignUp(ctx, domain.User{
+		Email:    cfg.Email,
+		Password: cfg.Password,
+	})
+	if err != nil && err != service.ErrDuplicateUser {
+		panic(err)
+	}
+	u, err := userRepo.FindByEmail(ctx, cfg.Email)
+	if err != nil {
+		panic(err)
+	}
+	err = svc.Grant(ctx, u.Id, domain.RoleAdmin)
+	if err != nil {
+		panic(err)
+	}
+	return svc
+}
@@ -0,0 +1,51 @@
+package ioc
+
+import (
+	"example/wb/internal/domain"
+	"example/wb/internal/service"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// InitCodePolicies 每个业务的验证码策略，key 是 biz。
+// 登录和注销账号是代码里面用到的，没有配置的话用默认策略
+func InitCodePolicies() *service.CodePolicies {
+	type Config struct {
+		// Templates key 是渠道：sms、email、voice
+		Templates      map[string]string `json:"templates"`
+		Length         int               `json:"length"`
+		Alphabet       string            `json:"alphabet"`
+		TTL            time.Duration     `json:"ttl"`
+		ResendInterval time.Duration     `json:"resendInterval"`
+		MaxAttempts    int               `json:"maxAttempts"`
+	}
+	cfgs := map[string]Config{
+		"login":          {},
+		"delete_account": {},
+	}
+	err := viper.UnmarshalKey("code", &cfgs)
+	if err != nil {
+		panic(err)
+	}
+	policies := service.NewCodePolicies()
+	for biz, cfg := range cfgs {
+		templates := make(map[domain.CodeChannel]string, len(cfg.Templates))
+		for ch, tpl := range cfg.Templates {
+			templates[domain.CodeChannel(ch)] = tpl
+		}
+		err = policies.Register(domain.CodePolicy{
+			Biz:            biz,
+			Templates:      templates,
+			Length:         cfg.Length,
+			Alphabet:       cfg.Alphabet,
+			TTL:            cfg.TTL,
+			ResendInterval: cfg.ResendInterval,
+			MaxAttempts:    cfg.MaxAttempts,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return policies
+}
@@ -0,0 +1,31 @@
+package ioc
+
+import (
+	"example/wb/internal/repository/cache"
+	"example/wb/pkg/breaker"
+	"example/wb/pkg/logger"
+	"time"
+
+	"github.com/coocood/freecache"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+)
+
+// InitCodeCache 多个实例之间通过 redis 共享验证码，redis 不可用的时候降级到本地缓存
+func InitCodeCache(redisCmd redis.Cmdable, fc *freecache.Cache, l logger.Logger) cache.CodeCache {
+	type Config struct {
+		// 连续失败多少次之后熔断，熔断期间直接使用本地缓存
+		BreakerThreshold int           `json:"breakerThreshold"`
+		BreakerCooldown  time.Duration `json:"breakerCooldown"`
+	}
+	cfg := Config{
+		BreakerThreshold: 3,
+		BreakerCooldown:  time.Second * 10,
+	}
+	err := viper.UnmarshalKey("codeCache", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return cache.NewFailoverCodeCache(cache.NewCodeCache(redisCmd), cache.NewCodeLocalCache(fc),
+		breaker.New(cfg.BreakerThreshold, cfg.BreakerCooldown), l)
+}
@@ -0,0 +1,89 @@
+package ioc
+
+import (
+	"example/wb/internal/domain"
+	"example/wb/internal/service"
+	"example/wb/internal/service/email"
+	"example/wb/internal/service/email/localemail"
+	"example/wb/internal/service/email/smtpemail"
+	"example/wb/internal/service/sms"
+	"example/wb/internal/service/voice/localvoice"
+	"example/wb/pkg/limiter"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+)
+
+// InitCodeSenders 验证码的发送渠道，只注册配置了服务商的渠道，
+// 没有注册的渠道发送验证码的时候返回 ErrCodeChannelUnsupported。
+// 短信在 InitSMSService 里面已经限流了，邮件和语音在这里各自限流
+func InitCodeSenders(smsSvc sms.Service, redisCmd redis.Cmdable) service.CodeSenders {
+	type SMTPConfig struct {
+		Addr     string `json:"addr"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		From     string `json:"from"`
+	}
+	type EmailConfig struct {
+		// Type smtp 或者 local，为空不启用
+		Type      string                        `json:"type"`
+		Rate      int                           `json:"rate"`
+		SMTP      SMTPConfig                    `json:"smtp"`
+		Templates map[string]smtpemail.Template `json:"templates"`
+	}
+	type VoiceConfig struct {
+		// Type 目前只有 local，为空不启用
+		Type string `json:"type"`
+		Rate int    `json:"rate"`
+	}
+	type Config struct {
+		Email EmailConfig `json:"email"`
+		Voice VoiceConfig `json:"voice"`
+	}
+	cfg := Config{
+		Email: EmailConfig{Rate: 100},
+		Voice: VoiceConfig{Rate: 20},
+	}
+	err := viper.UnmarshalKey("codeChannels", &cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	senders := service.CodeSenders{
+		domain.CodeChannelSMS: smsSvc,
+	}
+	var emailSvc email.Service
+	switch cfg.Email.Type {
+	case "":
+	case "local":
+		emailSvc = localemail.NewLocalService()
+	case "smtp":
+		if cfg.Email.SMTP.Addr == "" {
+			panic("codeChannels.email.smtp.addr 没有配置")
+		}
+		// smtp.PlainAuth 需要的是不带端口的 host
+		host, _, _ := strings.Cut(cfg.Email.SMTP.Addr, ":")
+		auth := smtp.PlainAuth("", cfg.Email.SMTP.Username, cfg.Email.SMTP.Password, host)
+		emailSvc = smtpemail.NewService(cfg.Email.SMTP.Addr, auth, cfg.Email.SMTP.From, cfg.Email.Templates)
+	default:
+		panic(fmt.Sprintf("未知的邮件服务类型 codeChannels.email.type: %q", cfg.Email.Type))
+	}
+	if emailSvc != nil {
+		senders[domain.CodeChannelEmail] = service.NewRateLimitCodeSender(emailSvc,
+			limiter.NewRedisLimter(redisCmd, time.Second, cfg.Email.Rate), domain.CodeChannelEmail)
+	}
+
+	switch cfg.Voice.Type {
+	case "":
+	case "local":
+		senders[domain.CodeChannelVoice] = service.NewRateLimitCodeSender(localvoice.NewLocalService(),
+			limiter.NewRedisLimter(redisCmd, time.Second, cfg.Voice.Rate), domain.CodeChannelVoice)
+	default:
+		panic(fmt.Sprintf("未知的语音服务类型 codeChannels.voice.type: %q", cfg.Voice.Type))
+	}
+	return senders
+}
@@ -0,0 +1,44 @@
+package ioc
+
+import (
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/logger"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+)
+
+func InitJWTHandler(redisCmd redis.Cmdable, l logger.Logger) ijwt.Handler {
+	type Config struct {
+		// redis 不可用的时候是否放行没有被本地记录注销的 ssid
+		FailOpen bool `json:"failOpen"`
+		// 连续失败多少次之后熔断
+		BreakerThreshold int           `json:"breakerThreshold"`
+		BreakerCooldown  time.Duration `json:"breakerCooldown"`
+		RevokedCacheSize int           `json:"revokedCacheSize"`
+	}
+	cfg := Config{
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Second * 10,
+		RevokedCacheSize: 10000,
+	}
+	err := viper.UnmarshalKey("jwt", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.RevokedCacheSize <= 0 {
+		panic(fmt.Sprintf("jwt.revokedCacheSize 必须大于 0，现在是 %d", cfg.RevokedCacheSize))
+	}
+	opts := []ijwt.Option{
+		ijwt.WithLogger(l),
+		ijwt.WithFailOpen(cfg.FailOpen),
+		ijwt.WithBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		ijwt.WithRevokedCacheSize(cfg.RevokedCacheSize),
+	}
+	if sub, ok := redisCmd.(ijwt.Subscriber); ok {
+		opts = append(opts, ijwt.WithRevokeSubscriber(sub))
+	}
+	return ijwt.NewJwtHandler(redisCmd, opts...)
+}
@@ -0,0 +1,55 @@
+package ioc
+
+import (
+	"example/wb/internal/repository"
+	"example/wb/internal/service"
+	"example/wb/internal/service/captcha"
+	"example/wb/internal/service/captcha/localcaptcha"
+	"example/wb/internal/service/captcha/siteverify"
+	"example/wb/pkg/limiter"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+)
+
+func InitLoginGuardService(redisCmd redis.Cmdable, repo repository.LoginGuardRepository) service.LoginGuardService {
+	// 同一个 IP 十分钟内最多失败 30 次
+	l := limiter.NewRedisLimter(redisCmd, time.Minute*10, 30)
+	return service.NewLoginGuardService(repo, l)
+}
+
+// InitCaptchaService 没有配置人机验证的时候不能启动，
+// local 只打印日志，带了 ticket 就放行，只能在本地开发的时候显式配置
+func InitCaptchaService() captcha.Service {
+	type Config struct {
+		// Type local 或者 siteverify
+		Type       string `json:"type"`
+		SiteVerify struct {
+			// Endpoint reCAPTCHA、hCaptcha、Turnstile 的校验地址
+			Endpoint string        `json:"endpoint"`
+			Secret   string        `json:"secret"`
+			Timeout  time.Duration `json:"timeout"`
+		} `json:"siteVerify"`
+	}
+	var cfg Config
+	cfg.SiteVerify.Timeout = time.Second * 3
+	err := viper.UnmarshalKey("captcha", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	switch cfg.Type {
+	case "local":
+		return localcaptcha.NewLocalService()
+	case "siteverify":
+		if cfg.SiteVerify.Endpoint == "" || cfg.SiteVerify.Secret == "" {
+			panic("人机验证没有配置 captcha.siteVerify.endpoint 或者 secret")
+		}
+		client := &http.Client{Timeout: cfg.SiteVerify.Timeout}
+		return siteverify.NewService(client, cfg.SiteVerify.Endpoint, cfg.SiteVerify.Secret)
+	default:
+		panic(fmt.Sprintf("未知的人机验证类型 captcha.type: %q", cfg.Type))
+	}
+}
@@ -0,0 +1,45 @@
+package ioc
+
+import (
+	"example/wb/internal/service/oauth2"
+	"example/wb/internal/service/oauth2/github"
+	"example/wb/internal/service/oauth2/oidc"
+
+	"github.com/spf13/viper"
+)
+
+// InitOAuth2Providers 除微信之外的第三方登录，没有配置 clientId 的平台不启用
+func InitOAuth2Providers() []oauth2.Provider {
+	type ClientConfig struct {
+		ClientId     string `json:"clientId"`
+		ClientSecret string `json:"clientSecret"`
+		RedirectURL  string `json:"redirectURL"`
+	}
+	type OIDCConfig struct {
+		Name         string `json:"name"`
+		Issuer       string `json:"issuer"`
+		ClientId     string `json:"clientId"`
+		ClientSecret string `json:"clientSecret"`
+		RedirectURL  string `json:"redirectURL"`
+	}
+	type Config struct {
+		Github ClientConfig `json:"github"`
+		OIDC   []OIDCConfig `json:"oidc"`
+	}
+
+	var cfg Config
+	err := viper.UnmarshalKey("oauth2", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	var res []oauth2.Provider
+	if cfg.Github.ClientId != "" {
+		res = append(res, github.NewProvider(cfg.Github.ClientId,
+			cfg.Github.ClientSecret, cfg.Github.RedirectURL))
+	}
+	for _, c := range cfg.OIDC {
+		res = append(res, oidc.NewProvider(c.Name, c.Issuer,
+			c.ClientId, c.ClientSecret, c.RedirectURL))
+	}
+	return res
+}
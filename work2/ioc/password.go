@@ -0,0 +1,53 @@
+package ioc
+
+import (
+	"example/wb/pkg/password"
+
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// InitPasswordHasher 新密码用 algorithm 配置的算法，另外一种只用来校验存量的密码。
+// 调整了算法或者参数之后，用户下次登录的时候会自动升级
+func InitPasswordHasher() password.Hasher {
+	type Argon2Config struct {
+		// 单位是 KiB
+		Memory      uint32 `json:"memory"`
+		Iterations  uint32 `json:"iterations"`
+		Parallelism uint8  `json:"parallelism"`
+	}
+	type Config struct {
+		// argon2id 或者 bcrypt
+		Algorithm  string       `json:"algorithm"`
+		BcryptCost int          `json:"bcryptCost"`
+		Argon2     Argon2Config `json:"argon2"`
+	}
+	def := password.DefaultArgon2Params
+	cfg := Config{
+		Algorithm:  "argon2id",
+		BcryptCost: bcrypt.DefaultCost,
+		Argon2: Argon2Config{
+			Memory:      def.Memory,
+			Iterations:  def.Iterations,
+			Parallelism: def.Parallelism,
+		},
+	}
+	err := viper.UnmarshalKey("password", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	params := def
+	params.Memory = cfg.Argon2.Memory
+	params.Iterations = cfg.Argon2.Iterations
+	params.Parallelism = cfg.Argon2.Parallelism
+	argon := password.NewArgon2id(params)
+	bc := password.NewBcrypt(cfg.BcryptCost)
+	switch cfg.Algorithm {
+	case "argon2id":
+		return password.NewVersioned(argon, bc)
+	case "bcrypt":
+		return password.NewVersioned(bc, argon)
+	default:
+		panic("不支持的密码哈希算法: " + cfg.Algorithm)
+	}
+}
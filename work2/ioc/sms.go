@@ -3,27 +3,171 @@ package ioc
 import (
 	"example/wb/internal/repository"
 	"example/wb/internal/service/sms"
+	"example/wb/internal/service/sms/aliyun"
 	"example/wb/internal/service/sms/async"
-	"example/wb/internal/service/sms/auth"
 	"example/wb/internal/service/sms/failover"
+	"example/wb/internal/service/sms/httpsms"
 	"example/wb/internal/service/sms/localsms"
 	"example/wb/internal/service/sms/ratelimit"
+	"example/wb/internal/service/sms/tencent"
 	"example/wb/pkg/limiter"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	tencentsms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
 )
 
-func InitSMSService(redisCmd redis.Cmdable, repo repository.AsyncSmsRepository) sms.Service {
-	sms1 := localsms.NewLocalService()
-	sms2 := localsms.NewLocalService()
-	ss := []sms.Service{sms1, sms2}
-	fl := failover.NewTimeOutFailoverSMSService(ss, 10)
+type smsProviderConfig struct {
+	Name string `json:"name"`
+	// Type local、tencent、aliyun、http
+	Type string `json:"type"`
+	// Weight 按照权重分配流量，0 表示不启用
+	Weight  int           `json:"weight"`
+	Timeout time.Duration `json:"timeout"`
+	Tencent struct {
+		SecretId  string `json:"secretId"`
+		SecretKey string `json:"secretKey"`
+		Region    string `json:"region"`
+		AppId     string `json:"appId"`
+		SignName  string `json:"signName"`
+		// Templates 逻辑上的模板名对应的腾讯云模板 ID
+		Templates []sms.TemplateMapping `json:"templates"`
+	} `json:"tencent"`
+	Aliyun struct {
+		Endpoint        string `json:"endpoint"`
+		AccessKeyId     string `json:"accessKeyId"`
+		AccessKeySecret string `json:"accessKeySecret"`
+		SignName        string `json:"signName"`
+		// Templates 逻辑上的模板名对应的阿里云模板 code，以及模板的参数名；
+		// viper 会把 map 的 key 转成小写，模板 code 又区分大小写，所以用列表
+		Templates []struct {
+			Name   string   `json:"name"`
+			Code   string   `json:"code"`
+			Params []string `json:"params"`
+		} `json:"templates"`
+	} `json:"aliyun"`
+	HTTP httpsms.Config `json:"http"`
+}
 
-	au := auth.NewSMSService(fl)
+func InitSMSService(redisCmd redis.Cmdable, repo repository.AsyncSmsRepository) sms.Service {
+	type Config struct {
+		// 发送耗时超过这个值就降低服务商的权重
+		SlowThreshold time.Duration `json:"slowThreshold"`
+		// 每个服务商连续失败多少次之后熔断
+		BreakerThreshold int           `json:"breakerThreshold"`
+		BreakerCooldown  time.Duration `json:"breakerCooldown"`
+		// Async 异步重试
+		Async struct {
+			Workers     int           `json:"workers"`
+			BatchSize   int           `json:"batchSize"`
+			Lease       time.Duration `json:"lease"`
+			BaseBackoff time.Duration `json:"baseBackoff"`
+			MaxBackoff  time.Duration `json:"maxBackoff"`
+		} `json:"async"`
+	}
+	cfg := Config{
+		SlowThreshold:    time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Second * 30,
+	}
+	cfg.Async.Workers = 4
+	cfg.Async.BatchSize = 10
+	cfg.Async.Lease = time.Minute
+	cfg.Async.BaseBackoff = time.Second * 30
+	cfg.Async.MaxBackoff = time.Minute * 10
+	err := viper.UnmarshalKey("sms", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	rls := initSyncSMSService(redisCmd, initSMSProviders(),
+		failover.WithSlowThreshold(cfg.SlowThreshold),
+		failover.WithProviderBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown))
+	return async.NewService(rls, repo,
+		async.WithWorkers(cfg.Async.Workers, cfg.Async.BatchSize),
+		async.WithLease(cfg.Async.Lease),
+		async.WithBackoff(cfg.Async.BaseBackoff, cfg.Async.MaxBackoff))
+	// return ratelimit.
+}
 
+// initSyncSMSService 同步发送的那一段：限流之后按照权重选服务商。
+// 调用方传的是逻辑上的模板名，例如 code，由各个服务商自己映射成模板 ID
+func initSyncSMSService(redisCmd redis.Cmdable, providers []failover.WeightedProvider,
+	opts ...failover.WeightedOption) sms.Service {
+	fl := failover.NewWeightedSMSService(providers, opts...)
 	l := limiter.NewRedisLimter(redisCmd, time.Second, 1000)
-	rls := ratelimit.NewRateLimitSMSService(au, l)
-	return async.NewService(rls, repo)
-	// return ratelimit.
+	return ratelimit.NewRateLimitSMSService(fl, l)
+}
+
+// initSMSProviders 没有配置的时候只用本地的实现
+func initSMSProviders() []failover.WeightedProvider {
+	var cfgs []smsProviderConfig
+	err := viper.UnmarshalKey("sms.providers", &cfgs)
+	if err != nil {
+		panic(err)
+	}
+	if len(cfgs) == 0 {
+		return []failover.WeightedProvider{
+			{Name: "local", Svc: localsms.NewLocalService(), Weight: 1},
+		}
+	}
+	res := make([]failover.WeightedProvider, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		if cfg.Weight <= 0 {
+			continue
+		}
+		res = append(res, failover.WeightedProvider{
+			Name:   cfg.Name,
+			Svc:    initSMSProvider(cfg),
+			Weight: cfg.Weight,
+		})
+	}
+	if len(res) == 0 {
+		panic("没有启用任何短信服务商")
+	}
+	return res
+}
+
+func initSMSProvider(cfg smsProviderConfig) sms.Service {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Second * 5
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	switch cfg.Type {
+	case "local":
+		return localsms.NewLocalService()
+	case "tencent":
+		c := cfg.Tencent
+		cpf := profile.NewClientProfile()
+		cpf.HttpProfile.ReqMethod = "POST"
+		cpf.HttpProfile.Endpoint = "sms.tencentcloudapi.com"
+		cpf.HttpProfile.ReqTimeout = int(cfg.Timeout.Seconds())
+		tc, err := tencentsms.NewClient(common.NewCredential(c.SecretId, c.SecretKey), c.Region, cpf)
+		if err != nil {
+			panic(err)
+		}
+		return tencent.NewService(tc, c.AppId, c.SignName, sms.NewTemplates(c.Templates))
+	case "aliyun":
+		c := cfg.Aliyun
+		templates := make(sms.Templates, len(c.Templates))
+		paramNames := make(map[string][]string, len(c.Templates))
+		for _, tpl := range c.Templates {
+			templates[tpl.Name] = tpl.Code
+			paramNames[tpl.Code] = tpl.Params
+		}
+		return aliyun.NewService(client, c.Endpoint, c.AccessKeyId, c.AccessKeySecret, c.SignName,
+			templates, paramNames)
+	case "http":
+		svc, err := httpsms.NewService(client, cfg.HTTP)
+		if err != nil {
+			panic(fmt.Errorf("短信服务商 %s 配置错误：%w", cfg.Name, err))
+		}
+		return svc
+	default:
+		panic(fmt.Errorf("短信服务商 %s 的类型 %s 不支持", cfg.Name, cfg.Type))
+	}
 }
@@ -0,0 +1,35 @@
+package ioc
+
+import (
+	"context"
+	"example/wb/internal/repository/cache/redismock"
+	"example/wb/internal/service/sms/failover"
+	smsmock "example/wb/internal/service/sms/mocks"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// TestInitSyncSMSService 逻辑上的模板名要原样交给服务商，不能被中间的装饰器拦下来
+func TestInitSyncSMSService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd := redismock.NewMockCmdable(ctrl)
+	// 没有触发限流
+	limited := redis.NewCmd(context.Background())
+	limited.SetVal(int64(0))
+	cmd.EXPECT().Eval(gomock.Any(), gomock.Any(), []string{"sms_ratelimit"}, gomock.Any()).
+		Return(limited)
+	provider := smsmock.NewMockService(ctrl)
+	provider.EXPECT().Send(gomock.Any(), "code", []string{"123456"}, "+8613800138000").
+		Return(nil)
+
+	svc := initSyncSMSService(cmd, []failover.WeightedProvider{
+		{Name: "mock", Svc: provider, Weight: 1},
+	})
+	err := svc.Send(context.Background(), "code", []string{"123456"}, "+8613800138000")
+	assert.NoError(t, err)
+}
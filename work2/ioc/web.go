@@ -2,12 +2,15 @@ package ioc
 
 import (
 	"context"
+	"example/wb/internal/domain"
+	"example/wb/internal/service"
 	"example/wb/internal/web"
 	"example/wb/internal/web/jwt"
 	"example/wb/internal/web/middleware"
 	"example/wb/pkg/ginx/middleware/ratelimit"
 	"example/wb/pkg/limiter"
 	"example/wb/pkg/logger"
+	"net/http"
 	"strings"
 	"time"
 
@@ -16,23 +19,33 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-func InitWebServer(mdls []gin.HandlerFunc, userHdl *web.UserHandler, wechatHdl *web.OAuth2WechatHandler) *gin.Engine {
+func InitWebServer(mdls []gin.HandlerFunc, userHdl *web.UserHandler,
+	wechatHdl *web.OAuth2WechatHandler, oauth2Hdl *web.OAuth2Handler,
+	accountHdl *web.AccountHandler, adminHdl *web.AdminHandler,
+	articleHdl *web.ArticleHandler, tokenHdl *web.AccessTokenHandler,
+	auditHdl *web.AuditHandler) *gin.Engine {
 	server := gin.Default()
 	server.Use(mdls...)
 	userHdl.RegisterRoutes(server)
 	wechatHdl.RegisterRoutes(server)
+	oauth2Hdl.RegisterRoutes(server)
+	accountHdl.RegisterRoutes(server)
+	adminHdl.RegisterRoutes(server)
+	articleHdl.RegisterRoutes(server)
+	tokenHdl.RegisterRoutes(server)
+	auditHdl.RegisterRoutes(server)
 	return server
 }
 
 func InitGinMiddlewares(redisClient redis.Cmdable,
-	hdl jwt.Handler, l logger.Logger) []gin.HandlerFunc {
+	hdl jwt.Handler, tokenSvc service.AccessTokenService, l logger.Logger) []gin.HandlerFunc {
 	return []gin.HandlerFunc{
 		cors.New(cors.Config{
 			// AllowOrigins:     []string{"https://localhost:3000"},
 			// AllowMethods:     []string{"POST"}, // 不配置默认全部
 			// "Authorization" 前端约定头部
 			AllowHeaders:     []string{"Content-Type", "Authorization"},
-			ExposeHeaders:    []string{"x-jwt-token", "x-refresh-token"},
+			ExposeHeaders:    []string{"x-jwt-token", "x-refresh-token", "x-pre-auth-token"},
 			AllowCredentials: true, // cookies一些东西允许带过来
 			AllowOriginFunc: func(origin string) bool {
 				return strings.Contains(origin, "localhost")
@@ -43,7 +56,32 @@ func InitGinMiddlewares(redisClient redis.Cmdable,
 		middleware.NewLogMiddlewareBuilder(func(ctx context.Context, al middleware.AccessLog) {
 			l.Debug("这是在debug", logger.Field{Key: "req", Val: al})
 		}).AllowReqBody().AllowRespBody().Build(),
-		middleware.NewLoginMiddlewareBuilder(hdl).CheckJWTLogin(),
+		loginMiddleware(hdl, tokenSvc),
 	}
 
 }
+
+// loginMiddleware 不需要登录的路由都列在这里。
+// /user/refresh 带的是 refresh token，由 RefreshToken 自己校验
+func loginMiddleware(hdl jwt.Handler, tokenSvc service.AccessTokenService) gin.HandlerFunc {
+	return middleware.NewLoginMiddlewareBuilder(hdl).
+		AllowAccessToken(tokenSvc).
+		// personal access token 只能访问下面这些路由，新增的路由要在这里声明 scope
+		AccessTokenRoute(http.MethodPost, "/article/publish", domain.ScopeArticleWrite).
+		AccessTokenRoute(http.MethodPost, "/article/edit", domain.ScopeArticleWrite).
+		AccessTokenRoute(http.MethodPost, "/article/withdraw", domain.ScopeArticleWrite).
+		AccessTokenRoute(http.MethodPost, "/detail/:id", domain.ScopeArticleRead).
+		AccessTokenRoute(http.MethodPost, "/list", domain.ScopeArticleRead).
+		IgnorePaths(
+			"/user/signup",
+			"/user/login",
+			"/user/login/2fa",
+			"/user/refresh",
+			"/user/hello",
+			"/user/login_sms/code/send",
+			"/user/login_sms",
+			// 第三方登录的 authurl 和 callback
+			"/oauth2/*",
+		).
+		CheckJWTLogin()
+}
@@ -0,0 +1,68 @@
+package ioc
+
+import (
+	"context"
+	"example/wb/internal/repository/cache/redismock"
+	svcmock "example/wb/internal/service/mocks"
+	"example/wb/internal/web"
+	ijwt "example/wb/internal/web/jwt"
+	"example/wb/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestLoginMiddleware_Refresh refresh token 要能穿过登录校验，换回新的一对 token
+func TestLoginMiddleware_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmd := redismock.NewMockCmdable(ctrl)
+	// ssid 没有被注销
+	exists := redis.NewIntCmd(context.Background())
+	exists.SetVal(0)
+	cmd.EXPECT().Exists(gomock.Any(), "users:ssid:ssid-1").Return(exists)
+	// jti 是最新的，轮换成功
+	rotate := redis.NewCmd(context.Background())
+	rotate.SetVal(int64(0))
+	cmd.EXPECT().Eval(gomock.Any(), gomock.Any(),
+		[]string{"users:refresh:ssid-1", "users:ssid:ssid-1"},
+		"jti-1", gomock.Any(), gomock.Any()).Return(rotate)
+	auditSvc := svcmock.NewMockAuditService(ctrl)
+	auditSvc.EXPECT().Record(gomock.Any(), gomock.Any())
+
+	hdl := ijwt.NewJwtHandler(cmd)
+	server := gin.New()
+	server.Use(loginMiddleware(hdl, nil))
+	web.NewUserHandler(nil, hdl, logger.NewNopLogger(), nil, nil, nil, nil, auditSvc).
+		RegisterRoutes(server)
+
+	rc, err := jwt.NewWithClaims(jwt.SigningMethodHS512, ijwt.RefreshClaims{
+		Uid:  1,
+		Ssid: "ssid-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString(ijwt.RCJWTkey)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/user/refresh", nil)
+	req.Header.Set("Authorization", "Bearer "+rc)
+	recorder := httptest.NewRecorder()
+
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("x-jwt-token"))
+	newRc := recorder.Header().Get("x-refresh-token")
+	assert.NotEmpty(t, newRc)
+	assert.NotEqual(t, rc, newRc)
+}
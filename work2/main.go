@@ -1,7 +1,10 @@
 package main
 
 import (
+	"example/wb/pkg/ginx"
+	"expvar"
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -15,10 +18,30 @@ func main() {
 	// initViper()
 	initViperRemote()
 	// // initViperWatch()
+	initMetrics()
 	server := InitWebServer()
 	server.Run(":8081")
 }
 
+// initMetrics 每个路由的统计通过 expvar 暴露在 metrics.addr 的 /debug/vars，
+// 单独一个端口，不走登录校验，默认只监听本机。
+// 用单独的 mux，不会把 DefaultServeMux 上面别的东西（pprof 之类的）一起暴露出去
+func initMetrics() {
+	ginx.SetMetrics(ginx.NewExpvarMetrics("http"))
+	addr := viper.GetString("metrics.addr")
+	if addr == "" {
+		addr = "127.0.0.1:8082"
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	go func() {
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			log.Println("metrics 服务启动失败", err)
+		}
+	}()
+}
+
 func initLogger() {
 	logger, err := zap.NewDevelopment()
 	if err != nil {
@@ -0,0 +1,117 @@
+// Package breaker 保护下游依赖的熔断器，例如 redis
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+type State int32
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker 连续失败 threshold 次之后打开，cooldown 之内不再访问下游；
+// cooldown 之后放一个请求去探测，成功就关闭，失败就重新打开
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     State
+	failures  int
+	openedAt  time.Time
+	// 半开的时候只放一个探测请求
+	probing bool
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow 返回这次能不能访问下游
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = HalfOpen
+		b.probing = true
+		return true
+	case HalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success 返回 true 代表这次让熔断器从打开恢复到了关闭
+func (b *Breaker) Success() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	recovered := b.state != Closed
+	b.state = Closed
+	b.failures = 0
+	b.probing = false
+	return recovered
+}
+
+// Failure 返回 true 代表这次让熔断器打开了
+func (b *Breaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	switch b.state {
+	case HalfOpen:
+		// 探测失败，重新计时
+		b.state = Open
+		b.openedAt = time.Now()
+		return false
+	case Open:
+		return false
+	}
+	b.failures++
+	if b.failures < b.threshold {
+		return false
+	}
+	b.state = Open
+	b.openedAt = time.Now()
+	return true
+}
+
+// Cancel 这次访问没有结果，例如调用方自己取消了，不算成功也不算失败；
+// 如果这次是半开时候的探测，放掉探测名额，让下一个请求重新探测
+func (b *Breaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
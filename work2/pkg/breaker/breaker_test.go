@@ -0,0 +1,38 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 半开的时候探测失败，继续熔断
+func TestBreaker_HalfOpenFailure(t *testing.T) {
+	b := New(1, time.Millisecond*20)
+	assert.True(t, b.Allow())
+	assert.True(t, b.Failure())
+	assert.False(t, b.Allow())
+
+	time.Sleep(time.Millisecond * 30)
+	assert.True(t, b.Allow())
+	// 只放一个探测请求
+	assert.False(t, b.Allow())
+	assert.False(t, b.Failure())
+	assert.Equal(t, Open, b.State())
+	assert.False(t, b.Allow())
+}
+
+// 探测请求被取消了，放掉名额，下一个请求可以继续探测
+func TestBreaker_HalfOpenCancel(t *testing.T) {
+	b := New(1, time.Millisecond*20)
+	assert.True(t, b.Failure())
+	time.Sleep(time.Millisecond * 30)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+	b.Cancel()
+	assert.Equal(t, HalfOpen, b.State())
+	assert.True(t, b.Allow())
+	assert.True(t, b.Success())
+	assert.Equal(t, Closed, b.State())
+}
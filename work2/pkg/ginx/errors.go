@@ -0,0 +1,49 @@
+package ginx
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrInvalidParam 请求参数绑定或者校验失败，ValidationError 也 Unwrap 到这里
+	ErrInvalidParam = errors.New("参数错误")
+	// ErrUnauthorized WrapClaims 取不到登录信息
+	ErrUnauthorized = errors.New("请先登录")
+)
+
+// ErrorRenderer 把 error 转换成响应。ginx 不依赖具体的错误码和多语言，
+// 由业务启动的时候通过 SetErrorRenderer 注入，需要识别 ErrInvalidParam 和 ErrUnauthorized
+type ErrorRenderer interface {
+	// Render 返回 HTTP 状态码、Result 里面的错误码和给前端展示的消息
+	Render(ctx *gin.Context, err error) (status int, code int, msg string)
+	// Locale 请求使用的语言，例如 zh-CN，也可以是 Accept-Language 这样按照优先级排好的列表，
+	// 参数校验的错误信息按照这个翻译
+	Locale(ctx *gin.Context) string
+}
+
+var renderer ErrorRenderer = defaultRenderer{}
+
+// SetErrorRenderer 启动的时候设置一次，不设置的时候错误码就是 HTTP 状态码
+func SetErrorRenderer(r ErrorRenderer) {
+	renderer = r
+}
+
+type defaultRenderer struct{}
+
+func (defaultRenderer) Render(ctx *gin.Context, err error) (int, int, string) {
+	switch {
+	case errors.Is(err, ErrInvalidParam):
+		return http.StatusBadRequest, http.StatusBadRequest, ErrInvalidParam.Error()
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized, http.StatusUnauthorized, ErrUnauthorized.Error()
+	default:
+		return http.StatusInternalServerError, http.StatusInternalServerError, "系统错误"
+	}
+}
+
+func (defaultRenderer) Locale(ctx *gin.Context) string {
+	return ctx.GetHeader("Accept-Language")
+}
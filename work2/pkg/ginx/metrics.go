@@ -0,0 +1,55 @@
+package ginx
+
+import (
+	"expvar"
+	"strconv"
+	"time"
+)
+
+// Metrics 统计每个路由的请求
+type Metrics interface {
+	// Observe route 是 "方法 路由"，status 是 HTTP 状态码，code 是 Result 里面的错误码
+	Observe(route string, status int, code int, duration time.Duration)
+}
+
+var metrics Metrics = nopMetrics{}
+
+// SetMetrics 启动的时候设置一次，不设置就不统计
+func SetMetrics(m Metrics) {
+	metrics = m
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Observe(route string, status int, code int, duration time.Duration) {}
+
+// ExpvarMetrics 用标准库的 expvar 暴露统计数据，
+// 每个路由记录请求数、每个错误码的次数和总耗时
+type ExpvarMetrics struct {
+	vars *expvar.Map
+}
+
+// NewExpvarMetrics name 在进程里面只能用一次，重复会 panic
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	return &ExpvarMetrics{
+		vars: expvar.NewMap(name),
+	}
+}
+
+func (m *ExpvarMetrics) Observe(route string, status int, code int, duration time.Duration) {
+	m.vars.Add(route+".count", 1)
+	m.vars.Add(route+".status."+strconv.Itoa(status), 1)
+	if code != 0 {
+		m.vars.Add(route+".code."+strconv.Itoa(code), 1)
+	}
+	m.vars.Add(route+".latency_us", duration.Microseconds())
+}
+
+// Count 测试和调试用
+func (m *ExpvarMetrics) Count(route string) int64 {
+	v, ok := m.vars.Get(route + ".count").(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return v.Value()
+}
@@ -0,0 +1,43 @@
+package ginx
+
+import (
+	"errors"
+	"example/wb/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Result struct {
+	Code int
+	Msg  string
+	Data any
+}
+
+// RenderError 所有的错误都从这里写回去，保证前端拿到的 Result 格式一致。
+// 错误码、HTTP 状态码和消息由 SetErrorRenderer 注入的 ErrorRenderer 决定；
+// 5xx 的都带上原因记录日志
+func RenderError(ctx *gin.Context, l logger.Logger, err error) {
+	status, code, msg := renderer.Render(ctx, err)
+	renderError(ctx, l, err, status, code, msg)
+}
+
+func renderError(ctx *gin.Context, l logger.Logger, err error, status int, code int, msg string) {
+	if status >= http.StatusInternalServerError {
+		l.Error("处理请求失败",
+			logger.Error(err),
+			logger.String("method", ctx.Request.Method),
+			logger.String("route", ctx.FullPath()))
+	}
+	res := Result{
+		Code: code,
+		Msg:  msg,
+	}
+	var ve *ValidationError
+	if errors.As(err, &ve) && len(ve.Fields) > 0 {
+		// 第一个字段的错误直接展示，全部的放在 Data 里面给前端标在表单上
+		res.Msg = ve.Fields[0].Msg
+		res.Data = ve.Fields
+	}
+	ctx.JSON(status, res)
+}
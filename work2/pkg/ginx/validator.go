@@ -0,0 +1,271 @@
+package ginx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dlclark/regexp2"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	zhtrans "github.com/go-playground/validator/v10/translations/zh"
+)
+
+const (
+	// 至少一个小写字母、至少一个大写字母、至少一个数字和至少一个特殊字符
+	passwordRegex = `^(?=.*[a-z])(?=.*[A-Z])(?=.*\d)(?=.*[@!%*?&])[A-Za-z\d@!%*?&]{8,}$`
+	// E.164，+ 号开头，国家码不能是 0，最多 15 位数字
+	phoneRegex = `^\+[1-9]\d{6,14}$`
+	// 国内手机号的常见写法，没有国家码或者国家码前面没有 +
+	cnMobileRegex = `^(?:0086|86)?(1[3-9]\d{9})$`
+)
+
+var (
+	passwordExp = regexp2.MustCompile(passwordRegex, regexp2.None)
+	phoneExp    = regexp.MustCompile(phoneRegex)
+	cnMobileExp = regexp.MustCompile(cnMobileRegex)
+
+	// validator 不支持协商出来的语言的时候用中文
+	uni = ut.New(zh.New(), zh.New(), en.New())
+)
+
+// FieldError 某一个字段没有通过校验，Msg 已经按照请求的语言翻译过了
+type FieldError struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+// ValidationError 请求参数没有通过校验，会放在 Result 的 Data 里面返回给前端。
+// 错误码和 HTTP 状态码沿用 ErrInvalidParam
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Msg)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidParam
+}
+
+// 自定义的校验规则，tag 写在 binding 里面，例如 `binding:"required,maxrunes=50"`
+var customValidations = []struct {
+	tag string
+	fn  validator.Func
+	zh  string
+	en  string
+}{
+	{
+		tag: "password",
+		fn: func(fl validator.FieldLevel) bool {
+			ok, err := passwordExp.MatchString(fl.Field().String())
+			return err == nil && ok
+		},
+		zh: "{0}至少8位，需要包含大小写字母、数字和特殊字符",
+		en: "{0} must be at least 8 characters and contain upper and lower case letters, digits and special characters",
+	},
+	{
+		// 国内的写法也可以，handler 里面用 NormalizePhone 转成 E.164 之后再用
+		tag: "phone",
+		fn: func(fl validator.FieldLevel) bool {
+			return phoneExp.MatchString(NormalizePhone(fl.Field().String()))
+		},
+		zh: "{0}必须是手机号码，例如13800138000或者+8613800138000",
+		en: "{0} must be a phone number, such as 13800138000 or +8613800138000",
+	},
+	{
+		tag: "dateonly",
+		fn: func(fl validator.FieldLevel) bool {
+			_, err := time.Parse(time.DateOnly, fl.Field().String())
+			return err == nil
+		},
+		zh: "{0}必须是yyyy-mm-dd格式的日期",
+		en: "{0} must be a date in yyyy-mm-dd format",
+	},
+	{
+		// 内置的 max 在不同类型上含义不一样，这里明确按照字符而不是字节计数
+		tag: "maxrunes",
+		fn: func(fl validator.FieldLevel) bool {
+			n, err := strconv.Atoi(fl.Param())
+			return err == nil && utf8.RuneCountInString(fl.Field().String()) <= n
+		},
+		zh: "{0}不能超过{1}个字符",
+		en: "{0} must be at most {1} characters",
+	},
+	{
+		tag: "minrunes",
+		fn: func(fl validator.FieldLevel) bool {
+			n, err := strconv.Atoi(fl.Param())
+			return err == nil && utf8.RuneCountInString(fl.Field().String()) >= n
+		},
+		zh: "{0}不能少于{1}个字符",
+		en: "{0} must be at least {1} characters",
+	},
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// 错误信息里面用 json 的字段名，和前端保持一致
+	v.RegisterTagNameFunc(jsonFieldName)
+	zhTrans, _ := uni.GetTranslator("zh")
+	enTrans, _ := uni.GetTranslator("en")
+	mustRegister(zhtrans.RegisterDefaultTranslations(v, zhTrans))
+	mustRegister(entrans.RegisterDefaultTranslations(v, enTrans))
+	for _, cv := range customValidations {
+		mustRegister(v.RegisterValidation(cv.tag, cv.fn))
+		mustRegister(registerTranslation(v, zhTrans, cv.tag, cv.zh))
+		mustRegister(registerTranslation(v, enTrans, cv.tag, cv.en))
+	}
+}
+
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// NormalizePhone 手机号统一存成 E.164。国内的手机号没有带国家码的时候补上 +86，
+// 其他的只去掉空格和连字符，格式对不对交给 phone 校验
+func NormalizePhone(phone string) string {
+	phone = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, phone)
+	if m := cnMobileExp.FindStringSubmatch(phone); m != nil {
+		return "+86" + m[1]
+	}
+	return phone
+}
+
+// fieldParamTags 参数是同一个结构体里面另一个字段的规则，
+// 默认的翻译会直接用 Go 的字段名，这里换成 json 的字段名
+var fieldParamTags = map[string]bool{
+	"eqfield":  true,
+	"nefield":  true,
+	"gtfield":  true,
+	"gtefield": true,
+	"ltfield":  true,
+	"ltefield": true,
+}
+
+func translate(trans ut.Translator, fe validator.FieldError, obj any) string {
+	if fieldParamTags[fe.Tag()] {
+		if name, ok := paramFieldName(reflect.TypeOf(obj), fe); ok {
+			if msg, err := trans.T(fe.Tag(), fe.Field(), name); err == nil {
+				return msg
+			}
+		}
+	}
+	return fe.Translate(trans)
+}
+
+// paramFieldName 顺着 StructNamespace 找到出错字段所在的结构体，再找参数指向的字段
+func paramFieldName(t reflect.Type, fe validator.FieldError) (string, bool) {
+	segs := strings.Split(fe.StructNamespace(), ".")
+	if len(segs) < 2 {
+		return "", false
+	}
+	t = elemType(t)
+	for _, seg := range segs[1 : len(segs)-1] {
+		seg, _, _ = strings.Cut(seg, "[")
+		f, ok := t.FieldByName(seg)
+		if !ok {
+			return "", false
+		}
+		t = elemType(f.Type)
+	}
+	if t.Kind() != reflect.Struct {
+		return "", false
+	}
+	f, ok := t.FieldByName(fe.Param())
+	if !ok {
+		return "", false
+	}
+	return jsonFieldName(f), true
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+func registerTranslation(v *validator.Validate, trans ut.Translator, tag, text string) error {
+	return v.RegisterTranslation(tag, trans, func(ut ut.Translator) error {
+		return ut.Add(tag, text, true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		msg, err := ut.T(fe.Tag(), fe.Field(), fe.Param())
+		if err != nil {
+			return fe.Error()
+		}
+		return msg
+	})
+}
+
+func mustRegister(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// bindError 绑定的错误都是 ErrInvalidParam，校验失败的时候带上每个字段的错误信息。
+// obj 是绑定的请求，用来找规则参数里面字段的 json 名字
+func bindError(ctx *gin.Context, err error, obj any) error {
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return fmt.Errorf("%w: %w", ErrInvalidParam, err)
+	}
+	trans := translator(ctx)
+	fields := make([]FieldError, 0, len(ves))
+	for _, fe := range ves {
+		fields = append(fields, FieldError{
+			Field: fe.Field(),
+			Msg:   translate(trans, fe, obj),
+		})
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// translator 和其他消息一样按照 ErrorRenderer 给的语言，validator 只区分语言不区分地区
+func translator(ctx *gin.Context) ut.Translator {
+	var langs []string
+	for _, tag := range strings.Split(renderer.Locale(ctx), ",") {
+		tag, _, _ = strings.Cut(tag, ";")
+		lang, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+		if lang != "" {
+			langs = append(langs, strings.ToLower(lang))
+		}
+	}
+	trans, _ := uni.FindTranslator(langs...)
+	return trans
+}
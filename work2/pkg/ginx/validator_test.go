@@ -0,0 +1,138 @@
+package ginx
+
+import (
+	"bytes"
+	"encoding/json"
+	"example/wb/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateReq struct {
+	Password string `json:"password" binding:"omitempty,password"`
+	Confirm  string `json:"confirm" binding:"omitempty,eqfield=Password"`
+	Phone    string `json:"phone" binding:"omitempty,phone"`
+	Birthday string `json:"birthday" binding:"omitempty,dateonly"`
+	NickName string `json:"nickname" binding:"omitempty,minrunes=2,maxrunes=4"`
+}
+
+func TestValidator(t *testing.T) {
+	testCase := []struct {
+		name string
+
+		body string
+		lang string
+
+		wantFields []FieldError
+	}{
+		{
+			name: "全部通过",
+			body: `{"password":"helLo@dsf46","phone":"+8613800138000","birthday":"2000-01-02","nickname":"中文昵称"}`,
+		},
+		{
+			name: "密码太弱",
+			body: `{"password":"hello123"}`,
+			wantFields: []FieldError{
+				{Field: "password", Msg: "password至少8位，需要包含大小写字母、数字和特殊字符"},
+			},
+		},
+		{
+			name: "国内手机号没有国家码",
+			body: `{"phone":"138 0013 8000"}`,
+		},
+		{
+			name: "手机号不对",
+			body: `{"phone":"12800138000"}`,
+			wantFields: []FieldError{
+				{Field: "phone", Msg: "phone必须是手机号码，例如13800138000或者+8613800138000"},
+			},
+		},
+		{
+			name: "两次密码不一致",
+			body: `{"password":"helLo@dsf46","confirm":"helLo@dsf4"}`,
+			wantFields: []FieldError{
+				{Field: "confirm", Msg: "confirm必须等于password"},
+			},
+		},
+		{
+			name: "日期不存在",
+			body: `{"birthday":"2000-02-30"}`,
+			lang: "en",
+			wantFields: []FieldError{
+				{Field: "birthday", Msg: "birthday must be a date in yyyy-mm-dd format"},
+			},
+		},
+		{
+			// 4 个汉字是 12 个字节，按照字符数算是合法的
+			name: "昵称太长",
+			body: `{"nickname":"中文昵称啊"}`,
+			wantFields: []FieldError{
+				{Field: "nickname", Msg: "nickname不能超过4个字符"},
+			},
+		},
+		{
+			name: "多个字段出错",
+			body: `{"phone":"+0123","nickname":"a"}`,
+			lang: "fr-FR,en;q=0.8",
+			wantFields: []FieldError{
+				{Field: "phone", Msg: "phone must be a phone number, such as 13800138000 or +8613800138000"},
+				{Field: "nickname", Msg: "nickname must be at least 2 characters"},
+			},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			server := gin.New()
+			server.POST("/test", WrapBody(logger.NewNopLogger(), func(ctx *gin.Context, req validateReq) (Result, error) {
+				return Result{Msg: "OK"}, nil
+			}))
+			req, err := http.NewRequest(http.MethodPost, "/test", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tc.lang)
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			var res struct {
+				Code int
+				Msg  string
+				Data []FieldError
+			}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+			if len(tc.wantFields) == 0 {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			assert.Equal(t, tc.wantFields[0].Msg, res.Msg)
+			assert.Equal(t, tc.wantFields, res.Data)
+		})
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	testCase := []struct {
+		name  string
+		phone string
+		want  string
+	}{
+		{name: "国内", phone: "13800138000", want: "+8613800138000"},
+		{name: "国内带空格", phone: "138 0013-8000", want: "+8613800138000"},
+		{name: "国家码没有加号", phone: "8613800138000", want: "+8613800138000"},
+		{name: "国家码00开头", phone: "008613800138000", want: "+8613800138000"},
+		{name: "已经是E.164", phone: "+8613800138000", want: "+8613800138000"},
+		{name: "其他国家", phone: "+14155550100", want: "+14155550100"},
+		{name: "不是手机号", phone: "12345", want: "12345"},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, NormalizePhone(tc.phone))
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package ginx
+
+import (
+	"example/wb/pkg/logger"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimsKey 登录校验的 middleware 把 claims 放在 ctx 的这个 key 下面
+const ClaimsKey = "user"
+
+// Wrap 业务函数只需要返回 Result 或者 error，写响应、记录日志和统计都在这里做。
+// 如果业务函数自己已经写了响应，例如下载文件，这里就不会再写
+func Wrap(l logger.Logger, fn func(ctx *gin.Context) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		res, err := fn(ctx)
+		finish(ctx, l, start, res, err)
+	}
+}
+
+// WrapBody 先把请求体绑定到 Req 上并按照 binding tag 校验，失败直接返回参数错误
+func WrapBody[Req any](l logger.Logger, fn func(ctx *gin.Context, req Req) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		var req Req
+		if err := ctx.ShouldBind(&req); err != nil {
+			finish(ctx, l, start, Result{}, bindError(ctx, err, &req))
+			return
+		}
+		res, err := fn(ctx, req)
+		finish(ctx, l, start, res, err)
+	}
+}
+
+// WrapClaims 取出登录用户的 claims，没有登录直接返回 401
+func WrapClaims[Claims any](l logger.Logger, fn func(ctx *gin.Context, uc Claims) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		uc, ok := claims[Claims](ctx)
+		if !ok {
+			finish(ctx, l, start, Result{}, ErrUnauthorized)
+			return
+		}
+		res, err := fn(ctx, uc)
+		finish(ctx, l, start, res, err)
+	}
+}
+
+func WrapBodyAndClaims[Req any, Claims any](l logger.Logger,
+	fn func(ctx *gin.Context, req Req, uc Claims) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		var req Req
+		if err := ctx.ShouldBind(&req); err != nil {
+			finish(ctx, l, start, Result{}, bindError(ctx, err, &req))
+			return
+		}
+		uc, ok := claims[Claims](ctx)
+		if !ok {
+			finish(ctx, l, start, Result{}, ErrUnauthorized)
+			return
+		}
+		res, err := fn(ctx, req, uc)
+		finish(ctx, l, start, res, err)
+	}
+}
+
+func claims[Claims any](ctx *gin.Context) (Claims, bool) {
+	val, ok := ctx.Get(ClaimsKey)
+	if !ok {
+		var zero Claims
+		return zero, false
+	}
+	uc, ok := val.(Claims)
+	return uc, ok
+}
+
+func finish(ctx *gin.Context, l logger.Logger, start time.Time, res Result, err error) {
+	code := res.Code
+	switch {
+	case err != nil:
+		var status int
+		var msg string
+		status, code, msg = renderer.Render(ctx, err)
+		if !ctx.Writer.Written() {
+			renderError(ctx, l, err, status, code, msg)
+		}
+	case !ctx.Writer.Written():
+		ctx.JSON(http.StatusOK, res)
+	}
+	metrics.Observe(ctx.Request.Method+" "+ctx.FullPath(), ctx.Writer.Status(), code, time.Since(start))
+}
@@ -0,0 +1,133 @@
+package ginx
+
+import (
+	"bytes"
+	"errors"
+	"example/wb/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testReq struct {
+	Name string `json:"name"`
+}
+
+type testClaims struct {
+	Uid int64
+}
+
+func TestWrapBodyAndClaims(t *testing.T) {
+	m := NewExpvarMetrics("ginx_test")
+	SetMetrics(m)
+	defer SetMetrics(nopMetrics{})
+
+	testCase := []struct {
+		name string
+
+		body   string
+		claims any
+		fn     func(ctx *gin.Context, req testReq, uc testClaims) (Result, error)
+
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:   "成功",
+			body:   `{"name":"abc"}`,
+			claims: testClaims{Uid: 1},
+			fn: func(ctx *gin.Context, req testReq, uc testClaims) (Result, error) {
+				return Result{Msg: req.Name, Data: uc.Uid}, nil
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"Code":0,"Msg":"abc","Data":1}`,
+		},
+		{
+			name:   "Bind出错",
+			body:   `{"name":`,
+			claims: testClaims{Uid: 1},
+			fn: func(ctx *gin.Context, req testReq, uc testClaims) (Result, error) {
+				t.Fatal("不应该调用业务函数")
+				return Result{}, nil
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"Code":400,"Msg":"参数错误","Data":null}`,
+		},
+		{
+			name: "没有登录",
+			body: `{"name":"abc"}`,
+			fn: func(ctx *gin.Context, req testReq, uc testClaims) (Result, error) {
+				t.Fatal("不应该调用业务函数")
+				return Result{}, nil
+			},
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"Code":401,"Msg":"请先登录","Data":null}`,
+		},
+		{
+			name:   "claims类型不对",
+			body:   `{"name":"abc"}`,
+			claims: "abc",
+			fn: func(ctx *gin.Context, req testReq, uc testClaims) (Result, error) {
+				t.Fatal("不应该调用业务函数")
+				return Result{}, nil
+			},
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"Code":401,"Msg":"请先登录","Data":null}`,
+		},
+		{
+			name:   "业务错误",
+			body:   `{"name":"abc"}`,
+			claims: testClaims{Uid: 1},
+			fn: func(ctx *gin.Context, req testReq, uc testClaims) (Result, error) {
+				return Result{}, ErrUnauthorized
+			},
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"Code":401,"Msg":"请先登录","Data":null}`,
+		},
+		{
+			name:   "系统错误",
+			body:   `{"name":"abc"}`,
+			claims: testClaims{Uid: 1},
+			fn: func(ctx *gin.Context, req testReq, uc testClaims) (Result, error) {
+				return Result{}, errors.New("db 错误")
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"Code":500,"Msg":"系统错误","Data":null}`,
+		},
+		{
+			name:   "业务函数自己写了响应",
+			body:   `{"name":"abc"}`,
+			claims: testClaims{Uid: 1},
+			fn: func(ctx *gin.Context, req testReq, uc testClaims) (Result, error) {
+				ctx.String(http.StatusTeapot, "abc")
+				return Result{}, nil
+			},
+			wantCode: http.StatusTeapot,
+			wantBody: "abc",
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			server := gin.New()
+			server.POST("/test", func(ctx *gin.Context) {
+				if tc.claims != nil {
+					ctx.Set(ClaimsKey, tc.claims)
+				}
+			}, WrapBodyAndClaims(logger.NewNopLogger(), tc.fn))
+			req, err := http.NewRequest(http.MethodPost, "/test", bytes.NewReader([]byte(tc.body)))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+	// 每个请求不管成功失败都要统计
+	assert.Equal(t, int64(len(testCase)), m.Count("POST /test"))
+}
@@ -0,0 +1,113 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params 参数的含义见 RFC 9106
+type Argon2Params struct {
+	// Memory 单位是 KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params RFC 9106 推荐的第二组参数，64MiB 内存，3 轮
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2id 哈希使用 PHC 字符串格式：
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>，salt 和 hash 是不带填充的 base64
+type Argon2id struct {
+	params Argon2Params
+}
+
+func NewArgon2id(params Argon2Params) *Argon2id {
+	return &Argon2id{params: params}
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.params.Iterations,
+		a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		a.params.Memory, a.params.Iterations, a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2id) Verify(hash, password string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	// 用哈希里面记录的参数，而不是当前的配置
+	other := argon2.IDKey([]byte(password), salt, p.Iterations,
+		p.Memory, p.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a *Argon2id) NeedsRehash(hash string) bool {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return p.Memory != a.params.Memory ||
+		p.Iterations != a.params.Iterations ||
+		p.Parallelism != a.params.Parallelism ||
+		uint32(len(salt)) != a.params.SaltLength ||
+		uint32(len(key)) != a.params.KeyLength
+}
+
+func (a *Argon2id) Supports(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	// 第一段是空字符串
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrMalformedHash
+	}
+	if version != argon2.Version {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism)
+	if err != nil || p.Iterations == 0 || p.Parallelism == 0 {
+		return p, nil, nil, ErrMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrMalformedHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
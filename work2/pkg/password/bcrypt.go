@@ -0,0 +1,55 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt 哈希形如 $2a$10$...，cost 记录在哈希里面
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt cost 不合法的时候用 bcrypt.DefaultCost
+func NewBcrypt(cost int) *Bcrypt {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &Bcrypt{cost: cost}
+}
+
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b *Bcrypt) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, ErrMalformedHash
+	}
+}
+
+func (b *Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.cost
+}
+
+func (b *Bcrypt) Supports(hash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
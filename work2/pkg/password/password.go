@@ -0,0 +1,76 @@
+// Package password 负责密码的哈希和校验。
+// 哈希的结果里面带上了算法和参数，调整算法或者参数之后，老的哈希依旧可以校验，
+// 校验通过的时候再用新的配置重新哈希，慢慢把存量的密码升级上去
+package password
+
+import (
+	"errors"
+)
+
+var (
+	// ErrUnsupportedHash 不认识的哈希格式，一般是数据有问题，或者没有配置对应的算法
+	ErrUnsupportedHash = errors.New("password: 不支持的哈希格式")
+	// ErrMalformedHash 认识哈希的算法，但是里面的参数解析不出来
+	ErrMalformedHash = errors.New("password: 哈希格式错误")
+)
+
+type Hasher interface {
+	// Hash 用当前的配置哈希密码
+	Hash(password string) (string, error)
+	// Verify 校验密码，密码不对返回 false 而不是 error
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash 哈希不是用当前的算法或者参数生成的
+	NeedsRehash(hash string) bool
+	// Supports 这个哈希是不是由当前算法生成的
+	Supports(hash string) bool
+}
+
+// Versioned 新的密码用 current 哈希，老的密码按照哈希里面记录的算法校验
+type Versioned struct {
+	current Hasher
+	legacy  []Hasher
+}
+
+// NewVersioned legacy 是历史上用过的算法，只用来校验
+func NewVersioned(current Hasher, legacy ...Hasher) *Versioned {
+	return &Versioned{
+		current: current,
+		legacy:  legacy,
+	}
+}
+
+func (v *Versioned) Hash(password string) (string, error) {
+	return v.current.Hash(password)
+}
+
+func (v *Versioned) Verify(hash, password string) (bool, error) {
+	h, ok := v.find(hash)
+	if !ok {
+		return false, ErrUnsupportedHash
+	}
+	return h.Verify(hash, password)
+}
+
+func (v *Versioned) NeedsRehash(hash string) bool {
+	if !v.current.Supports(hash) {
+		return true
+	}
+	return v.current.NeedsRehash(hash)
+}
+
+func (v *Versioned) Supports(hash string) bool {
+	_, ok := v.find(hash)
+	return ok
+}
+
+func (v *Versioned) find(hash string) (Hasher, bool) {
+	if v.current.Supports(hash) {
+		return v.current, true
+	}
+	for _, h := range v.legacy {
+		if h.Supports(hash) {
+			return h, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,153 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 测试里面用小一点的参数，不然跑得太慢
+var testArgon2Params = Argon2Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2id(t *testing.T) {
+	h := NewArgon2id(testArgon2Params)
+	hash, err := h.Hash("hello#world123")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, hash)
+
+	ok, err := h.Verify(hash, "hello#world123")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = h.Verify(hash, "hello#world124")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, h.NeedsRehash(hash))
+
+	// 加大了参数之后老的哈希依旧能校验，但是需要重新哈希
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+	h2 := NewArgon2id(stronger)
+	ok, err = h2.Verify(hash, "hello#world123")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h2.NeedsRehash(hash))
+}
+
+func TestArgon2id_Malformed(t *testing.T) {
+	testCase := []struct {
+		name    string
+		hash    string
+		wantErr error
+	}{
+		{name: "不是argon2id", hash: "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5", wantErr: ErrUnsupportedHash},
+		{name: "版本不对", hash: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", wantErr: ErrUnsupportedHash},
+		{name: "缺少参数", hash: "$argon2id$v=19$m=1024,t=1$c2FsdA$a2V5", wantErr: ErrMalformedHash},
+		{name: "轮数为0", hash: "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5", wantErr: ErrMalformedHash},
+		{name: "salt不是base64", hash: "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5", wantErr: ErrMalformedHash},
+		{name: "段数不对", hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", wantErr: ErrUnsupportedHash},
+	}
+	h := NewArgon2id(testArgon2Params)
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := h.Verify(tc.hash, "hello")
+			assert.Equal(t, tc.wantErr, err)
+			assert.False(t, ok)
+			assert.True(t, h.NeedsRehash(tc.hash))
+		})
+	}
+}
+
+func TestBcrypt(t *testing.T) {
+	h := NewBcrypt(bcrypt.MinCost)
+	hash, err := h.Hash("hello#world123")
+	require.NoError(t, err)
+	assert.True(t, h.Supports(hash))
+
+	ok, err := h.Verify(hash, "hello#world123")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = h.Verify(hash, "hello#world124")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, h.NeedsRehash(hash))
+	assert.True(t, NewBcrypt(bcrypt.MinCost+1).NeedsRehash(hash))
+
+	_, err = h.Verify("$2a$xx", "hello")
+	assert.Equal(t, ErrMalformedHash, err)
+}
+
+func TestVersioned(t *testing.T) {
+	bc := NewBcrypt(bcrypt.MinCost)
+	bcryptHash, err := bc.Hash("hello#world123")
+	require.NoError(t, err)
+	argon := NewArgon2id(testArgon2Params)
+	argonHash, err := argon.Hash("hello#world123")
+	require.NoError(t, err)
+
+	testCase := []struct {
+		name string
+		h    *Versioned
+		hash string
+
+		wantOk      bool
+		wantErr     error
+		wantRehash  bool
+		wantSupport bool
+	}{
+		{
+			name:        "老的bcrypt密码，需要升级",
+			h:           NewVersioned(argon, bc),
+			hash:        bcryptHash,
+			wantOk:      true,
+			wantRehash:  true,
+			wantSupport: true,
+		},
+		{
+			name:        "已经是argon2id",
+			h:           NewVersioned(argon, bc),
+			hash:        argonHash,
+			wantOk:      true,
+			wantSupport: true,
+		},
+		{
+			name:        "回滚到bcrypt，argon2id也能校验",
+			h:           NewVersioned(bc, argon),
+			hash:        argonHash,
+			wantOk:      true,
+			wantRehash:  true,
+			wantSupport: true,
+		},
+		{
+			name:       "没有配置bcrypt",
+			h:          NewVersioned(argon),
+			hash:       bcryptHash,
+			wantErr:    ErrUnsupportedHash,
+			wantRehash: true,
+		},
+		{
+			// 只用手机号注册的用户没有密码
+			name:       "空密码",
+			h:          NewVersioned(argon, bc),
+			hash:       "",
+			wantErr:    ErrUnsupportedHash,
+			wantRehash: true,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := tc.h.Verify(tc.hash, "hello#world123")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantOk, ok)
+			assert.Equal(t, tc.wantRehash, tc.h.NeedsRehash(tc.hash))
+			assert.Equal(t, tc.wantSupport, tc.h.Supports(tc.hash))
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package totp 实现了 RFC 6238 基于时间的一次性密码
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TOTP struct {
+	// 每个验证码的有效时长
+	period time.Duration
+	digits int
+	// 前后各允许偏差几个时间步，用来容忍客户端的时钟误差
+	skew int
+}
+
+// New 默认参数和 Google Authenticator 等主流 App 保持一致
+func New() *TOTP {
+	return &TOTP{
+		period: time.Second * 30,
+		digits: 6,
+		skew:   1,
+	}
+}
+
+func (t *TOTP) Digits(digits int) *TOTP {
+	t.digits = digits
+	return t
+}
+
+func (t *TOTP) Skew(skew int) *TOTP {
+	t.skew = skew
+	return t
+}
+
+// GenerateSecret 生成一个 160 位的随机密钥，使用不带填充的 base32 编码
+func GenerateSecret() (string, error) {
+	key := make([]byte, 20)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(key), nil
+}
+
+// Code 计算 secret 在 now 这个时间点的验证码
+func (t *TOTP) Code(secret string, now time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return t.hotp(key, t.counter(now)), nil
+}
+
+// Validate 校验验证码，允许前后 skew 个时间步的误差
+func (t *TOTP) Validate(secret string, code string, now time.Time) bool {
+	_, ok := t.Match(secret, code, now)
+	return ok
+}
+
+// Match 和 Validate 一样，同时返回验证码对应的时间步。
+// 误差窗口内同一个验证码一直有效，调用者要记住用过的时间步防止重放
+func (t *TOTP) Match(secret string, code string, now time.Time) (int64, bool) {
+	if len(code) != t.digits {
+		return 0, false
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	counter := int64(t.counter(now))
+	for i := -t.skew; i <= t.skew; i++ {
+		expected := t.hotp(key, uint64(counter+int64(i)))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// Window 一个验证码最长能用多久，记录用过的时间步保留这么久就够了
+func (t *TOTP) Window() time.Duration {
+	return t.period * time.Duration(2*t.skew+1)
+}
+
+// URI 生成 otpauth:// 链接，前端可以直接转成二维码给 App 扫描
+func (t *TOTP) URI(issuer string, account string, secret string) string {
+	vals := url.Values{}
+	vals.Set("secret", secret)
+	vals.Set("issuer", issuer)
+	vals.Set("algorithm", "SHA1")
+	vals.Set("digits", fmt.Sprintf("%d", t.digits))
+	vals.Set("period", fmt.Sprintf("%d", int64(t.period.Seconds())))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + vals.Encode()
+}
+
+func (t *TOTP) counter(now time.Time) uint64 {
+	return uint64(now.Unix() / int64(t.period.Seconds()))
+}
+
+// hotp RFC 4226 里面的算法
+func (t *TOTP) hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < t.digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", t.digits, bin%mod)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimRight(secret, "="))
+	return encoding.DecodeString(secret)
+}
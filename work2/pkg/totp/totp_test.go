@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 附录 B 中 SHA1 的测试向量
+func TestTOTP_Code(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	testCase := []struct {
+		name string
+		now  int64
+		want string
+	}{
+		{name: "59", now: 59, want: "94287082"},
+		{name: "1111111109", now: 1111111109, want: "07081804"},
+		{name: "1111111111", now: 1111111111, want: "14050471"},
+		{name: "1234567890", now: 1234567890, want: "89005924"},
+		{name: "2000000000", now: 2000000000, want: "69279037"},
+		{name: "20000000000", now: 20000000000, want: "65353130"},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := New().Digits(8).Code(secret, time.Unix(tt.now, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+}
+
+func TestTOTP_Validate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	totp := New()
+	code, err := totp.Code(secret, now)
+	assert.NoError(t, err)
+
+	testCase := []struct {
+		name string
+		code string
+		now  time.Time
+		want bool
+	}{
+		{name: "当前时间步", code: code, now: now, want: true},
+		{name: "上一个时间步", code: code, now: now.Add(time.Second * 30), want: true},
+		{name: "超出误差", code: code, now: now.Add(time.Minute * 2), want: false},
+		{name: "长度不对", code: code[:5], now: now, want: false},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, totp.Validate(secret, tt.code, tt.now))
+		})
+	}
+}
+
+func TestTOTP_URI(t *testing.T) {
+	uri := New().URI("webook", "a@qq.com", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/webook:a@qq.com?algorithm=SHA1&digits=6&issuer=webook&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
+
+func TestTOTP_Match(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	totp := New()
+	code, err := totp.Code(secret, now)
+	assert.NoError(t, err)
+
+	testCase := []struct {
+		name     string
+		now      time.Time
+		wantStep int64
+		wantOk   bool
+	}{
+		{name: "当前时间步", now: now, wantStep: 1700000000 / 30, wantOk: true},
+		// 时间步是验证码生成的那个，不是校验的时候的
+		{name: "上一个时间步", now: now.Add(time.Second * 30), wantStep: 1700000000 / 30, wantOk: true},
+		{name: "超出误差", now: now.Add(time.Minute * 2)},
+	}
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := totp.Match(secret, code, tt.now)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantStep, step)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+-- 手机号统一改成 E.164 之后，把以前存的国内写法补上 +86，
+-- 否则老用户用短信登录会找不到账号，又注册一个新的。
+-- 上线新版本之前执行一次，重复执行没有影响。
+-- 如果同一个号码两种写法都已经有账号了，会因为唯一索引失败，需要先人工合并。
+UPDATE webook.users
+SET phone = CONCAT('+86', phone)
+WHERE phone REGEXP '^1[3-9][0-9]{9}$';
+
+UPDATE webook.users
+SET phone = CONCAT('+', phone)
+WHERE phone REGEXP '^861[3-9][0-9]{9}$';
@@ -8,7 +8,6 @@ import (
 	"example/wb/internal/repository/dao"
 	"example/wb/internal/service"
 	"example/wb/internal/web"
-	ijwt "example/wb/internal/web/jwt"
 	"example/wb/ioc"
 
 	"github.com/gin-gonic/gin"
@@ -20,19 +19,35 @@ func InitWebServer() *gin.Engine {
 		// 初始化第三方依赖
 		ioc.InitFreeCache,
 		ioc.InitRedis, ioc.InitDB,
-		ioc.InitWechatService,
+		ioc.InitWechatService, ioc.InitOAuth2Providers,
 		ioc.InitLogger,
 
-		dao.NewUserDao, dao.NewSmsDao,
+		dao.NewUserDao, dao.NewSmsDao, dao.NewTwoFactorDao, dao.NewUserIdentityDao,
+		dao.NewWechatTokenDao, dao.NewArticleGORMDAO, dao.NewUserRoleDao,
+		dao.NewAccessTokenDao, dao.NewAuditDao,
 		// cache部分
-		cache.NewUserCache, cache.NewCodeLocalCache,
+		cache.NewUserCache, ioc.InitCodeCache, cache.NewLoginGuardCache,
+		cache.NewTwoFactorCache,
+		cache.NewOAuth2StateCache, cache.NewArticleRedisCache, cache.NewRoleCache,
 		// repository部分
 		repository.NewCachedCodeRepository, repository.NewCachedUserRepository,
-		repository.NewAsyncSMSRepository,
+		repository.NewAsyncSMSRepository, repository.NewTwoFactorRepository,
+		repository.NewLoginGuardRepository, repository.NewIdentityRepository,
+		repository.NewOAuth2StateRepository, repository.NewWechatTokenRepository,
+		repository.NewArticleRepository, repository.NewRoleRepository,
+		repository.NewAccessTokenRepository, repository.NewAuditRepository,
 		// service部分
-		ioc.InitSMSService, service.NewCodeService, service.NewUserService,
+		ioc.InitSMSService, ioc.InitCodeSenders, ioc.InitCodePolicies, service.NewCodeService, service.NewUserService, ioc.InitPasswordHasher,
+		service.NewTwoFactorService,
+		ioc.InitLoginGuardService, ioc.InitCaptchaService,
+		service.NewOAuth2StateService, service.NewWechatLoginService,
+		ioc.InitAccountService, ioc.InitAuthzService, service.NewAdminService,
+		service.NewArticleService, service.NewAccessTokenService,
+		service.NewAuditService,
 		// web部分
-		web.NewUserHandler, web.NewOAuth2WechatHandler, ijwt.NewJwtHandler,
+		web.NewUserHandler, web.NewOAuth2WechatHandler, web.NewOAuth2Handler, ioc.InitJWTHandler,
+		web.NewAccountHandler, web.NewAdminHandler,
+		web.NewArticleHandler, web.NewAccessTokenHandler, web.NewAuditHandler,
 
 		ioc.InitGinMiddlewares,
 		ioc.InitWebServer,
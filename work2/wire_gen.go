@@ -12,7 +12,6 @@ import (
 	"example/wb/internal/repository/dao"
 	"example/wb/internal/service"
 	"example/wb/internal/web"
-	"example/wb/internal/web/jwt"
 	"example/wb/ioc"
 	"github.com/gin-gonic/gin"
 )
@@ -25,24 +24,66 @@ import (
 
 func InitWebServer() *gin.Engine {
 	cmdable := ioc.InitRedis()
-	handler := jwt.NewJwtHandler(cmdable)
 	logger := ioc.InitLogger()
-	v := ioc.InitGinMiddlewares(cmdable, handler, logger)
+	handler := ioc.InitJWTHandler(cmdable, logger)
 	db := ioc.InitDB(logger)
+	accessTokenDao := dao.NewAccessTokenDao(db)
+	accessTokenRepository := repository.NewAccessTokenRepository(accessTokenDao)
 	userDao := dao.NewUserDao(db)
 	userCache := cache.NewUserCache(cmdable)
 	userRepository := repository.NewCachedUserRepository(userDao, userCache)
-	userService := service.NewUserService(userRepository)
+	accessTokenService := service.NewAccessTokenService(accessTokenRepository, userRepository)
+	v := ioc.InitGinMiddlewares(cmdable, handler, accessTokenService, logger)
+	userIdentityDao := dao.NewUserIdentityDao(db)
+	identityRepository := repository.NewIdentityRepository(userIdentityDao)
+	hasher := ioc.InitPasswordHasher()
+	userService := service.NewUserService(userRepository, identityRepository, hasher, logger)
 	freecacheCache := ioc.InitFreeCache()
-	codeCache := cache.NewCodeLocalCache(freecacheCache)
+	codeCache := ioc.InitCodeCache(cmdable, freecacheCache, logger)
 	codeRepository := repository.NewCachedCodeRepository(codeCache)
 	asyncSmsDao := dao.NewSmsDao(db)
 	asyncSmsRepository := repository.NewAsyncSMSRepository(asyncSmsDao)
 	smsService := ioc.InitSMSService(cmdable, asyncSmsRepository)
-	codeService := service.NewCodeService(codeRepository, smsService)
-	userHandler := web.NewUserHandler(userService, handler, logger, codeService)
+	codeSenders := ioc.InitCodeSenders(smsService, cmdable)
+	codePolicies := ioc.InitCodePolicies()
+	codeService := service.NewCodeService(codeRepository, codeSenders, codePolicies)
+	twoFactorDao := dao.NewTwoFactorDao(db)
+	twoFactorCache := cache.NewTwoFactorCache(cmdable)
+	twoFactorRepository := repository.NewTwoFactorRepository(twoFactorDao, twoFactorCache)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepository, userRepository)
+	loginGuardCache := cache.NewLoginGuardCache(cmdable)
+	loginGuardRepository := repository.NewLoginGuardRepository(loginGuardCache)
+	loginGuardService := ioc.InitLoginGuardService(cmdable, loginGuardRepository)
+	captchaService := ioc.InitCaptchaService()
+	auditDao := dao.NewAuditDao(db)
+	auditRepository := repository.NewAuditRepository(auditDao)
+	auditService := service.NewAuditService(auditRepository, logger)
+	userHandler := web.NewUserHandler(userService, handler, logger, codeService, twoFactorService, loginGuardService, captchaService, auditService)
 	wechatService := ioc.InitWechatService()
-	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, handler, logger, userService)
-	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler)
+	oAuth2StateCache := cache.NewOAuth2StateCache(cmdable)
+	oAuth2StateRepository := repository.NewOAuth2StateRepository(oAuth2StateCache)
+	oAuth2StateService := service.NewOAuth2StateService(oAuth2StateRepository)
+	wechatTokenDao := dao.NewWechatTokenDao(db)
+	wechatTokenRepository := repository.NewWechatTokenRepository(wechatTokenDao)
+	wechatLoginService := service.NewWechatLoginService(wechatService, userRepository, wechatTokenRepository, logger)
+	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, handler, logger, wechatLoginService, oAuth2StateService, auditService)
+	v2 := ioc.InitOAuth2Providers()
+	oAuth2Handler := web.NewOAuth2Handler(v2, handler, logger, userService, oAuth2StateService, auditService)
+	articleDAO := dao.NewArticleGORMDAO(db)
+	articleCache := cache.NewArticleRedisCache(cmdable)
+	articleRepository := repository.NewArticleRepository(articleDAO, articleCache)
+	accountService := ioc.InitAccountService(userRepository, articleRepository, codeService, twoFactorService, hasher, logger)
+	accountHandler := web.NewAccountHandler(accountService, handler, auditService, logger)
+	userRoleDao := dao.NewUserRoleDao(db)
+	roleCache := cache.NewRoleCache(cmdable)
+	roleRepository := repository.NewRoleRepository(userRoleDao, roleCache, logger)
+	authzService := ioc.InitAuthzService(roleRepository, userRepository, userService)
+	adminService := service.NewAdminService(userRepository, articleRepository, authzService)
+	adminHandler := web.NewAdminHandler(adminService, authzService, handler, auditService, logger)
+	articleService := service.NewArticleService(articleRepository, userRepository, logger)
+	articleHandler := web.NewArticleHandler(articleService, logger)
+	accessTokenHandler := web.NewAccessTokenHandler(accessTokenService, auditService, logger)
+	auditHandler := web.NewAuditHandler(auditService, authzService, logger)
+	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler, oAuth2Handler, accountHandler, adminHandler, articleHandler, accessTokenHandler, auditHandler)
 	return engine
 }